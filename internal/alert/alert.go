@@ -0,0 +1,84 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/circuit"
+)
+
+// Sender posts JSON alerts to the ops alert webhook through a circuit breaker.
+// A Sender without a URL drops every alert.
+type Sender struct {
+	mu         sync.RWMutex
+	url        string
+	httpClient *http.Client
+	breaker    *circuit.Breaker
+}
+
+// NewSender creates an alert sender for the webhook URL, which may be empty
+func NewSender(url string, breaker *circuit.Breaker) *Sender {
+	return &Sender{
+		url:        url,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		breaker:    breaker,
+	}
+}
+
+// SetURL changes the webhook URL alerts are posted to; an empty URL drops alerts
+func (s *Sender) SetURL(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.url = url
+}
+
+// Enabled reports whether alerts are delivered anywhere
+func (s *Sender) Enabled() bool {
+	return s.target() != ""
+}
+
+// target returns the webhook URL, or "" for a nil Sender
+func (s *Sender) target() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.url
+}
+
+// Send posts an alert of the given type. A timestamp is added to the fields.
+func (s *Sender) Send(alertType string, fields map[string]any) error {
+	url := s.target()
+	if url == "" {
+		return nil
+	}
+
+	body := map[string]any{
+		"type":      alertType,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	for key, value := range fields {
+		body[key] = value
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	return s.breaker.Do(func() error {
+		resp, err := s.httpClient.Post(url, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("alert webhook responded with status %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ErrAliasFileInvalid is returned for alias changes while the alias file could not be loaded
+var ErrAliasFileInvalid = errors.New("alias file could not be loaded")
+
+// AliasStore maps stable business names to session users, persisted as a JSON file.
+type AliasStore struct {
+	mu      sync.RWMutex
+	path    string
+	aliases map[string]string
+	loadErr error // Why the file could not be loaded; it is never overwritten then
+}
+
+// NewAliasStore creates an AliasStore backed by the given file, loading existing aliases.
+// A file that cannot be read, parsed or holds aliases of aliases is left as it
+// is: the store starts empty and refuses changes until the file is fixed.
+func NewAliasStore(path string) (*AliasStore, error) {
+	s := &AliasStore{
+		path:    path,
+		aliases: make(map[string]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		s.loadErr = fmt.Errorf("failed to read alias file: %v", err)
+		return s, s.loadErr
+	}
+
+	aliases := make(map[string]string)
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		s.loadErr = fmt.Errorf("failed to parse alias file: %v", err)
+		return s, s.loadErr
+	}
+	for alias, user := range aliases {
+		if _, isAlias := aliases[user]; isAlias {
+			s.loadErr = fmt.Errorf("alias file is invalid: %s points to %s, which is itself an alias", alias, user)
+			return s, s.loadErr
+		}
+	}
+
+	s.aliases = aliases
+	return s, nil
+}
+
+// Resolve returns the user an alias points to, or the name itself if it is not an alias.
+func (s *AliasStore) Resolve(name string) string {
+	if s == nil {
+		return name
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if user, ok := s.aliases[name]; ok {
+		return user
+	}
+	return name
+}
+
+// Set creates or replaces an alias.
+func (s *AliasStore) Set(alias, user string) error {
+	if alias == "" || user == "" {
+		return fmt.Errorf("alias and user are required")
+	}
+	if alias == user {
+		return fmt.Errorf("alias must differ from user")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loadErr != nil {
+		return s.unwritableLocked()
+	}
+	if _, isAlias := s.aliases[user]; isAlias {
+		return fmt.Errorf("%s is itself an alias", user)
+	}
+	for existing, target := range s.aliases {
+		if target == alias {
+			return fmt.Errorf("%s is the target of alias %s", alias, existing)
+		}
+	}
+
+	s.aliases[alias] = user
+	return s.saveLocked()
+}
+
+// Delete removes an alias, reporting whether it existed.
+func (s *AliasStore) Delete(alias string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loadErr != nil {
+		return false, s.unwritableLocked()
+	}
+	if _, ok := s.aliases[alias]; !ok {
+		return false, nil
+	}
+
+	delete(s.aliases, alias)
+	return true, s.saveLocked()
+}
+
+// List returns a copy of all aliases.
+func (s *AliasStore) List() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	aliases := make(map[string]string, len(s.aliases))
+	for alias, user := range s.aliases {
+		aliases[alias] = user
+	}
+	return aliases
+}
+
+// AliasesFor returns the sorted aliases pointing at a user.
+func (s *AliasStore) AliasesFor(user string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var aliases []string
+	for alias, target := range s.aliases {
+		if target == user {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// unwritableLocked returns the error changes fail with while the alias file
+// could not be loaded. The caller must hold s.mu.
+func (s *AliasStore) unwritableLocked() error {
+	return fmt.Errorf("%w, aliases cannot be changed until %s is fixed or removed: %v", ErrAliasFileInvalid, s.path, s.loadErr)
+}
+
+// saveLocked writes the aliases to disk. The caller must hold s.mu.
+func (s *AliasStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.aliases, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode aliases: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create alias directory: %v", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write alias file: %v", err)
+	}
+	return os.Rename(tmpPath, s.path)
+}
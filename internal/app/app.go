@@ -1,10 +1,14 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"github.com/neekaru/whatsappgo-bot/internal/contentfilter"
 	"github.com/neekaru/whatsappgo-bot/pkg/logger"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/store/sqlstore"
@@ -19,9 +23,40 @@ type Session struct {
 	Phone        string
 	IsLoggedIn   bool
 	LatestQRCode string       // Store the latest QR code
+	QRCreatedAt  time.Time    // When LatestQRCode was received
+	QRExpiresAt  time.Time    // When LatestQRCode stops being scannable
 	QRLock       sync.RWMutex // Lock to protect access to LatestQRCode
 }
 
+// SetLatestQRCode stores a pairing code that stays valid for ttl
+func (s *Session) SetLatestQRCode(code string, ttl time.Duration) {
+	s.QRLock.Lock()
+	defer s.QRLock.Unlock()
+	s.LatestQRCode = code
+	s.QRCreatedAt = time.Now()
+	s.QRExpiresAt = s.QRCreatedAt.Add(ttl)
+}
+
+// GetLatestQRCode returns the latest pairing code with its creation and expiry
+// times, or an empty string once it has expired
+func (s *Session) GetLatestQRCode() (string, time.Time, time.Time) {
+	s.QRLock.RLock()
+	defer s.QRLock.RUnlock()
+	if s.LatestQRCode == "" || time.Now().After(s.QRExpiresAt) {
+		return "", time.Time{}, time.Time{}
+	}
+	return s.LatestQRCode, s.QRCreatedAt, s.QRExpiresAt
+}
+
+// ClearLatestQRCode forgets the stored pairing code
+func (s *Session) ClearLatestQRCode() {
+	s.QRLock.Lock()
+	defer s.QRLock.Unlock()
+	s.LatestQRCode = ""
+	s.QRCreatedAt = time.Time{}
+	s.QRExpiresAt = time.Time{}
+}
+
 // App holds shared application state and resources
 type App struct {
 	// Legacy session management - kept for backward compatibility
@@ -33,40 +68,126 @@ type App struct {
 
 	SendLimiter *SendRateLimiter
 	DuplicateLimiter *DuplicateMessageLimiter
+
+	Aliases *AliasStore // Business-name aliases for session users
+
+	Options *SessionOptionsStore // Per-session behaviour settings
+
+	Metadata *SessionMetadataStore // Per-session key/value tags
+
+	BusinessHours *BusinessHoursStore // Per-session opening hours and away message
+
+	Outbox *OutboxStore // Record of outgoing messages
+
+	Received *ReceivedStore // IDs of incoming messages already delivered
+
+	OptIns *OptInStore // Recipients that confirmed bulk messages
+
+	Conversations *ConversationStore // Handoff state of chats between bot and agents
+
+	MediaHandles *MediaHandleStore // Media uploaded once and sent by handle
+
+	Threads *ThreadStore // Recent messages with their quote, reaction and edit references
+
+	Polls *PollStore // Polls and their votes
+
+	Deliveries *DeliveryTracker // Send-to-delivery latency of recent messages per session
+
+	Disk *DiskGuard // Free space of the data directory's filesystem
+
+	ContentFilter *contentfilter.Chain // Optional compliance filter for outgoing text; nil filters nothing
+
+	PanicCount atomic.Uint64 // Number of panics recovered in HTTP handlers
+}
+
+// Send priorities, highest first. Higher-priority sends are spaced only against
+// each other, so a backlog of bulk traffic never delays transactional messages.
+const (
+	SendPriorityHigh   = "high"
+	SendPriorityNormal = "normal"
+	SendPriorityLow    = "low"
+)
+
+// sendPriorities lists the send priorities from highest to lowest
+var sendPriorities = []string{SendPriorityHigh, SendPriorityNormal, SendPriorityLow}
+
+// ParseSendPriority validates a send priority, defaulting an empty value to normal.
+func ParseSendPriority(priority string) (string, error) {
+	if priority == "" {
+		return SendPriorityNormal, nil
+	}
+	for _, known := range sendPriorities {
+		if priority == known {
+			return priority, nil
+		}
+	}
+	return "", fmt.Errorf("invalid priority %q, must be one of high, normal, low", priority)
+}
+
+// sendPriorityRank returns the lane index of a priority, using the normal lane for unknown values
+func sendPriorityRank(priority string) int {
+	for i, known := range sendPriorities {
+		if priority == known {
+			return i
+		}
+	}
+	return sendPriorityRank(SendPriorityNormal)
 }
 
 // SendRateLimiter enforces a minimum delay between send operations per user.
+// Each priority has its own lane so higher-priority sends skip queued lower ones.
 type SendRateLimiter struct {
 	mu          sync.Mutex
 	nextAllowed map[string]time.Time
+
+	// Sessions WhatsApp is throttling, and the backoff after the first throttle
+	throttles       map[string]*throttleState
+	throttleBackoff time.Duration
 }
 
 // NewSendRateLimiter creates a new SendRateLimiter.
 func NewSendRateLimiter() *SendRateLimiter {
 	return &SendRateLimiter{
-		nextAllowed: make(map[string]time.Time),
+		nextAllowed:     make(map[string]time.Time),
+		throttles:       make(map[string]*throttleState),
+		throttleBackoff: defaultThrottleBackoff,
 	}
 }
 
-// Wait blocks until the caller is allowed to send for the given user.
+// Wait blocks until the caller is allowed to send a normal-priority message for the given user.
 func (l *SendRateLimiter) Wait(user string, delay time.Duration) {
+	l.WaitPriority(user, SendPriorityNormal, delay)
+}
+
+// WaitPriority blocks until the caller is allowed to send for the given user
+// in the lane of the given priority. Lower-priority lanes are pushed back so
+// they do not send before this message.
+func (l *SendRateLimiter) WaitPriority(user, priority string, delay time.Duration) {
 	if delay <= 0 {
 		return
 	}
 
+	rank := sendPriorityRank(priority)
 	now := time.Now()
 
 	l.mu.Lock()
-	next := l.nextAllowed[user]
-	if next.After(now) {
-		l.nextAllowed[user] = next.Add(delay)
-		l.mu.Unlock()
-		time.Sleep(time.Until(next))
-		return
+	lane := user + "|" + sendPriorities[rank]
+	slot := now
+	if next := l.nextAllowed[lane]; next.After(now) {
+		slot = next
 	}
+	after := slot.Add(delay)
+	l.nextAllowed[lane] = after
 
-	l.nextAllowed[user] = now.Add(delay)
+	for _, lower := range sendPriorities[rank+1:] {
+		lowerLane := user + "|" + lower
+		if l.nextAllowed[lowerLane].Before(after) {
+			l.nextAllowed[lowerLane] = after
+		}
+	}
 	l.mu.Unlock()
+
+	time.Sleep(time.Until(slot))
 }
 
 // DuplicateMessageLimiter blocks repeated messages per key for a fixed window.
@@ -118,19 +239,115 @@ func (l *DuplicateMessageLimiter) Allow(key string, max int, window time.Duratio
 	return true, 0
 }
 
+// appDatabases are the databases NewApp keeps in the data directory, next to
+// the per-session databases
+var appDatabases = map[string]bool{
+	"outbox":        true,
+	"received":      true,
+	"opt_in":        true,
+	"conversations": true,
+	"media":         true,
+	"threads":       true,
+	"polls":         true,
+}
+
+// IsAppDatabase reports whether a database name in the data directory, without
+// its .db extension, belongs to the app rather than to a session
+func IsAppDatabase(name string) bool {
+	return appDatabases[name]
+}
+
 // NewApp creates a new App instance with initialized resources
 func NewApp(appLogger *logger.Logger) *App {
 	// Initialize the ClientManager singleton
 	manager := client.GetInstance()
 	manager.SetLogger(appLogger.WithPrefix("ClientManager"))
 
+	aliases, err := NewAliasStore("data/aliases.json")
+	if err != nil {
+		appLogger.Printf("Error: failed to load session aliases, none are resolved and changes are refused until the file is fixed: %v", err)
+	}
+
+	options, err := NewSessionOptionsStore("data/session_options.json")
+	if err != nil {
+		appLogger.Printf("Failed to load session options: %v", err)
+	}
+
+	metadata, err := NewSessionMetadataStore("data/session_metadata.json")
+	if err != nil {
+		appLogger.Printf("Error: failed to load session metadata, none is shown and changes are refused until the file is fixed: %v", err)
+	}
+
+	businessHours, err := NewBusinessHoursStore("data/business_hours.json")
+	if err != nil {
+		appLogger.Printf("Failed to load business hours: %v", err)
+	}
+
+	outbox, err := NewOutboxStore("data/outbox.db")
+	if err != nil {
+		appLogger.Printf("Failed to open outbox, outgoing messages will not be recorded: %v", err)
+	}
+
+	received, err := NewReceivedStore("data/received.db")
+	if err != nil {
+		appLogger.Printf("Failed to open received message store, incoming messages will not be deduplicated: %v", err)
+	}
+
+	optIns, err := NewOptInStore("data/opt_in.db")
+	if err != nil {
+		appLogger.Printf("Failed to open opt-in store, opt-in confirmation will not be enforced: %v", err)
+	}
+
+	conversations, err := NewConversationStore("data/conversations.db")
+	if err != nil {
+		appLogger.Printf("Failed to open conversation store, handoff state will not be tracked: %v", err)
+	}
+
+	mediaHandles, err := NewMediaHandleStore("data/media.db")
+	if err != nil {
+		appLogger.Printf("Failed to open media handle store, media cannot be uploaded ahead of sending: %v", err)
+	}
+
+	threads, err := NewThreadStore("data/threads.db")
+	if err != nil {
+		appLogger.Printf("Failed to open thread store, message threads will not be recorded: %v", err)
+	}
+
+	polls, err := NewPollStore("data/polls.db")
+	if err != nil {
+		appLogger.Printf("Failed to open poll store, poll votes will not be recorded: %v", err)
+	}
+
 	return &App{
 		Sessions:  make(map[string]*Session),
 		Logger:    appLogger,
 		StartTime: time.Now(),
 		SendLimiter: NewSendRateLimiter(),
 		DuplicateLimiter: NewDuplicateMessageLimiter(),
+		Aliases:          aliases,
+		Options:          options,
+		Metadata:         metadata,
+		BusinessHours:    businessHours,
+		Outbox:           outbox,
+		Received:         received,
+		OptIns:           optIns,
+		Conversations:    conversations,
+		MediaHandles:     mediaHandles,
+		Threads:          threads,
+		Polls:            polls,
+		Deliveries:       NewDeliveryTracker(),
+		Disk:             NewDiskGuard(),
+	}
+}
+
+// AcquireClientOp waits for a free send/upload slot on the user's client. The
+// returned function releases it; sessions without a managed client are not limited.
+func (a *App) AcquireClientOp(ctx context.Context, user string) (func(), error) {
+	whatsappClient, ok := a.GetClientManager().GetClient(user)
+	if !ok {
+		return func() {}, nil
 	}
+	return whatsappClient.AcquireOp(ctx)
 }
 
 // GetClientManager returns the ClientManager singleton
@@ -0,0 +1,236 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	// Embed the timezone database, the runtime image has no zoneinfo
+	_ "time/tzdata"
+)
+
+// DefaultAwayReplyInterval is how long a chat is not sent the away message
+// again when a session does not set reply_interval_hours
+const DefaultAwayReplyInterval = 12 * time.Hour
+
+// MaxAwayMessageLength bounds the away message of a session
+const MaxAwayMessageLength = 4096
+
+// weekdays maps the day names accepted in opening hours to weekdays
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// OpeningHours is a daily opening time on some days of the week. A Close
+// before Open runs past midnight into the next day.
+type OpeningHours struct {
+	Days  []string `json:"days"`  // mon, tue, wed, thu, fri, sat, sun
+	Open  string   `json:"open"`  // HH:MM
+	Close string   `json:"close"` // HH:MM, or 24:00 for midnight
+}
+
+// BusinessHours are the opening hours of a session and the away message
+// replied to chats that write outside them
+type BusinessHours struct {
+	Timezone           string         `json:"timezone"` // IANA name, UTC when empty
+	Hours              []OpeningHours `json:"hours"`
+	AwayMessage        string         `json:"away_message"`
+	ReplyIntervalHours int            `json:"reply_interval_hours,omitempty"` // Default 12
+}
+
+// Validate checks that business hours can be stored and evaluated
+func (h BusinessHours) Validate() error {
+	if _, err := time.LoadLocation(h.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q", h.Timezone)
+	}
+	if len(h.Hours) == 0 {
+		return fmt.Errorf("hours must not be empty")
+	}
+	for i, hours := range h.Hours {
+		if len(hours.Days) == 0 {
+			return fmt.Errorf("hours[%d] needs days", i)
+		}
+		for _, day := range hours.Days {
+			if _, ok := weekdays[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("hours[%d] has invalid day %q, must be one of mon, tue, wed, thu, fri, sat, sun", i, day)
+			}
+		}
+		opens, err := parseClock(hours.Open)
+		if err != nil || opens == 24*60 {
+			return fmt.Errorf("hours[%d] has invalid open %q, must be HH:MM", i, hours.Open)
+		}
+		closes, err := parseClock(hours.Close)
+		if err != nil {
+			return fmt.Errorf("hours[%d] has invalid close %q, must be HH:MM", i, hours.Close)
+		}
+		if opens == closes {
+			return fmt.Errorf("hours[%d] opens and closes at the same time", i)
+		}
+	}
+	if strings.TrimSpace(h.AwayMessage) == "" {
+		return fmt.Errorf("away_message must not be empty")
+	}
+	if len(h.AwayMessage) > MaxAwayMessageLength {
+		return fmt.Errorf("away_message is longer than %d characters", MaxAwayMessageLength)
+	}
+	if h.ReplyIntervalHours < 0 {
+		return fmt.Errorf("reply_interval_hours must not be negative")
+	}
+	return nil
+}
+
+// ReplyInterval returns how long a chat is not sent the away message again
+func (h BusinessHours) ReplyInterval() time.Duration {
+	if h.ReplyIntervalHours == 0 {
+		return DefaultAwayReplyInterval
+	}
+	return time.Duration(h.ReplyIntervalHours) * time.Hour
+}
+
+// IsOpen reports whether t falls within the opening hours, in their timezone
+func (h BusinessHours) IsOpen(t time.Time) bool {
+	location, err := time.LoadLocation(h.Timezone)
+	if err != nil {
+		return true
+	}
+	t = t.In(location)
+	now := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+
+	for _, hours := range h.Hours {
+		opens, err := parseClock(hours.Open)
+		if err != nil {
+			continue
+		}
+		closes, err := parseClock(hours.Close)
+		if err != nil {
+			continue
+		}
+		for _, name := range hours.Days {
+			day := weekdays[strings.ToLower(name)]
+			switch {
+			case opens < closes:
+				if day == today && now >= opens && now < closes {
+					return true
+				}
+			default:
+				// Runs past midnight: the evening of the day itself and the
+				// early hours of the next one
+				if day == today && now >= opens {
+					return true
+				}
+				if day == yesterday && now < closes {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// parseClock parses an HH:MM time of day into minutes after midnight
+func parseClock(value string) (int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(value, "%2d:%2d", &hour, &minute); err != nil || len(value) != 5 {
+		return 0, fmt.Errorf("invalid time of day %q", value)
+	}
+	if hour == 24 && minute == 0 {
+		return 24 * 60, nil
+	}
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid time of day %q", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// BusinessHoursStore keeps per-session business hours, persisted as a JSON file.
+type BusinessHoursStore struct {
+	mu    sync.RWMutex
+	path  string
+	hours map[string]BusinessHours
+}
+
+// NewBusinessHoursStore creates a BusinessHoursStore backed by the given file, loading existing business hours.
+func NewBusinessHoursStore(path string) (*BusinessHoursStore, error) {
+	s := &BusinessHoursStore{
+		path:  path,
+		hours: make(map[string]BusinessHours),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read business hours file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &s.hours); err != nil {
+		return s, fmt.Errorf("failed to parse business hours file: %v", err)
+	}
+
+	return s, nil
+}
+
+// Get returns the business hours of a user, and whether any are set.
+func (s *BusinessHoursStore) Get(user string) (BusinessHours, bool) {
+	if s == nil {
+		return BusinessHours{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hours, ok := s.hours[user]
+	return hours, ok
+}
+
+// Set validates and stores the business hours of a user.
+func (s *BusinessHoursStore) Set(user string, hours BusinessHours) error {
+	if err := hours.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hours[user] = hours
+	return s.saveLocked()
+}
+
+// Delete removes the business hours of a user, turning off the away message.
+func (s *BusinessHoursStore) Delete(user string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.hours[user]; !ok {
+		return nil
+	}
+	delete(s.hours, user)
+	return s.saveLocked()
+}
+
+// saveLocked writes the business hours to disk. The caller must hold s.mu.
+func (s *BusinessHoursStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.hours, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode business hours: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create business hours directory: %v", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write business hours file: %v", err)
+	}
+	return os.Rename(tmpPath, s.path)
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedPrefix marks values encrypted by a FieldCipher. Values without it
+// are plaintext written before encryption was enabled.
+const encryptedPrefix = "enc:v1:"
+
+// ErrNoMasterKey is returned when reading an encrypted value without a key
+var ErrNoMasterKey = errors.New("value is encrypted but no master key is configured")
+
+// FieldCipher encrypts individual database values with AES-256-GCM under the
+// application master key. A nil FieldCipher stores values as plaintext.
+type FieldCipher struct {
+	aead cipher.AEAD
+}
+
+// NewFieldCipher creates a cipher for a 32 byte master key
+func NewFieldCipher(key []byte) (*FieldCipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &FieldCipher{aead: aead}, nil
+}
+
+// ParseMasterKey decodes a 32 byte master key given as base64 or hex
+func ParseMasterKey(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if key, err := hex.DecodeString(value); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(value); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, fmt.Errorf("master key must be 32 bytes encoded as base64 or hex")
+}
+
+// Encrypt seals a value. Empty values are stored as is so they stay
+// recognisable as empty.
+func (c *FieldCipher) Encrypt(value string) (string, error) {
+	if c == nil || value == "" {
+		return value, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt. Plaintext values are returned
+// unchanged, so rows written before encryption was enabled stay readable.
+func (c *FieldCipher) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	if c == nil {
+		return "", ErrNoMasterKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value, the master key may have changed: %v", err)
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,13 @@
+package app
+
+// FilterContent runs the text of an outgoing message through the content
+// filter, logging any violations. It returns the text to send, redacted when
+// the filter redacts, or a *contentfilter.RejectedError when it rejects.
+func (a *App) FilterContent(user, recipient, text string) (string, error) {
+	filtered, violations, err := a.ContentFilter.Check(text)
+	if len(violations) > 0 {
+		a.Logger.Printf("Content filter violation in message from user %s to %s (action %s): %v",
+			user, recipient, a.ContentFilter.Action(), violations)
+	}
+	return filtered, err
+}
@@ -0,0 +1,278 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Handoff states of a conversation
+const (
+	ConversationBot      = "bot"      // Handled by the bot; the state of chats never transitioned
+	ConversationQueued   = "queued"   // Waiting for a human agent
+	ConversationAssigned = "assigned" // Handled by a human agent
+	ConversationClosed   = "closed"   // Resolved
+)
+
+// conversationTransitions lists the states each state may move to. Assigned
+// conversations may be assigned again to hand them to another agent.
+var conversationTransitions = map[string][]string{
+	ConversationBot:      {ConversationQueued, ConversationAssigned, ConversationClosed},
+	ConversationQueued:   {ConversationBot, ConversationAssigned, ConversationClosed},
+	ConversationAssigned: {ConversationBot, ConversationQueued, ConversationAssigned, ConversationClosed},
+	ConversationClosed:   {ConversationBot, ConversationQueued},
+}
+
+// ErrInvalidTransition is returned for a state change the handoff state
+// machine does not allow
+var ErrInvalidTransition = errors.New("invalid conversation transition")
+
+// ValidConversationState reports whether state is a handoff state
+func ValidConversationState(state string) bool {
+	_, ok := conversationTransitions[state]
+	return ok
+}
+
+// Conversation is the handoff state of one chat of a session
+type Conversation struct {
+	User      string     `json:"user"`
+	Chat      string     `json:"chat"`
+	State     string     `json:"state"`
+	Agent     string     `json:"agent,omitempty"`      // Set while assigned
+	UpdatedAt *time.Time `json:"updated_at,omitempty"` // Unset for chats never transitioned
+}
+
+// MaxChatNoteLength bounds the body of a chat note
+const MaxChatNoteLength = 4096
+
+// ChatNote is an internal note on a chat, never sent to WhatsApp
+type ChatNote struct {
+	ID        int64     `json:"id"`
+	Author    string    `json:"author,omitempty"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConversationStore keeps the handoff state of chats and their internal
+// notes, in a SQLite database.
+type ConversationStore struct {
+	db *sql.DB
+}
+
+const conversationSchema = `
+CREATE TABLE IF NOT EXISTS conversations (
+	user       TEXT NOT NULL,
+	chat       TEXT NOT NULL,
+	state      TEXT NOT NULL,
+	agent      TEXT NOT NULL DEFAULT '',
+	updated_at INTEGER NOT NULL,
+	PRIMARY KEY (user, chat)
+);
+CREATE INDEX IF NOT EXISTS conversations_state ON conversations (user, state, updated_at);
+CREATE TABLE IF NOT EXISTS chat_notes (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	user       TEXT NOT NULL,
+	chat       TEXT NOT NULL,
+	author     TEXT NOT NULL DEFAULT '',
+	body       TEXT NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS chat_notes_chat ON chat_notes (user, chat, id);
+`
+
+// NewConversationStore opens (creating if needed) the conversation database at path.
+func NewConversationStore(path string) (*ConversationStore, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open conversation database: %v", err)
+	}
+	// SQLite handles a single writer; serialise access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(conversationSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create conversation schema: %v", err)
+	}
+
+	return &ConversationStore{db: db}, nil
+}
+
+// Get returns the handoff state of a chat; chats never transitioned are with the bot
+func (s *ConversationStore) Get(user, chat string) (Conversation, error) {
+	if s == nil {
+		return Conversation{}, fmt.Errorf("conversation store is not available")
+	}
+	return getConversation(s.db.QueryRow, user, chat)
+}
+
+// Transition moves a chat to state, returning the conversation before and
+// after the change. Assigning requires an agent; other states clear it.
+func (s *ConversationStore) Transition(user, chat, state, agent string) (Conversation, Conversation, error) {
+	if s == nil {
+		return Conversation{}, Conversation{}, fmt.Errorf("conversation store is not available")
+	}
+	if !ValidConversationState(state) {
+		return Conversation{}, Conversation{}, fmt.Errorf("%w: unknown state %q", ErrInvalidTransition, state)
+	}
+	if state == ConversationAssigned && agent == "" {
+		return Conversation{}, Conversation{}, fmt.Errorf("%w: assigning needs an agent", ErrInvalidTransition)
+	}
+	if state != ConversationAssigned {
+		agent = ""
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Conversation{}, Conversation{}, fmt.Errorf("failed to start conversation transition: %v", err)
+	}
+	defer tx.Rollback()
+
+	previous, err := getConversation(tx.QueryRow, user, chat)
+	if err != nil {
+		return Conversation{}, Conversation{}, err
+	}
+	if !transitionAllowed(previous.State, state) || (previous.State == state && previous.Agent == agent) {
+		return Conversation{}, Conversation{}, fmt.Errorf("%w from %s to %s", ErrInvalidTransition, previous.State, state)
+	}
+
+	now := time.Now()
+	current := Conversation{User: user, Chat: chat, State: state, Agent: agent, UpdatedAt: &now}
+	if _, err := tx.Exec(
+		`INSERT INTO conversations (user, chat, state, agent, updated_at) VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (user, chat) DO UPDATE SET state = excluded.state, agent = excluded.agent, updated_at = excluded.updated_at`,
+		user, chat, state, agent, now.UnixMilli(),
+	); err != nil {
+		return Conversation{}, Conversation{}, fmt.Errorf("failed to store conversation state: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Conversation{}, Conversation{}, fmt.Errorf("failed to store conversation state: %v", err)
+	}
+	return previous, current, nil
+}
+
+// List returns the conversations of a user that left the bot state at some
+// point, optionally filtered by state and agent, most recently changed first,
+// along with their total count.
+func (s *ConversationStore) List(user, state, agent string, limit, offset int) ([]Conversation, int, error) {
+	if s == nil {
+		return []Conversation{}, 0, nil
+	}
+
+	where := ` WHERE user = ?`
+	args := []interface{}{user}
+	if state != "" {
+		where += ` AND state = ?`
+		args = append(args, state)
+	}
+	if agent != "" {
+		where += ` AND agent = ?`
+		args = append(args, agent)
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM conversations`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count conversations: %v", err)
+	}
+
+	rows, err := s.db.Query(
+		`SELECT user, chat, state, agent, updated_at FROM conversations`+where+` ORDER BY updated_at DESC LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list conversations: %v", err)
+	}
+	defer rows.Close()
+
+	conversations := []Conversation{}
+	for rows.Next() {
+		var conversation Conversation
+		var updatedAt int64
+		if err := rows.Scan(&conversation.User, &conversation.Chat, &conversation.State, &conversation.Agent, &updatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to read conversation: %v", err)
+		}
+		updated := time.UnixMilli(updatedAt)
+		conversation.UpdatedAt = &updated
+		conversations = append(conversations, conversation)
+	}
+	return conversations, total, rows.Err()
+}
+
+// AddNote attaches an internal note to a chat
+func (s *ConversationStore) AddNote(user, chat, author, body string) (ChatNote, error) {
+	if s == nil {
+		return ChatNote{}, fmt.Errorf("conversation store is not available")
+	}
+	note := ChatNote{Author: author, Body: body, CreatedAt: time.Now()}
+	result, err := s.db.Exec(
+		`INSERT INTO chat_notes (user, chat, author, body, created_at) VALUES (?, ?, ?, ?, ?)`,
+		user, chat, author, body, note.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return ChatNote{}, fmt.Errorf("failed to store chat note: %v", err)
+	}
+	note.ID, _ = result.LastInsertId()
+	return note, nil
+}
+
+// Notes returns the internal notes of a chat, oldest first
+func (s *ConversationStore) Notes(user, chat string) ([]ChatNote, error) {
+	if s == nil {
+		return []ChatNote{}, nil
+	}
+	rows, err := s.db.Query(
+		`SELECT id, author, body, created_at FROM chat_notes WHERE user = ? AND chat = ? ORDER BY id`, user, chat,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chat notes: %v", err)
+	}
+	defer rows.Close()
+
+	notes := []ChatNote{}
+	for rows.Next() {
+		var note ChatNote
+		var createdAt int64
+		if err := rows.Scan(&note.ID, &note.Author, &note.Body, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to read chat note: %v", err)
+		}
+		note.CreatedAt = time.UnixMilli(createdAt)
+		notes = append(notes, note)
+	}
+	return notes, rows.Err()
+}
+
+// Close closes the conversation database
+func (s *ConversationStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// getConversation reads the state of a chat through db or a transaction
+func getConversation(queryRow func(string, ...interface{}) *sql.Row, user, chat string) (Conversation, error) {
+	conversation := Conversation{User: user, Chat: chat, State: ConversationBot}
+	var updatedAt int64
+	err := queryRow(
+		`SELECT state, agent, updated_at FROM conversations WHERE user = ? AND chat = ?`, user, chat,
+	).Scan(&conversation.State, &conversation.Agent, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return conversation, nil
+	}
+	if err != nil {
+		return Conversation{}, fmt.Errorf("failed to read conversation: %v", err)
+	}
+	updated := time.UnixMilli(updatedAt)
+	conversation.UpdatedAt = &updated
+	return conversation, nil
+}
+
+// transitionAllowed reports whether the state machine allows from -> to
+func transitionAllowed(from, to string) bool {
+	for _, allowed := range conversationTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
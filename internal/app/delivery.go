@@ -0,0 +1,205 @@
+package app
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Rolling window delivery latencies are kept for by default
+const defaultDeliveryWindow = time.Hour
+
+// Latency samples and unacknowledged sends kept per session; the oldest are
+// dropped past this so busy sessions don't grow without bound
+const maxDeliverySamples = 10000
+
+// DeliveryStats summarizes how long a session's messages took from being sent
+// to being delivered to the recipient's phone over the rolling window
+type DeliveryStats struct {
+	User      string  `json:"user"`
+	Delivered int     `json:"delivered"` // Messages delivered within the window
+	Pending   int     `json:"pending"`   // Messages sent within the window and not delivered yet
+	P50Ms     float64 `json:"p50_ms"`
+	P95Ms     float64 `json:"p95_ms"`
+	P99Ms     float64 `json:"p99_ms"`
+	MaxMs     float64 `json:"max_ms"`
+}
+
+// deliverySample is the latency of one delivered message
+type deliverySample struct {
+	at      time.Time
+	latency time.Duration
+}
+
+// deliveryState holds the sends awaiting a receipt and recent latencies of a session
+type deliveryState struct {
+	pending map[string]time.Time // Send time by message ID
+	samples []deliverySample     // Oldest first
+}
+
+// DeliveryTracker measures per session how long sent messages take to be
+// delivered, from the send to the first delivery receipt
+type DeliveryTracker struct {
+	mu       sync.Mutex
+	window   time.Duration
+	sessions map[string]*deliveryState
+}
+
+// NewDeliveryTracker creates a delivery tracker with the default window
+func NewDeliveryTracker() *DeliveryTracker {
+	return &DeliveryTracker{
+		window:   defaultDeliveryWindow,
+		sessions: make(map[string]*deliveryState),
+	}
+}
+
+// SetWindow sets how far back latencies are summarized. Non-positive values
+// are ignored.
+func (t *DeliveryTracker) SetWindow(window time.Duration) {
+	if window <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.window = window
+}
+
+// Window returns how far back latencies are summarized
+func (t *DeliveryTracker) Window() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.window
+}
+
+// Sent records that a message of user was sent at sentAt
+func (t *DeliveryTracker) Sent(user, messageID string, sentAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.sessions[user]
+	if !ok {
+		state = &deliveryState{pending: make(map[string]time.Time)}
+		t.sessions[user] = state
+	}
+	if len(state.pending) >= maxDeliverySamples {
+		t.expire(state, time.Now())
+	}
+	if len(state.pending) >= maxDeliverySamples {
+		return
+	}
+	state.pending[messageID] = sentAt
+}
+
+// Delivered records that messages of user were delivered at deliveredAt.
+// Only the first receipt of a message counts; messages not sent through
+// Sent are ignored.
+func (t *DeliveryTracker) Delivered(user string, messageIDs []string, deliveredAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.sessions[user]
+	if !ok {
+		return
+	}
+	for _, id := range messageIDs {
+		sentAt, ok := state.pending[id]
+		if !ok {
+			continue
+		}
+		delete(state.pending, id)
+		latency := deliveredAt.Sub(sentAt)
+		if latency < 0 {
+			latency = 0
+		}
+		state.samples = append(state.samples, deliverySample{at: deliveredAt, latency: latency})
+	}
+	if over := len(state.samples) - maxDeliverySamples; over > 0 {
+		state.samples = append(state.samples[:0:0], state.samples[over:]...)
+	}
+}
+
+// Stats returns the delivery latency of every session with sends in the
+// window, ordered by user
+func (t *DeliveryTracker) Stats() []DeliveryStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]DeliveryStats, 0, len(t.sessions))
+	for user, state := range t.sessions {
+		t.expire(state, now)
+		if len(state.pending) == 0 && len(state.samples) == 0 {
+			delete(t.sessions, user)
+			continue
+		}
+		stats = append(stats, summarizeDelivery(user, state))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].User < stats[j].User })
+	return stats
+}
+
+// UserStats returns the delivery latency of user, and false when the user had
+// no sends in the window
+func (t *DeliveryTracker) UserStats(user string) (DeliveryStats, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.sessions[user]
+	if !ok {
+		return DeliveryStats{}, false
+	}
+	t.expire(state, time.Now())
+	if len(state.pending) == 0 && len(state.samples) == 0 {
+		return DeliveryStats{}, false
+	}
+	return summarizeDelivery(user, state), true
+}
+
+// expire drops samples and pending sends older than the window
+func (t *DeliveryTracker) expire(state *deliveryState, now time.Time) {
+	cutoff := now.Add(-t.window)
+	for id, sentAt := range state.pending {
+		if sentAt.Before(cutoff) {
+			delete(state.pending, id)
+		}
+	}
+	keep := sort.Search(len(state.samples), func(i int) bool { return !state.samples[i].at.Before(cutoff) })
+	if keep > 0 {
+		state.samples = append(state.samples[:0:0], state.samples[keep:]...)
+	}
+}
+
+// summarizeDelivery computes the latency percentiles of a session
+func summarizeDelivery(user string, state *deliveryState) DeliveryStats {
+	stats := DeliveryStats{User: user, Delivered: len(state.samples), Pending: len(state.pending)}
+	if len(state.samples) == 0 {
+		return stats
+	}
+
+	latencies := make([]time.Duration, len(state.samples))
+	for i, sample := range state.samples {
+		latencies[i] = sample.latency
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	stats.P50Ms = percentileMs(latencies, 0.50)
+	stats.P95Ms = percentileMs(latencies, 0.95)
+	stats.P99Ms = percentileMs(latencies, 0.99)
+	stats.MaxMs = durationMs(latencies[len(latencies)-1])
+	return stats
+}
+
+// percentileMs returns the nearest-rank percentile of sorted latencies in milliseconds
+func percentileMs(sorted []time.Duration, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return durationMs(sorted[rank])
+}
+
+// durationMs converts a duration to milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"sync"
+	"time"
+)
+
+// DiskStatus describes the free space of the filesystem holding the data
+// directory and the space taken by the service's directories
+type DiskStatus struct {
+	Path         string           `json:"path"`
+	TotalBytes   uint64           `json:"total_bytes"`
+	FreeBytes    uint64           `json:"free_bytes"` // Available to the service
+	MinFreeBytes uint64           `json:"min_free_bytes"`
+	Low          bool             `json:"low"` // Free space is below MinFreeBytes
+	Usage        []DirectoryUsage `json:"usage"`
+	CheckedAt    time.Time        `json:"checked_at"`
+}
+
+// DirectoryUsage is the space taken by the files in a directory
+type DirectoryUsage struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// DiskGuard holds the latest disk status, so writers that can wait, such as
+// the raw event archive and backups, pause while free space is low rather
+// than fill the disk and fail mid-write
+type DiskGuard struct {
+	mu      sync.RWMutex
+	status  DiskStatus
+	checked bool
+}
+
+// NewDiskGuard creates a disk guard that reports enough space until a status is set
+func NewDiskGuard() *DiskGuard {
+	return &DiskGuard{}
+}
+
+// Update replaces the disk status
+func (g *DiskGuard) Update(status DiskStatus) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.status = status
+	g.checked = true
+}
+
+// Status returns the latest disk status, and false before the first check
+func (g *DiskGuard) Status() (DiskStatus, bool) {
+	if g == nil {
+		return DiskStatus{}, false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.status, g.checked
+}
+
+// Low reports whether free space was below the threshold at the last check
+func (g *DiskGuard) Low() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.status.Low
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Longest text WhatsApp accepts, in characters. Longer messages are rejected
+// by the server, or cut off on the recipient's phone.
+const (
+	MaxTextLength    = 65536 // Text messages
+	MaxCaptionLength = 1024  // Captions of images, videos and files
+)
+
+// TooLongError is returned for message text longer than WhatsApp accepts
+type TooLongError struct {
+	Field  string // Request field that is too long
+	Length int
+	Limit  int
+}
+
+func (e *TooLongError) Error() string {
+	return fmt.Sprintf("%s is %d characters long, WhatsApp allows at most %d", e.Field, e.Length, e.Limit)
+}
+
+// CheckLength returns a *TooLongError when value has more than limit characters
+func CheckLength(field, value string, limit int) error {
+	if length := utf8.RuneCountInString(value); length > limit {
+		return &TooLongError{Field: field, Length: length, Limit: limit}
+	}
+	return nil
+}
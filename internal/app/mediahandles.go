@@ -0,0 +1,189 @@
+package app
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// How long an uploaded media handle can be sent. WhatsApp only keeps uploaded
+// media on its servers for a limited time; expired handles must be uploaded again.
+const MediaHandleTTL = 14 * 24 * time.Hour
+
+// ErrMediaHandleNotFound is returned for handles that do not exist, belong to
+// another session or have expired
+var ErrMediaHandleNotFound = errors.New("media handle not found or expired")
+
+// MediaHandle is media uploaded to WhatsApp once that can be attached to any
+// number of messages of the session that uploaded it, without uploading it again
+type MediaHandle struct {
+	ID        string    `json:"handle"`
+	User      string    `json:"user"`
+	Type      string    `json:"type"`
+	FileName  string    `json:"file_name,omitempty"`
+	MimeType  string    `json:"mime_type"`
+	Size      uint64    `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Location and keys of the uploaded media, attached to the messages sent with it
+	URL           string `json:"-"`
+	DirectPath    string `json:"-"`
+	MediaKey      []byte `json:"-"`
+	FileSHA256    []byte `json:"-"`
+	FileEncSHA256 []byte `json:"-"`
+	Thumbnail     []byte `json:"-"`
+
+	// Length of audio and waveform of voice notes, shown by the recipient's player
+	Seconds  uint32 `json:"seconds,omitempty"`
+	Waveform []byte `json:"-"`
+}
+
+// MediaHandleStore keeps uploaded media handles in a SQLite database
+type MediaHandleStore struct {
+	db *sql.DB
+
+	cipher atomic.Pointer[FieldCipher] // Encrypts media keys when set
+}
+
+const mediaHandleSchema = `
+CREATE TABLE IF NOT EXISTS media_handles (
+	id              TEXT PRIMARY KEY,
+	user            TEXT NOT NULL,
+	type            TEXT NOT NULL,
+	file_name       TEXT NOT NULL DEFAULT '',
+	mime_type       TEXT NOT NULL,
+	size            INTEGER NOT NULL,
+	url             TEXT NOT NULL,
+	direct_path     TEXT NOT NULL,
+	media_key       TEXT NOT NULL,
+	file_sha256     BLOB,
+	file_enc_sha256 BLOB,
+	thumbnail       BLOB,
+	created_at      INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS media_handles_created ON media_handles (created_at);
+`
+
+// mediaHandleAddedColumns are columns added to media_handles after its first release
+var mediaHandleAddedColumns = []string{
+	`seconds INTEGER NOT NULL DEFAULT 0`,
+	`waveform BLOB`,
+}
+
+// NewMediaHandleStore opens (creating if needed) the media handle database at path.
+func NewMediaHandleStore(path string) (*MediaHandleStore, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open media handle database: %v", err)
+	}
+	// SQLite handles a single writer; serialise access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(mediaHandleSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create media handle schema: %v", err)
+	}
+
+	for _, column := range mediaHandleAddedColumns {
+		if _, err := db.Exec(`ALTER TABLE media_handles ADD COLUMN ` + column); err != nil &&
+			!strings.Contains(err.Error(), "duplicate column name") {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate media handle schema: %v", err)
+		}
+	}
+
+	return &MediaHandleStore{db: db}, nil
+}
+
+// SetCipher encrypts the media keys of handles stored from now on. Handles
+// stored in plaintext stay readable.
+func (s *MediaHandleStore) SetCipher(cipher *FieldCipher) {
+	if s == nil {
+		return
+	}
+	s.cipher.Store(cipher)
+}
+
+// Create stores an uploaded media handle, filling in its ID and times, and
+// removes the handles that have expired
+func (s *MediaHandleStore) Create(handle *MediaHandle) error {
+	if s == nil {
+		return fmt.Errorf("media handle store is not available")
+	}
+
+	idBytes := make([]byte, 16)
+	_, _ = rand.Read(idBytes)
+	handle.ID = hex.EncodeToString(idBytes)
+	handle.CreatedAt = time.Now()
+	handle.ExpiresAt = handle.CreatedAt.Add(MediaHandleTTL)
+
+	mediaKey, err := s.cipher.Load().Encrypt(base64.StdEncoding.EncodeToString(handle.MediaKey))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt media handle: %v", err)
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO media_handles (id, user, type, file_name, mime_type, size, url, direct_path, media_key, file_sha256, file_enc_sha256, thumbnail, seconds, waveform, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		handle.ID, handle.User, handle.Type, handle.FileName, handle.MimeType, int64(handle.Size),
+		handle.URL, handle.DirectPath, mediaKey, handle.FileSHA256, handle.FileEncSHA256, handle.Thumbnail,
+		handle.Seconds, handle.Waveform, handle.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record media handle: %v", err)
+	}
+
+	_, _ = s.db.Exec(`DELETE FROM media_handles WHERE created_at < ?`, time.Now().Add(-MediaHandleTTL).UnixMilli())
+	return nil
+}
+
+// Get returns a handle uploaded by user, or ErrMediaHandleNotFound
+func (s *MediaHandleStore) Get(user, id string) (*MediaHandle, error) {
+	if s == nil {
+		return nil, ErrMediaHandleNotFound
+	}
+
+	handle := &MediaHandle{ID: id, User: user}
+	var size, createdAt int64
+	var mediaKey string
+	err := s.db.QueryRow(
+		`SELECT type, file_name, mime_type, size, url, direct_path, media_key, file_sha256, file_enc_sha256, thumbnail, seconds, waveform, created_at
+		 FROM media_handles WHERE id = ? AND user = ? AND created_at >= ?`,
+		id, user, time.Now().Add(-MediaHandleTTL).UnixMilli(),
+	).Scan(
+		&handle.Type, &handle.FileName, &handle.MimeType, &size, &handle.URL, &handle.DirectPath,
+		&mediaKey, &handle.FileSHA256, &handle.FileEncSHA256, &handle.Thumbnail, &handle.Seconds, &handle.Waveform, &createdAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMediaHandleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read media handle: %v", err)
+	}
+
+	if mediaKey, err = s.cipher.Load().Decrypt(mediaKey); err != nil {
+		return nil, fmt.Errorf("failed to read media handle %s: %v", id, err)
+	}
+	if handle.MediaKey, err = base64.StdEncoding.DecodeString(mediaKey); err != nil {
+		return nil, fmt.Errorf("failed to read media handle %s: %v", id, err)
+	}
+	handle.Size = uint64(size)
+	handle.CreatedAt = time.UnixMilli(createdAt)
+	handle.ExpiresAt = handle.CreatedAt.Add(MediaHandleTTL)
+	return handle, nil
+}
+
+// Close closes the media handle database
+func (s *MediaHandleStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
@@ -0,0 +1,193 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Limits on session metadata
+const (
+	MaxMetadataKeys        = 32
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 256
+)
+
+// ErrMetadataFileInvalid is returned for metadata changes while the metadata file could not be loaded
+var ErrMetadataFileInvalid = errors.New("session metadata file could not be loaded")
+
+// SessionMetadataStore keeps arbitrary key/value metadata per session, such as
+// team, customer ID or environment, persisted as a JSON file.
+type SessionMetadataStore struct {
+	mu       sync.RWMutex
+	path     string
+	metadata map[string]map[string]string
+	loadErr  error // Why the file could not be loaded; it is never overwritten then
+}
+
+// NewSessionMetadataStore creates a SessionMetadataStore backed by the given file, loading existing metadata.
+// A file that cannot be read or parsed is left as it is: the store starts
+// empty and refuses changes until the file is fixed.
+func NewSessionMetadataStore(path string) (*SessionMetadataStore, error) {
+	s := &SessionMetadataStore{
+		path:     path,
+		metadata: make(map[string]map[string]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		s.loadErr = fmt.Errorf("failed to read session metadata file: %v", err)
+		return s, s.loadErr
+	}
+
+	metadata := make(map[string]map[string]string)
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		s.loadErr = fmt.Errorf("failed to parse session metadata file: %v", err)
+		return s, s.loadErr
+	}
+
+	s.metadata = metadata
+	return s, nil
+}
+
+// ValidateMetadataKey checks that a metadata key can be stored and filtered on
+func ValidateMetadataKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("metadata key must not be empty")
+	}
+	if len(key) > MaxMetadataKeyLength {
+		return fmt.Errorf("metadata key %q is longer than %d characters", key, MaxMetadataKeyLength)
+	}
+	if strings.Contains(key, ":") {
+		return fmt.Errorf("metadata key %q must not contain ':'", key)
+	}
+	return nil
+}
+
+// Get returns a copy of the metadata of a user, empty if none is stored.
+func (s *SessionMetadataStore) Get(user string) map[string]string {
+	metadata := make(map[string]string)
+	if s == nil {
+		return metadata
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key, value := range s.metadata[user] {
+		metadata[key] = value
+	}
+	return metadata
+}
+
+// Update sets the given keys and removes the unset ones from a user's metadata,
+// saves the result and returns it.
+func (s *SessionMetadataStore) Update(user string, set map[string]string, unset []string) (map[string]string, error) {
+	for key, value := range set {
+		if err := ValidateMetadataKey(key); err != nil {
+			return nil, err
+		}
+		if len(value) > MaxMetadataValueLength {
+			return nil, fmt.Errorf("metadata value of %q is longer than %d characters", key, MaxMetadataValueLength)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loadErr != nil {
+		return nil, s.unwritableLocked()
+	}
+	metadata := make(map[string]string)
+	for key, value := range s.metadata[user] {
+		metadata[key] = value
+	}
+	for key, value := range set {
+		metadata[key] = value
+	}
+	for _, key := range unset {
+		delete(metadata, key)
+	}
+	if len(metadata) > MaxMetadataKeys {
+		return nil, fmt.Errorf("sessions can have at most %d metadata keys", MaxMetadataKeys)
+	}
+
+	if len(metadata) == 0 {
+		delete(s.metadata, user)
+	} else {
+		s.metadata[user] = metadata
+	}
+
+	result := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		result[key] = value
+	}
+	return result, s.saveLocked()
+}
+
+// Delete removes all metadata of a user, for sessions that were deleted
+func (s *SessionMetadataStore) Delete(user string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.metadata[user]; !ok {
+		return nil
+	}
+	if s.loadErr != nil {
+		return s.unwritableLocked()
+	}
+	delete(s.metadata, user)
+	return s.saveLocked()
+}
+
+// Matches reports whether a user's metadata has every key of filter with the
+// same value.
+func (s *SessionMetadataStore) Matches(user string, filter map[string]string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	metadata := s.metadata[user]
+	for key, value := range filter {
+		if stored, ok := metadata[key]; !ok || stored != value {
+			return false
+		}
+	}
+	return true
+}
+
+// unwritableLocked returns the error changes fail with while the metadata
+// file could not be loaded. The caller must hold s.mu.
+func (s *SessionMetadataStore) unwritableLocked() error {
+	return fmt.Errorf("%w, metadata cannot be changed until %s is fixed or removed: %v", ErrMetadataFileInvalid, s.path, s.loadErr)
+}
+
+// saveLocked writes the metadata to disk. The caller must hold s.mu.
+func (s *SessionMetadataStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session metadata: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create session metadata directory: %v", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write session metadata file: %v", err)
+	}
+	return os.Rename(tmpPath, s.path)
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeText repairs text from upstream systems that mishandle encodings,
+// so it renders the same on every recipient's phone. Surrogate pairs encoded
+// one half at a time (CESU-8, common in Java and MySQL utf8 exports) are
+// joined into the character they encode, lone surrogates, other invalid
+// bytes and the U+FFFD they were replaced with by JSON decoding are dropped,
+// and the result is composed to Unicode NFC.
+func NormalizeText(text string) string {
+	if utf8.ValidString(text) && !strings.ContainsRune(text, utf8.RuneError) && norm.NFC.IsNormalString(text) {
+		return text
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for i := 0; i < len(text); {
+		if r, size := decodeSurrogatePair(text[i:]); size > 0 {
+			b.WriteRune(r)
+			i += size
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != utf8.RuneError {
+			b.WriteRune(r)
+		}
+		// Invalid bytes and replacement characters are dropped alike
+		i += size
+	}
+	return norm.NFC.String(b.String())
+}
+
+// decodeSurrogatePair decodes a UTF-16 surrogate pair encoded as two
+// three-byte sequences at the start of s, returning its size or 0 if there is none
+func decodeSurrogatePair(s string) (rune, int) {
+	if len(s) < 6 || s[0] != 0xED || s[3] != 0xED {
+		return 0, 0
+	}
+	for _, c := range []byte{s[1], s[2], s[4], s[5]} {
+		if c&0xC0 != 0x80 {
+			return 0, 0
+		}
+	}
+	high := rune(s[0]&0x0F)<<12 | rune(s[1]&0x3F)<<6 | rune(s[2]&0x3F)
+	low := rune(s[3]&0x0F)<<12 | rune(s[4]&0x3F)<<6 | rune(s[5]&0x3F)
+	if high < 0xD800 || high > 0xDBFF || low < 0xDC00 || low > 0xDFFF {
+		return 0, 0
+	}
+	return 0x10000 + (high-0xD800)<<10 + (low - 0xDC00), 6
+}
+
+// NormalizeContent normalizes outgoing text with NormalizeText when the
+// session's options or the message's options ask for it
+func (a *App) NormalizeContent(user, text string, options *SendOptions) string {
+	if (options != nil && options.Normalize) || a.Options.Get(user).NormalizeText {
+		return NormalizeText(text)
+	}
+	return text
+}
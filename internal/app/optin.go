@@ -0,0 +1,196 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Opt-in states of a recipient
+const (
+	OptInPending   = "pending"   // Asked to confirm, has not replied with the keyword yet
+	OptInConfirmed = "confirmed" // Replied with the keyword, or was confirmed through the API
+)
+
+// OptIn is the opt-in state of one recipient of a session
+type OptIn struct {
+	User        string     `json:"user"`
+	Recipient   string     `json:"recipient"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requested_at"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+}
+
+// OptInStore tracks which recipients confirmed they want to receive bulk
+// messages, in a SQLite database.
+type OptInStore struct {
+	db *sql.DB
+}
+
+const optInSchema = `
+CREATE TABLE IF NOT EXISTS opt_ins (
+	user         TEXT NOT NULL,
+	recipient    TEXT NOT NULL,
+	status       TEXT NOT NULL,
+	requested_at INTEGER NOT NULL,
+	confirmed_at INTEGER,
+	PRIMARY KEY (user, recipient)
+);
+`
+
+// NewOptInStore opens (creating if needed) the opt-in database at path.
+func NewOptInStore(path string) (*OptInStore, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open opt-in database: %v", err)
+	}
+	// SQLite handles a single writer; serialise access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(optInSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create opt-in schema: %v", err)
+	}
+
+	return &OptInStore{db: db}, nil
+}
+
+// Request records that a recipient was asked to opt in and reports whether
+// this is the first time. Recipients already known keep their state.
+func (s *OptInStore) Request(user, recipient string) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+	result, err := s.db.Exec(
+		`INSERT OR IGNORE INTO opt_ins (user, recipient, status, requested_at) VALUES (?, ?, ?, ?)`,
+		user, recipient, OptInPending, time.Now().UnixMilli(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record opt-in request: %v", err)
+	}
+	inserted, _ := result.RowsAffected()
+	return inserted > 0, nil
+}
+
+// Confirm marks a pending recipient as confirmed, reporting whether it was pending
+func (s *OptInStore) Confirm(user, recipient string) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+	result, err := s.db.Exec(
+		`UPDATE opt_ins SET status = ?, confirmed_at = ? WHERE user = ? AND recipient = ? AND status = ?`,
+		OptInConfirmed, time.Now().UnixMilli(), user, recipient, OptInPending,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to confirm opt-in: %v", err)
+	}
+	confirmed, _ := result.RowsAffected()
+	return confirmed > 0, nil
+}
+
+// Set stores the state of a recipient regardless of its current one, for
+// consent collected outside WhatsApp. An empty status forgets the recipient.
+func (s *OptInStore) Set(user, recipient, status string) error {
+	if s == nil {
+		return fmt.Errorf("opt-in store is not available")
+	}
+	if status == "" {
+		_, err := s.db.Exec(`DELETE FROM opt_ins WHERE user = ? AND recipient = ?`, user, recipient)
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	var confirmedAt sql.NullInt64
+	if status == OptInConfirmed {
+		confirmedAt = sql.NullInt64{Int64: now, Valid: true}
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO opt_ins (user, recipient, status, requested_at, confirmed_at) VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (user, recipient) DO UPDATE SET status = excluded.status, confirmed_at = excluded.confirmed_at`,
+		user, recipient, status, now, confirmedAt,
+	)
+	return err
+}
+
+// Get returns the opt-in state of a recipient
+func (s *OptInStore) Get(user, recipient string) (OptIn, bool, error) {
+	if s == nil {
+		return OptIn{}, false, nil
+	}
+	rows, err := s.db.Query(`SELECT `+optInColumns+` FROM opt_ins WHERE user = ? AND recipient = ?`, user, recipient)
+	if err != nil {
+		return OptIn{}, false, err
+	}
+	defer rows.Close()
+
+	optIns, err := scanOptIns(rows)
+	if err != nil || len(optIns) == 0 {
+		return OptIn{}, false, err
+	}
+	return optIns[0], true, nil
+}
+
+// List returns the recipients of a user, optionally only those with the given
+// status, most recently requested first, along with their total count.
+func (s *OptInStore) List(user, status string, limit, offset int) ([]OptIn, int, error) {
+	if s == nil {
+		return []OptIn{}, 0, nil
+	}
+
+	where := ` WHERE user = ?`
+	args := []interface{}{user}
+	if status != "" {
+		where += ` AND status = ?`
+		args = append(args, status)
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM opt_ins`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count opt-ins: %v", err)
+	}
+
+	rows, err := s.db.Query(
+		`SELECT `+optInColumns+` FROM opt_ins`+where+` ORDER BY requested_at DESC LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list opt-ins: %v", err)
+	}
+	defer rows.Close()
+
+	optIns, err := scanOptIns(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return optIns, total, nil
+}
+
+// Close closes the opt-in database
+func (s *OptInStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+const optInColumns = `user, recipient, status, requested_at, confirmed_at`
+
+// scanOptIns reads rows selected with optInColumns
+func scanOptIns(rows *sql.Rows) ([]OptIn, error) {
+	optIns := []OptIn{}
+	for rows.Next() {
+		var optIn OptIn
+		var requestedAt int64
+		var confirmedAt sql.NullInt64
+		if err := rows.Scan(&optIn.User, &optIn.Recipient, &optIn.Status, &requestedAt, &confirmedAt); err != nil {
+			return nil, fmt.Errorf("failed to read opt-in: %v", err)
+		}
+		optIn.RequestedAt = time.UnixMilli(requestedAt)
+		if confirmedAt.Valid {
+			confirmed := time.UnixMilli(confirmedAt.Int64)
+			optIn.ConfirmedAt = &confirmed
+		}
+		optIns = append(optIns, optIn)
+	}
+	return optIns, rows.Err()
+}
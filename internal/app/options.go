@@ -0,0 +1,139 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Auto-read modes: when incoming messages are marked as read on the consumer's behalf
+const (
+	AutoReadOff     = "off"     // Never; the consumer calls /msg/read itself
+	AutoReadWebhook = "webhook" // Once the webhook consumer responds with a 2xx status
+	AutoReadAck     = "ack"     // Once the consumer acknowledges the message via /msg/ack
+)
+
+// ParseAutoRead validates an auto-read mode
+func ParseAutoRead(mode string) (string, error) {
+	switch mode {
+	case AutoReadOff, AutoReadWebhook, AutoReadAck:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid auto_read %q, must be one of off, webhook, ack", mode)
+	}
+}
+
+// ErrReceiveOnly is returned for sends from a session in receive-only mode
+var ErrReceiveOnly = errors.New("session is receive-only, outbound messages are disabled")
+
+// SessionOptions are per-session behaviour settings
+type SessionOptions struct {
+	AutoRead    string `json:"auto_read"`
+	DebugEvents bool   `json:"debug_events"` // Archive raw protocol events for debugging
+
+	// Tag incoming text messages published to sinks with their detected language
+	DetectLanguage bool `json:"detect_language,omitempty"`
+
+	// Reply recipients must send before low priority (bulk) messages reach
+	// them after the first one; empty disables opt-in confirmation
+	OptInKeyword string `json:"opt_in_keyword,omitempty"`
+
+	// Block every outbound message, for monitoring and archiving accounts
+	ReceiveOnly bool `json:"receive_only,omitempty"`
+
+	// Repair badly encoded outgoing text and compose it to NFC before sending
+	NormalizeText bool `json:"normalize_text,omitempty"`
+
+	// Publish messages the account sends from its phone or other linked
+	// devices to sinks, with from_me set, and record them in the outbox
+	OwnMessages bool `json:"own_messages,omitempty"`
+
+	// Show the session online again each time it connects or reconnects
+	AvailableOnConnect bool `json:"available_on_connect,omitempty"`
+
+	// Phone number the session must be logged in as; sends are rejected
+	// from any other account
+	ExpectedPhone string `json:"expected_phone,omitempty"`
+}
+
+// defaultSessionOptions returns the options of a session that has none stored
+func defaultSessionOptions() SessionOptions {
+	return SessionOptions{AutoRead: AutoReadOff}
+}
+
+// SessionOptionsStore keeps per-session options, persisted as a JSON file.
+type SessionOptionsStore struct {
+	mu      sync.RWMutex
+	path    string
+	options map[string]SessionOptions
+}
+
+// NewSessionOptionsStore creates a SessionOptionsStore backed by the given file, loading existing options.
+func NewSessionOptionsStore(path string) (*SessionOptionsStore, error) {
+	s := &SessionOptionsStore{
+		path:    path,
+		options: make(map[string]SessionOptions),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read session options file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &s.options); err != nil {
+		return s, fmt.Errorf("failed to parse session options file: %v", err)
+	}
+
+	return s, nil
+}
+
+// Get returns the options of a user, falling back to the defaults.
+func (s *SessionOptionsStore) Get(user string) SessionOptions {
+	if s == nil {
+		return defaultSessionOptions()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if options, ok := s.options[user]; ok {
+		return options
+	}
+	return defaultSessionOptions()
+}
+
+// Update applies fn to the user's current options and saves the result.
+func (s *SessionOptionsStore) Update(user string, fn func(*SessionOptions)) (SessionOptions, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	options, ok := s.options[user]
+	if !ok {
+		options = defaultSessionOptions()
+	}
+	fn(&options)
+	s.options[user] = options
+	return options, s.saveLocked()
+}
+
+// saveLocked writes the options to disk. The caller must hold s.mu.
+func (s *SessionOptionsStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.options, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session options: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create session options directory: %v", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write session options file: %v", err)
+	}
+	return os.Rename(tmpPath, s.path)
+}
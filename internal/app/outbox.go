@@ -0,0 +1,719 @@
+package app
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Outbox message states
+const (
+	OutboxStatusScheduled = "scheduled"
+	OutboxStatusPending   = "pending"
+	OutboxStatusSent      = "sent"
+	OutboxStatusFailed    = "failed"
+	OutboxStatusExpired   = "expired"
+	OutboxStatusRevoked   = "revoked" // Sent, then deleted for everyone
+)
+
+// OutboxOriginDevice marks messages the account sent from its phone or another
+// linked device rather than through the API. They are recorded once sent.
+const OutboxOriginDevice = "device"
+
+// ErrMessageExpired is returned by sends that could not happen before the
+// message's expires_at deadline. Such messages end as expired, not failed.
+var ErrMessageExpired = errors.New("message expired before it could be sent")
+
+// OutboxMessage is a single outgoing message recorded in the outbox
+type OutboxMessage struct {
+	ID          string       `json:"id"`
+	User        string       `json:"user"`
+	Recipient   string       `json:"recipient"`
+	Type        string       `json:"type"`
+	Body        string       `json:"body,omitempty"`
+	FileName    string       `json:"file_name,omitempty"`
+	MimeType    string       `json:"mime_type,omitempty"`
+	MediaURL    string       `json:"media_url,omitempty"`
+	MediaHandle string       `json:"media_handle,omitempty"`
+	Priority    string       `json:"priority"`
+	Status      string       `json:"status"`
+	Error       string       `json:"error,omitempty"`
+	Attempts    int          `json:"attempts"`
+	MessageID   string       `json:"message_id,omitempty"`
+	SendAfter   *time.Time   `json:"send_after,omitempty"`
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty"`
+	Options     *SendOptions `json:"options,omitempty"`
+	Origin      string       `json:"origin,omitempty"` // OutboxOriginDevice, or empty for API sends
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	SentAt      *time.Time   `json:"sent_at,omitempty"`
+}
+
+// Deadline returns the message's expiry, or the zero time if it never expires
+func (m OutboxMessage) Deadline() time.Time {
+	if m.ExpiresAt == nil {
+		return time.Time{}
+	}
+	return *m.ExpiresAt
+}
+
+// DeadLetter is an outbox message that permanently failed
+type DeadLetter struct {
+	OutboxMessage
+	Reason   string    `json:"reason"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// OutboxFilter selects outbox messages for listing
+type OutboxFilter struct {
+	User      string
+	Status    string
+	Recipient string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+	Offset    int
+}
+
+// OutboxStore records outgoing messages in a SQLite database so their delivery
+// can be checked later.
+type OutboxStore struct {
+	db *sql.DB
+
+	bodyLimit atomic.Int64 // Characters of a sent message's body kept; 0 keeps all
+
+	cipher atomic.Pointer[FieldCipher] // Encrypts bodies and media URLs when set
+}
+
+const outboxSchema = `
+CREATE TABLE IF NOT EXISTS outbox_messages (
+	id         TEXT PRIMARY KEY,
+	user       TEXT NOT NULL,
+	recipient  TEXT NOT NULL,
+	type       TEXT NOT NULL,
+	body       TEXT NOT NULL DEFAULT '',
+	file_name  TEXT NOT NULL DEFAULT '',
+	media_url  TEXT NOT NULL DEFAULT '',
+	priority   TEXT NOT NULL DEFAULT 'normal',
+	status     TEXT NOT NULL,
+	error      TEXT NOT NULL DEFAULT '',
+	attempts   INTEGER NOT NULL DEFAULT 0,
+	message_id TEXT NOT NULL DEFAULT '',
+	send_after INTEGER,
+	expires_at INTEGER,
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL,
+	sent_at    INTEGER
+);
+CREATE INDEX IF NOT EXISTS outbox_messages_user_created ON outbox_messages (user, created_at);
+CREATE TABLE IF NOT EXISTS outbox_dead_letters (
+	id        TEXT PRIMARY KEY REFERENCES outbox_messages (id) ON DELETE CASCADE,
+	reason    TEXT NOT NULL,
+	attempts  INTEGER NOT NULL,
+	failed_at INTEGER NOT NULL
+);
+`
+
+// outboxAddedColumns are columns added to outbox_messages after its first release
+var outboxAddedColumns = []string{
+	`media_url TEXT NOT NULL DEFAULT ''`,
+	`priority TEXT NOT NULL DEFAULT 'normal'`,
+	`send_after INTEGER`,
+	`expires_at INTEGER`,
+	`options TEXT NOT NULL DEFAULT ''`,
+	`media_handle TEXT NOT NULL DEFAULT ''`,
+	`mime_type TEXT NOT NULL DEFAULT ''`,
+	`origin TEXT NOT NULL DEFAULT ''`,
+}
+
+// outboxIndexes are created after migration, since they may use added columns
+const outboxIndexes = `
+CREATE INDEX IF NOT EXISTS outbox_messages_status_send_after ON outbox_messages (status, send_after);
+CREATE INDEX IF NOT EXISTS outbox_messages_user_message_id ON outbox_messages (user, message_id);
+`
+
+// NewOutboxStore opens (creating if needed) the outbox database at path.
+func NewOutboxStore(path string) (*OutboxStore, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox database: %v", err)
+	}
+	// SQLite handles a single writer; serialise access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(outboxSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create outbox schema: %v", err)
+	}
+
+	// Add columns missing from databases created by older versions
+	for _, column := range outboxAddedColumns {
+		if _, err := db.Exec(`ALTER TABLE outbox_messages ADD COLUMN ` + column); err != nil &&
+			!strings.Contains(err.Error(), "duplicate column name") {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate outbox schema: %v", err)
+		}
+	}
+
+	if _, err := db.Exec(outboxIndexes); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create outbox indexes: %v", err)
+	}
+
+	return &OutboxStore{db: db}, nil
+}
+
+// ParseSendAfter reads a send_after value, either a duration from now ("5m",
+// "1h30m") or an RFC 3339 timestamp. An empty value returns the zero time,
+// meaning send immediately.
+func ParseSendAfter(value string, now time.Time) (time.Time, error) {
+	return parseOutboxTime("send_after", value, now)
+}
+
+// ParseExpiresAt reads an expires_at value, in the same formats as send_after,
+// and checks that the deadline is still ahead and after sendAfter. An empty
+// value returns the zero time, meaning the message never expires.
+func ParseExpiresAt(value string, now, sendAfter time.Time) (time.Time, error) {
+	expiresAt, err := parseOutboxTime("expires_at", value, now)
+	if err != nil || expiresAt.IsZero() {
+		return expiresAt, err
+	}
+	if !expiresAt.After(now) {
+		return time.Time{}, fmt.Errorf("invalid expires_at %q, must be in the future", value)
+	}
+	if !expiresAt.After(sendAfter) {
+		return time.Time{}, fmt.Errorf("invalid expires_at %q, must be after send_after", value)
+	}
+	return expiresAt, nil
+}
+
+// MessageExpired reports whether a message with the given deadline may no
+// longer be sent. The zero time never expires.
+func MessageExpired(expiresAt time.Time) bool {
+	return !expiresAt.IsZero() && time.Now().After(expiresAt)
+}
+
+// OptionalTime returns nil for the zero time, for optional JSON and store fields
+func OptionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// parseOutboxTime reads a duration from now or an RFC 3339 timestamp
+func parseOutboxTime(field, value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if delay, err := time.ParseDuration(value); err == nil {
+		if delay < 0 {
+			return time.Time{}, fmt.Errorf("invalid %s %q, duration must not be negative", field, value)
+		}
+		return now.Add(delay), nil
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q, must be a duration such as 5m or an RFC 3339 timestamp", field, value)
+	}
+	return at, nil
+}
+
+// Record adds msg to the outbox as pending, or as scheduled when it has a
+// SendAfter time, and returns its new ID. Failures are returned but callers may
+// ignore them for immediate sends; the outbox never blocks a send.
+func (s *OutboxStore) Record(msg OutboxMessage) (string, error) {
+	if s == nil {
+		return "", nil
+	}
+
+	idBytes := make([]byte, 8)
+	_, _ = rand.Read(idBytes)
+	id := hex.EncodeToString(idBytes)
+	now := time.Now().UnixMilli()
+	if msg.Priority == "" {
+		msg.Priority = SendPriorityNormal
+	}
+	status := OutboxStatusPending
+	var sendAfter, expiresAt sql.NullInt64
+	if msg.SendAfter != nil {
+		status = OutboxStatusScheduled
+		sendAfter = sql.NullInt64{Int64: msg.SendAfter.UnixMilli(), Valid: true}
+	}
+	if msg.ExpiresAt != nil {
+		expiresAt = sql.NullInt64{Int64: msg.ExpiresAt.UnixMilli(), Valid: true}
+	}
+
+	body, err := s.cipher.Load().Encrypt(msg.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt outbox message: %v", err)
+	}
+	mediaURL, err := s.cipher.Load().Encrypt(msg.MediaURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt outbox message: %v", err)
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO outbox_messages (id, user, recipient, type, body, file_name, mime_type, media_url, media_handle, priority, status, send_after, expires_at, options, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, msg.User, msg.Recipient, msg.Type, body, msg.FileName, msg.MimeType, mediaURL, msg.MediaHandle, msg.Priority,
+		status, sendAfter, expiresAt, encodeSendOptions(msg.Options), now, now,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to record outbox message: %v", err)
+	}
+	return id, nil
+}
+
+// RecordSent adds a message that was already sent outside the outbox, such as
+// from another device of the account, as sent. Messages already recorded with
+// the same message ID are skipped, so replays after reconnecting are harmless.
+// It reports whether the message was added.
+func (s *OutboxStore) RecordSent(msg OutboxMessage) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+
+	idBytes := make([]byte, 8)
+	_, _ = rand.Read(idBytes)
+	id := hex.EncodeToString(idBytes)
+	now := time.Now().UnixMilli()
+	sentAt := now
+	if msg.SentAt != nil {
+		sentAt = msg.SentAt.UnixMilli()
+	}
+	if msg.Priority == "" {
+		msg.Priority = SendPriorityNormal
+	}
+
+	body, err := s.cipher.Load().Encrypt(msg.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to encrypt outbox message: %v", err)
+	}
+
+	result, err := s.db.Exec(
+		`INSERT INTO outbox_messages (id, user, recipient, type, body, file_name, mime_type, priority, status, message_id, origin, created_at, updated_at, sent_at)
+		 SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		 WHERE NOT EXISTS (SELECT 1 FROM outbox_messages WHERE user = ? AND message_id = ?)`,
+		id, msg.User, msg.Recipient, msg.Type, body, msg.FileName, msg.MimeType, msg.Priority,
+		OutboxStatusSent, msg.MessageID, msg.Origin, sentAt, now, sentAt,
+		msg.User, msg.MessageID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record outbox message: %v", err)
+	}
+	added, _ := result.RowsAffected()
+	return added > 0, nil
+}
+
+// Due returns up to limit scheduled messages whose send_after time has passed,
+// earliest first
+func (s *OutboxStore) Due(now time.Time, limit int) ([]OutboxMessage, error) {
+	if s == nil {
+		return []OutboxMessage{}, nil
+	}
+	rows, err := s.db.Query(
+		`SELECT `+outboxColumns+` FROM outbox_messages WHERE status = ? AND send_after <= ? ORDER BY send_after LIMIT ?`,
+		OutboxStatusScheduled, now.UnixMilli(), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due outbox messages: %v", err)
+	}
+	defer rows.Close()
+	return s.scanOutboxMessages(rows)
+}
+
+// Claim moves a scheduled message to pending, reporting false if it was not
+// scheduled, so a due message is only sent once
+func (s *OutboxStore) Claim(id string) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+	result, err := s.db.Exec(
+		`UPDATE outbox_messages SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		OutboxStatusPending, time.Now().UnixMilli(), id, OutboxStatusScheduled,
+	)
+	if err != nil {
+		return false, err
+	}
+	claimed, _ := result.RowsAffected()
+	return claimed > 0, nil
+}
+
+// RecordAttempt increments the attempt counter of a message
+func (s *OutboxStore) RecordAttempt(id string) error {
+	if s == nil || id == "" {
+		return nil
+	}
+	_, err := s.db.Exec(
+		`UPDATE outbox_messages SET attempts = attempts + 1, updated_at = ? WHERE id = ?`,
+		time.Now().UnixMilli(), id,
+	)
+	return err
+}
+
+// SetBodyLimit sets how many characters of a sent message's body the outbox
+// keeps, for deployments that must not retain full message content. 0 keeps
+// bodies whole; negative values are ignored.
+func (s *OutboxStore) SetBodyLimit(limit int) {
+	if s == nil || limit < 0 {
+		return
+	}
+	s.bodyLimit.Store(int64(limit))
+}
+
+// SetCipher encrypts message bodies and media URLs written from now on.
+// Existing plaintext rows stay readable.
+func (s *OutboxStore) SetCipher(cipher *FieldCipher) {
+	if s == nil {
+		return
+	}
+	s.cipher.Store(cipher)
+}
+
+// Finish stores the outcome of a send: sent with its WhatsApp message ID,
+// expired when it missed its deadline, or failed with the error that stopped
+// it. Failed messages are moved to the dead letter queue; expired ones are not,
+// since sending them later is pointless. Scheduled messages WhatsApp throttled
+// are scheduled again for when the throttle backoff ends. The body of a sent
+// message is cut to the limit set with SetBodyLimit.
+func (s *OutboxStore) Finish(id, messageID string, sendErr error) error {
+	if s == nil || id == "" {
+		return nil
+	}
+
+	now := time.Now().UnixMilli()
+	if sendErr == nil {
+		_, err := s.db.Exec(
+			`UPDATE outbox_messages SET status = ?, error = '', message_id = ?, updated_at = ?, sent_at = ? WHERE id = ?`,
+			OutboxStatusSent, messageID, now, now, id,
+		)
+		if err != nil {
+			return err
+		}
+		// Sent messages are never resent, so their body can be cut down
+		return s.truncateBody(id)
+	}
+	if errors.Is(sendErr, ErrMessageExpired) {
+		_, err := s.db.Exec(
+			`UPDATE outbox_messages SET status = ?, error = ?, updated_at = ? WHERE id = ?`,
+			OutboxStatusExpired, sendErr.Error(), now, id,
+		)
+		return err
+	}
+	var throttled *ThrottledError
+	if errors.As(sendErr, &throttled) {
+		result, err := s.db.Exec(
+			`UPDATE outbox_messages SET status = ?, error = ?, send_after = ?, updated_at = ? WHERE id = ? AND send_after IS NOT NULL`,
+			OutboxStatusScheduled, sendErr.Error(), time.Now().Add(throttled.RetryAfter).UnixMilli(), now, id,
+		)
+		if err != nil {
+			return err
+		}
+		if rescheduled, _ := result.RowsAffected(); rescheduled > 0 {
+			return nil
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`UPDATE outbox_messages SET status = ?, error = ?, updated_at = ? WHERE id = ?`,
+		OutboxStatusFailed, sendErr.Error(), now, id,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`INSERT OR REPLACE INTO outbox_dead_letters (id, reason, attempts, failed_at)
+		 SELECT id, ?, attempts, ? FROM outbox_messages WHERE id = ?`,
+		sendErr.Error(), now, id,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// truncateBody cuts the stored body of a message to the body limit. Bodies may
+// be encrypted, so this is done here rather than in SQL.
+func (s *OutboxStore) truncateBody(id string) error {
+	limit := int(s.bodyLimit.Load())
+	if limit == 0 {
+		return nil
+	}
+
+	var stored string
+	if err := s.db.QueryRow(`SELECT body FROM outbox_messages WHERE id = ?`, id).Scan(&stored); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	cipher := s.cipher.Load()
+	body, err := cipher.Decrypt(stored)
+	if err != nil {
+		return err
+	}
+	runes := []rune(body)
+	if len(runes) <= limit {
+		return nil
+	}
+	truncated, err := cipher.Encrypt(string(runes[:limit]) + "…")
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`UPDATE outbox_messages SET body = ? WHERE id = ?`, truncated, id)
+	return err
+}
+
+// Requeue takes a message out of the dead letter queue and marks it pending
+// again, reporting whether it was dead-lettered.
+func (s *OutboxStore) Requeue(id string) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM outbox_dead_letters WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	if removed, _ := result.RowsAffected(); removed == 0 {
+		return false, nil
+	}
+	if _, err := tx.Exec(
+		`UPDATE outbox_messages SET status = ?, error = '', updated_at = ? WHERE id = ?`,
+		OutboxStatusPending, time.Now().UnixMilli(), id,
+	); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// DeadLetters returns the dead-lettered messages of a user, most recent
+// failure first, along with their total count.
+func (s *OutboxStore) DeadLetters(user string, limit, offset int) ([]DeadLetter, int, error) {
+	if s == nil {
+		return []DeadLetter{}, 0, nil
+	}
+
+	var total int
+	err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM outbox_dead_letters d JOIN outbox_messages m ON m.id = d.id WHERE m.user = ?`,
+		user,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead letters: %v", err)
+	}
+
+	rows, err := s.db.Query(
+		`SELECT `+outboxColumnsPrefixed+`, d.reason, d.failed_at
+		 FROM outbox_dead_letters d JOIN outbox_messages m ON m.id = d.id
+		 WHERE m.user = ? ORDER BY d.failed_at DESC LIMIT ? OFFSET ?`,
+		user, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list dead letters: %v", err)
+	}
+	defer rows.Close()
+
+	letters := []DeadLetter{}
+	for rows.Next() {
+		var letter DeadLetter
+		var failedAt int64
+		if err := s.scanOutboxMessage(rows, &letter.OutboxMessage, &letter.Reason, &failedAt); err != nil {
+			return nil, 0, err
+		}
+		letter.FailedAt = time.UnixMilli(failedAt)
+		letters = append(letters, letter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return letters, total, nil
+}
+
+// Get returns a single outbox message
+func (s *OutboxStore) Get(id string) (*OutboxMessage, bool, error) {
+	if s == nil {
+		return nil, false, nil
+	}
+	rows, err := s.db.Query(`SELECT `+outboxColumns+` FROM outbox_messages WHERE id = ?`, id)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	messages, err := s.scanOutboxMessages(rows)
+	if err != nil || len(messages) == 0 {
+		return nil, false, err
+	}
+	return &messages[0], true, nil
+}
+
+// FindSent returns the message user sent with the given WhatsApp message ID
+func (s *OutboxStore) FindSent(user, messageID string) (*OutboxMessage, bool, error) {
+	if s == nil || messageID == "" {
+		return nil, false, nil
+	}
+	rows, err := s.db.Query(
+		`SELECT `+outboxColumns+` FROM outbox_messages WHERE user = ? AND message_id = ? AND status IN (?, ?)`,
+		user, messageID, OutboxStatusSent, OutboxStatusRevoked,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	messages, err := s.scanOutboxMessages(rows)
+	if err != nil || len(messages) == 0 {
+		return nil, false, err
+	}
+	return &messages[0], true, nil
+}
+
+// MarkRevoked records that a sent message was deleted for everyone
+func (s *OutboxStore) MarkRevoked(id string) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(
+		`UPDATE outbox_messages SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		OutboxStatusRevoked, time.Now().UnixMilli(), id, OutboxStatusSent,
+	)
+	return err
+}
+
+// List returns the messages matching the filter, newest first, along with the
+// total number of matches ignoring pagination.
+func (s *OutboxStore) List(filter OutboxFilter) ([]OutboxMessage, int, error) {
+	if s == nil {
+		return []OutboxMessage{}, 0, nil
+	}
+
+	var conditions []string
+	var args []interface{}
+	if filter.User != "" {
+		conditions = append(conditions, "user = ?")
+		args = append(args, filter.User)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Recipient != "" {
+		conditions = append(conditions, "recipient = ?")
+		args = append(args, filter.Recipient)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.Since.UnixMilli())
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, filter.Until.UnixMilli())
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM outbox_messages`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count outbox messages: %v", err)
+	}
+
+	rows, err := s.db.Query(
+		`SELECT `+outboxColumns+` FROM outbox_messages`+where+` ORDER BY created_at DESC LIMIT ? OFFSET ?`,
+		append(args, filter.Limit, filter.Offset)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list outbox messages: %v", err)
+	}
+	defer rows.Close()
+
+	messages, err := s.scanOutboxMessages(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
+}
+
+// Close closes the outbox database
+func (s *OutboxStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+const outboxColumns = `id, user, recipient, type, body, file_name, mime_type, media_url, media_handle, priority, status, error, attempts, message_id, send_after, expires_at, options, origin, created_at, updated_at, sent_at`
+
+// outboxColumnsPrefixed is outboxColumns qualified for queries joining outbox_messages as m
+const outboxColumnsPrefixed = `m.id, m.user, m.recipient, m.type, m.body, m.file_name, m.mime_type, m.media_url, m.media_handle, m.priority, m.status, m.error, m.attempts, m.message_id, m.send_after, m.expires_at, m.options, m.origin, m.created_at, m.updated_at, m.sent_at`
+
+// scanOutboxMessages reads rows selected with outboxColumns
+func (s *OutboxStore) scanOutboxMessages(rows *sql.Rows) ([]OutboxMessage, error) {
+	messages := []OutboxMessage{}
+	for rows.Next() {
+		var msg OutboxMessage
+		if err := s.scanOutboxMessage(rows, &msg); err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+// scanOutboxMessage reads one row selected with outboxColumns, followed by any
+// extra columns, decrypting its body and media URL
+func (s *OutboxStore) scanOutboxMessage(rows *sql.Rows, msg *OutboxMessage, extra ...interface{}) error {
+	var createdAt, updatedAt int64
+	var sendAfter, expiresAt, sentAt sql.NullInt64
+	var options string
+	dest := []interface{}{
+		&msg.ID, &msg.User, &msg.Recipient, &msg.Type, &msg.Body, &msg.FileName, &msg.MimeType, &msg.MediaURL, &msg.MediaHandle, &msg.Priority,
+		&msg.Status, &msg.Error, &msg.Attempts, &msg.MessageID,
+		&sendAfter, &expiresAt, &options, &msg.Origin, &createdAt, &updatedAt, &sentAt,
+	}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return fmt.Errorf("failed to read outbox message: %v", err)
+	}
+	var err error
+	if msg.Body, err = s.cipher.Load().Decrypt(msg.Body); err != nil {
+		return fmt.Errorf("failed to read outbox message %s: %v", msg.ID, err)
+	}
+	if msg.MediaURL, err = s.cipher.Load().Decrypt(msg.MediaURL); err != nil {
+		return fmt.Errorf("failed to read outbox message %s: %v", msg.ID, err)
+	}
+	msg.Options = decodeSendOptions(options)
+	msg.CreatedAt = time.UnixMilli(createdAt)
+	msg.UpdatedAt = time.UnixMilli(updatedAt)
+	if sendAfter.Valid {
+		at := time.UnixMilli(sendAfter.Int64)
+		msg.SendAfter = &at
+	}
+	if expiresAt.Valid {
+		at := time.UnixMilli(expiresAt.Int64)
+		msg.ExpiresAt = &at
+	}
+	if sentAt.Valid {
+		sent := time.UnixMilli(sentAt.Int64)
+		msg.SentAt = &sent
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Poll is a poll a session sent or received, with its options in order
+type Poll struct {
+	ID              string    `json:"id"`
+	Chat            string    `json:"chat"` // Without device suffix
+	Sender          string    `json:"sender,omitempty"`
+	Question        string    `json:"question"`
+	Options         []string  `json:"options"`
+	SelectableCount int       `json:"selectable_count"` // Options a voter may pick; 0 allows any number
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// PollVote is the latest vote of one voter in a poll. Options holds the
+// SHA-256 hashes of the option names, as WhatsApp sends them; an empty
+// selection withdraws the vote.
+type PollVote struct {
+	Voter     string    `json:"voter"`
+	Options   [][]byte  `json:"options"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PollStore keeps the polls of every session and each voter's latest vote in
+// a SQLite database
+type PollStore struct {
+	db *sql.DB
+
+	cipher atomic.Pointer[FieldCipher] // Encrypts questions and options when set
+}
+
+const pollSchema = `
+CREATE TABLE IF NOT EXISTS polls (
+	user       TEXT NOT NULL,
+	id         TEXT NOT NULL,
+	chat       TEXT NOT NULL,
+	sender     TEXT NOT NULL DEFAULT '',
+	question   TEXT NOT NULL,
+	options    TEXT NOT NULL,
+	selectable INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY (user, id)
+);
+CREATE TABLE IF NOT EXISTS poll_votes (
+	user      TEXT NOT NULL,
+	poll_id   TEXT NOT NULL,
+	voter     TEXT NOT NULL,
+	options   TEXT NOT NULL,
+	timestamp INTEGER NOT NULL,
+	PRIMARY KEY (user, poll_id, voter)
+);
+`
+
+// NewPollStore opens (creating if needed) the poll database at path.
+func NewPollStore(path string) (*PollStore, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open poll database: %v", err)
+	}
+	// SQLite handles a single writer; serialise access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(pollSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create poll schema: %v", err)
+	}
+	return &PollStore{db: db}, nil
+}
+
+// SetCipher encrypts the questions and options of polls recorded from now
+// on. Polls recorded in plaintext stay readable.
+func (s *PollStore) SetCipher(cipher *FieldCipher) {
+	if s == nil {
+		return
+	}
+	s.cipher.Store(cipher)
+}
+
+// RecordPoll stores a poll of user. Polls already recorded, such as replays
+// after reconnecting, are left as they are.
+func (s *PollStore) RecordPoll(user string, poll Poll) error {
+	if s == nil {
+		return nil
+	}
+
+	encoded, err := json.Marshal(poll.Options)
+	if err != nil {
+		return err
+	}
+	cipher := s.cipher.Load()
+	question, err := cipher.Encrypt(poll.Question)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt poll: %v", err)
+	}
+	options, err := cipher.Encrypt(string(encoded))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt poll: %v", err)
+	}
+
+	_, err = s.db.Exec(
+		`INSERT OR IGNORE INTO polls (user, id, chat, sender, question, options, selectable, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		user, poll.ID, poll.Chat, poll.Sender, question, options, poll.SelectableCount, poll.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record poll: %v", err)
+	}
+	return nil
+}
+
+// RecordVote stores a vote in a poll of user, replacing the voter's earlier
+// vote. Votes older than the one stored, such as replays, are ignored.
+func (s *PollStore) RecordVote(user, pollID string, vote PollVote) error {
+	if s == nil {
+		return nil
+	}
+
+	options, err := json.Marshal(vote.Options)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(
+		`INSERT INTO poll_votes (user, poll_id, voter, options, timestamp) VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (user, poll_id, voter) DO UPDATE SET options = excluded.options, timestamp = excluded.timestamp
+		 WHERE excluded.timestamp >= poll_votes.timestamp`,
+		user, pollID, vote.Voter, string(options), vote.Timestamp.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record poll vote: %v", err)
+	}
+	return nil
+}
+
+// Poll returns a recorded poll of user
+func (s *PollStore) Poll(user, id string) (*Poll, bool, error) {
+	if s == nil {
+		return nil, false, nil
+	}
+
+	poll := Poll{ID: id}
+	var options string
+	var createdAt int64
+	err := s.db.QueryRow(
+		`SELECT chat, sender, question, options, selectable, created_at FROM polls WHERE user = ? AND id = ?`,
+		user, id,
+	).Scan(&poll.Chat, &poll.Sender, &poll.Question, &options, &poll.SelectableCount, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read poll: %v", err)
+	}
+
+	cipher := s.cipher.Load()
+	if poll.Question, err = cipher.Decrypt(poll.Question); err != nil {
+		return nil, false, fmt.Errorf("failed to read poll %s: %v", id, err)
+	}
+	if options, err = cipher.Decrypt(options); err != nil {
+		return nil, false, fmt.Errorf("failed to read poll %s: %v", id, err)
+	}
+	if err := json.Unmarshal([]byte(options), &poll.Options); err != nil {
+		return nil, false, fmt.Errorf("failed to read poll %s: %v", id, err)
+	}
+	poll.CreatedAt = time.UnixMilli(createdAt)
+	return &poll, true, nil
+}
+
+// Votes returns the latest vote of every voter in a poll of user, oldest first
+func (s *PollStore) Votes(user, pollID string) ([]PollVote, error) {
+	if s == nil {
+		return []PollVote{}, nil
+	}
+	rows, err := s.db.Query(
+		`SELECT voter, options, timestamp FROM poll_votes WHERE user = ? AND poll_id = ? ORDER BY timestamp, voter`,
+		user, pollID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	votes := []PollVote{}
+	for rows.Next() {
+		var vote PollVote
+		var options string
+		var timestamp int64
+		if err := rows.Scan(&vote.Voter, &options, &timestamp); err != nil {
+			return nil, fmt.Errorf("failed to read poll vote: %v", err)
+		}
+		if err := json.Unmarshal([]byte(options), &vote.Options); err != nil {
+			return nil, fmt.Errorf("failed to read poll vote: %v", err)
+		}
+		vote.Timestamp = time.UnixMilli(timestamp)
+		votes = append(votes, vote)
+	}
+	return votes, rows.Err()
+}
+
+// Close closes the poll database
+func (s *PollStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
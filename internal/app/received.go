@@ -0,0 +1,113 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Received message IDs kept when no limit is configured
+const defaultReceivedLimit = 50000
+
+// How many inserts happen between two prunes of the oldest IDs
+const receivedPruneEvery = 1000
+
+// ReceivedStore remembers the IDs of incoming messages already handed to the
+// event sinks, in a SQLite database, so history syncs and reconnect replays
+// after a restart do not deliver the same message twice. Only the most recent
+// IDs are kept.
+type ReceivedStore struct {
+	db      *sql.DB
+	limit   atomic.Int64
+	inserts atomic.Int64
+}
+
+const receivedSchema = `
+CREATE TABLE IF NOT EXISTS received_messages (
+	user        TEXT NOT NULL,
+	chat        TEXT NOT NULL,
+	id          TEXT NOT NULL,
+	received_at INTEGER NOT NULL,
+	PRIMARY KEY (user, chat, id)
+);
+`
+
+// NewReceivedStore opens (creating if needed) the received message database at path.
+func NewReceivedStore(path string) (*ReceivedStore, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open received message database: %v", err)
+	}
+	// SQLite handles a single writer; serialise access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(receivedSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create received message schema: %v", err)
+	}
+
+	s := &ReceivedStore{db: db}
+	s.limit.Store(defaultReceivedLimit)
+	return s, nil
+}
+
+// SetLimit sets how many message IDs are kept; values below 1 are ignored
+func (s *ReceivedStore) SetLimit(limit int) {
+	if s == nil || limit < 1 {
+		return
+	}
+	s.limit.Store(int64(limit))
+}
+
+// MarkSeen records a message ID and reports whether it was already recorded.
+// On error the message is reported as new so it is still delivered.
+func (s *ReceivedStore) MarkSeen(user, chat, id string) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+
+	res, err := s.db.Exec(
+		`INSERT OR IGNORE INTO received_messages (user, chat, id, received_at) VALUES (?, ?, ?, ?)`,
+		user, chat, id, time.Now().UnixMilli(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record received message: %v", err)
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record received message: %v", err)
+	}
+	if inserted == 0 {
+		return true, nil
+	}
+
+	if s.inserts.Add(1)%receivedPruneEvery == 0 {
+		if err := s.prune(); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// prune deletes all but the most recent IDs
+func (s *ReceivedStore) prune() error {
+	_, err := s.db.Exec(
+		`DELETE FROM received_messages WHERE rowid <= (
+			SELECT rowid FROM received_messages ORDER BY rowid DESC LIMIT 1 OFFSET ?
+		)`,
+		s.limit.Load(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prune received messages: %v", err)
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *ReceivedStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
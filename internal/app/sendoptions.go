@@ -0,0 +1,118 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Disappearing message timers supported by WhatsApp, in seconds
+const (
+	EphemeralDay     = 24 * 60 * 60
+	EphemeralWeek    = 7 * EphemeralDay
+	EphemeralQuarter = 90 * EphemeralDay
+)
+
+// quotedSenderPhonePattern matches a quoted sender given as a phone number in
+// international format, with or without '+'
+var quotedSenderPhonePattern = regexp.MustCompile(`^\+?[1-9][0-9]{6,14}$`)
+
+// SendOptions are per-message settings of a send, mapped to fields of the
+// WhatsApp message
+type SendOptions struct {
+	// NoLinkPreview marks text messages as having no link preview, so clients
+	// do not render a placeholder for links. Ignored for media.
+	NoLinkPreview bool `json:"no_link_preview,omitempty"`
+	// EphemeralSeconds sends the message as disappearing after this many
+	// seconds: 86400 (24h), 604800 (7d) or 7776000 (90d)
+	EphemeralSeconds uint32 `json:"ephemeral_seconds,omitempty"`
+	// Normalize repairs badly encoded text or captions and composes them to
+	// NFC before sending, see NormalizeText
+	Normalize bool `json:"normalize,omitempty"`
+	// Quote sends a text message as a reply to another message. Ignored for media.
+	Quote *QuotedMessage `json:"quote,omitempty"`
+}
+
+// QuotedMessage is the message a reply quotes
+type QuotedMessage struct {
+	MessageID string `json:"message_id"`
+	// Sender is the author of the quoted message as a phone number or user
+	// JID. Required in group chats; a direct chat reply without one carries no
+	// author.
+	Sender string `json:"sender,omitempty"`
+	// Text of the quoted message, shown in the reply's quote bubble
+	Text string `json:"text,omitempty"`
+}
+
+// Validate checks that the options can be applied to a message. A nil
+// SendOptions is valid and means default behaviour.
+func (o *SendOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.Quote != nil {
+		if o.Quote.MessageID == "" {
+			return fmt.Errorf("quoted_message_id is required to quote a message")
+		}
+		if o.Quote.Sender != "" {
+			if err := validateQuotedSender(o.Quote.Sender); err != nil {
+				return fmt.Errorf("invalid quoted_sender %s: %v", o.Quote.Sender, err)
+			}
+		}
+	}
+	switch o.EphemeralSeconds {
+	case 0, EphemeralDay, EphemeralWeek, EphemeralQuarter:
+		return nil
+	default:
+		return fmt.Errorf("invalid ephemeral_seconds %d, must be one of %d, %d, %d", o.EphemeralSeconds, EphemeralDay, EphemeralWeek, EphemeralQuarter)
+	}
+}
+
+// validateQuotedSender checks that a quoted sender is a phone number or the
+// JID of a user
+func validateQuotedSender(sender string) error {
+	if !strings.Contains(sender, "@") {
+		if !quotedSenderPhonePattern.MatchString(sender) {
+			return fmt.Errorf("must be 7-15 digits in international format")
+		}
+		return nil
+	}
+
+	jid, err := types.ParseJID(sender)
+	if err != nil {
+		return err
+	}
+	if jid.User == "" || (jid.Server != types.DefaultUserServer && jid.Server != types.HiddenUserServer) {
+		return fmt.Errorf("must be the JID of a user")
+	}
+	return nil
+}
+
+// IsZero reports whether no option is set
+func (o *SendOptions) IsZero() bool {
+	return o == nil || *o == SendOptions{}
+}
+
+// encodeSendOptions stores options in an outbox column, empty when none are set
+func encodeSendOptions(o *SendOptions) string {
+	if o.IsZero() {
+		return ""
+	}
+	data, _ := json.Marshal(o)
+	return string(data)
+}
+
+// decodeSendOptions reads options stored by encodeSendOptions
+func decodeSendOptions(value string) *SendOptions {
+	if value == "" {
+		return nil
+	}
+	var o SendOptions
+	if err := json.Unmarshal([]byte(value), &o); err != nil {
+		return nil
+	}
+	return &o
+}
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Kinds of messages recorded for threading
+const (
+	ThreadKindMessage  = "message"  // A message; its parent is the message it quotes
+	ThreadKindReaction = "reaction" // A reaction; its parent is the message reacted to
+	ThreadKindEdit     = "edit"     // An edit; its parent is the message edited
+)
+
+// Thread messages kept; the oldest are pruned past this
+const defaultThreadLimit = 200000
+
+// How many inserts happen between two prunes of the oldest thread messages
+const threadPruneEvery = 1000
+
+// ThreadMessage is a message, reaction or edit with a reference to the message
+// it relates to, recorded so reply chains can be rebuilt later
+type ThreadMessage struct {
+	ID        string    `json:"id"`
+	Chat      string    `json:"chat"` // Without device suffix
+	Sender    string    `json:"sender,omitempty"`
+	FromMe    bool      `json:"from_me"`
+	Kind      string    `json:"kind"`
+	ParentID  string    `json:"parent_id,omitempty"`
+	Text      string    `json:"text,omitempty"` // Text or caption, reaction emoji, or edited text
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ThreadStore keeps recent messages of every session with their quote,
+// reaction and edit references in a SQLite database
+type ThreadStore struct {
+	db      *sql.DB
+	limit   atomic.Int64
+	inserts atomic.Int64
+
+	cipher atomic.Pointer[FieldCipher] // Encrypts message text when set
+}
+
+const threadSchema = `
+CREATE TABLE IF NOT EXISTS thread_messages (
+	user      TEXT NOT NULL,
+	id        TEXT NOT NULL,
+	chat      TEXT NOT NULL,
+	sender    TEXT NOT NULL DEFAULT '',
+	from_me   INTEGER NOT NULL DEFAULT 0,
+	kind      TEXT NOT NULL,
+	parent_id TEXT NOT NULL DEFAULT '',
+	text      TEXT NOT NULL DEFAULT '',
+	timestamp INTEGER NOT NULL,
+	PRIMARY KEY (user, id)
+);
+CREATE INDEX IF NOT EXISTS thread_messages_parent ON thread_messages (user, parent_id);
+`
+
+// NewThreadStore opens (creating if needed) the thread database at path.
+func NewThreadStore(path string) (*ThreadStore, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open thread database: %v", err)
+	}
+	// SQLite handles a single writer; serialise access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(threadSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create thread schema: %v", err)
+	}
+
+	s := &ThreadStore{db: db}
+	s.limit.Store(defaultThreadLimit)
+	return s, nil
+}
+
+// SetCipher encrypts the text of messages recorded from now on. Messages
+// recorded in plaintext stay readable.
+func (s *ThreadStore) SetCipher(cipher *FieldCipher) {
+	if s == nil {
+		return
+	}
+	s.cipher.Store(cipher)
+}
+
+// Record stores a message of user. Messages already recorded, such as
+// replays after reconnecting, are left as they are.
+func (s *ThreadStore) Record(user string, msg ThreadMessage) error {
+	if s == nil {
+		return nil
+	}
+
+	text, err := s.cipher.Load().Encrypt(msg.Text)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt thread message: %v", err)
+	}
+
+	res, err := s.db.Exec(
+		`INSERT OR IGNORE INTO thread_messages (user, id, chat, sender, from_me, kind, parent_id, text, timestamp)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		user, msg.ID, msg.Chat, msg.Sender, msg.FromMe, msg.Kind, msg.ParentID, text, msg.Timestamp.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record thread message: %v", err)
+	}
+	if inserted, _ := res.RowsAffected(); inserted == 0 {
+		return nil
+	}
+
+	if s.inserts.Add(1)%threadPruneEvery == 0 {
+		return s.prune()
+	}
+	return nil
+}
+
+// Get returns a recorded message of user
+func (s *ThreadStore) Get(user, id string) (*ThreadMessage, bool, error) {
+	if s == nil {
+		return nil, false, nil
+	}
+	rows, err := s.db.Query(`SELECT `+threadColumns+` FROM thread_messages WHERE user = ? AND id = ?`, user, id)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	messages, err := s.scanThreadMessages(rows)
+	if err != nil || len(messages) == 0 {
+		return nil, false, err
+	}
+	return &messages[0], true, nil
+}
+
+// Children returns the replies, reactions and edits referring to a message of
+// user, oldest first
+func (s *ThreadStore) Children(user, parentID string) ([]ThreadMessage, error) {
+	if s == nil {
+		return []ThreadMessage{}, nil
+	}
+	rows, err := s.db.Query(
+		`SELECT `+threadColumns+` FROM thread_messages WHERE user = ? AND parent_id = ? ORDER BY timestamp, rowid`,
+		user, parentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return s.scanThreadMessages(rows)
+}
+
+// prune deletes all but the most recent messages
+func (s *ThreadStore) prune() error {
+	_, err := s.db.Exec(
+		`DELETE FROM thread_messages WHERE rowid <= (
+			SELECT rowid FROM thread_messages ORDER BY rowid DESC LIMIT 1 OFFSET ?
+		)`,
+		s.limit.Load(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prune thread messages: %v", err)
+	}
+	return nil
+}
+
+// Close closes the thread database
+func (s *ThreadStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// RecordSentThread records a message the session sent through the API for
+// threading. WhatsApp does not echo these back as message events, so senders
+// record them once sent.
+func (a *App) RecordSentThread(user string, chat types.JID, messageID, text string, options *SendOptions, sentAt time.Time) {
+	msg := ThreadMessage{
+		ID:        messageID,
+		Chat:      chat.ToNonAD().String(),
+		FromMe:    true,
+		Kind:      ThreadKindMessage,
+		Text:      text,
+		Timestamp: sentAt,
+	}
+	if options != nil && options.Quote != nil {
+		msg.ParentID = options.Quote.MessageID
+	}
+	if whatsappClient, ok := a.GetClientManager().GetClient(user); ok && whatsappClient.WhatsmeowClient != nil {
+		if own := whatsappClient.WhatsmeowClient.Store.ID; own != nil {
+			msg.Sender = own.ToNonAD().String()
+		}
+	}
+	if err := a.Threads.Record(user, msg); err != nil {
+		a.Logger.Printf("Failed to record message %s of user %s for threading: %v", messageID, user, err)
+	}
+}
+
+// threadColumns are the columns read by scanThreadMessages
+const threadColumns = `id, chat, sender, from_me, kind, parent_id, text, timestamp`
+
+// scanThreadMessages reads rows selected with threadColumns, decrypting their text
+func (s *ThreadStore) scanThreadMessages(rows *sql.Rows) ([]ThreadMessage, error) {
+	messages := []ThreadMessage{}
+	for rows.Next() {
+		var msg ThreadMessage
+		var timestamp int64
+		if err := rows.Scan(&msg.ID, &msg.Chat, &msg.Sender, &msg.FromMe, &msg.Kind, &msg.ParentID, &msg.Text, &timestamp); err != nil {
+			return nil, fmt.Errorf("failed to read thread message: %v", err)
+		}
+		var err error
+		if msg.Text, err = s.cipher.Load().Decrypt(msg.Text); err != nil {
+			return nil, fmt.Errorf("failed to read thread message %s: %v", msg.ID, err)
+		}
+		msg.Timestamp = time.UnixMilli(timestamp)
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
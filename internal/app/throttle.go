@@ -0,0 +1,132 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// Backoff after WhatsApp first throttles a session, and the cap it doubles up to
+// while the throttling continues
+const (
+	defaultThrottleBackoff = time.Minute
+	maxThrottleBackoff     = 15 * time.Minute
+)
+
+// Status WhatsApp uses for rate limits, in info query errors and message acks
+const throttleStatus = 429
+
+// ThrottledError is returned when WhatsApp is throttling the session's sends.
+// Sends should not be retried before RetryAfter has passed.
+type ThrottledError struct {
+	RetryAfter time.Duration
+	Err        error // Error WhatsApp throttled a send with, nil when the send was not attempted
+}
+
+func (e *ThrottledError) Error() string {
+	msg := fmt.Sprintf("WhatsApp is throttling this session, retry after %d seconds", e.RetryAfterSeconds())
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfterSeconds returns RetryAfter rounded up to whole seconds, for Retry-After headers
+func (e *ThrottledError) RetryAfterSeconds() int {
+	seconds := int((e.RetryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// IsThrottling reports whether a whatsmeow error means WhatsApp is rate
+// limiting the session, either as an info query error or as a message ack error
+func IsThrottling(err error) bool {
+	var iqErr *whatsmeow.IQError
+	if errors.As(err, &iqErr) && iqErr.Code == throttleStatus {
+		return true
+	}
+	if errors.Is(err, whatsmeow.ErrServerReturnedError) {
+		// Ack errors are formatted as "server returned error <code>"
+		code := strings.TrimPrefix(err.Error(), whatsmeow.ErrServerReturnedError.Error()+" ")
+		if status, convErr := strconv.Atoi(code); convErr == nil && status == throttleStatus {
+			return true
+		}
+	}
+	return false
+}
+
+// throttleState tracks how long a throttled session must back off
+type throttleState struct {
+	until   time.Time
+	backoff time.Duration
+}
+
+// SetThrottleBackoff sets the backoff after WhatsApp first throttles a session.
+// Non-positive values are ignored.
+func (l *SendRateLimiter) SetThrottleBackoff(backoff time.Duration) {
+	if backoff <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.throttleBackoff = backoff
+}
+
+// Throttle records that WhatsApp throttled a send of the user and pushes all
+// of the user's send lanes back, so queued and scheduled sends wait it out.
+// The backoff doubles while the throttling continues; it is returned.
+func (l *SendRateLimiter) Throttle(user string) time.Duration {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, ok := l.throttles[user]
+	switch {
+	case !ok:
+		state = &throttleState{backoff: l.throttleBackoff}
+		l.throttles[user] = state
+	case now.Before(state.until):
+		// Sends already waiting when the throttle started; keep the current backoff
+		return state.until.Sub(now)
+	default:
+		state.backoff = min(state.backoff*2, maxThrottleBackoff)
+	}
+	state.until = now.Add(state.backoff)
+
+	for _, priority := range sendPriorities {
+		lane := user + "|" + priority
+		if l.nextAllowed[lane].Before(state.until) {
+			l.nextAllowed[lane] = state.until
+		}
+	}
+	return state.backoff
+}
+
+// ThrottledFor returns how long sends of the user must still back off, or 0
+func (l *SendRateLimiter) ThrottledFor(user string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state, ok := l.throttles[user]
+	if !ok {
+		return 0
+	}
+	return max(time.Until(state.until), 0)
+}
+
+// ClearThrottle resets the backoff of the user after a successful send
+func (l *SendRateLimiter) ClearThrottle(user string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.throttles, user)
+}
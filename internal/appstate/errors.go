@@ -0,0 +1,6 @@
+package appstate
+
+import "errors"
+
+// ErrSyncInProgress is returned when a sync is requested while one is running
+var ErrSyncInProgress = errors.New("app state sync already in progress")
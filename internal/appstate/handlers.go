@@ -0,0 +1,87 @@
+package appstate
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Handlers contains HTTP handlers for app state sync
+type Handlers struct {
+	app     *app.App
+	service *Service
+}
+
+// NewHandlers creates a new app state handlers instance
+func NewHandlers(app *app.App) *Handlers {
+	return &Handlers{
+		app:     app,
+		service: NewService(app),
+	}
+}
+
+// SyncHandler handles POST /wa/appstate/sync - starts resyncing app state patches
+func (h *Handlers) SyncHandler(c *gin.Context) {
+	var req SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	patches, err := parsePatches(req.Patches)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Sync(req.User, patches, req.FullSync); err != nil {
+		if errors.Is(err, ErrSyncInProgress) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.app.Logger.Printf("App state sync error for user %s: %v", req.User, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to start app state sync",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	names := make([]string, len(patches))
+	for i, patch := range patches {
+		names[i] = string(patch)
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"msg":       "App state sync started",
+		"user":      req.User,
+		"patches":   names,
+		"full_sync": req.FullSync,
+	})
+}
+
+// StatusHandler handles GET /wa/appstate/status - shows patch versions and sync progress
+func (h *Handlers) StatusHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	response, err := h.service.Status(user)
+	if err != nil {
+		h.app.Logger.Printf("App state status error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get app state status",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,33 @@
+package appstate
+
+import "time"
+
+// SyncRequest represents a request to resync app state
+type SyncRequest struct {
+	User     string   `json:"user"`
+	Patches  []string `json:"patches"`   // Optional: patch names, all patches when empty
+	FullSync bool     `json:"full_sync"` // Discard the local state and fetch every patch from scratch
+}
+
+// PatchStatus describes the local state of one app state patch
+type PatchStatus struct {
+	Name     string    `json:"name"`
+	Version  uint64    `json:"version"`
+	LastSync *SyncInfo `json:"last_sync,omitempty"` // Last sync requested through the API
+}
+
+// SyncInfo describes a sync requested through the API
+type SyncInfo struct {
+	State      string     `json:"state"` // started, completed or failed
+	FullSync   bool       `json:"full_sync"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// StatusResponse represents the app state of a session
+type StatusResponse struct {
+	User    string        `json:"user"`
+	Syncing bool          `json:"syncing"`
+	Patches []PatchStatus `json:"patches"`
+}
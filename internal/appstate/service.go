@@ -0,0 +1,183 @@
+package appstate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"go.mau.fi/whatsmeow/appstate"
+)
+
+// How long a single patch may take to sync
+const patchSyncTimeout = 2 * time.Minute
+
+// syncTracker remembers the last API-requested sync of every patch per user
+type syncTracker struct {
+	mu      sync.Mutex
+	running map[string]bool
+	last    map[string]map[string]SyncInfo
+}
+
+// Global sync tracker, shared by all service instances
+var syncs = &syncTracker{
+	running: make(map[string]bool),
+	last:    make(map[string]map[string]SyncInfo),
+}
+
+// Service handles app state sync operations
+type Service struct {
+	app *app.App
+}
+
+// NewService creates a new app state service
+func NewService(app *app.App) *Service {
+	return &Service{
+		app: app,
+	}
+}
+
+// parsePatches validates patch names, returning every known patch for an empty list
+func parsePatches(names []string) ([]appstate.WAPatchName, error) {
+	if len(names) == 0 {
+		return appstate.AllPatchNames[:], nil
+	}
+
+	patches := make([]appstate.WAPatchName, 0, len(names))
+	for _, name := range names {
+		patch := appstate.WAPatchName(name)
+		known := false
+		for _, candidate := range appstate.AllPatchNames {
+			if patch == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown patch %q", name)
+		}
+		patches = append(patches, patch)
+	}
+	return patches, nil
+}
+
+// loggedInClient returns the client for a user if it is logged in
+func (s *Service) loggedInClient(user string) (*client.Client, error) {
+	whatsappClient, exists := s.app.GetClientManager().GetClient(user)
+	if !exists {
+		return nil, fmt.Errorf("client not found for user %s", user)
+	}
+
+	if !whatsappClient.IsLoggedIn() {
+		return nil, fmt.Errorf("client is not logged in")
+	}
+
+	return whatsappClient, nil
+}
+
+// Sync starts resyncing the given patches in the background. Progress is
+// published as appstate events and reported by Status.
+func (s *Service) Sync(user string, patches []appstate.WAPatchName, fullSync bool) error {
+	whatsappClient, err := s.loggedInClient(user)
+	if err != nil {
+		return err
+	}
+
+	syncs.mu.Lock()
+	if syncs.running[user] {
+		syncs.mu.Unlock()
+		return ErrSyncInProgress
+	}
+	syncs.running[user] = true
+	syncs.mu.Unlock()
+
+	go func() {
+		defer func() {
+			syncs.mu.Lock()
+			delete(syncs.running, user)
+			syncs.mu.Unlock()
+		}()
+
+		for _, patch := range patches {
+			s.syncPatch(whatsappClient, user, patch, fullSync)
+		}
+	}()
+
+	return nil
+}
+
+// syncPatch syncs one patch, recording and publishing its progress
+func (s *Service) syncPatch(whatsappClient *client.Client, user string, patch appstate.WAPatchName, fullSync bool) {
+	manager := s.app.GetClientManager()
+	info := SyncInfo{State: client.AppStateSyncStarted, FullSync: fullSync, StartedAt: time.Now()}
+	syncs.record(user, string(patch), info)
+	manager.DispatchEvent(client.NewAppStateEvent(user, string(patch), info.State, fullSync, 0, ""))
+
+	ctx, cancel := context.WithTimeout(context.Background(), patchSyncTimeout)
+	defer cancel()
+
+	err := whatsappClient.WhatsmeowClient.FetchAppState(ctx, patch, fullSync, false)
+	finished := time.Now()
+	info.FinishedAt = &finished
+
+	if err != nil {
+		s.app.Logger.Printf("Failed to sync app state %s for user %s: %v", patch, user, err)
+		info.State = client.AppStateSyncFailed
+		info.Error = err.Error()
+		syncs.record(user, string(patch), info)
+		manager.DispatchEvent(client.NewAppStateEvent(user, string(patch), info.State, fullSync, 0, info.Error))
+		return
+	}
+
+	version, _, _ := whatsappClient.WhatsmeowClient.Store.AppState.GetAppStateVersion(ctx, string(patch))
+	s.app.Logger.Printf("Synced app state %s for user %s at version %d", patch, user, version)
+	info.State = client.AppStateSyncCompleted
+	syncs.record(user, string(patch), info)
+	manager.DispatchEvent(client.NewAppStateEvent(user, string(patch), info.State, fullSync, version, ""))
+}
+
+// record stores the latest sync info of a patch
+func (t *syncTracker) record(user, patch string, info SyncInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.last[user] == nil {
+		t.last[user] = make(map[string]SyncInfo)
+	}
+	t.last[user][patch] = info
+}
+
+// Status returns the local version and last sync of every patch
+func (s *Service) Status(user string) (*StatusResponse, error) {
+	whatsappClient, exists := s.app.GetClientManager().GetClient(user)
+	if !exists {
+		return nil, fmt.Errorf("client not found for user %s", user)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	syncs.mu.Lock()
+	defer syncs.mu.Unlock()
+
+	response := &StatusResponse{
+		User:    user,
+		Syncing: syncs.running[user],
+		Patches: make([]PatchStatus, 0, len(appstate.AllPatchNames)),
+	}
+	for _, patch := range appstate.AllPatchNames {
+		status := PatchStatus{Name: string(patch)}
+		version, _, err := whatsappClient.WhatsmeowClient.Store.AppState.GetAppStateVersion(ctx, string(patch))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get app state %s version: %v", patch, err)
+		}
+		status.Version = version
+		if info, ok := syncs.last[user][string(patch)]; ok {
+			status.LastSync = &info
+		}
+		response.Patches = append(response.Patches, status)
+	}
+
+	return response, nil
+}
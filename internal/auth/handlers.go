@@ -1,11 +1,28 @@
 package auth
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"go.mau.fi/whatsmeow"
+)
+
+// PairCodeRequest is the body of POST /wa/pair-code
+type PairCodeRequest struct {
+	User  string `json:"user"`
+	Phone string `json:"phone"` // International format, e.g. 6281234567890
+}
+
+// Bounds for the QR image size query parameter
+const (
+	minQRSize = 64
+	maxQRSize = 1024
 )
 
 // Handlers contains HTTP handlers for authentication
@@ -24,16 +41,169 @@ func NewHandlers(app *app.App) *Handlers {
 
 // QRImageHandler handles generating a QR code for WhatsApp Web authentication
 func (h *Handlers) QRImageHandler(c *gin.Context) {
+	opts, ok := ParseQROptions(c)
+	if !ok {
+		return
+	}
+
+	code, ok := h.waitForQRCode(c)
+	if !ok {
+		return
+	}
+
+	if opts.Format == QRFormatRaw {
+		c.String(http.StatusOK, code)
+		return
+	}
+
+	qrCode, err := RenderQRCodeBase64(code, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"qrcode": "data:image/png;base64," + qrCode})
+}
+
+// QRPNGHandler handles generating a QR code and returning it as a PNG image
+func (h *Handlers) QRPNGHandler(c *gin.Context) {
+	opts, ok := ParseQROptions(c)
+	if !ok {
+		return
+	}
+
+	code, ok := h.waitForQRCode(c)
+	if !ok {
+		return
+	}
+
+	if opts.Format == QRFormatRaw {
+		c.String(http.StatusOK, code)
+		return
+	}
+
+	png, err := RenderQRCodePNG(code, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// QRLatestHandler returns the cached pairing code without starting a new pairing flow
+func (h *Handlers) QRLatestHandler(c *gin.Context) {
 	user := c.Query("user")
 	if user == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
 		return
 	}
 
-	qrCode, err := h.service.GenerateQRCode(user)
+	opts, ok := ParseQROptions(c)
+	if !ok {
+		return
+	}
+
+	latest, err := h.service.LatestQRCode(user)
+	if err != nil {
+		if errors.Is(err, ErrNoPairingInProgress) || errors.Is(err, ErrNoQRCodeAvailable) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if opts.Format == QRFormatRaw {
+		c.String(http.StatusOK, latest.Code)
+		return
+	}
+
+	qrCode, err := RenderQRCodeBase64(latest.Code, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"qrcode":             "data:image/png;base64," + qrCode,
+		"code":               latest.Code,
+		"created_at":         latest.CreatedAt,
+		"expires_at":         latest.ExpiresAt,
+		"age_seconds":        int(time.Since(latest.CreatedAt).Seconds()),
+		"expires_in_seconds": int(time.Until(latest.ExpiresAt).Seconds()),
+	})
+}
+
+// QRCancelHandler aborts an in-progress QR pairing for a user
+func (h *Handlers) QRCancelHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	if err := h.service.CancelPairing(user); err != nil {
+		if errors.Is(err, ErrNoPairingInProgress) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "cancelled", "user": user})
+}
+
+// ParseQROptions reads the size, level and format query parameters, writing a 400 response on invalid input
+func ParseQROptions(c *gin.Context) (QROptions, bool) {
+	opts := DefaultQROptions()
+
+	if size := c.Query("size"); size != "" {
+		parsed, err := strconv.Atoi(size)
+		if err != nil || parsed < minQRSize || parsed > maxQRSize {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid size, must be between %d and %d", minQRSize, maxQRSize),
+			})
+			return opts, false
+		}
+		opts.Size = parsed
+	}
+
+	switch format := c.Query("format"); format {
+	case "", QRFormatImage:
+		opts.Format = QRFormatImage
+	case QRFormatRaw:
+		opts.Format = QRFormatRaw
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, must be one of image, raw"})
+		return opts, false
+	}
+
+	if level := c.Query("level"); level != "" {
+		parsed, err := ParseQRLevel(level)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return opts, false
+		}
+		opts.Level = parsed
+	}
+
+	return opts, true
+}
+
+// waitForQRCode obtains a pairing code for the requested user, writing an error response on failure
+func (h *Handlers) waitForQRCode(c *gin.Context) (string, bool) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return "", false
+	}
+
+	code, err := h.service.GenerateQRCode(user)
 	if err != nil {
 		// If the user is already logged in, return a specific message
-		if err.Error() == "session is already logged in and connected" {
+		if errors.Is(err, ErrAlreadyLoggedIn) {
 			sess, exists := h.service.sessionService.FindSessionByUser(user)
 			if exists {
 				c.JSON(http.StatusBadRequest, gin.H{
@@ -44,15 +214,54 @@ func (h *Handlers) QRImageHandler(c *gin.Context) {
 						"user":      user,
 					},
 				})
-				return
+				return "", false
 			}
 		}
-		
+
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return "", false
+	}
+
+	return code, true
+}
+
+// PairCodeHandler handles POST /wa/pair-code - starts a pairing completed by
+// entering a linking code on the phone, for sessions that cannot scan a QR code
+func (h *Handlers) PairCodeHandler(c *gin.Context) {
+	var req PairCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	if req.Phone == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing phone"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"qrcode": "data:image/png;base64," + qrCode})
+	pairCode, err := h.service.GeneratePairCode(req.User, req.Phone)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmeow.ErrPhoneNumberTooShort), errors.Is(err, whatsmeow.ErrPhoneNumberIsNotInternational):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrAlreadyLoggedIn):
+			c.JSON(http.StatusConflict, gin.H{"error": "Session is already logged in and connected. No pairing code needed."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":               req.User,
+		"phone":              pairCode.Phone,
+		"code":               pairCode.Code,
+		"expires_at":         pairCode.ExpiresAt,
+		"expires_in_seconds": int(time.Until(pairCode.ExpiresAt).Seconds()),
+	})
 }
 
 // PasskeyStatusHandler handles checking the current passkey pairing status
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"sync"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// pairingAttempt is an in-progress QR pairing for a single user
+type pairingAttempt struct {
+	session *app.Session
+	cancel  context.CancelFunc
+}
+
+// pairingRegistry tracks in-progress QR pairings so they can be cancelled
+type pairingRegistry struct {
+	mu       sync.Mutex
+	attempts map[string]*pairingAttempt
+}
+
+// Global registry of pairing attempts, shared by all handler instances
+var pairings = &pairingRegistry{attempts: make(map[string]*pairingAttempt)}
+
+// start registers a new pairing attempt for a user, cancelling any previous one.
+// The returned function must be called once the attempt has finished.
+func (r *pairingRegistry) start(user string, sess *app.Session) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempt := &pairingAttempt{session: sess, cancel: cancel}
+
+	r.mu.Lock()
+	if previous, ok := r.attempts[user]; ok {
+		previous.cancel()
+		previous.session.ClearLatestQRCode()
+	}
+	r.attempts[user] = attempt
+	r.mu.Unlock()
+
+	return ctx, func() {
+		cancel()
+		// A newer attempt may already be showing its own code on the session
+		r.mu.Lock()
+		if r.attempts[user] == attempt {
+			delete(r.attempts, user)
+			sess.ClearLatestQRCode()
+		}
+		r.mu.Unlock()
+	}
+}
+
+// cancel aborts the pairing attempt for a user, reporting whether one was running
+func (r *pairingRegistry) cancel(user string) bool {
+	r.mu.Lock()
+	attempt, ok := r.attempts[user]
+	if ok {
+		delete(r.attempts, user)
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	attempt.cancel()
+	attempt.session.ClearLatestQRCode()
+	return true
+}
+
+// session returns the session of the running pairing attempt for a user
+func (r *pairingRegistry) session(user string) (*app.Session, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempt, ok := r.attempts[user]
+	if !ok {
+		return nil, false
+	}
+	return attempt.session, true
+}
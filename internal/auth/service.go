@@ -4,17 +4,51 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
 	"github.com/neekaru/whatsappgo-bot/internal/session"
 	"github.com/skip2/go-qrcode"
+	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 )
 
+// ErrNoPairingInProgress is returned when cancelling a pairing that is not running
+var ErrNoPairingInProgress = errors.New("no pairing in progress")
+
+// ErrNoQRCodeAvailable is returned when a pairing is running but its last code has expired
+var ErrNoQRCodeAvailable = errors.New("no unexpired QR code available")
+
+// ErrAlreadyLoggedIn is returned when starting a pairing for a session that is already logged in
+var ErrAlreadyLoggedIn = errors.New("session is already logged in and connected")
+
+// How long a phone pairing code stays usable. WhatsApp closes the login
+// websocket once the QR codes it sends alongside run out.
+const pairCodeLifetime = 160 * time.Second
+
+// Browser shown on the phone for sessions linked with a pairing code. WhatsApp
+// only accepts common "Browser (OS)" combinations.
+const pairCodeClientName = "Chrome (Linux)"
+
+// PairCode is the linking code of an in-progress phone number pairing
+type PairCode struct {
+	Code      string
+	Phone     string
+	ExpiresAt time.Time
+}
+
+// LatestQR is the most recent pairing code of an in-progress pairing
+type LatestQR struct {
+	Code      string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
 // Service handles authentication-related business logic
 type Service struct {
 	app            *app.App
@@ -29,7 +63,8 @@ func NewService(app *app.App) *Service {
 	}
 }
 
-// GenerateQRCode generates a QR code for WhatsApp Web authentication
+// GenerateQRCode waits for a pairing code for WhatsApp Web authentication and
+// returns the raw code string
 func (s *Service) GenerateQRCode(user string) (string, error) {
 	sess, exists := s.sessionService.FindSessionByUser(user)
 	if !exists {
@@ -39,7 +74,7 @@ func (s *Service) GenerateQRCode(user string) (string, error) {
 	// Check both logged_in and connection status
 	if sess.IsLoggedIn && sess.Client.IsConnected() {
 		s.app.Logger.Printf("User %s is already logged in and connected, no QR code needed", user)
-		return "", fmt.Errorf("session is already logged in and connected")
+		return "", ErrAlreadyLoggedIn
 	}
 
 	// Always disconnect first to avoid "websocket is already connected" error
@@ -58,35 +93,40 @@ func (s *Service) GenerateQRCode(user string) (string, error) {
 	qrCodeChan := make(chan string, 1)
 	errorChan := make(chan error, 1)
 
+	// Register the attempt so it can be cancelled; this aborts any earlier one
+	ctx, finish := pairings.start(user, sess)
+
 	// Start the client connection and QR code generation in a goroutine
 	go func() {
-		client := sess.Client
+		whatsmeowClient := sess.Client
 
 		// Set up event handlers before connecting
-		qrChan, _ := client.GetQRChannel(context.Background())
+		qrChan, _ := whatsmeowClient.GetQRChannel(ctx)
 
 		// Connect the client with error handling
-		err := client.Connect()
+		err := whatsmeowClient.Connect()
 		if err != nil {
 			// Try to handle specific error types
-			if strings.Contains(err.Error(), "websocket is already connected") {
+			if errors.Is(err, client.ErrAlreadyConnected) {
 				s.app.Logger.Printf("Got 'already connected' error for %s, trying to disconnect and reconnect", user)
 				// Force disconnect and try again after a delay
-				client.Disconnect()
+				whatsmeowClient.Disconnect()
 				time.Sleep(1 * time.Second)
-				err = client.Connect()
+				err = whatsmeowClient.Connect()
 				if err != nil {
+					finish()
 					errorChan <- fmt.Errorf("failed to connect client after retry: %v", err)
 					return
 				}
 			} else {
+				finish()
 				errorChan <- fmt.Errorf("failed to connect client: %v", err)
 				return
 			}
 		}
 
 		// Add connection event handler
-		client.AddEventHandler(func(evt interface{}) {
+		whatsmeowClient.AddEventHandler(func(evt interface{}) {
 			switch e := evt.(type) {
 			case *events.Connected:
 				sess.IsLoggedIn = true
@@ -107,36 +147,32 @@ func (s *Service) GenerateQRCode(user string) (string, error) {
 			select {
 			case evt := <-qrChan:
 				if evt.Code != "" {
-					sess.QRLock.Lock()
-					sess.LatestQRCode = evt.Code
-					sess.QRLock.Unlock()
+					sess.SetLatestQRCode(evt.Code, evt.Timeout)
 
 					s.app.Logger.Printf("Generated QR code for user %s", user)
-
-					// Generate QR code image
-					qr, err := qrcode.New(evt.Code, qrcode.Medium)
-					if err != nil {
-						errorChan <- fmt.Errorf("failed to generate QR code: %v", err)
-						return
-					}
-
-					// Convert QR code to PNG bytes
-					png, err := qr.PNG(256)
-					if err != nil {
-						errorChan <- fmt.Errorf("failed to generate PNG: %v", err)
-						return
-					}
-
-					// Convert to base64
-					qrBase64 := base64.StdEncoding.EncodeToString(png)
-					qrCodeChan <- qrBase64
+					qrCodeChan <- evt.Code
 				} else {
+					finish()
 					errorChan <- fmt.Errorf("received empty QR code")
+					return
 				}
+			case <-ctx.Done():
+				finish()
+				errorChan <- fmt.Errorf("pairing cancelled")
+				return
 			case <-time.After(30 * time.Second):
+				finish()
 				errorChan <- fmt.Errorf("timed out waiting for QR code generation")
+				return
 			}
+
+			// Keep tracking rotated codes until pairing succeeds, times out or is cancelled
+			s.followPairing(ctx, user, qrChan, func(evt whatsmeow.QRChannelItem) {
+				sess.SetLatestQRCode(evt.Code, evt.Timeout)
+			})
+			finish()
 		} else {
+			finish()
 			errorChan <- fmt.Errorf("failed to create QR channel")
 		}
 	}()
@@ -152,6 +188,220 @@ func (s *Service) GenerateQRCode(user string) (string, error) {
 	}
 }
 
+// followPairing reads the events of a pairing until its channel is closed or
+// the attempt is cancelled, passing rotated codes to onCode. whatsmeow does not
+// close the channel on an expected disconnect, so an abandoned attempt only
+// ends through its context.
+func (s *Service) followPairing(ctx context.Context, user string, qrChan <-chan whatsmeow.QRChannelItem, onCode func(whatsmeow.QRChannelItem)) {
+	for {
+		select {
+		case <-ctx.Done():
+			s.app.Logger.Printf("Pairing for user %s ended: %v", user, ctx.Err())
+			return
+		case evt, ok := <-qrChan:
+			if !ok {
+				return
+			}
+			if evt.Event == whatsmeow.QRChannelEventCode {
+				if onCode != nil {
+					onCode(evt)
+				}
+				continue
+			}
+			s.app.Logger.Printf("Pairing for user %s finished with status %s", user, evt.Event)
+		}
+	}
+}
+
+// GeneratePairCode starts a pairing that is completed by entering a linking
+// code on the phone instead of scanning a QR code, and returns the code
+func (s *Service) GeneratePairCode(user, phone string) (*PairCode, error) {
+	phone, err := normalizePairPhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, exists := s.sessionService.FindSessionByUser(user)
+	if !exists {
+		return nil, fmt.Errorf("session not found")
+	}
+
+	if sess.IsLoggedIn && sess.Client.IsConnected() {
+		s.app.Logger.Printf("User %s is already logged in and connected, no pairing code needed", user)
+		return nil, ErrAlreadyLoggedIn
+	}
+
+	// A fresh login websocket gives the code the full time before it expires
+	if sess.Client.IsConnected() {
+		s.app.Logger.Printf("Disconnecting existing connection for user %s before generating pairing code", user)
+		sess.Client.Disconnect()
+		time.Sleep(500 * time.Millisecond)
+	}
+	sess.IsLoggedIn = false
+
+	// Phone pairings share the registry with QR pairings, so either cancels the other
+	ctx, finish := pairings.start(user, sess)
+
+	client := sess.Client
+	qrChan, err := client.GetQRChannel(ctx)
+	if err != nil {
+		finish()
+		return nil, fmt.Errorf("failed to create QR channel: %v", err)
+	}
+	if err := client.Connect(); err != nil {
+		finish()
+		return nil, fmt.Errorf("failed to connect client: %v", err)
+	}
+
+	// The first QR event means the login websocket is ready for the pairing request
+	select {
+	case evt := <-qrChan:
+		if evt.Event != whatsmeow.QRChannelEventCode {
+			finish()
+			return nil, fmt.Errorf("pairing ended before a code could be requested: %s", evt.Event)
+		}
+	case <-ctx.Done():
+		finish()
+		return nil, fmt.Errorf("pairing cancelled")
+	case <-time.After(30 * time.Second):
+		finish()
+		return nil, fmt.Errorf("timed out waiting for the login connection")
+	}
+
+	code, err := client.PairPhone(ctx, phone, true, whatsmeow.PairClientChrome, pairCodeClientName)
+	if err != nil {
+		finish()
+		client.Disconnect()
+		return nil, fmt.Errorf("failed to request pairing code: %w", err)
+	}
+	expiresAt := time.Now().Add(pairCodeLifetime)
+	s.app.Logger.Printf("Generated pairing code for user %s and phone %s", user, phone)
+
+	// Keep the pairing registered until the phone links, the codes run out or it is cancelled
+	go func() {
+		defer finish()
+		s.followPairing(ctx, user, qrChan, nil)
+	}()
+
+	return &PairCode{Code: code, Phone: phone, ExpiresAt: expiresAt}, nil
+}
+
+// normalizePairPhone strips formatting from a phone number and checks it is in
+// the international form WhatsApp requires for pairing codes
+func normalizePairPhone(phone string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+
+	if len(digits) <= 6 {
+		return "", whatsmeow.ErrPhoneNumberTooShort
+	}
+	if strings.HasPrefix(digits, "0") {
+		return "", whatsmeow.ErrPhoneNumberIsNotInternational
+	}
+	return digits, nil
+}
+
+// LatestQRCode returns the cached pairing code of an in-progress pairing without
+// starting a new one
+func (s *Service) LatestQRCode(user string) (*LatestQR, error) {
+	sess, ok := pairings.session(user)
+	if !ok {
+		return nil, ErrNoPairingInProgress
+	}
+
+	code, createdAt, expiresAt := sess.GetLatestQRCode()
+	if code == "" {
+		return nil, ErrNoQRCodeAvailable
+	}
+
+	return &LatestQR{
+		Code:      code,
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// CancelPairing aborts an in-progress QR pairing for a user and disconnects
+// the client so the pending code can no longer be scanned
+func (s *Service) CancelPairing(user string) error {
+	if !pairings.cancel(user) {
+		return ErrNoPairingInProgress
+	}
+
+	if sess, exists := s.sessionService.FindSessionByUser(user); exists && !sess.IsLoggedIn {
+		sess.Client.Disconnect()
+	}
+
+	s.app.Logger.Printf("Cancelled pairing for user %s", user)
+	return nil
+}
+
+// QR output formats
+const (
+	QRFormatImage = "image"
+	QRFormatRaw   = "raw"
+)
+
+// QROptions controls how a pairing code is rendered
+type QROptions struct {
+	Size   int
+	Level  qrcode.RecoveryLevel
+	Format string
+}
+
+// DefaultQROptions returns the rendering options used when none are given
+func DefaultQROptions() QROptions {
+	return QROptions{
+		Size:   256,
+		Level:  qrcode.Medium,
+		Format: QRFormatImage,
+	}
+}
+
+// ParseQRLevel converts a level name (low, medium, high, highest) to a recovery level
+func ParseQRLevel(level string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToLower(level) {
+	case "l", "low":
+		return qrcode.Low, nil
+	case "m", "medium":
+		return qrcode.Medium, nil
+	case "q", "high":
+		return qrcode.High, nil
+	case "h", "highest":
+		return qrcode.Highest, nil
+	default:
+		return qrcode.Medium, fmt.Errorf("invalid QR level %q, must be one of low, medium, high, highest", level)
+	}
+}
+
+// RenderQRCodePNG renders a pairing code as a PNG image
+func RenderQRCodePNG(code string, opts QROptions) ([]byte, error) {
+	qr, err := qrcode.New(code, opts.Level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %v", err)
+	}
+
+	png, err := qr.PNG(opts.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PNG: %v", err)
+	}
+
+	return png, nil
+}
+
+// RenderQRCodeBase64 renders a pairing code as a base64 encoded PNG image
+func RenderQRCodeBase64(code string, opts QROptions) (string, error) {
+	png, err := RenderQRCodePNG(code, opts)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(png), nil
+}
+
 // GetPasskeyStatus returns the current passkey pairing status for a user
 func (s *Service) GetPasskeyStatus(user string) (map[string]interface{}, error) {
 	clientManager := s.app.GetClientManager()
@@ -0,0 +1,326 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Backup archives are named after their UTC creation time, e.g.
+// backup-20240102T030405Z.tar.gz
+const (
+	archivePrefix     = "backup-"
+	archiveSuffix     = ".tar.gz"
+	archiveTimeFormat = "20060102T150405Z"
+)
+
+// Name of the archive entry describing its contents
+const manifestName = "manifest.json"
+
+// Backups kept by default; older ones are deleted after each backup
+const defaultRetention = 7
+
+// Config configures backups of the data directory
+type Config struct {
+	DataDir   string
+	Interval  time.Duration // Time between scheduled backups; 0 backs up on request only
+	Retention int           // Backups kept in the target
+
+	// Target: an S3 bucket and key prefix, or else a local directory
+	Dir      string
+	S3Bucket string
+	S3Prefix string
+}
+
+// manifest describes the contents of a backup archive
+type manifest struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Files     []string  `json:"files"`
+}
+
+// Manager snapshots the session databases, message stores and settings in
+// the data directory into compressed archives kept in a directory or an S3
+// bucket, and stages archives to be restored at the next start.
+type Manager struct {
+	app       *app.App
+	dataDir   string
+	interval  time.Duration
+	retention int
+	target    target
+
+	running sync.Mutex // Held while a backup or restore runs
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewManager creates a backup manager for the configured target
+func NewManager(app *app.App, cfg Config) (*Manager, error) {
+	var t target
+	var err error
+	if cfg.S3Bucket != "" {
+		t, err = newS3Target(cfg.S3Bucket, cfg.S3Prefix)
+	} else {
+		t, err = newDirTarget(cfg.Dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	retention := cfg.Retention
+	if retention < 1 {
+		retention = defaultRetention
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		app:       app,
+		dataDir:   cfg.DataDir,
+		interval:  cfg.Interval,
+		retention: retention,
+		target:    t,
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// Start runs scheduled backups in the background, if an interval is set
+func (m *Manager) Start() {
+	if m.interval <= 0 {
+		close(m.done)
+		return
+	}
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := m.Run(m.ctx); err != nil {
+					m.app.Logger.Printf("Scheduled backup failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops scheduled backups, aborting one that is running
+func (m *Manager) Close() {
+	m.cancel()
+	<-m.done
+}
+
+// Run takes a backup now, then deletes backups past the retention
+func (m *Manager) Run(ctx context.Context) (*Backup, error) {
+	// Archives are staged on local disk before they reach the target
+	if m.app.Disk.Low() {
+		return nil, ErrDiskSpaceLow
+	}
+	if !m.running.TryLock() {
+		return nil, ErrBackupRunning
+	}
+	defer m.running.Unlock()
+
+	started := time.Now()
+	createdAt := started.UTC().Truncate(time.Second)
+	name := archivePrefix + createdAt.Format(archiveTimeFormat) + archiveSuffix
+
+	archive, err := os.CreateTemp("", "whatsapp-backup-*"+archiveSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup archive: %v", err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	files, err := m.writeArchive(ctx, archive, manifest{Name: name, CreatedAt: createdAt})
+	if err != nil {
+		return nil, err
+	}
+	info, err := archive.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.target.Put(ctx, name, archive); err != nil {
+		return nil, err
+	}
+	m.app.Logger.Printf("Backup %s of %d files (%d bytes) stored in %s target in %s", name, len(files), info.Size(), m.target.Name(), time.Since(started).Round(time.Millisecond))
+
+	if err := m.prune(ctx); err != nil {
+		m.app.Logger.Printf("Failed to delete old backups: %v", err)
+	}
+	return &Backup{Name: name, Size: info.Size(), CreatedAt: createdAt}, nil
+}
+
+// List returns the backups kept in the target, newest first
+func (m *Manager) List(ctx context.Context) (*ListResponse, error) {
+	backups, err := m.target.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sortNewestFirst(backups)
+	if backups == nil {
+		backups = []Backup{}
+	}
+	resp := &ListResponse{Target: m.target.Name(), Backups: backups, Retention: m.retention}
+	if pending, err := readManifest(filepath.Join(m.dataDir, restoreDirName)); err == nil {
+		resp.PendingRestore = pending.Name
+	}
+	return resp, nil
+}
+
+// writeArchive snapshots the data directory into a gzipped tar archive.
+// Databases are copied with SQLite's backup API so they are consistent while
+// in use; JSON settings files are copied as they are. Subdirectories, such as
+// the raw event archive and staged restores, are left out.
+func (m *Manager) writeArchive(ctx context.Context, archive *os.File, mf manifest) ([]string, error) {
+	entries, err := os.ReadDir(m.dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data directory: %v", err)
+	}
+
+	staging, err := os.MkdirTemp("", "whatsapp-backup-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup staging directory: %v", err)
+	}
+	defer os.RemoveAll(staging)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !backedUp(name) {
+			continue
+		}
+		src := filepath.Join(m.dataDir, name)
+		dest := filepath.Join(staging, name)
+		if strings.HasSuffix(name, ".db") {
+			err = snapshotDatabase(ctx, src, dest)
+		} else {
+			err = copyFile(src, dest)
+		}
+		if err != nil {
+			return nil, err
+		}
+		mf.Files = append(mf.Files, name)
+	}
+
+	gz := gzip.NewWriter(archive)
+	tw := tar.NewWriter(gz)
+	for _, name := range mf.Files {
+		if err := addFile(tw, filepath.Join(staging, name), name); err != nil {
+			return nil, fmt.Errorf("failed to write backup archive: %v", err)
+		}
+	}
+	encoded, err := json.MarshalIndent(mf, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: manifestName, Mode: 0600, Size: int64(len(encoded)), ModTime: mf.CreatedAt}); err != nil {
+		return nil, fmt.Errorf("failed to write backup archive: %v", err)
+	}
+	if _, err := tw.Write(encoded); err != nil {
+		return nil, fmt.Errorf("failed to write backup archive: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write backup archive: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write backup archive: %v", err)
+	}
+	return mf.Files, nil
+}
+
+// prune deletes the oldest backups past the retention
+func (m *Manager) prune(ctx context.Context) error {
+	backups, err := m.target.List(ctx)
+	if err != nil {
+		return err
+	}
+	sortNewestFirst(backups)
+	for i := m.retention; i < len(backups); i++ {
+		if err := m.target.Delete(ctx, backups[i].Name); err != nil {
+			return fmt.Errorf("failed to delete backup %s: %v", backups[i].Name, err)
+		}
+		m.app.Logger.Printf("Deleted backup %s past retention of %d", backups[i].Name, m.retention)
+	}
+	return nil
+}
+
+// backedUp reports whether a file in the data directory belongs in backups:
+// SQLite databases and JSON settings. WAL and journal files are folded into
+// the database snapshots.
+func backedUp(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	return strings.HasSuffix(name, ".db") || strings.HasSuffix(name, ".json")
+}
+
+// parseArchiveName returns the creation time of a backup archive name
+func parseArchiveName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, archivePrefix) || !strings.HasSuffix(name, archiveSuffix) {
+		return time.Time{}, false
+	}
+	createdAt, err := time.Parse(archiveTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, archivePrefix), archiveSuffix))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return createdAt, true
+}
+
+// sortNewestFirst orders backups by creation time, newest first
+func sortNewestFirst(backups []Backup) {
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+}
+
+// addFile writes the file at path to the archive under name
+func addFile(tw *tar.Writer, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// copyFile copies the file at src to dest
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %v", src, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %v", src, err)
+	}
+	return out.Close()
+}
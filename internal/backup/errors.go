@@ -0,0 +1,14 @@
+package backup
+
+import "errors"
+
+var (
+	// ErrBackupNotFound is returned for backups that are not in the target
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrBackupRunning is returned when a backup or restore is requested while another one runs
+	ErrBackupRunning = errors.New("a backup or restore is already running")
+	// ErrInvalidBackup is returned for archives that are not backups made by this service
+	ErrInvalidBackup = errors.New("invalid backup")
+	// ErrDiskSpaceLow is returned while free disk space is below the configured minimum
+	ErrDiskSpaceLow = errors.New("free disk space is low, backups are paused")
+)
@@ -0,0 +1,77 @@
+package backup
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Handlers contains HTTP handlers for backups
+type Handlers struct {
+	app     *app.App
+	manager *Manager
+}
+
+// NewHandlers creates a new backup handlers instance
+func NewHandlers(app *app.App, manager *Manager) *Handlers {
+	return &Handlers{
+		app:     app,
+		manager: manager,
+	}
+}
+
+// ListHandler handles GET /backups - lists the kept backups, newest first
+func (h *Handlers) ListHandler(c *gin.Context) {
+	resp, err := h.manager.List(c.Request.Context())
+	if err != nil {
+		h.app.Logger.Printf("List backups error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateHandler handles POST /backups - takes a backup now
+func (h *Handlers) CreateHandler(c *gin.Context) {
+	backup, err := h.manager.Run(c.Request.Context())
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"msg": "Backup created", "backup": backup})
+	case errors.Is(err, ErrBackupRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrDiskSpaceLow):
+		c.JSON(http.StatusInsufficientStorage, gin.H{"error": err.Error()})
+	default:
+		h.app.Logger.Printf("Backup error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Backup failed", "details": err.Error()})
+	}
+}
+
+// RestoreHandler handles POST /backups/restore - stages a backup to be
+// restored at the next start
+func (h *Handlers) RestoreHandler(c *gin.Context) {
+	var req RestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	resp, err := h.manager.Restore(c.Request.Context(), req.Name)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, resp)
+	case errors.Is(err, ErrBackupNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrBackupRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrDiskSpaceLow):
+		c.JSON(http.StatusInsufficientStorage, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidBackup):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Backup cannot be restored", "details": err.Error()})
+	default:
+		h.app.Logger.Printf("Restore backup %s error: %v", req.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Restore failed", "details": err.Error()})
+	}
+}
@@ -0,0 +1,30 @@
+package backup
+
+import "time"
+
+// Backup is a snapshot archive kept in the backup target
+type Backup struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListResponse lists the kept backups, newest first
+type ListResponse struct {
+	Target         string   `json:"target"` // dir or s3
+	Backups        []Backup `json:"backups"`
+	Retention      int      `json:"retention"`
+	PendingRestore string   `json:"pending_restore,omitempty"` // Backup staged to be restored at the next start
+}
+
+// RestoreRequest is the request body to stage a backup for restoring
+type RestoreRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// RestoreResponse describes a staged restore
+type RestoreResponse struct {
+	Msg   string   `json:"msg"`
+	Name  string   `json:"name"`
+	Files []string `json:"files"`
+}
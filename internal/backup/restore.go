@@ -0,0 +1,187 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Directory in the data directory holding a backup staged for restoring
+const restoreDirName = "restore"
+
+// Restore downloads a backup, checks it and stages it in the data directory.
+// Databases are in use while the service runs, so the staged files replace
+// the current ones at the next start, in ApplyPendingRestore. Staging another
+// backup replaces the one staged before.
+func (m *Manager) Restore(ctx context.Context, name string) (*RestoreResponse, error) {
+	if _, ok := parseArchiveName(name); !ok {
+		return nil, ErrBackupNotFound
+	}
+	if m.app.Disk.Low() {
+		return nil, ErrDiskSpaceLow
+	}
+	if !m.running.TryLock() {
+		return nil, ErrBackupRunning
+	}
+	defer m.running.Unlock()
+
+	archive, err := os.CreateTemp("", "whatsapp-restore-*"+archiveSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download backup: %v", err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	if err := m.target.Get(ctx, name, archive); err != nil {
+		return nil, err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// Extract next to the staging directory and swap it in once complete
+	staging := filepath.Join(m.dataDir, restoreDirName)
+	extracting := staging + ".tmp"
+	if err := os.RemoveAll(extracting); err != nil {
+		return nil, err
+	}
+	mf, err := extractArchive(archive, extracting)
+	if err != nil {
+		os.RemoveAll(extracting)
+		return nil, err
+	}
+	if err := os.RemoveAll(staging); err != nil {
+		os.RemoveAll(extracting)
+		return nil, err
+	}
+	if err := os.Rename(extracting, staging); err != nil {
+		os.RemoveAll(extracting)
+		return nil, fmt.Errorf("failed to stage restore: %v", err)
+	}
+
+	m.app.Logger.Printf("Backup %s staged for restoring at the next start (%d files)", name, len(mf.Files))
+	return &RestoreResponse{
+		Msg:   "Restore staged, restart the service to apply it",
+		Name:  mf.Name,
+		Files: mf.Files,
+	}, nil
+}
+
+// extractArchive extracts a backup archive into dir, checking that it only
+// holds files a backup is made of and that its databases are intact
+func extractArchive(archive io.Reader, dir string) (*manifest, error) {
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	extracted := make(map[string]bool)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+		}
+		name := header.Name
+		if header.Typeflag != tar.TypeReg || name != filepath.Base(name) || (name != manifestName && !backedUp(name)) {
+			return nil, fmt.Errorf("%w: unexpected entry %q", ErrInvalidBackup, name)
+		}
+
+		out, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			out.Close()
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+		}
+		if err := out.Close(); err != nil {
+			return nil, err
+		}
+		extracted[name] = true
+	}
+
+	mf, err := readManifest(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	for _, name := range mf.Files {
+		if !extracted[name] || !backedUp(name) {
+			return nil, fmt.Errorf("%w: %s is missing", ErrInvalidBackup, name)
+		}
+		if strings.HasSuffix(name, ".db") {
+			if err := checkDatabase(filepath.Join(dir, name)); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidBackup, name, err)
+			}
+		}
+	}
+	return mf, nil
+}
+
+// readManifest reads the manifest of a backup extracted into dir
+func readManifest(dir string) (*manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		return nil, err
+	}
+	var mf manifest
+	if err := json.Unmarshal(data, &mf); err != nil {
+		return nil, err
+	}
+	return &mf, nil
+}
+
+// ApplyPendingRestore replaces files in the data directory with those of a
+// backup staged by Restore, and returns the backup's name, or "" when none is
+// staged. It must run before anything opens the databases. Replaced files are
+// moved to a pre-restore-{time} directory rather than deleted; files the
+// backup does not hold, such as sessions added after it, are left in place.
+func ApplyPendingRestore(dataDir string) (string, error) {
+	staging := filepath.Join(dataDir, restoreDirName)
+	mf, err := readManifest(staging)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read staged restore: %v", err)
+	}
+
+	previous := filepath.Join(dataDir, "pre-restore-"+time.Now().UTC().Format(archiveTimeFormat))
+	if err := os.MkdirAll(previous, 0700); err != nil {
+		return "", fmt.Errorf("failed to keep files replaced by the restore: %v", err)
+	}
+
+	for _, name := range mf.Files {
+		// A leftover WAL of the replaced database would be replayed into the restored one
+		for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+			current := filepath.Join(dataDir, name+suffix)
+			if err := os.Rename(current, filepath.Join(previous, name+suffix)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return "", fmt.Errorf("failed to move %s aside: %v", current, err)
+			}
+		}
+		if err := os.Rename(filepath.Join(staging, name), filepath.Join(dataDir, name)); err != nil {
+			return "", fmt.Errorf("failed to restore %s: %v", name, err)
+		}
+	}
+
+	if err := os.RemoveAll(staging); err != nil {
+		return mf.Name, fmt.Errorf("failed to remove staged restore: %v", err)
+	}
+	return mf.Name, nil
+}
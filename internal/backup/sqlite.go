@@ -0,0 +1,100 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Pages copied per backup step; the source is only locked during a step, so
+// sessions keep writing between steps
+const backupStepPages = 256
+
+// snapshotDatabase copies the SQLite database at src to dest with SQLite's
+// online backup API. Unlike a file copy it yields a consistent database while
+// the source is being written to, including changes still in its WAL.
+func snapshotDatabase(ctx context.Context, src, dest string) error {
+	srcDB, err := sql.Open("sqlite3", "file:"+src+"?mode=ro&_busy_timeout=5000")
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", src, err)
+	}
+	defer srcDB.Close()
+
+	destDB, err := sql.Open("sqlite3", "file:"+dest)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", dest, err)
+	}
+	defer destDB.Close()
+
+	srcConn, err := srcDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", src, err)
+	}
+	defer srcConn.Close()
+
+	destConn, err := destDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", dest, err)
+	}
+	defer destConn.Close()
+
+	return destConn.Raw(func(destRaw any) error {
+		return srcConn.Raw(func(srcRaw any) error {
+			destSQLite, ok := destRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", destRaw)
+			}
+			srcSQLite, ok := srcRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", srcRaw)
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return fmt.Errorf("failed to start backup of %s: %v", src, err)
+			}
+			for {
+				// Steps hitting a busy or locked source report not done without error
+				done, err := backup.Step(backupStepPages)
+				if err != nil {
+					backup.Close()
+					return fmt.Errorf("failed to back up %s: %v", src, err)
+				}
+				if done {
+					break
+				}
+				select {
+				case <-ctx.Done():
+					backup.Close()
+					return ctx.Err()
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+			if err := backup.Finish(); err != nil {
+				return fmt.Errorf("failed to finish backup of %s: %v", src, err)
+			}
+			return nil
+		})
+	})
+}
+
+// checkDatabase reports whether the file at path is an intact SQLite database
+func checkDatabase(path string) error {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA quick_check").Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	return nil
+}
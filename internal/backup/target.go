@@ -0,0 +1,198 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// target stores backup archives by name
+type target interface {
+	// Name identifies the kind of target: dir or s3
+	Name() string
+	Put(ctx context.Context, name string, archive *os.File) error
+	// Get writes a stored archive to dest, or returns ErrBackupNotFound
+	Get(ctx context.Context, name string, dest *os.File) error
+	// List returns the stored backup archives in any order
+	List(ctx context.Context) ([]Backup, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// dirTarget keeps backup archives in a local directory, such as a mounted
+// volume on another disk
+type dirTarget struct {
+	dir string
+}
+
+func newDirTarget(dir string) (*dirTarget, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+	return &dirTarget{dir: dir}, nil
+}
+
+func (t *dirTarget) Name() string {
+	return "dir"
+}
+
+// Put copies the archive in under a temporary name first, so an interrupted
+// copy never looks like a complete backup
+func (t *dirTarget) Put(ctx context.Context, name string, archive *os.File) error {
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(t.dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to write backup: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, archive); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write backup: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write backup: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %v", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(t.dir, name))
+}
+
+func (t *dirTarget) Get(ctx context.Context, name string, dest *os.File) error {
+	file, err := os.Open(filepath.Join(t.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrBackupNotFound
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(dest, file)
+	return err
+}
+
+func (t *dirTarget) List(ctx context.Context) ([]Backup, error) {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %v", err)
+	}
+	var backups []Backup
+	for _, entry := range entries {
+		createdAt, ok := parseArchiveName(entry.Name())
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Name: entry.Name(), Size: info.Size(), CreatedAt: createdAt})
+	}
+	return backups, nil
+}
+
+func (t *dirTarget) Delete(ctx context.Context, name string) error {
+	return os.Remove(filepath.Join(t.dir, name))
+}
+
+// s3Target keeps backup archives in an S3 bucket under a key prefix.
+// Credentials and region come from the AWS SDK's default chain
+// (AWS_ACCESS_KEY_ID, AWS_REGION, shared config files or an instance role).
+type s3Target struct {
+	bucket string
+	prefix string
+
+	client     *s3.S3
+	uploader   *s3manager.Uploader
+	downloader *s3manager.Downloader
+}
+
+func newS3Target(bucket, prefix string) (*s3Target, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{SharedConfigState: session.SharedConfigEnable})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 session: %v", err)
+	}
+	return &s3Target{
+		bucket:     bucket,
+		prefix:     prefix,
+		client:     s3.New(sess),
+		uploader:   s3manager.NewUploader(sess),
+		downloader: s3manager.NewDownloader(sess),
+	}, nil
+}
+
+func (t *s3Target) Name() string {
+	return "s3"
+}
+
+func (t *s3Target) Put(ctx context.Context, name string, archive *os.File) error {
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := t.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(t.bucket),
+		Key:         aws.String(t.prefix + name),
+		Body:        archive,
+		ContentType: aws.String("application/gzip"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload backup to s3://%s/%s%s: %v", t.bucket, t.prefix, name, err)
+	}
+	return nil
+}
+
+func (t *s3Target) Get(ctx context.Context, name string, dest *os.File) error {
+	_, err := t.downloader.DownloadWithContext(ctx, dest, &s3.GetObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(t.prefix + name),
+	})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return ErrBackupNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download backup: %v", err)
+	}
+	return nil
+}
+
+func (t *s3Target) List(ctx context.Context) ([]Backup, error) {
+	var backups []Backup
+	err := t.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(t.bucket),
+		Prefix: aws.String(t.prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			name := strings.TrimPrefix(aws.StringValue(object.Key), t.prefix)
+			if createdAt, ok := parseArchiveName(name); ok {
+				backups = append(backups, Backup{Name: name, Size: aws.Int64Value(object.Size), CreatedAt: createdAt})
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %v", err)
+	}
+	return backups, nil
+}
+
+func (t *s3Target) Delete(ctx context.Context, name string) error {
+	_, err := t.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(t.prefix + name),
+	})
+	return err
+}
@@ -0,0 +1,77 @@
+package businesshours
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Handlers contains HTTP handlers for business hours
+type Handlers struct {
+	app *app.App
+}
+
+// NewHandlers creates a new business hours handlers instance
+func NewHandlers(app *app.App) *Handlers {
+	return &Handlers{app: app}
+}
+
+// GetHandler handles GET /wa/business-hours - shows the business hours of a session
+func (h *Handlers) GetHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	hours, ok := h.app.BusinessHours.Get(user)
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"user": user, "business_hours": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user, "business_hours": hours, "open": hours.IsOpen(time.Now())})
+}
+
+// SetHandler handles POST /wa/business-hours - sets the business hours and away message of a session
+func (h *Handlers) SetHandler(c *gin.Context) {
+	var req SetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	normalizeDays(&req.BusinessHours)
+	if err := h.app.BusinessHours.Set(req.User, req.BusinessHours); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":           req.User,
+		"business_hours": req.BusinessHours,
+		"open":           req.BusinessHours.IsOpen(time.Now()),
+	})
+}
+
+// DeleteHandler handles DELETE /wa/business-hours - removes the business hours of a session
+func (h *Handlers) DeleteHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	if err := h.app.BusinessHours.Delete(user); err != nil {
+		h.app.Logger.Printf("Delete business hours error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"msg": "Business hours removed", "user": user})
+}
@@ -0,0 +1,9 @@
+package businesshours
+
+import "github.com/neekaru/whatsappgo-bot/internal/app"
+
+// SetRequest represents a request to set the business hours of a session
+type SetRequest struct {
+	User string `json:"user"`
+	app.BusinessHours
+}
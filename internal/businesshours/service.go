@@ -0,0 +1,127 @@
+package businesshours
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"github.com/neekaru/whatsappgo-bot/internal/messaging"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// maxMessageAge skips messages delivered late, such as the backlog received
+// after being offline, so a reconnect does not answer old chats
+const maxMessageAge = 10 * time.Minute
+
+// pruneThreshold is the number of tracked chats above which expired entries
+// are dropped
+const pruneThreshold = 1024
+
+// Service sends the away message of a session to chats that write outside its
+// business hours, at most once per chat per reply interval.
+type Service struct {
+	app       *app.App
+	messaging *messaging.Service
+
+	mu        sync.Mutex
+	nextReply map[string]time.Time // user and chat -> earliest next away reply
+}
+
+// NewService creates a new business hours service
+func NewService(app *app.App) *Service {
+	return &Service{
+		app:       app,
+		messaging: messaging.NewService(app),
+		nextReply: make(map[string]time.Time),
+	}
+}
+
+// Start registers the service for incoming messages
+func (s *Service) Start() {
+	s.app.GetClientManager().RegisterObserver(client.EventTypeRaw, client.ObserverFunc(s.OnEvent))
+}
+
+// OnEvent replies with the away message to an incoming direct message received
+// outside the session's business hours
+func (s *Service) OnEvent(event client.Event) {
+	msg, ok := event.GetData().(*events.Message)
+	if !ok || msg.Info.IsFromMe || msg.Info.IsGroup || msg.Message == nil {
+		return
+	}
+	if msg.Message.GetProtocolMessage() != nil || msg.Message.GetReactionMessage() != nil {
+		return
+	}
+
+	recipient := chatRecipient(msg.Info.Chat)
+	if recipient == "" {
+		return
+	}
+
+	user := event.GetClientID()
+	hours, ok := s.app.BusinessHours.Get(user)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(msg.Info.Timestamp) > maxMessageAge || hours.IsOpen(now) {
+		return
+	}
+	if !s.reserve(user+"|"+recipient, now, hours.ReplyInterval()) {
+		return
+	}
+
+	// Sending waits for the session's send spacing
+	go func() {
+		if err := s.messaging.SendMessage(user, recipient, hours.AwayMessage, app.SendPriorityNormal, time.Time{}, nil); err != nil {
+			s.app.Logger.Printf("Failed to send away message to %s for user %s: %v", recipient, user, err)
+			return
+		}
+		s.app.Logger.Printf("Away message sent to %s for user %s", recipient, user)
+	}()
+}
+
+// reserve reports whether a chat is due an away reply, and if so marks it as
+// replied until interval has passed
+func (s *Service) reserve(key string, now time.Time, interval time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if next, ok := s.nextReply[key]; ok && now.Before(next) {
+		return false
+	}
+	if len(s.nextReply) >= pruneThreshold {
+		for k, next := range s.nextReply {
+			if !now.Before(next) {
+				delete(s.nextReply, k)
+			}
+		}
+	}
+	s.nextReply[key] = now.Add(interval)
+	return true
+}
+
+// chatRecipient returns the recipient to reply to in a direct chat: the phone
+// number, or the LID JID for hidden users
+func chatRecipient(chat types.JID) string {
+	switch chat.Server {
+	case types.DefaultUserServer:
+		return chat.User
+	case types.HiddenUserServer:
+		return chat.User + "@" + types.HiddenUserServer
+	default:
+		return ""
+	}
+}
+
+// normalizeDays lowercases day names so stored hours read consistently
+func normalizeDays(hours *app.BusinessHours) {
+	for i := range hours.Hours {
+		for j, day := range hours.Hours[i].Days {
+			hours.Hours[i].Days[j] = strings.ToLower(day)
+		}
+	}
+}
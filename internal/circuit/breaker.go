@@ -0,0 +1,197 @@
+package circuit
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned when a call is rejected because the breaker is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a circuit breaker
+type State int
+
+// Breaker states
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+// String returns a string representation of the state
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker opens after a number of consecutive failures, rejects calls while
+// open, and after a cool-down lets a single probe call through (half-open).
+// A successful probe closes the breaker, a failed one opens it again.
+type Breaker struct {
+	name             string
+	failureThreshold int
+	cooldown         time.Duration
+
+	mu                  sync.Mutex
+	state               State
+	consecutiveFailures int
+	openedAt            time.Time
+	probing             bool
+
+	successes  uint64
+	failures   uint64
+	rejections uint64
+	opens      uint64
+}
+
+// Stats is a point-in-time view of a breaker
+type Stats struct {
+	Name                string `json:"name"`
+	State               string `json:"state"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	Successes           uint64 `json:"successes"`
+	Failures            uint64 `json:"failures"`
+	Rejections          uint64 `json:"rejections"`
+	Opens               uint64 `json:"opens"`
+}
+
+// Breakers are registered by name so their stats can be exported as metrics
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[string]*Breaker)
+)
+
+// New creates a breaker and registers it under name, replacing any breaker
+// previously registered with that name.
+func New(name string, failureThreshold int, cooldown time.Duration) *Breaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	b := &Breaker{
+		name:             name,
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+	}
+
+	registryLock.Lock()
+	registry[name] = b
+	registryLock.Unlock()
+
+	return b
+}
+
+// All returns the stats of every registered breaker, sorted by name
+func All() []Stats {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	stats := make([]Stats, 0, len(registry))
+	for _, b := range registry {
+		stats = append(stats, b.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// Do runs fn if the breaker allows it and records the outcome
+func (b *Breaker) Do(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	err := fn()
+	if err != nil {
+		b.onFailure()
+	} else {
+		b.onSuccess()
+	}
+	return err
+}
+
+// allow reports whether a call may proceed, moving an open breaker to
+// half-open once its cool-down has passed
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			b.rejections++
+			return ErrOpen
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+		return nil
+	case StateHalfOpen:
+		// Only one probe at a time while half-open
+		if b.probing {
+			b.rejections++
+			return ErrOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *Breaker) onSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.successes++
+	b.consecutiveFailures = 0
+	b.probing = false
+	b.state = StateClosed
+}
+
+func (b *Breaker) onFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.consecutiveFailures++
+	b.probing = false
+	if b.state == StateHalfOpen || b.consecutiveFailures >= b.failureThreshold {
+		if b.state != StateOpen {
+			b.opens++
+		}
+		b.state = StateOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// State returns the current state of the breaker
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Stats returns the current counters of the breaker
+func (b *Breaker) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return Stats{
+		Name:                b.name,
+		State:               b.state.String(),
+		ConsecutiveFailures: b.consecutiveFailures,
+		Successes:           b.successes,
+		Failures:            b.failures,
+		Rejections:          b.rejections,
+		Opens:               b.opens,
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// BanInfo describes a temporary ban WhatsApp reported when the client connected
+type BanInfo struct {
+	Code      int        `json:"code"`
+	Reason    string     `json:"reason"`
+	BannedAt  time.Time  `json:"banned_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Unset when WhatsApp did not say
+}
+
+// Ban returns the client's temporary ban, or nil if it is not banned. A ban
+// is cleared once it expires or the client logs in again.
+func (c *Client) Ban() *BanInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ban == nil {
+		return nil
+	}
+	if c.ban.ExpiresAt != nil && time.Now().After(*c.ban.ExpiresAt) {
+		return nil
+	}
+	ban := *c.ban
+	return &ban
+}
+
+// handleTemporaryBan marks the client banned. whatsmeow does not reconnect
+// after a ban, so a reconnect is scheduled for when it expires, if known.
+func (c *Client) handleTemporaryBan(e *events.TemporaryBan) {
+	ban := BanInfo{
+		Code:     int(e.Code),
+		Reason:   e.Code.String(),
+		BannedAt: time.Now(),
+	}
+	if e.Expire > 0 {
+		expiresAt := ban.BannedAt.Add(e.Expire)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	c.mu.Lock()
+	c.ban = &ban
+	c.cancelReconnectLocked()
+	c.releaseConnection()
+	c.setStatusLocked(StatusError, "temporarily banned: "+ban.Reason)
+	if e.Expire > 0 {
+		c.reconnectAttempts = 0
+		c.reconnectTimer = time.AfterFunc(e.Expire, c.runReconnect)
+	}
+	c.unlock()
+
+	if ban.ExpiresAt != nil {
+		c.manager.logger.Printf("Client %s is temporarily banned until %s: %s", c.ID, ban.ExpiresAt.Format(time.RFC3339), ban.Reason)
+	} else {
+		c.manager.logger.Printf("Client %s is temporarily banned: %s", c.ID, ban.Reason)
+	}
+	c.manager.DispatchEvent(NewBannedEvent(c.ID, ban))
+}
+
+// clearBan forgets a ban once the client has logged in again
+func (c *Client) clearBan() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ban = nil
+}
+
+// restartAfterPairing reconnects after WhatsApp closed the stream with code
+// 515 to have a freshly paired device log in. Going through Connect keeps the
+// new connection tracked like any other.
+func (c *Client) restartAfterPairing() {
+	c.mu.Lock()
+	c.cancelReconnectLocked()
+	c.abortConnectLocked()
+	c.WhatsmeowClient.Disconnect()
+	c.releaseConnection()
+	c.setStatusLocked(StatusDisconnected, "restart required after pairing")
+	c.unlock()
+
+	c.manager.logger.Printf("Client %s restarting connection after pairing", c.ID)
+	if err := c.Connect(); err != nil {
+		c.manager.logger.Printf("Client %s failed to reconnect after pairing: %v", c.ID, err)
+		c.Reconnect()
+	}
+}
+
+// handleConnectFailure reports a connect failure whatsmeow has no dedicated
+// event for. whatsmeow does not reconnect after these.
+func (c *Client) handleConnectFailure(e *events.ConnectFailure) {
+	reason := fmt.Sprintf("connect failure %s", e.Reason)
+	if e.Message != "" {
+		reason += " (" + e.Message + ")"
+	}
+	c.manager.logger.Printf("Client %s %s", c.ID, reason)
+	c.setStatus(StatusError, reason)
+	c.manager.DispatchEvent(NewErrorEvent(c.ID, reason))
+}
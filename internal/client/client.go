@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"math"
 	"sync"
 	"time"
 
@@ -43,7 +42,7 @@ type Client struct {
 	manager         *ClientManager
 
 	reconnectAttempts int
-	lastReconnectTime time.Time
+	reconnectTimer    *time.Timer
 	lastActivityTime  time.Time
 
 	// Mutex for protecting client state
@@ -57,6 +56,37 @@ type Client struct {
 	passkeySkipHandoffUX bool
 	passkeyError         string
 	passkeyDone          bool
+
+	// Bounded history of status transitions
+	statusHistory []StatusTransition
+
+	// Per-client counters
+	metrics ClientMetrics
+
+	// Semaphore bounding concurrent send/upload operations
+	opSlots chan struct{}
+
+	// Outcome of the last pairing attempt
+	lastPairing *PairingResult
+
+	// Receives the account's push name while a pairing success event waits for it
+	pushNameWait chan string
+
+	// Temporary ban reported on the last connect, if any
+	ban *BanInfo
+
+	// Connect in progress, and the cancel function of the current connection's context
+	connecting      *connectAttempt
+	closeConnection context.CancelFunc
+
+	// Presence last set on the session
+	presence string
+
+	// Events queued under mu, dispatched by unlock once it is released
+	pendingEvents []Event
+
+	// Set for sandbox clients, which simulate their account instead of connecting
+	sandbox *SandboxOptions
 }
 
 // GetPasskeyState returns the current passkey pairing state
@@ -81,86 +111,30 @@ func (c *Client) GetPasskeyState() *PasskeyState {
 	return state
 }
 
-// Connect connects the client to WhatsApp
+// Connect connects the client to WhatsApp, giving up after the manager's
+// connect timeout
 func (c *Client) Connect() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.lastActivityTime = time.Now()
-
-	if c.WhatsmeowClient.IsConnected() {
-		return nil
-	}
-
-	c.Status = StatusConnecting
-	c.manager.DispatchEvent(NewStatusEvent(c.ID, c.Status))
-
-	err := c.WhatsmeowClient.Connect()
-	if err != nil {
-		c.Status = StatusError
-		c.manager.DispatchEvent(NewStatusEvent(c.ID, c.Status))
-		c.manager.logger.Printf("Error connecting client %s: %v", c.ID, err)
-		return err
-	}
-
-	c.Status = StatusConnected
-	c.manager.DispatchEvent(NewStatusEvent(c.ID, c.Status))
-	c.reconnectAttempts = 0
-
-	return nil
+	return c.ConnectContext(context.Background())
 }
 
 // Disconnect disconnects the client from WhatsApp
 func (c *Client) Disconnect() {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
 	c.lastActivityTime = time.Now()
 
+	c.cancelReconnectLocked()
+	if c.abortConnectLocked() {
+		c.setStatusLocked(StatusDisconnected, "connect aborted by disconnect")
+	}
 	if !c.WhatsmeowClient.IsConnected() {
 		return
 	}
 
 	c.WhatsmeowClient.Disconnect()
-	c.Status = StatusDisconnected
-	c.manager.DispatchEvent(NewStatusEvent(c.ID, c.Status))
-}
-
-// Reconnect attempts to reconnect the client with exponential backoff
-func (c *Client) Reconnect() {
-	c.mu.Lock()
-
-	c.lastActivityTime = time.Now()
-
-	backoffSeconds := math.Min(30, math.Pow(2, float64(c.reconnectAttempts)))
-	backoffDuration := time.Duration(backoffSeconds) * time.Second
-
-	timeSinceLastReconnect := time.Since(c.lastReconnectTime)
-	if timeSinceLastReconnect < backoffDuration {
-		waitTime := backoffDuration - timeSinceLastReconnect
-		c.mu.Unlock()
-
-		c.manager.logger.Printf("Waiting %v before reconnecting client %s (attempt %d)",
-			waitTime, c.ID, c.reconnectAttempts+1)
-		time.Sleep(waitTime)
-		c.mu.Lock()
-	}
-
-	c.lastReconnectTime = time.Now()
-	c.reconnectAttempts++
-	c.mu.Unlock()
-
-	c.manager.logger.Printf("Attempting to reconnect client %s (attempt %d)",
-		c.ID, c.reconnectAttempts)
-
-	err := c.Connect()
-	if err != nil {
-		c.manager.logger.Printf("Reconnection attempt %d for client %s failed: %v",
-			c.reconnectAttempts, c.ID, err)
-	} else {
-		c.manager.logger.Printf("Successfully reconnected client %s after %d attempts",
-			c.ID, c.reconnectAttempts)
-	}
+	c.releaseConnection()
+	c.setStatusLocked(StatusDisconnected, "disconnect requested")
 }
 
 // IsLoggedIn returns whether the client is logged in
@@ -170,9 +144,16 @@ func (c *Client) IsLoggedIn() bool {
 	return c.Status == StatusLoggedIn
 }
 
+// GetStatus returns the current client status
+func (c *Client) GetStatus() ClientStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Status
+}
+
 // IsConnected returns whether the client is connected
 func (c *Client) IsConnected() bool {
-	return c.WhatsmeowClient.IsConnected()
+	return c.IsSandbox() || c.WhatsmeowClient.IsConnected()
 }
 
 // NeedsQR returns whether the client needs a QR code for login
@@ -180,6 +161,35 @@ func (c *Client) NeedsQR() bool {
 	return c.WhatsmeowClient.Store.ID == nil
 }
 
+// IsPaired returns whether the device is paired with a WhatsApp account,
+// whether or not it is currently connected
+func (c *Client) IsPaired() bool {
+	return c.WhatsmeowClient.Store.ID != nil
+}
+
+// How long a pairing success event waits for the account's push name
+const pairedPushNameWait = 30 * time.Second
+
+// dispatchPaired publishes a pairing success event. WhatsApp only sends the
+// push name in the app state synced after the post-pairing reconnect, so the
+// event waits a little for the push name setting to arrive on wait and goes
+// out without it if it doesn't.
+func (c *Client) dispatchPaired(evt *PairedEvent, wait chan string) {
+	timer := time.NewTimer(pairedPushNameWait)
+	defer timer.Stop()
+	select {
+	case evt.PushName = <-wait:
+	case <-timer.C:
+	}
+
+	c.mu.Lock()
+	if c.pushNameWait == wait {
+		c.pushNameWait = nil
+	}
+	c.mu.Unlock()
+	c.manager.DispatchEvent(evt)
+}
+
 // handleWhatsmeowEvent handles events from the whatsmeow client
 func (c *Client) handleWhatsmeowEvent(evt interface{}) {
 	c.mu.Lock()
@@ -188,11 +198,22 @@ func (c *Client) handleWhatsmeowEvent(evt interface{}) {
 
 	switch e := evt.(type) {
 	case *events.Connected:
-		c.mu.Lock()
-		c.Status = StatusLoggedIn
-		c.mu.Unlock()
-		c.manager.DispatchEvent(NewStatusEvent(c.ID, c.Status))
-		c.manager.logger.Printf("Client %s connected and logged in", c.ID)
+		// Only a paired device is logged in once connected
+		if !c.IsPaired() {
+			c.setStatus(StatusConnected, "connected event without pairing")
+			c.manager.logger.Printf("Client %s connected but is not paired", c.ID)
+		} else {
+			c.setStatus(StatusLoggedIn, "connected event")
+			c.clearBan()
+			c.manager.logger.Printf("Client %s connected and logged in as %s", c.ID, c.JID())
+			if err := c.VerifyPhone(); err != nil {
+				c.manager.logger.Printf("Warning: %v", err)
+				c.manager.DispatchEvent(NewErrorEvent(c.ID, err.Error()))
+			}
+			if c.manager.isAvailableOnConnect(c.ID) {
+				go c.markAvailable()
+			}
+		}
 
 	case *events.LoggedOut:
 		lo := e
@@ -202,38 +223,53 @@ func (c *Client) handleWhatsmeowEvent(evt interface{}) {
 			c.manager.logger.Printf("Client %s logged out (stream error). Reason not provided in stream:error", c.ID)
 		}
 
-		c.mu.Lock()
-		c.Status = StatusLoggedOut
-		c.mu.Unlock()
+		reason := "logged out (stream error)"
+		if lo.OnConnect {
+			reason = "logged out on connect: " + lo.Reason.String()
+		}
+		c.setStatus(StatusLoggedOut, reason)
 
 		c.passkeyLock.Lock()
 		c.passkeyPending = false
 		c.passkeyLock.Unlock()
 
-		c.manager.DispatchEvent(NewStatusEvent(c.ID, c.Status))
-
 	case *events.Disconnected:
 		c.mu.Lock()
 		wasConnected := c.Status == StatusConnected || c.Status == StatusLoggedIn
-		c.Status = StatusDisconnected
-		c.mu.Unlock()
+		c.setStatusLocked(StatusDisconnected, "disconnected event")
+		c.unlock()
 
 		c.passkeyLock.Lock()
 		c.passkeyPending = false
 		c.passkeyLock.Unlock()
-
-		c.manager.DispatchEvent(NewStatusEvent(c.ID, c.Status))
 		c.manager.logger.Printf("Client %s disconnected", c.ID)
 
 		if wasConnected {
-			go c.Reconnect()
+			c.Reconnect()
 		}
 
+	case *events.ManualLoginReconnect:
+		// Stream error 515 after pairing; whatsmeow leaves the reconnect to us
+		c.manager.logger.Printf("Client %s got restart required after pairing", c.ID)
+		go c.restartAfterPairing()
+
+	case *events.TemporaryBan:
+		c.handleTemporaryBan(e)
+
+	case *events.ConnectFailure:
+		c.handleConnectFailure(e)
+
 	case *events.StreamError:
 		c.manager.logger.Printf("Client %s stream error: %v", c.ID, e)
 		c.manager.DispatchEvent(NewErrorEvent(c.ID, fmt.Sprintf("Stream error: %v", e)))
 
+	case *events.Message:
+		if !e.Info.IsFromMe {
+			c.metrics.messagesReceived.Add(1)
+		}
+
 	case *events.QR:
+		c.metrics.qrGenerated.Add(1)
 		c.manager.logger.Printf("Client %s received QR code", c.ID)
 		c.manager.DispatchEvent(NewQREvent(c.ID, e))
 
@@ -243,6 +279,34 @@ func (c *Client) handleWhatsmeowEvent(evt interface{}) {
 		c.passkeyDone = true
 		c.passkeyLock.Unlock()
 		c.manager.logger.Printf("Client %s pair success", c.ID)
+		paired := NewPairedEvent(c.ID, e)
+		c.recordPairing(PairingResult{Outcome: PairingSucceeded, JID: paired.JID, At: paired.PairedAt})
+		wait := make(chan string, 1)
+		c.mu.Lock()
+		c.pushNameWait = wait
+		c.mu.Unlock()
+		go c.dispatchPaired(paired, wait)
+
+	case *events.PushNameSetting:
+		c.mu.Lock()
+		if c.pushNameWait != nil {
+			select {
+			case c.pushNameWait <- e.Action.GetName():
+			default:
+			}
+		}
+		c.mu.Unlock()
+
+	case *events.PairError:
+		c.passkeyLock.Lock()
+		c.passkeyPending = false
+		c.passkeyError = e.Error.Error()
+		c.passkeyLock.Unlock()
+		failed := NewPairFailedEvent(c.ID, e)
+		c.recordPairing(PairingResult{Outcome: PairingFailed, JID: failed.JID, Error: failed.Error, At: failed.FailedAt})
+		c.setStatus(StatusError, "pairing failed: "+failed.Error)
+		c.manager.logger.Printf("Client %s pairing with %s failed: %s", c.ID, failed.JID, failed.Error)
+		c.manager.DispatchEvent(failed)
 
 	case *events.PairPasskeyRequest:
 		pubJSON, err := json.Marshal(e.PublicKey)
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Default time a connect attempt may take before it is aborted
+const defaultConnectTimeout = 30 * time.Second
+
+// SetConnectTimeout sets how long a connect attempt may take before it is
+// aborted. It applies to attempts started afterwards.
+func (m *ClientManager) SetConnectTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	m.connectTimeout.Store(int64(timeout))
+}
+
+// ConnectTimeout returns how long a connect attempt may take
+func (m *ClientManager) ConnectTimeout() time.Duration {
+	if timeout := time.Duration(m.connectTimeout.Load()); timeout > 0 {
+		return timeout
+	}
+	return defaultConnectTimeout
+}
+
+// connectAttempt is a connect in progress, shared by callers that ask to
+// connect while it runs
+type connectAttempt struct {
+	done    chan struct{}
+	err     error
+	cancel  context.CancelFunc
+	aborted bool // Set under c.mu when Disconnect aborted the attempt
+}
+
+// ConnectContext connects the client to WhatsApp, giving up when ctx is done
+// or the manager's connect timeout passes. The client mutex is not held during
+// the network call, so Disconnect and status reads are never blocked by a
+// slow connect, and Disconnect aborts a connect in progress. Callers that ask
+// to connect while an attempt runs wait for its outcome.
+func (c *Client) ConnectContext(ctx context.Context) error {
+	c.mu.Lock()
+	c.lastActivityTime = time.Now()
+	if c.sandbox != nil && c.Status != StatusLoggedIn {
+		c.setStatusLocked(StatusLoggedIn, "sandbox session")
+	}
+	if c.sandbox != nil || c.WhatsmeowClient.IsConnected() {
+		c.unlock()
+		return nil
+	}
+	if attempt := c.connecting; attempt != nil {
+		c.mu.Unlock()
+		select {
+		case <-attempt.done:
+			return attempt.err
+		case <-ctx.Done():
+			return fmt.Errorf("connect cancelled: %w", ctx.Err())
+		}
+	}
+
+	// whatsmeow keeps the connection alive for as long as the context it was
+	// connected with, so the attempt gets its own context that is only
+	// cancelled to abort it or once the connection is closed
+	connCtx, cancel := context.WithCancel(c.WhatsmeowClient.BackgroundEventCtx)
+	attempt := &connectAttempt{done: make(chan struct{}), cancel: cancel}
+	c.connecting = attempt
+	c.setStatusLocked(StatusConnecting, "connect requested")
+	c.unlock()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- c.WhatsmeowClient.ConnectContext(connCtx)
+	}()
+
+	timeout := c.manager.ConnectTimeout()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case err = <-result:
+		// whatsmeow's own reconnect loop got there first
+		if errors.Is(err, ErrAlreadyConnected) {
+			err = nil
+		}
+	case <-ctx.Done():
+		err = fmt.Errorf("connect cancelled: %w", ctx.Err())
+	case <-timer.C:
+		err = fmt.Errorf("%w after %v", ErrConnectTimeout, timeout)
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+	defer close(attempt.done)
+	c.connecting = nil
+
+	switch {
+	case attempt.aborted:
+		cancel()
+		attempt.err = errors.New("connect aborted by disconnect")
+	case err != nil:
+		cancel()
+		attempt.err = err
+		c.setStatusLocked(StatusError, fmt.Sprintf("connect failed: %v", err))
+		c.manager.logger.Printf("Error connecting client %s: %v", c.ID, err)
+	default:
+		c.releaseConnection()
+		c.closeConnection = cancel
+		c.setStatusLocked(StatusConnected, "websocket connected")
+		c.reconnectAttempts = 0
+	}
+	return attempt.err
+}
+
+// abortConnectLocked aborts a connect in progress, reporting whether there
+// was one. The caller must hold c.mu.
+func (c *Client) abortConnectLocked() bool {
+	if c.connecting == nil {
+		return false
+	}
+	c.connecting.aborted = true
+	c.connecting.cancel()
+	return true
+}
+
+// releaseConnection cancels the context of the previous connection once it
+// is closed. The caller must hold c.mu.
+func (c *Client) releaseConnection() {
+	if c.closeConnection != nil {
+		c.closeConnection()
+		c.closeConnection = nil
+	}
+}
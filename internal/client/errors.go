@@ -0,0 +1,29 @@
+package client
+
+import (
+	"errors"
+
+	"go.mau.fi/whatsmeow"
+)
+
+var (
+	// ErrConnectTimeout is returned when a connect attempt does not finish in time
+	ErrConnectTimeout = errors.New("connect timed out")
+
+	// ErrAlreadyConnected is returned by whatsmeow when connecting a client whose websocket is open
+	ErrAlreadyConnected = whatsmeow.ErrAlreadyConnected
+	// ErrNotConnected is returned by whatsmeow when sending on a client without an open websocket
+	ErrNotConnected = whatsmeow.ErrNotConnected
+
+	// ErrSandbox is returned when a sandbox session is asked for something it does not simulate
+	ErrSandbox = errors.New("sandbox session has no WhatsApp connection")
+	// ErrNotSandbox is returned when simulating events on a session that is not a sandbox
+	ErrNotSandbox = errors.New("session is not a sandbox")
+)
+
+// IsDisconnected reports whether a whatsmeow request failed because the
+// websocket was not connected or dropped before the response arrived
+func IsDisconnected(err error) bool {
+	var disconnected *whatsmeow.DisconnectedError
+	return errors.As(err, &disconnected) || errors.Is(err, whatsmeow.ErrNotConnected)
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"time"
+
 	"go.mau.fi/whatsmeow/types/events"
 )
 
@@ -9,6 +11,7 @@ type Event interface {
 	GetType() string
 	GetClientID() string
 	GetData() interface{}
+	GetReceivedAt() time.Time
 }
 
 // BaseEvent is the base implementation of Event
@@ -16,6 +19,9 @@ type BaseEvent struct {
 	Type     string
 	ClientID string
 	Data     interface{}
+
+	// When the event was dispatched, before it waited for an observer worker
+	ReceivedAt time.Time
 }
 
 // GetType returns the event type
@@ -33,12 +39,31 @@ func (e *BaseEvent) GetData() interface{} {
 	return e.Data
 }
 
+// GetReceivedAt returns when the event was dispatched, or the zero time for
+// events that were not
+func (e *BaseEvent) GetReceivedAt() time.Time {
+	return e.ReceivedAt
+}
+
+// setReceivedAt stamps the event when it is dispatched
+func (e *BaseEvent) setReceivedAt(at time.Time) {
+	if e.ReceivedAt.IsZero() {
+		e.ReceivedAt = at
+	}
+}
+
 // Event types
 const (
 	EventTypeStatus = "status"
 	EventTypeQR     = "qr"
 	EventTypeError  = "error"
 	EventTypeRaw    = "raw"
+
+	EventTypeAppState     = "appstate"
+	EventTypePaired       = "paired"
+	EventTypePairFailed   = "pair_failed"
+	EventTypeBanned       = "banned"
+	EventTypeConversation = "conversation"
 )
 
 // StatusEvent represents a client status change event
@@ -110,3 +135,149 @@ func NewRawEvent(clientID string, rawEvent interface{}) *RawEvent {
 		},
 	}
 }
+
+// PairedEvent reports that a device finished pairing with a WhatsApp account
+type PairedEvent struct {
+	BaseEvent
+	JID          string
+	LID          string
+	Phone        string
+	PushName     string
+	BusinessName string
+	Platform     string
+	PairedAt     time.Time
+}
+
+// NewPairedEvent creates a new pairing success event
+func NewPairedEvent(clientID string, pair *events.PairSuccess) *PairedEvent {
+	evt := &PairedEvent{
+		BaseEvent: BaseEvent{
+			Type:     EventTypePaired,
+			ClientID: clientID,
+		},
+		JID:          pair.ID.ToNonAD().String(),
+		Phone:        pair.ID.User,
+		BusinessName: pair.BusinessName,
+		Platform:     pair.Platform,
+		PairedAt:     time.Now(),
+	}
+	if !pair.LID.IsEmpty() {
+		evt.LID = pair.LID.ToNonAD().String()
+	}
+	evt.Data = evt
+	return evt
+}
+
+// PairFailedEvent reports that the phone accepted a pairing but finishing it
+// on this side failed, so the session is still not paired
+type PairFailedEvent struct {
+	BaseEvent
+	JID          string
+	LID          string
+	BusinessName string
+	Platform     string
+	Error        string
+	FailedAt     time.Time
+}
+
+// NewPairFailedEvent creates a new pairing failure event
+func NewPairFailedEvent(clientID string, pair *events.PairError) *PairFailedEvent {
+	evt := &PairFailedEvent{
+		BaseEvent: BaseEvent{
+			Type:     EventTypePairFailed,
+			ClientID: clientID,
+		},
+		JID:          pair.ID.ToNonAD().String(),
+		BusinessName: pair.BusinessName,
+		Platform:     pair.Platform,
+		Error:        pair.Error.Error(),
+		FailedAt:     time.Now(),
+	}
+	if !pair.LID.IsEmpty() {
+		evt.LID = pair.LID.ToNonAD().String()
+	}
+	evt.Data = evt
+	return evt
+}
+
+// BannedEvent reports that WhatsApp temporarily banned the account of a client
+type BannedEvent struct {
+	BaseEvent
+	Ban BanInfo
+}
+
+// NewBannedEvent creates a new temporary ban event
+func NewBannedEvent(clientID string, ban BanInfo) *BannedEvent {
+	evt := &BannedEvent{
+		BaseEvent: BaseEvent{
+			Type:     EventTypeBanned,
+			ClientID: clientID,
+		},
+		Ban: ban,
+	}
+	evt.Data = evt
+	return evt
+}
+
+// App state sync progress states
+const (
+	AppStateSyncStarted   = "started"
+	AppStateSyncCompleted = "completed"
+	AppStateSyncFailed    = "failed"
+)
+
+// AppStateEvent reports the progress of an app state sync requested through the API
+type AppStateEvent struct {
+	BaseEvent
+	Patch    string
+	State    string // started, completed or failed
+	FullSync bool
+	Version  uint64 // Patch version after a completed sync
+	Error    string
+}
+
+// NewAppStateEvent creates a new app state sync event
+func NewAppStateEvent(clientID, patch, state string, fullSync bool, version uint64, errorMsg string) *AppStateEvent {
+	evt := &AppStateEvent{
+		BaseEvent: BaseEvent{
+			Type:     EventTypeAppState,
+			ClientID: clientID,
+		},
+		Patch:    patch,
+		State:    state,
+		FullSync: fullSync,
+		Version:  version,
+		Error:    errorMsg,
+	}
+	evt.Data = evt
+	return evt
+}
+
+// ConversationEvent reports a change of a chat's handoff state between bot and agents
+type ConversationEvent struct {
+	BaseEvent
+	Chat          string
+	State         string
+	PreviousState string
+	Agent         string // Set while assigned
+	PreviousAgent string
+	ChangedAt     time.Time
+}
+
+// NewConversationEvent creates a new conversation handoff event
+func NewConversationEvent(clientID, chat, state, previousState, agent, previousAgent string, changedAt time.Time) *ConversationEvent {
+	evt := &ConversationEvent{
+		BaseEvent: BaseEvent{
+			Type:     EventTypeConversation,
+			ClientID: clientID,
+		},
+		Chat:          chat,
+		State:         state,
+		PreviousState: previousState,
+		Agent:         agent,
+		PreviousAgent: previousAgent,
+		ChangedAt:     changedAt,
+	}
+	evt.Data = evt
+	return evt
+}
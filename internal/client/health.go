@@ -0,0 +1,40 @@
+package client
+
+import "time"
+
+// StatusSince returns when the client entered its current status
+func (c *Client) StatusSince() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.statusHistory) == 0 {
+		return c.lastActivityTime
+	}
+	return c.statusHistory[len(c.statusHistory)-1].Timestamp
+}
+
+// LastActivity returns when the client last saw a WhatsApp event or API action
+func (c *Client) LastActivity() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastActivityTime
+}
+
+// ForceReconnect drops the connection, whatever state it is in, and connects
+// again. It is used to recover sessions that are stuck. Sandbox clients have
+// no connection, so it leaves them as they are.
+func (c *Client) ForceReconnect(reason string) error {
+	c.mu.Lock()
+	if c.sandbox != nil {
+		c.mu.Unlock()
+		return nil
+	}
+	c.cancelReconnectLocked()
+	c.abortConnectLocked()
+	c.WhatsmeowClient.Disconnect()
+	c.releaseConnection()
+	c.setStatusLocked(StatusDisconnected, reason)
+	c.unlock()
+
+	c.metrics.remediations.Add(1)
+	return c.Connect()
+}
@@ -0,0 +1,65 @@
+package client
+
+import "time"
+
+// maxStatusHistory is the number of status transitions kept per client
+const maxStatusHistory = 100
+
+// StatusTransition represents a single change of client status
+type StatusTransition struct {
+	Timestamp time.Time `json:"timestamp"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Reason    string    `json:"reason"`
+}
+
+// setStatusLocked changes the client status, records the transition and queues
+// a status event, which is dispatched once the caller releases c.mu with
+// unlock. The caller must hold c.mu.
+func (c *Client) setStatusLocked(status ClientStatus, reason string) {
+	transition := StatusTransition{
+		Timestamp: time.Now(),
+		From:      c.Status.String(),
+		To:        status.String(),
+		Reason:    reason,
+	}
+
+	c.Status = status
+
+	c.statusHistory = append(c.statusHistory, transition)
+	if len(c.statusHistory) > maxStatusHistory {
+		c.statusHistory = c.statusHistory[len(c.statusHistory)-maxStatusHistory:]
+	}
+
+	c.pendingEvents = append(c.pendingEvents, NewStatusEvent(c.ID, status))
+}
+
+// unlock releases c.mu and then dispatches the events queued while it was
+// held. Dispatching can wait on the manager's workers, so it must never
+// happen under c.mu.
+func (c *Client) unlock() {
+	pending := c.pendingEvents
+	c.pendingEvents = nil
+	c.mu.Unlock()
+
+	for _, event := range pending {
+		c.manager.DispatchEvent(event)
+	}
+}
+
+// setStatus is like setStatusLocked but acquires c.mu itself
+func (c *Client) setStatus(status ClientStatus, reason string) {
+	c.mu.Lock()
+	defer c.unlock()
+	c.setStatusLocked(status, reason)
+}
+
+// StatusHistory returns a copy of the recorded status transitions, oldest first
+func (c *Client) StatusHistory() []StatusTransition {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	history := make([]StatusTransition, len(c.statusHistory))
+	copy(history, c.statusHistory)
+	return history
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/neekaru/whatsappgo-bot/pkg/logger"
@@ -51,6 +52,21 @@ type ClientManager struct {
 	observersLock sync.RWMutex
 	logger        *logger.Logger
 	workerPool    chan func()
+
+	// Concurrent send/upload operations allowed per client
+	maxConcurrentOps int
+
+	// Time a connect attempt may take, in nanoseconds; 0 uses the default
+	connectTimeout atomic.Int64
+
+	// Consecutive reconnects a client makes before giving up; 0 is unlimited
+	maxReconnectAttempts atomic.Int64
+
+	// Decides whether a client marks itself available after each connect
+	availableOnConnect atomic.Pointer[func(clientID string) bool]
+
+	// Returns the phone number a client must be logged in as
+	expectedPhones atomic.Pointer[func(clientID string) string]
 }
 
 var (
@@ -67,7 +83,10 @@ func GetInstance() *ClientManager {
 			observers:  make(map[string][]Observer),
 			logger:     fallbackLogger,
 			workerPool: make(chan func(), 100), // Buffer size of 100 tasks
+
+			maxConcurrentOps: defaultMaxConcurrentOps,
 		}
+		instance.maxReconnectAttempts.Store(defaultMaxReconnectAttempts)
 		// Start worker pool
 		for i := 0; i < 5; i++ { // 5 workers
 			go instance.worker()
@@ -114,9 +133,15 @@ func (m *ClientManager) AddClient(id string, container *sqlstore.Container, what
 		Container:       container,
 		Status:          StatusDisconnected,
 		manager:         m,
+		opSlots:         make(chan struct{}, m.maxConcurrentOps),
 	}
 
-	// Set up event handler
+	// Set up event handler. The reconnect after pairing is handled there too,
+	// so it goes through Connect like every other connection. Reconnects after
+	// a lost connection are left to the client's own backoff, which honors
+	// the attempt limit, instead of racing whatsmeow's reconnect loop.
+	whatsmeowClient.DisableLoginAutoReconnect = true
+	whatsmeowClient.EnableAutoReconnect = false
 	whatsmeowClient.AddEventHandler(client.handleWhatsmeowEvent)
 
 	m.clients[id] = client
@@ -124,16 +149,19 @@ func (m *ClientManager) AddClient(id string, container *sqlstore.Container, what
 	return client, nil
 }
 
-// RemoveClient removes a client
-func (m *ClientManager) RemoveClient(id string) error {
+// RemoveClient logs a client out and removes it, reporting whether the
+// logout went through. The client is removed even if it did not.
+func (m *ClientManager) RemoveClient(id string) (bool, error) {
 	m.clientsLock.Lock()
 	defer m.clientsLock.Unlock()
 
 	client, exists := m.clients[id]
 	if !exists {
-		return fmt.Errorf("client with ID %s not found", id)
+		return false, fmt.Errorf("client with ID %s not found", id)
 	}
 
+	client.CancelReconnect()
+
 	// Always try to disconnect first to ensure a clean state
 	if client.WhatsmeowClient.IsConnected() {
 		m.logger.Printf("Disconnecting client %s", id)
@@ -145,6 +173,7 @@ func (m *ClientManager) RemoveClient(id string) error {
 	err := client.Connect()
 
 	// If connected successfully, try to logout
+	loggedOut := false
 	if err == nil && client.WhatsmeowClient.IsConnected() {
 		m.logger.Printf("Successfully connected client %s, attempting logout", id)
 
@@ -154,6 +183,7 @@ func (m *ClientManager) RemoveClient(id string) error {
 			m.logger.Printf("Logout error for client %s (this is usually not critical): %v", id, logoutErr)
 		} else {
 			m.logger.Printf("Successfully logged out client %s", id)
+			loggedOut = true
 		}
 
 		// Always disconnect after logout attempt
@@ -170,6 +200,28 @@ func (m *ClientManager) RemoveClient(id string) error {
 
 	delete(m.clients, id)
 	m.logger.Printf("Removed client with ID %s", id)
+	return loggedOut, nil
+}
+
+// ReleaseClient disconnects a client and frees its resources without logging out,
+// so the stored credentials can be used to restore it later
+func (m *ClientManager) ReleaseClient(id string) error {
+	m.clientsLock.Lock()
+	defer m.clientsLock.Unlock()
+
+	client, exists := m.clients[id]
+	if !exists {
+		return fmt.Errorf("client with ID %s not found", id)
+	}
+
+	client.Disconnect()
+
+	if client.Container != nil {
+		client.Container.Close()
+	}
+
+	delete(m.clients, id)
+	m.logger.Printf("Released client with ID %s (credentials kept)", id)
 	return nil
 }
 
@@ -214,10 +266,13 @@ func (m *ClientManager) UnregisterObserver(eventType string, observer Observer)
 		return
 	}
 
-	// Find and remove the observer
+	// Find and remove the observer. The slice is copied rather than shifted in
+	// place, because DispatchEvent may still be iterating over the old one.
 	for i, obs := range observers {
 		if obs == observer {
-			m.observers[eventType] = append(observers[:i], observers[i+1:]...)
+			remaining := make([]Observer, 0, len(observers)-1)
+			remaining = append(remaining, observers[:i]...)
+			m.observers[eventType] = append(remaining, observers[i+1:]...)
 			m.logger.Printf("Unregistered observer for event type %s", eventType)
 			break
 		}
@@ -226,6 +281,10 @@ func (m *ClientManager) UnregisterObserver(eventType string, observer Observer)
 
 // DispatchEvent dispatches an event to all registered observers
 func (m *ClientManager) DispatchEvent(event Event) {
+	if stamped, ok := event.(interface{ setReceivedAt(time.Time) }); ok {
+		stamped.setReceivedAt(time.Now())
+	}
+
 	m.observersLock.RLock()
 	observers, exists := m.observers[event.GetType()]
 	m.observersLock.RUnlock()
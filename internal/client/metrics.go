@@ -0,0 +1,47 @@
+package client
+
+import "sync/atomic"
+
+// ClientMetrics holds per-client counters
+type ClientMetrics struct {
+	messagesSent     atomic.Uint64
+	messagesReceived atomic.Uint64
+	bytesUploaded    atomic.Uint64
+	reconnects       atomic.Uint64
+	qrGenerated      atomic.Uint64
+	remediations     atomic.Uint64
+}
+
+// MetricsSnapshot is a point-in-time copy of a client's counters
+type MetricsSnapshot struct {
+	MessagesSent     uint64 `json:"messages_sent"`
+	MessagesReceived uint64 `json:"messages_received"`
+	BytesUploaded    uint64 `json:"bytes_uploaded"`
+	Reconnects       uint64 `json:"reconnects"`
+	QRGenerated      uint64 `json:"qr_generated"`
+	Remediations     uint64 `json:"remediations"`
+}
+
+// RecordMessageSent increments the sent message counter
+func (c *Client) RecordMessageSent() {
+	c.metrics.messagesSent.Add(1)
+}
+
+// RecordBytesUploaded adds n to the uploaded bytes counter
+func (c *Client) RecordBytesUploaded(n int) {
+	if n > 0 {
+		c.metrics.bytesUploaded.Add(uint64(n))
+	}
+}
+
+// Metrics returns a snapshot of the client's counters
+func (c *Client) Metrics() MetricsSnapshot {
+	return MetricsSnapshot{
+		MessagesSent:     c.metrics.messagesSent.Load(),
+		MessagesReceived: c.metrics.messagesReceived.Load(),
+		BytesUploaded:    c.metrics.bytesUploaded.Load(),
+		Reconnects:       c.metrics.reconnects.Load(),
+		QRGenerated:      c.metrics.qrGenerated.Load(),
+		Remediations:     c.metrics.remediations.Load(),
+	}
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// Default number of concurrent send/upload operations per client
+const defaultMaxConcurrentOps = 2
+
+// SetMaxConcurrentOps sets how many send/upload operations each client may run
+// at once. It applies to clients added afterwards.
+func (m *ClientManager) SetMaxConcurrentOps(n int) {
+	if n <= 0 {
+		return
+	}
+	m.clientsLock.Lock()
+	defer m.clientsLock.Unlock()
+	m.maxConcurrentOps = n
+}
+
+// AcquireOp waits for a free operation slot on the client's websocket. The
+// returned function releases the slot and must be called once the operation
+// has finished.
+func (c *Client) AcquireOp(ctx context.Context) (func(), error) {
+	select {
+	case c.opSlots <- struct{}{}:
+		return func() { <-c.opSlots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting for a free operation slot: %w", ctx.Err())
+	}
+}
+
+// OpsInFlight returns the number of send/upload operations currently running
+func (c *Client) OpsInFlight() int {
+	return len(c.opSlots)
+}
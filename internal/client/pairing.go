@@ -0,0 +1,36 @@
+package client
+
+import "time"
+
+// Pairing outcomes
+const (
+	PairingSucceeded = "paired"
+	PairingFailed    = "failed"
+)
+
+// PairingResult describes how the last pairing attempt of a client ended
+type PairingResult struct {
+	Outcome string    `json:"outcome"` // paired or failed
+	JID     string    `json:"jid"`
+	Error   string    `json:"error,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// LastPairing returns the outcome of the client's last pairing attempt since
+// it was loaded, or nil if it has not paired in that time
+func (c *Client) LastPairing() *PairingResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lastPairing == nil {
+		return nil
+	}
+	result := *c.lastPairing
+	return &result
+}
+
+// recordPairing stores the outcome of a pairing attempt
+func (c *Client) recordPairing(result PairingResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastPairing = &result
+}
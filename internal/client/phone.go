@@ -0,0 +1,67 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PhoneMismatchError indicates the logged-in account does not match the expected phone number
+type PhoneMismatchError struct {
+	ClientID string
+	Expected string
+	Actual   string
+}
+
+func (e *PhoneMismatchError) Error() string {
+	return fmt.Sprintf("session %s is logged in as %s, expected %s", e.ClientID, e.Actual, e.Expected)
+}
+
+// normalizePhone strips formatting so phone numbers can be compared
+func normalizePhone(phone string) string {
+	return strings.TrimPrefix(strings.TrimSpace(phone), "+")
+}
+
+// JID returns the account's own JID, or an empty string if the device is not paired
+func (c *Client) JID() string {
+	id := c.WhatsmeowClient.Store.ID
+	if id == nil {
+		return ""
+	}
+	return id.ToNonAD().String()
+}
+
+// Phone returns the account's own phone number, or an empty string if the device is not paired
+func (c *Client) Phone() string {
+	id := c.WhatsmeowClient.Store.ID
+	if id == nil {
+		return ""
+	}
+	return id.User
+}
+
+// SetExpectedPhones sets the function returning the phone number each client
+// must be logged in as; sends are rejected when the logged-in account does not
+// match. An empty number, or a nil function, leaves a client unbound.
+func (m *ClientManager) SetExpectedPhones(fn func(clientID string) string) {
+	m.expectedPhones.Store(&fn)
+}
+
+// ExpectedPhone returns the phone number the session is bound to, if any
+func (c *Client) ExpectedPhone() string {
+	fn := c.manager.expectedPhones.Load()
+	if fn == nil || *fn == nil {
+		return ""
+	}
+	return normalizePhone((*fn)(c.ID))
+}
+
+// VerifyPhone returns a PhoneMismatchError if the session is bound to a phone number
+// and the logged-in account is a different one
+func (c *Client) VerifyPhone() error {
+	expected := c.ExpectedPhone()
+	actual := c.Phone()
+	if expected == "" || actual == "" || expected == actual {
+		return nil
+	}
+	return &PhoneMismatchError{ClientID: c.ID, Expected: expected, Actual: actual}
+}
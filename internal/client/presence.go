@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Presence values a session can be set to
+const (
+	PresenceAvailable   = "available"   // Shown online
+	PresenceUnavailable = "unavailable" // Shown offline, with a last seen time
+)
+
+// How long sending presence after a reconnect may take
+const presenceTimeout = 10 * time.Second
+
+// SetAvailableOnConnect sets the function deciding whether a client marks
+// itself available each time it (re)connects. nil turns it off for every client.
+func (m *ClientManager) SetAvailableOnConnect(fn func(clientID string) bool) {
+	m.availableOnConnect.Store(&fn)
+}
+
+// isAvailableOnConnect reports whether the client should mark itself
+// available once connected
+func (m *ClientManager) isAvailableOnConnect(clientID string) bool {
+	fn := m.availableOnConnect.Load()
+	return fn != nil && *fn != nil && (*fn)(clientID)
+}
+
+// SetPresence shows the session online or offline to its contacts. The
+// presence is remembered, so sends leave a session set available online.
+func (c *Client) SetPresence(ctx context.Context, presence string) error {
+	var state types.Presence
+	switch presence {
+	case PresenceAvailable:
+		state = types.PresenceAvailable
+	case PresenceUnavailable:
+		state = types.PresenceUnavailable
+	default:
+		return fmt.Errorf("invalid presence %q, must be one of available, unavailable", presence)
+	}
+
+	if err := c.WhatsmeowClient.SendPresence(ctx, state); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.presence = presence
+	c.mu.Unlock()
+	return nil
+}
+
+// Presence returns the presence last set on the session, or an empty string
+// if none was set since it was loaded
+func (c *Client) Presence() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.presence
+}
+
+// StaysOnline reports whether the session was set available, so presence
+// should not be dropped to unavailable after sending
+func (c *Client) StaysOnline() bool {
+	return c.Presence() == PresenceAvailable
+}
+
+// markAvailable sets the session available after it connected
+func (c *Client) markAvailable() {
+	ctx, cancel := context.WithTimeout(context.Background(), presenceTimeout)
+	defer cancel()
+	if err := c.SetPresence(ctx, PresenceAvailable); err != nil {
+		c.manager.logger.Printf("Client %s failed to set presence available: %v", c.ID, err)
+	}
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Default number of consecutive failed reconnects before giving up
+const defaultMaxReconnectAttempts = 10
+
+// Longest delay between reconnect attempts
+const maxReconnectBackoff = 30 * time.Second
+
+// SetMaxReconnectAttempts sets how many consecutive reconnects a client makes
+// after losing its connection before giving up. 0 retries forever.
+func (m *ClientManager) SetMaxReconnectAttempts(n int) {
+	if n < 0 {
+		return
+	}
+	m.maxReconnectAttempts.Store(int64(n))
+}
+
+// MaxReconnectAttempts returns how many consecutive reconnects a client makes
+// before giving up, 0 meaning forever
+func (m *ClientManager) MaxReconnectAttempts() int {
+	return int(m.maxReconnectAttempts.Load())
+}
+
+// reconnectBackoff returns the delay before a reconnect attempt: 1s, 2s, 4s
+// and so on, capped at maxReconnectBackoff
+func reconnectBackoff(attempt int) time.Duration {
+	seconds := math.Pow(2, float64(attempt))
+	if seconds >= maxReconnectBackoff.Seconds() {
+		return maxReconnectBackoff
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Reconnect schedules a reconnect with exponential backoff and returns
+// immediately. A client has at most one reconnect pending, so repeated
+// disconnects while one is waiting do not pile up attempts.
+func (c *Client) Reconnect() {
+	c.mu.Lock()
+	defer c.unlock()
+	c.scheduleReconnectLocked()
+}
+
+// CancelReconnect stops a pending reconnect, reporting whether there was one
+func (c *Client) CancelReconnect() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cancelReconnectLocked()
+}
+
+// scheduleReconnectLocked arms the reconnect timer unless one is already
+// pending or the attempts are used up. The caller must hold c.mu and release
+// it with unlock.
+func (c *Client) scheduleReconnectLocked() {
+	if c.reconnectTimer != nil || c.sandbox != nil {
+		return
+	}
+
+	max := c.manager.MaxReconnectAttempts()
+	if max > 0 && c.reconnectAttempts >= max {
+		reason := fmt.Sprintf("reconnect gave up after %d attempts", c.reconnectAttempts)
+		c.setStatusLocked(StatusError, reason)
+		c.manager.logger.Printf("Client %s %s", c.ID, reason)
+		c.pendingEvents = append(c.pendingEvents, NewErrorEvent(c.ID, reason))
+		return
+	}
+
+	delay := reconnectBackoff(c.reconnectAttempts)
+	c.manager.logger.Printf("Reconnecting client %s in %v (attempt %d)",
+		c.ID, delay, c.reconnectAttempts+1)
+	c.reconnectTimer = time.AfterFunc(delay, c.runReconnect)
+}
+
+// cancelReconnectLocked stops a pending reconnect. The caller must hold c.mu.
+func (c *Client) cancelReconnectLocked() bool {
+	if c.reconnectTimer == nil {
+		return false
+	}
+	c.reconnectTimer.Stop()
+	c.reconnectTimer = nil
+	return true
+}
+
+// runReconnect makes a scheduled reconnect attempt, and schedules the next
+// one if it fails
+func (c *Client) runReconnect() {
+	c.mu.Lock()
+	c.reconnectTimer = nil
+	if c.WhatsmeowClient.IsConnected() || c.Status == StatusLoggedOut {
+		c.mu.Unlock()
+		return
+	}
+	c.reconnectAttempts++
+	attempt := c.reconnectAttempts
+	c.mu.Unlock()
+
+	c.metrics.reconnects.Add(1)
+	c.manager.logger.Printf("Attempting to reconnect client %s (attempt %d)", c.ID, attempt)
+
+	if err := c.Connect(); err != nil {
+		c.manager.logger.Printf("Reconnection attempt %d for client %s failed: %v", attempt, c.ID, err)
+		c.mu.Lock()
+		// A disconnect requested meanwhile means the client should stay down
+		if c.Status != StatusDisconnected {
+			c.scheduleReconnectLocked()
+		}
+		c.unlock()
+		return
+	}
+	c.manager.logger.Printf("Successfully reconnected client %s after %d attempts", c.ID, attempt)
+}
@@ -0,0 +1,223 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// Default delays of the receipts a sandbox session's recipients send
+const (
+	defaultSandboxDeliveredAfter = time.Second
+	defaultSandboxReadAfter      = 2 * time.Second
+)
+
+// SandboxOptions set how the recipients of a sandbox session respond to the
+// messages it sends
+type SandboxOptions struct {
+	DeliveredAfter time.Duration // Until a sent message is reported delivered; 0 uses the default
+	ReadAfter      time.Duration // From delivered until read; 0 uses the default, negative never
+	Reply          string        // Text the recipient replies with once read; empty sends none
+}
+
+// AddSandboxClient adds a client that simulates a connected account instead
+// of connecting to WhatsApp. Its device must have an ID, which becomes the
+// account's phone number. Sends are accepted and answered with synthetic
+// receipts, and incoming messages are simulated with SimulateIncoming.
+func (m *ClientManager) AddSandboxClient(id string, container *sqlstore.Container, whatsmeowClient *whatsmeow.Client, opts SandboxOptions) (*Client, error) {
+	if whatsmeowClient.Store.ID == nil {
+		return nil, fmt.Errorf("sandbox device of client %s has no ID", id)
+	}
+	if opts.DeliveredAfter <= 0 {
+		opts.DeliveredAfter = defaultSandboxDeliveredAfter
+	}
+	if opts.ReadAfter == 0 {
+		opts.ReadAfter = defaultSandboxReadAfter
+	}
+
+	// Anything still reaching for the network, such as features the sandbox
+	// does not simulate, fails instead of connecting with the fake device
+	whatsmeowClient.SetProxy(func(*http.Request) (*url.URL, error) {
+		return nil, ErrSandbox
+	})
+
+	client, err := m.AddClient(id, container, whatsmeowClient)
+	if err != nil {
+		return nil, err
+	}
+	client.mu.Lock()
+	client.sandbox = &opts
+	client.setStatusLocked(StatusLoggedIn, "sandbox session")
+	client.unlock()
+	return client, nil
+}
+
+// IsSandbox reports whether the client simulates its account instead of
+// connecting to WhatsApp
+func (c *Client) IsSandbox() bool {
+	return c.SandboxOptions() != nil
+}
+
+// SandboxOptions returns the options of a sandbox client, or nil if it is a
+// real one
+func (c *Client) SandboxOptions() *SandboxOptions {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sandbox == nil {
+		return nil
+	}
+	opts := *c.sandbox
+	return &opts
+}
+
+// SandboxSend accepts a message as WhatsApp would. Recipients that are users
+// other than the account itself report it delivered and read, and reply,
+// after the client's delays. Messages to the account itself come back as
+// messages sent from another device, like in the chat with yourself.
+func (c *Client) SandboxSend(to types.JID, msg *waE2E.Message, extra whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	opts := c.SandboxOptions()
+	if opts == nil {
+		return whatsmeow.SendResponse{}, ErrNotSandbox
+	}
+
+	id := extra.ID
+	if id == "" {
+		id = c.WhatsmeowClient.GenerateMessageID()
+	}
+	own := c.WhatsmeowClient.Store.ID.ToNonAD()
+	isUser := to.Server == types.DefaultUserServer || to.Server == types.HiddenUserServer
+	switch {
+	case isUser && to.User == own.User:
+		go c.simulateOwnMessage(own, id, msg, *opts)
+	case isUser:
+		go c.simulateRecipient(to.ToNonAD(), id, *opts)
+	}
+	c.mu.Lock()
+	c.lastActivityTime = time.Now()
+	c.mu.Unlock()
+	return whatsmeow.SendResponse{ID: id, Timestamp: time.Now(), Sender: own}, nil
+}
+
+// SandboxUpload accepts media as WhatsApp would, returning a made-up location
+// and the media's real hashes
+func (c *Client) SandboxUpload(media []byte) (whatsmeow.UploadResponse, error) {
+	if !c.IsSandbox() {
+		return whatsmeow.UploadResponse{}, ErrNotSandbox
+	}
+
+	mediaKey := make([]byte, 32)
+	if _, err := rand.Read(mediaKey); err != nil {
+		return whatsmeow.UploadResponse{}, err
+	}
+	fileSHA256 := sha256.Sum256(media)
+	fileEncSHA256 := sha256.Sum256(append(mediaKey, media...))
+	directPath := "/sandbox/" + hex.EncodeToString(fileSHA256[:])
+	return whatsmeow.UploadResponse{
+		URL:           "https://sandbox.invalid" + directPath,
+		DirectPath:    directPath,
+		MediaKey:      mediaKey,
+		FileEncSHA256: fileEncSHA256[:],
+		FileSHA256:    fileSHA256[:],
+		FileLength:    uint64(len(media)),
+	}, nil
+}
+
+// SimulateIncoming delivers a text message from a contact to a sandbox
+// client, returning the ID of the message
+func (c *Client) SimulateIncoming(from types.JID, text string) (types.MessageID, error) {
+	if !c.IsSandbox() {
+		return "", ErrNotSandbox
+	}
+
+	id := c.WhatsmeowClient.GenerateMessageID()
+	message := &waE2E.Message{Conversation: proto.String(text)}
+	c.simulateEvent(&events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: from, Sender: from},
+			ID:            id,
+			Type:          "text",
+			PushName:      from.User,
+			Timestamp:     time.Now(),
+		},
+		Message:    message,
+		RawMessage: message,
+	})
+	return id, nil
+}
+
+// simulateRecipient reports a sent message delivered and read, and sends the
+// canned reply, as long as the client is still loaded
+func (c *Client) simulateRecipient(to types.JID, id types.MessageID, opts SandboxOptions) {
+	time.Sleep(opts.DeliveredAfter)
+	if !c.loaded() {
+		return
+	}
+	c.simulateReceipt(to, id, types.ReceiptTypeDelivered)
+
+	if opts.ReadAfter < 0 {
+		return
+	}
+	time.Sleep(opts.ReadAfter)
+	if !c.loaded() {
+		return
+	}
+	c.simulateReceipt(to, id, types.ReceiptTypeRead)
+
+	if opts.Reply != "" {
+		if _, err := c.SimulateIncoming(to, opts.Reply); err != nil {
+			c.manager.logger.Printf("Sandbox client %s failed to reply: %v", c.ID, err)
+		}
+	}
+}
+
+// simulateOwnMessage delivers a message the account sent to itself back to
+// it, once it would have been delivered
+func (c *Client) simulateOwnMessage(own types.JID, id types.MessageID, msg *waE2E.Message, opts SandboxOptions) {
+	time.Sleep(opts.DeliveredAfter)
+	if !c.loaded() {
+		return
+	}
+	c.simulateEvent(&events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: own, Sender: own, IsFromMe: true},
+			ID:            id,
+			Type:          "text",
+			Timestamp:     time.Now(),
+		},
+		Message:    msg,
+		RawMessage: msg,
+	})
+}
+
+// simulateReceipt delivers a receipt from to for a sent message
+func (c *Client) simulateReceipt(to types.JID, id types.MessageID, receiptType types.ReceiptType) {
+	c.simulateEvent(&events.Receipt{
+		MessageSource: types.MessageSource{Chat: to, Sender: to},
+		MessageIDs:    []types.MessageID{id},
+		Timestamp:     time.Now(),
+		Type:          receiptType,
+	})
+}
+
+// simulateEvent passes a synthetic event through the same handlers as the
+// events whatsmeow receives
+func (c *Client) simulateEvent(evt any) {
+	c.WhatsmeowClient.DangerousInternals().DispatchEvent(evt)
+}
+
+// loaded reports whether the client is still the one the manager holds for its ID
+func (c *Client) loaded() bool {
+	current, ok := c.manager.GetClient(c.ID)
+	return ok && current == c
+}
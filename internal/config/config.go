@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -11,25 +13,289 @@ import (
 type Config struct {
 	ServerPort string
 	DataDir    string
+
+	// Store for session credentials: sqlite3 keeps one database per session in
+	// the data directory, postgres keeps them all in the database at DBDSN
+	DBDriver string
+	DBDSN    string
+
+	// Browser origins allowed to call the API and open event streams. Empty
+	// allows any origin over HTTP but only the server's own for WebSockets.
+	CORSOrigins []string
+
+	// Request rate limit for QR and send endpoints, per API key or client IP
+	RateLimitPerSecond float64
+	RateLimitBurst     int
+
+	// Optional webhook notified about operational alerts such as recovered panics
+	AlertWebhookURL string
+
+	// Session used by /health/deep to check WhatsApp reachability end to end
+	HealthCanaryUser string
+
+	// Session watchdog: check interval, how long a session may stay connecting
+	// or go without activity (0 disables), and forced reconnects before alerting
+	WatchdogInterval          time.Duration
+	WatchdogConnectingTimeout time.Duration
+	WatchdogStaleActivity     time.Duration
+	WatchdogMaxRemediations   int
+
+	// Concurrent send/upload operations allowed per session
+	MaxConcurrentOpsPerSession int
+
+	// Time a session's sends back off after WhatsApp first throttles it
+	ThrottleBackoff time.Duration
+
+	// Rolling window /stats/slo summarizes send-to-delivery latency over
+	DeliverySLOWindow time.Duration
+
+	// Time a connect to WhatsApp may take before it is aborted, and consecutive
+	// reconnects after a lost connection before giving up (0 retries forever)
+	ConnectTimeout       time.Duration
+	MaxReconnectAttempts int
+
+	// Optional webhook receiving incoming messages, and its request timeout
+	WebhookURL     string
+	WebhookTimeout time.Duration
+
+	// Circuit breaker for outbound webhook calls: consecutive failures before
+	// opening, and how long it stays open before a probe is let through
+	CircuitBreakerFailures int
+	CircuitBreakerCooldown time.Duration
+
+	// Optional MQTT broker receiving session events on wa/{user}/{event}
+	MQTTBrokerURL string
+	MQTTClientID  string
+	MQTTUsername  string
+	MQTTPassword  string
+	MQTTQoS       int
+
+	// Optional Google Cloud Pub/Sub project receiving session events on {prefix}{event} topics
+	PubSubProjectID   string
+	PubSubTopicPrefix string
+
+	// Events buffered per sink and event type, and what happens when the buffer is full
+	SinkBufferSize int
+	SinkPolicies   map[string]string
+
+	// Incoming message IDs remembered to skip redelivery after a restart
+	ReceivedDedupeLimit int
+
+	// Default level of whatsmeow's protocol logs: DEBUG, INFO, WARN or ERROR
+	WhatsmeowLogLevel string
+
+	// Size cap and number of rotated files of each session's raw event archive
+	DebugArchiveMaxBytes int64
+	DebugArchiveFiles    int
+
+	// Optional NATS server receiving session events on wa.{user}.{event}
+	NATSURL string
+
+	// Optional Kafka brokers receiving session events on one topic
+	KafkaBrokers []string
+	KafkaTopic   string
+
+	// Optional RabbitMQ queue consumed for send commands
+	AMQPURL      string
+	AMQPQueue    string
+	AMQPPrefetch int
+
+	// JSON file configuring the content filter applied to outgoing messages
+	ContentFilterFile string
+
+	// Mask phone numbers and message bodies in logs, with phone numbers hashed
+	// under the key (random per run if empty), and characters of sent message
+	// bodies kept in the outbox (0 keeps them whole)
+	PIIRedaction      bool
+	PIIRedactionKey   string
+	AuditBodyMaxChars int
+
+	// Master key (32 bytes, base64 or hex) encrypting message content at rest
+	MessageStoreKey string
+
+	// Backups of the data directory: minutes between scheduled backups (0
+	// backs up on request only), backups kept, and the target, an S3 bucket
+	// and key prefix or else a local directory. Empty targets disable backups.
+	BackupInterval  time.Duration
+	BackupRetention int
+	BackupDir       string
+	BackupS3Bucket  string
+	BackupS3Prefix  string
+
+	// Free space of the data directory's disk below which the raw event
+	// archive and backups pause, and seconds between checks
+	DiskMinFree       uint64
+	DiskCheckInterval time.Duration
+
+	// Feature flags of experimental route groups that are turned on
+	Features []string
 }
 
-// NewConfig creates a new configuration with default values
+// NewConfig creates a new configuration with default values, overridable from the environment
 func NewConfig() *Config {
 	return &Config{
-		ServerPort: "8080",
-		DataDir:    "data",
+		ServerPort:         "8080",
+		DataDir:            "data",
+		DBDriver:           envString("DB_DRIVER", "sqlite3"),
+		DBDSN:              getenv("DB_DSN"),
+		CORSOrigins:        envList("CORS_ORIGINS"),
+		RateLimitPerSecond: envFloat("RATE_LIMIT_PER_SECOND", 2),
+		RateLimitBurst:     envInt("RATE_LIMIT_BURST", 10),
+		AlertWebhookURL:    getenv("ALERT_WEBHOOK_URL"),
+		HealthCanaryUser:   getenv("HEALTH_CANARY_USER"),
+
+		WatchdogInterval:          time.Duration(envInt("WATCHDOG_INTERVAL_SECONDS", 30)) * time.Second,
+		WatchdogConnectingTimeout: time.Duration(envInt("WATCHDOG_CONNECTING_TIMEOUT_SECONDS", 120)) * time.Second,
+		WatchdogStaleActivity:     time.Duration(envIntAllowZero("WATCHDOG_STALE_ACTIVITY_MINUTES", 60)) * time.Minute,
+		WatchdogMaxRemediations:   envInt("WATCHDOG_MAX_REMEDIATIONS", 2),
+
+		MaxConcurrentOpsPerSession: envInt("MAX_CONCURRENT_OPS_PER_SESSION", 2),
+
+		ThrottleBackoff: time.Duration(envInt("THROTTLE_BACKOFF_SECONDS", 60)) * time.Second,
+
+		DeliverySLOWindow: time.Duration(envInt("DELIVERY_SLO_WINDOW_MINUTES", 60)) * time.Minute,
+
+		ConnectTimeout:       time.Duration(envInt("CONNECT_TIMEOUT_SECONDS", 30)) * time.Second,
+		MaxReconnectAttempts: envIntAllowZero("MAX_RECONNECT_ATTEMPTS", 10),
+
+		WebhookURL:     getenv("WEBHOOK_URL"),
+		WebhookTimeout: time.Duration(envInt("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
+
+		CircuitBreakerFailures: envInt("CIRCUIT_BREAKER_FAILURES", 5),
+		CircuitBreakerCooldown: time.Duration(envInt("CIRCUIT_BREAKER_COOLDOWN_SECONDS", 30)) * time.Second,
+
+		MQTTBrokerURL: getenv("MQTT_BROKER_URL"),
+		MQTTClientID:  envString("MQTT_CLIENT_ID", "whatsappgo-bot"),
+		MQTTUsername:  getenv("MQTT_USERNAME"),
+		MQTTPassword:  getenv("MQTT_PASSWORD"),
+		MQTTQoS:       envIntAllowZero("MQTT_QOS", 1),
+
+		PubSubProjectID:   getenv("PUBSUB_PROJECT_ID"),
+		PubSubTopicPrefix: envString("PUBSUB_TOPIC_PREFIX", "wa-"),
+
+		SinkBufferSize: envInt("SINK_BUFFER_SIZE", 1000),
+		SinkPolicies:   envMap("SINK_POLICIES"),
+
+		ReceivedDedupeLimit: envInt("RECEIVED_DEDUPE_LIMIT", 50000),
+
+		WhatsmeowLogLevel: envString("WHATSMEOW_LOG_LEVEL", "INFO"),
+
+		DebugArchiveMaxBytes: int64(envInt("DEBUG_ARCHIVE_MAX_MB", 10)) << 20,
+		DebugArchiveFiles:    envInt("DEBUG_ARCHIVE_FILES", 3),
+
+		NATSURL: getenv("NATS_URL"),
+
+		KafkaBrokers: envList("KAFKA_BROKERS"),
+		KafkaTopic:   envString("KAFKA_TOPIC", "wa-events"),
+
+		AMQPURL:      getenv("AMQP_URL"),
+		AMQPQueue:    envString("AMQP_QUEUE", "wa.commands"),
+		AMQPPrefetch: envInt("AMQP_PREFETCH", 4),
+
+		ContentFilterFile: envString("CONTENT_FILTER_FILE", "data/content_filter.json"),
+
+		PIIRedaction:      envBool("PII_REDACTION", false),
+		PIIRedactionKey:   getenv("PII_REDACTION_KEY"),
+		AuditBodyMaxChars: envIntAllowZero("AUDIT_BODY_MAX_CHARS", 0),
+
+		MessageStoreKey: getenv("MESSAGE_STORE_KEY"),
+
+		BackupInterval:  time.Duration(envIntAllowZero("BACKUP_INTERVAL_MINUTES", 0)) * time.Minute,
+		BackupRetention: envInt("BACKUP_RETENTION", 7),
+		BackupDir:       getenv("BACKUP_DIR"),
+		BackupS3Bucket:  getenv("BACKUP_S3_BUCKET"),
+		BackupS3Prefix:  envString("BACKUP_S3_PREFIX", "whatsapp-backups/"),
+
+		DiskMinFree:       uint64(envIntAllowZero("DISK_MIN_FREE_MB", 500)) << 20,
+		DiskCheckInterval: time.Duration(envInt("DISK_CHECK_INTERVAL_SECONDS", 60)) * time.Second,
+
+		Features: envList("FEATURES"),
 	}
 }
 
+// envFloat reads a positive float from the environment, falling back to def
+func envFloat(key string, def float64) float64 {
+	if v, err := strconv.ParseFloat(getenv(key), 64); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// envInt reads a positive integer from the environment, falling back to def
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// envIntAllowZero reads a non-negative integer from the environment, falling back to def
+func envIntAllowZero(key string, def int) int {
+	if v, err := strconv.Atoi(getenv(key)); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+// envBool reads a boolean from the environment, falling back to def when unset or invalid
+func envBool(key string, def bool) bool {
+	if v, err := strconv.ParseBool(getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// envString reads a string from the environment, falling back to def when unset
+func envString(key, def string) string {
+	if v := getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envList reads a comma-separated list from the environment, skipping empty items
+func envList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envMap reads comma-separated key=value pairs from the environment, skipping malformed items
+func envMap(key string) map[string]string {
+	items := make(map[string]string)
+	for _, item := range envList(key) {
+		k, v, ok := strings.Cut(item, "=")
+		if k, v = strings.TrimSpace(k), strings.TrimSpace(v); ok && k != "" && v != "" {
+			items[k] = v
+		}
+	}
+	return items
+}
+
 // EnsureDataDir ensures the data directory exists
 func (c *Config) EnsureDataDir() error {
 	return os.MkdirAll(c.DataDir, 0755)
 }
 
-// GetCorsConfig returns CORS configuration for the application
+// GetCorsConfig returns CORS configuration for the application, allowing the
+// origins in CORS_ORIGINS or any origin when none are set. Entries that are
+// not http(s) origins are skipped.
 func (c *Config) GetCorsConfig() cors.Config {
 	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowAllOrigins = true
+	for _, origin := range c.CORSOrigins {
+		if origin == "*" {
+			corsConfig.AllowOrigins = nil
+			break
+		}
+		if strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://") {
+			corsConfig.AllowOrigins = append(corsConfig.AllowOrigins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+	corsConfig.AllowAllOrigins = len(corsConfig.AllowOrigins) == 0
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
 	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"}
 	corsConfig.ExposeHeaders = []string{"Content-Length", "Content-Type"}
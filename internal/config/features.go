@@ -0,0 +1,48 @@
+package config
+
+// Feature flags reserved for experimental route groups, off unless listed in
+// FEATURES. No route group is behind them yet.
+const (
+	FeatureInteractive = "interactive" // Buttons, lists and other interactive messages
+	FeatureNewsletters = "newsletters" // WhatsApp Channels
+	FeatureCommunities = "communities" // Communities and their announcement groups
+)
+
+// knownFeatures lists every feature flag, in the order they are reported
+var knownFeatures = []string{FeatureInteractive, FeatureNewsletters, FeatureCommunities}
+
+// FeatureEnabled reports whether the named feature flag is on
+func (c *Config) FeatureEnabled(name string) bool {
+	return contains(c.Features, name)
+}
+
+// FeatureFlags returns the state of every known feature flag
+func (c *Config) FeatureFlags() map[string]bool {
+	flags := make(map[string]bool, len(knownFeatures))
+	for _, name := range knownFeatures {
+		flags[name] = c.FeatureEnabled(name)
+	}
+	return flags
+}
+
+// UnknownFeatures returns the names in FEATURES that are not feature flags,
+// such as typos, which enable nothing
+func (c *Config) UnknownFeatures() []string {
+	var unknown []string
+	for _, name := range c.Features {
+		if !contains(knownFeatures, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+// contains reports whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
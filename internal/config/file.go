@@ -0,0 +1,70 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// fileValues holds the settings read from the config file by Load. They take
+// precedence over the environment.
+var fileValues atomic.Pointer[map[string]string]
+
+// getenv reads a setting from the config file, falling back to the environment
+func getenv(key string) string {
+	if values := fileValues.Load(); values != nil {
+		if v, ok := (*values)[key]; ok {
+			return v
+		}
+	}
+	return os.Getenv(key)
+}
+
+// Load reads the config file at path over the environment and returns the
+// resulting configuration. An empty path reads the environment only. When
+// the file cannot be read the settings read before are kept.
+func Load(path string) (*Config, error) {
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		fileValues.Store(&values)
+	}
+	return NewConfig(), nil
+}
+
+// readFile parses a file of KEY=VALUE lines, the format of Docker env files.
+// Blank lines and lines starting with # are skipped; an export prefix and
+// quotes around values are dropped.
+func readFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("config file %s line %d: expected KEY=VALUE", path, n)
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	return values, nil
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers contains HTTP handlers for the configuration
+type Handlers struct {
+	reloader *Reloader
+}
+
+// NewHandlers creates a new configuration handlers instance
+func NewHandlers(reloader *Reloader) *Handlers {
+	return &Handlers{reloader: reloader}
+}
+
+// ReloadHandler handles POST /admin/config/reload - reloads the config file
+// and reports which settings changed
+func (h *Handlers) ReloadHandler(c *gin.Context) {
+	report, err := h.reloader.Reload()
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Config reload failed", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ReloadReport lists the settings a reload found changed, by Config field name
+type ReloadReport struct {
+	File            string    `json:"file,omitempty"`
+	Applied         []string  `json:"applied"`          // Changed and in effect
+	RestartRequired []string  `json:"restart_required"` // Changed, but only read at startup
+	Errors          []string  `json:"errors,omitempty"` // Changed, but rejected; the previous value stays
+	ReloadedAt      time.Time `json:"reloaded_at"`
+}
+
+// reloadHandler applies a group of settings that can change at runtime
+type reloadHandler struct {
+	fields []string
+	apply  func(*Config) error
+}
+
+// Reloader re-reads the config file and hands changed settings to the parts
+// of the service that can apply them without a restart. Settings nothing
+// handles are reported as requiring a restart and keep their running value.
+type Reloader struct {
+	mu       sync.Mutex
+	file     string
+	current  *Config // Settings in effect
+	handlers []reloadHandler
+}
+
+// NewReloader creates a reloader for the config file at path, which may be
+// empty to reload from the environment only, starting from the running config
+func NewReloader(path string, running *Config) *Reloader {
+	current := *running
+	return &Reloader{
+		file:    path,
+		current: &current,
+	}
+}
+
+// Handle registers apply to be called with the reloaded config when any of
+// the named Config fields change. It must be called before Reload.
+func (r *Reloader) Handle(apply func(*Config) error, fields ...string) {
+	for _, field := range fields {
+		if _, ok := reflect.TypeOf(Config{}).FieldByName(field); !ok {
+			panic(fmt.Sprintf("config has no field %s", field))
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, reloadHandler{fields: fields, apply: apply})
+}
+
+// Reload reads the config file again and applies the settings that changed
+func (r *Reloader) Reload() (*ReloadReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := Load(r.file)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReloadReport{
+		File:            r.file,
+		Applied:         []string{},
+		RestartRequired: []string{},
+		ReloadedAt:      time.Now(),
+	}
+	changed := changedFields(r.current, next)
+
+	// Settings stay at their running value unless a handler applies them
+	updated := *r.current
+	handled := make(map[string]bool)
+	for _, h := range r.handlers {
+		var fields []string
+		for _, field := range h.fields {
+			handled[field] = true
+			if changed[field] {
+				fields = append(fields, field)
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		if err := h.apply(next); err != nil {
+			for _, field := range fields {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", field, err))
+			}
+			continue
+		}
+		for _, field := range fields {
+			reflect.ValueOf(&updated).Elem().FieldByName(field).Set(reflect.ValueOf(next).Elem().FieldByName(field))
+			report.Applied = append(report.Applied, field)
+		}
+	}
+	for _, field := range fieldNames() {
+		if changed[field] && !handled[field] {
+			report.RestartRequired = append(report.RestartRequired, field)
+		}
+	}
+
+	r.current = &updated
+	return report, nil
+}
+
+// changedFields returns the names of the fields that differ between two configs
+func changedFields(old, next *Config) map[string]bool {
+	changed := make(map[string]bool)
+	oldValue, nextValue := reflect.ValueOf(old).Elem(), reflect.ValueOf(next).Elem()
+	for i, field := range fieldNames() {
+		if !reflect.DeepEqual(oldValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			changed[field] = true
+		}
+	}
+	return changed
+}
+
+// fieldNames returns the names of the Config fields in declaration order
+func fieldNames() []string {
+	t := reflect.TypeOf(Config{})
+	names := make([]string, t.NumField())
+	for i := range names {
+		names[i] = t.Field(i).Name
+	}
+	return names
+}
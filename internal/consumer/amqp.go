@@ -0,0 +1,317 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/contentfilter"
+	"github.com/neekaru/whatsappgo-bot/internal/media"
+	"github.com/neekaru/whatsappgo-bot/internal/messaging"
+	"github.com/neekaru/whatsappgo-bot/internal/session"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Delay before reconnecting after the broker connection is lost
+const amqpReconnectDelay = 5 * time.Second
+
+// Consumer tag used to cancel the subscription on shutdown
+const amqpConsumerTag = "whatsappgo-bot-commands"
+
+// AMQPConfig configures the AMQP command consumer
+type AMQPConfig struct {
+	URL      string
+	Queue    string
+	Prefetch int
+}
+
+// AMQPConsumer reads send commands from a RabbitMQ queue and executes them.
+// Up to Prefetch commands are processed concurrently. Commands are acked once
+// executed; failed sends are recorded in the outbox like HTTP sends, and
+// malformed commands are rejected without requeueing so a dead letter
+// exchange configured on the queue can pick them up.
+type AMQPConsumer struct {
+	app              *app.App
+	cfg              AMQPConfig
+	messagingService *messaging.Service
+	mediaService     *media.Service
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewAMQPConsumer creates a command consumer
+func NewAMQPConsumer(app *app.App, cfg AMQPConfig) *AMQPConsumer {
+	if cfg.Prefetch < 1 {
+		cfg.Prefetch = 1
+	}
+	return &AMQPConsumer{
+		app:              app,
+		cfg:              cfg,
+		messagingService: messaging.NewService(app),
+		mediaService:     media.NewService(app),
+		stop:             make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+}
+
+// Start consumes commands in the background once ready is closed, so commands
+// are not consumed before the sessions they are sent from are restored. It
+// reconnects when the broker connection drops.
+func (c *AMQPConsumer) Start(ready <-chan struct{}) {
+	go func() {
+		defer close(c.done)
+		select {
+		case <-c.stop:
+			return
+		case <-ready:
+		}
+
+		for {
+			err := c.consume()
+			select {
+			case <-c.stop:
+				return
+			default:
+			}
+			c.app.Logger.Printf("Warning: AMQP command consumer stopped: %v, reconnecting in %s", err, amqpReconnectDelay)
+			select {
+			case <-c.stop:
+				return
+			case <-time.After(amqpReconnectDelay):
+			}
+		}
+	}()
+}
+
+// Close stops consuming and waits for in-flight commands to finish
+func (c *AMQPConsumer) Close() {
+	close(c.stop)
+	<-c.done
+}
+
+// consume runs one broker connection until it is closed or the consumer is stopped
+func (c *AMQPConsumer) consume() error {
+	conn, err := amqp.Dial(c.cfg.URL)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open channel: %v", err)
+	}
+	if err := ch.Qos(c.cfg.Prefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch: %v", err)
+	}
+	if _, err := ch.QueueDeclare(c.cfg.Queue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare queue %s: %v", c.cfg.Queue, err)
+	}
+	deliveries, err := ch.Consume(c.cfg.Queue, amqpConsumerTag, false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume queue %s: %v", c.cfg.Queue, err)
+	}
+	c.app.Logger.Printf("Consuming send commands from AMQP queue %s", c.cfg.Queue)
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.cfg.Prefetch; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range deliveries {
+				c.handle(ch, delivery)
+			}
+		}()
+	}
+
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	select {
+	case <-c.stop:
+		// Stop receiving new deliveries and let workers drain the rest
+		_ = ch.Cancel(amqpConsumerTag, false)
+		wg.Wait()
+		return nil
+	case amqpErr := <-closed:
+		wg.Wait()
+		if amqpErr == nil {
+			return fmt.Errorf("connection closed")
+		}
+		return amqpErr
+	}
+}
+
+// handle executes one delivery, acknowledges it and publishes the result to
+// its reply queue
+func (c *AMQPConsumer) handle(ch *amqp.Channel, delivery amqp.Delivery) {
+	result, valid := c.execute(delivery.Body)
+
+	if valid {
+		_ = delivery.Ack(false)
+	} else {
+		c.app.Logger.Printf("Warning: rejected AMQP command %s: %s", delivery.MessageId, result.Error)
+		_ = delivery.Nack(false, false)
+	}
+
+	if delivery.ReplyTo == "" {
+		return
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = ch.PublishWithContext(ctx, "", delivery.ReplyTo, false, false, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: delivery.CorrelationId,
+		Body:          body,
+	})
+	if err != nil {
+		c.app.Logger.Printf("Failed to publish AMQP command result to %s: %v", delivery.ReplyTo, err)
+	}
+}
+
+// execute decodes and runs a command. It reports false for commands that are
+// malformed and can never succeed.
+func (c *AMQPConsumer) execute(body []byte) (CommandResult, bool) {
+	var header commandHeader
+	if err := json.Unmarshal(body, &header); err != nil {
+		return CommandResult{Status: "rejected", Error: "invalid JSON: " + err.Error()}, false
+	}
+
+	switch header.Type {
+	case "", CommandText:
+		var req messaging.SendMessageRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return CommandResult{Status: "rejected", Error: "invalid JSON: " + err.Error()}, false
+		}
+		user, err := c.resolveUser(req.User, req.Priority)
+		if err != nil {
+			return CommandResult{Status: "rejected", Error: err.Error()}, false
+		}
+		now := time.Now()
+		sendAfter, err := app.ParseSendAfter(req.SendAfter, now)
+		if err != nil {
+			return CommandResult{Status: "rejected", Error: err.Error()}, false
+		}
+		expiresAt, err := app.ParseExpiresAt(req.ExpiresAt, now, sendAfter)
+		if err != nil {
+			return CommandResult{Status: "rejected", Error: err.Error()}, false
+		}
+		options, err := req.SendOptions()
+		if err != nil {
+			return CommandResult{Status: "rejected", Error: err.Error()}, false
+		}
+		req.Options = options
+		if sendAfter.After(now) && req.Split {
+			return CommandResult{Status: "rejected", Error: "split cannot be combined with send_after"}, false
+		}
+		if sendAfter.After(now) {
+			outboxID, err := c.messagingService.ScheduleMessage(user, req.PhoneNumber, req.Message, req.Priority, sendAfter, expiresAt, req.Options)
+			if err != nil {
+				return CommandResult{Status: "failed", Error: err.Error()}, true
+			}
+			return CommandResult{Status: "scheduled", OutboxID: outboxID}, true
+		}
+		if req.Split {
+			split, err := c.messagingService.SendSplitMessage(user, req.PhoneNumber, req.Message, req.Priority, req.SplitLength, expiresAt, req.Options)
+			if err != nil {
+				result := sendFailure(err)
+				result.Split = split
+				return result, true
+			}
+			return CommandResult{Status: "sent", Split: split}, true
+		}
+		if err := c.messagingService.SendMessage(user, req.PhoneNumber, req.Message, req.Priority, expiresAt, req.Options); err != nil {
+			return sendFailure(err), true
+		}
+		return CommandResult{Status: "sent"}, true
+
+	case CommandImage, CommandVideo, CommandFile:
+		var req media.SendMediaRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return CommandResult{Status: "rejected", Error: "invalid JSON: " + err.Error()}, false
+		}
+		user, err := c.resolveUser(req.User, req.Priority)
+		if err != nil {
+			return CommandResult{Status: "rejected", Error: err.Error()}, false
+		}
+		now := time.Now()
+		sendAfter, err := app.ParseSendAfter(req.SendAfter, now)
+		if err != nil {
+			return CommandResult{Status: "rejected", Error: err.Error()}, false
+		}
+		expiresAt, err := app.ParseExpiresAt(req.ExpiresAt, now, sendAfter)
+		if err != nil {
+			return CommandResult{Status: "rejected", Error: err.Error()}, false
+		}
+		if err := req.Options.Validate(); err != nil {
+			return CommandResult{Status: "rejected", Error: err.Error()}, false
+		}
+		if sendAfter.After(now) {
+			outboxID, err := c.mediaService.ScheduleMedia(user, req.PhoneNumber, header.Type, req.Media, req.URL, req.Handle, req.Caption, req.FileName, req.MimeType, req.Priority, sendAfter, expiresAt, req.Options)
+			if err != nil {
+				return CommandResult{Status: "failed", Error: err.Error()}, true
+			}
+			return CommandResult{Status: "scheduled", OutboxID: outboxID}, true
+		}
+		result, err := c.mediaService.SendMedia(user, req.PhoneNumber, header.Type, req.Media, req.URL, req.Handle, req.Caption, req.FileName, req.MimeType, req.Priority, expiresAt, req.Options)
+		if err != nil {
+			return sendFailure(err), true
+		}
+		return CommandResult{Status: "sent", FileName: result.FileName, Media: result}, true
+
+	default:
+		return CommandResult{
+			Status: "rejected",
+			Error:  fmt.Sprintf("invalid type %q, must be one of text, image, video, file", header.Type),
+		}, false
+	}
+}
+
+// sendFailure reports a send that did not happen, as expired when it missed its deadline,
+// as throttled when WhatsApp is rate limiting the session and as rejected when the
+// content filter refused it
+func sendFailure(err error) CommandResult {
+	if errors.Is(err, app.ErrMessageExpired) {
+		return CommandResult{Status: app.OutboxStatusExpired, Error: err.Error()}
+	}
+	var throttled *app.ThrottledError
+	if errors.As(err, &throttled) {
+		return CommandResult{Status: "throttled", Error: err.Error(), RetryAfterSeconds: throttled.RetryAfterSeconds()}
+	}
+	var rejected *contentfilter.RejectedError
+	if errors.As(err, &rejected) {
+		return CommandResult{Status: "rejected", Error: err.Error()}
+	}
+	var tooLong *app.TooLongError
+	if errors.As(err, &tooLong) {
+		return CommandResult{Status: "rejected", Error: err.Error()}
+	}
+	var invalid *media.ValidationError
+	if errors.As(err, &invalid) {
+		return CommandResult{Status: "rejected", Error: err.Error(), Code: invalid.Code}
+	}
+	return CommandResult{Status: "failed", Error: err.Error()}
+}
+
+// resolveUser resolves aliases and validates the session user and priority, and rejects
+// receive-only sessions, as the HTTP API does
+func (c *AMQPConsumer) resolveUser(user, priority string) (string, error) {
+	resolved := c.app.Aliases.Resolve(user)
+	if err := session.ValidateUser(resolved); err != nil {
+		return "", err
+	}
+	if _, err := app.ParseSendPriority(priority); err != nil {
+		return "", err
+	}
+	if c.app.Options.Get(resolved).ReceiveOnly {
+		return "", app.ErrReceiveOnly
+	}
+	return resolved, nil
+}
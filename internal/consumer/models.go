@@ -0,0 +1,34 @@
+package consumer
+
+import (
+	"github.com/neekaru/whatsappgo-bot/internal/media"
+	"github.com/neekaru/whatsappgo-bot/internal/messaging"
+)
+
+// Command types accepted on the command queue
+const (
+	CommandText  = "text"
+	CommandImage = "image"
+	CommandVideo = "video"
+	CommandFile  = "file"
+)
+
+// commandHeader selects how the rest of a command is decoded. The body is
+// otherwise a messaging.SendMessageRequest (text) or media.SendMediaRequest.
+type commandHeader struct {
+	Type string `json:"type"`
+}
+
+// CommandResult is published to the command's reply_to queue, if set
+type CommandResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Code     string `json:"code,omitempty"` // Set for media rejected before upload
+	FileName string `json:"file_name,omitempty"`
+	OutboxID string `json:"outbox_id,omitempty"` // Set for scheduled commands
+
+	RetryAfterSeconds int `json:"retry_after_seconds,omitempty"` // Set for throttled commands
+
+	Media *media.SendMediaResult `json:"media,omitempty"` // Set for sent media commands
+	Split *messaging.SplitResult `json:"split,omitempty"` // Set for text commands sent with split
+}
@@ -0,0 +1,132 @@
+package contact
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// contactCacheTTL is how long a loaded contact list is served from memory
+const contactCacheTTL = 5 * time.Minute
+
+// cachedContacts holds the contact list of a single session
+type cachedContacts struct {
+	contacts map[string]Contact
+	loadedAt time.Time
+}
+
+// contactCache keeps per-session contact lists and applies contact events to them
+type contactCache struct {
+	mu       sync.RWMutex
+	sessions map[string]*cachedContacts
+}
+
+var (
+	contacts             = &contactCache{sessions: make(map[string]*cachedContacts)}
+	registerObserverOnce sync.Once
+)
+
+// get returns the cached contact list of a user, sorted by JID, if it has not expired
+func (c *contactCache) get(user string) ([]Contact, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cached, ok := c.sessions[user]
+	if !ok || time.Since(cached.loadedAt) > contactCacheTTL {
+		return nil, false
+	}
+
+	return sortedContacts(cached.contacts), true
+}
+
+// set replaces the cached contact list of a user
+func (c *contactCache) set(user string, list map[string]Contact) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessions[user] = &cachedContacts{contacts: list, loadedAt: time.Now()}
+}
+
+// invalidate drops the cached contact list of a user
+func (c *contactCache) invalidate(user string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.sessions, user)
+}
+
+// update replaces a single contact in a user's cached list, if the list is cached
+func (c *contactCache) update(user string, contact Contact) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.sessions[user]
+	if !ok {
+		return
+	}
+	if existing, ok := cached.contacts[contact.JID]; ok && contact.LID == "" {
+		contact.LID = existing.LID
+	}
+	cached.contacts[contact.JID] = contact
+}
+
+// has reports whether a user's contact list is cached
+func (c *contactCache) has(user string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.sessions[user]
+	return ok
+}
+
+// sortedContacts returns the contacts ordered by JID so responses are stable
+func sortedContacts(list map[string]Contact) []Contact {
+	result := make([]Contact, 0, len(list))
+	for _, contact := range list {
+		result = append(result, contact)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].JID < result[j].JID
+	})
+	return result
+}
+
+// registerContactObserver subscribes the contact cache to contact-related client events
+func registerContactObserver(clientManager *client.ClientManager) {
+	registerObserverOnce.Do(func() {
+		clientManager.RegisterObserver(client.EventTypeRaw, client.ObserverFunc(func(event client.Event) {
+			var jid types.JID
+			switch e := event.GetData().(type) {
+			case *events.Contact:
+				jid = e.JID
+			case *events.PushName:
+				jid = e.JID
+			case *events.BusinessName:
+				jid = e.JID
+			default:
+				return
+			}
+
+			user := event.GetClientID()
+			if !contacts.has(user) {
+				return
+			}
+
+			whatsappClient, exists := clientManager.GetClient(user)
+			if !exists {
+				return
+			}
+
+			ctx := context.Background()
+			device := whatsappClient.WhatsmeowClient.Store
+			info, err := device.Contacts.GetContact(ctx, jid)
+			if err != nil {
+				return
+			}
+
+			contacts.update(user, resolveContact(ctx, device, jid, info))
+		}))
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/utils"
 )
 
 // Handlers contains HTTP handlers for contact management
@@ -144,4 +145,30 @@ func (h *Handlers) RefreshContactsHandler(c *gin.Context) {
 		"msg":  "Contacts refreshed successfully",
 		"user": req.User,
 	})
-}
\ No newline at end of file
+}
+
+// ResolveJIDHandler handles GET /contact/resolve - resolves any JID form to its canonical user JID
+func (h *Handlers) ResolveJIDHandler(c *gin.Context) {
+	jid := c.Query("jid")
+	if jid == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing jid"})
+		return
+	}
+	if _, err := utils.CanonicalJID(jid); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.Query("user")
+	response, err := h.service.ResolveJID(user, jid)
+	if err != nil {
+		h.app.Logger.Printf("Resolve JID error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to resolve JID",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
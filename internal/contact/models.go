@@ -8,8 +8,11 @@ type UserRequest struct {
 // Contact represents a WhatsApp contact
 type Contact struct {
 	JID          string `json:"jid"`           // WhatsApp JID (e.g., "1234567890@s.whatsapp.net")
-	PhoneNumber  string `json:"phone_number"`  // Phone number without country code formatting
-	Name         string `json:"name"`          // Contact name (empty if not saved)
+	PhoneNumber  string `json:"phone_number"`  // Phone number without country code formatting (empty for unmapped LID contacts)
+	LID          string `json:"lid,omitempty"` // Linked identity JID (e.g., "98765432101234@lid"), if known
+	Name         string `json:"name"`          // Display name: saved name, else push name, else business name
+	SavedName    string `json:"saved_name"`    // Name saved in the phone's address book (empty if not saved)
+	FirstName    string `json:"first_name"`    // First name from the phone's address book
 	PushName     string `json:"push_name"`     // Name set by the contact themselves
 	BusinessName string `json:"business_name"` // Business name if it's a business contact
 	IsSaved      bool   `json:"is_saved"`      // Whether this contact is saved in the phone
@@ -21,4 +24,13 @@ type ContactsResponse struct {
 	Contacts []Contact `json:"contacts"`
 	Total    int       `json:"total"`
 	User     string    `json:"user"`
-}
\ No newline at end of file
+}
+
+// ResolveResponse is the canonical form of a JID
+type ResolveResponse struct {
+	Input    string `json:"input"`
+	JID      string `json:"jid"`                 // Canonical JID without device or agent suffix
+	Server   string `json:"server"`              // s.whatsapp.net, lid, g.us, ...
+	PhoneJID string `json:"phone_jid,omitempty"` // Phone number JID, if known
+	LID      string `json:"lid,omitempty"`       // Linked identity JID, if known
+}
@@ -6,8 +6,19 @@ import (
 	"strings"
 
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/utils"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
 )
 
+// savedName returns the name the account owner saved the contact under, if any
+func savedName(fullName, firstName string) string {
+	if fullName != "" {
+		return fullName
+	}
+	return firstName
+}
+
 func contactDisplayName(contactName, pushName, businessName string) string {
 	switch {
 	case contactName != "":
@@ -28,11 +39,87 @@ type Service struct {
 
 // NewService creates a new contact service
 func NewService(app *app.App) *Service {
+	registerContactObserver(app.GetClientManager())
 	return &Service{
 		app: app,
 	}
 }
 
+// DisplayName returns the best display name for a store entry: saved name, then push name, then business name
+func DisplayName(info types.ContactInfo) string {
+	return contactDisplayName(savedName(info.FullName, info.FirstName), info.PushName, info.BusinessName)
+}
+
+// buildContact converts a store entry into a Contact
+func buildContact(jid types.JID, contact types.ContactInfo) Contact {
+	// Parse phone number from JID; LID JIDs carry no phone number
+	phoneNumber := ""
+	if jid.Server != types.HiddenUserServer {
+		phoneNumber = strings.Split(jid.User, "@")[0]
+	}
+
+	saved := savedName(contact.FullName, contact.FirstName)
+	displayName := contactDisplayName(saved, contact.PushName, contact.BusinessName)
+
+	return Contact{
+		JID:          jid.String(),
+		PhoneNumber:  phoneNumber,
+		Name:         displayName,
+		SavedName:    saved,
+		FirstName:    contact.FirstName,
+		PushName:     contact.PushName,
+		BusinessName: contact.BusinessName,
+		IsSaved:      saved != "",
+		IsBusiness:   contact.BusinessName != "",
+	}
+}
+
+// resolveContact builds a Contact for a store entry, mapping LID JIDs to their phone
+// number JID when the mapping is known
+func resolveContact(ctx context.Context, device *store.Device, jid types.JID, info types.ContactInfo) Contact {
+	if jid.Server != types.HiddenUserServer {
+		return buildContact(jid, info)
+	}
+
+	pn, err := device.LIDs.GetPNForLID(ctx, jid)
+	if err != nil || pn.IsEmpty() {
+		contact := buildContact(jid, info)
+		contact.LID = jid.String()
+		return contact
+	}
+
+	contact := buildContact(pn.ToNonAD(), info)
+	contact.LID = jid.String()
+	return contact
+}
+
+// mergeContact adds a contact to the list, filling in blanks when the same person
+// is already listed under another JID (phone number and LID entries)
+func mergeContact(list map[string]Contact, contact Contact) {
+	existing, ok := list[contact.JID]
+	if !ok {
+		list[contact.JID] = contact
+		return
+	}
+
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&existing.PhoneNumber, contact.PhoneNumber)
+	fill(&existing.LID, contact.LID)
+	fill(&existing.SavedName, contact.SavedName)
+	fill(&existing.FirstName, contact.FirstName)
+	fill(&existing.PushName, contact.PushName)
+	fill(&existing.BusinessName, contact.BusinessName)
+	existing.Name = contactDisplayName(existing.SavedName, existing.PushName, existing.BusinessName)
+	existing.IsSaved = existing.SavedName != ""
+	existing.IsBusiness = existing.BusinessName != ""
+
+	list[contact.JID] = existing
+}
+
 // GetAllContacts retrieves all contacts for a user
 func (s *Service) GetAllContacts(user string) ([]Contact, error) {
 	clientManager := s.app.GetClientManager()
@@ -45,42 +132,46 @@ func (s *Service) GetAllContacts(user string) ([]Contact, error) {
 		return nil, fmt.Errorf("client is not logged in")
 	}
 
+	if cached, ok := contacts.get(user); ok {
+		return cached, nil
+	}
+
 	ctx := context.Background()
-	contacts, err := client.WhatsmeowClient.Store.Contacts.GetAllContacts(ctx)
+	device := client.WhatsmeowClient.Store
+	storeContacts, err := device.Contacts.GetAllContacts(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get contacts: %v", err)
 	}
 
-	var result []Contact
-	for jid, contact := range contacts {
-		// Skip group JIDs (those containing "@lid" are for groups, not individual contacts)
-		if strings.Contains(jid.String(), "@lid") {
-			continue
+	list := make(map[string]Contact, len(storeContacts))
+	var phoneJIDs []types.JID
+	for jid, info := range storeContacts {
+		contact := resolveContact(ctx, device, jid, info)
+		mergeContact(list, contact)
+		if jid.Server == types.DefaultUserServer {
+			phoneJIDs = append(phoneJIDs, jid)
 		}
+	}
 
-		// Parse phone number from JID
-		phoneNumber := strings.Split(jid.User, "@")[0]
-
-		displayName := contactDisplayName(contact.FullName, contact.PushName, contact.BusinessName)
-		isSaved := displayName != ""
-
-		contactInfo := Contact{
-			JID:          jid.String(),
-			PhoneNumber:  phoneNumber,
-			Name:         displayName,
-			PushName:     contact.PushName,
-			BusinessName: contact.BusinessName,
-			IsSaved:      isSaved,
-			IsBusiness:   contact.BusinessName != "",
+	// Attach known LIDs to phone number contacts
+	if len(phoneJIDs) > 0 {
+		lids, err := device.LIDs.GetManyLIDsForPNs(ctx, phoneJIDs)
+		if err != nil {
+			s.app.Logger.Printf("Warning: failed to load LID mappings for user %s: %v", user, err)
+		}
+		for pn, lid := range lids {
+			if contact, ok := list[pn.String()]; ok && contact.LID == "" {
+				contact.LID = lid.String()
+				list[pn.String()] = contact
+			}
 		}
-
-		result = append(result, contactInfo)
 	}
 
-	return result, nil
+	contacts.set(user, list)
+	return sortedContacts(list), nil
 }
 
-// GetSavedContacts retrieves only saved contacts (contacts with names)
+// GetSavedContacts retrieves only saved contacts (contacts with a saved name)
 func (s *Service) GetSavedContacts(user string) ([]Contact, error) {
 	allContacts, err := s.GetAllContacts(user)
 	if err != nil {
@@ -97,7 +188,7 @@ func (s *Service) GetSavedContacts(user string) ([]Contact, error) {
 	return savedContacts, nil
 }
 
-// GetUnsavedContacts retrieves only unsaved contacts (contacts without names)
+// GetUnsavedContacts retrieves only unsaved contacts (contacts without a saved name)
 func (s *Service) GetUnsavedContacts(user string) ([]Contact, error) {
 	allContacts, err := s.GetAllContacts(user)
 	if err != nil {
@@ -131,6 +222,63 @@ func (s *Service) RefreshContacts(user string) error {
 	// We can trigger a sync by requesting presence updates or by reconnecting
 	// For now, we'll return success as contacts are managed automatically
 	s.app.Logger.Printf("Contact refresh requested for user %s - contacts are automatically synced by whatsmeow", user)
+
+	// Drop the cached list so the next request reloads it from the store
+	contacts.invalidate(user)
 	
 	return nil
 }
+
+// ResolveJID returns the canonical user JID of any JID form: a bare phone
+// number, a device JID ("123:1@s.whatsapp.net") or a LID. With a user, the
+// session's LID mappings fill in the phone number JID and LID of the contact.
+func (s *Service) ResolveJID(user, input string) (*ResolveResponse, error) {
+	jid, err := utils.CanonicalJID(input)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ResolveResponse{
+		Input:  input,
+		JID:    jid.String(),
+		Server: jid.Server,
+	}
+	switch jid.Server {
+	case types.DefaultUserServer:
+		response.PhoneJID = jid.String()
+	case types.HiddenUserServer:
+		response.LID = jid.String()
+	}
+	if user == "" || (response.PhoneJID == "" && response.LID == "") {
+		return response, nil
+	}
+
+	client, exists := s.app.GetClientManager().GetClient(user)
+	if !exists {
+		return nil, fmt.Errorf("client not found for user %s", user)
+	}
+	if !client.IsLoggedIn() {
+		return nil, fmt.Errorf("client is not logged in")
+	}
+
+	ctx := context.Background()
+	lids := client.WhatsmeowClient.Store.LIDs
+	if response.LID != "" {
+		pn, err := lids.GetPNForLID(ctx, jid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up phone number: %v", err)
+		}
+		if !pn.IsEmpty() {
+			response.PhoneJID = pn.ToNonAD().String()
+		}
+	} else {
+		lid, err := lids.GetLIDForPN(ctx, jid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up LID: %v", err)
+		}
+		if !lid.IsEmpty() {
+			response.LID = lid.ToNonAD().String()
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,147 @@
+package contentfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Actions taken on a message that violates the chain
+const (
+	ActionReject = "reject" // Refuse to send the message
+	ActionRedact = "redact" // Send the message with offending parts redacted
+)
+
+// Violation is a rule broken by an outgoing message
+type Violation struct {
+	Rule   string `json:"rule"`
+	Detail string `json:"detail"`
+}
+
+// String returns the violation as "rule: detail"
+func (v Violation) String() string {
+	return v.Rule + ": " + v.Detail
+}
+
+// RejectedError is returned for messages refused by a chain with ActionReject
+type RejectedError struct {
+	Violations []Violation
+}
+
+func (e *RejectedError) Error() string {
+	details := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		details[i] = v.String()
+	}
+	return "message rejected by content filter: " + strings.Join(details, "; ")
+}
+
+// Config configures a chain, as stored in the content filter file
+type Config struct {
+	Action         string   `json:"action"` // reject (default) or redact
+	DenyWords      []string `json:"deny_words"`
+	DenyPatterns   []string `json:"deny_patterns"` // Go regular expressions
+	MaxLength      int      `json:"max_length"`    // Characters; 0 disables
+	URLPolicy      string   `json:"url_policy"`    // allow (default), deny or allowlist
+	AllowedDomains []string `json:"allowed_domains"`
+}
+
+// Chain runs outgoing message text through a list of filters. A nil Chain
+// lets every message through unchanged.
+type Chain struct {
+	action  string
+	filters []Filter
+}
+
+// NewChain creates a chain applying the filters in order
+func NewChain(action string, filters ...Filter) (*Chain, error) {
+	switch action {
+	case "":
+		action = ActionReject
+	case ActionReject, ActionRedact:
+	default:
+		return nil, fmt.Errorf("invalid action %q, must be one of reject, redact", action)
+	}
+	return &Chain{action: action, filters: filters}, nil
+}
+
+// FromConfig builds the chain described by a config
+func FromConfig(cfg Config) (*Chain, error) {
+	var filters []Filter
+	if len(cfg.DenyWords) > 0 {
+		filters = append(filters, NewDenyWords(cfg.DenyWords))
+	}
+	if len(cfg.DenyPatterns) > 0 {
+		patterns, err := NewDenyPatterns(cfg.DenyPatterns)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, patterns)
+	}
+	if cfg.URLPolicy != "" && cfg.URLPolicy != URLPolicyAllow {
+		policy, err := NewURLPolicy(cfg.URLPolicy, cfg.AllowedDomains)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, policy)
+	}
+	// Truncate last so redactions cannot push text over the limit
+	if cfg.MaxLength > 0 {
+		filters = append(filters, NewMaxLength(cfg.MaxLength))
+	}
+	return NewChain(cfg.Action, filters...)
+}
+
+// Load builds the chain configured in a JSON file. It returns a nil chain,
+// which filters nothing, when the file does not exist.
+func Load(path string) (*Chain, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content filter: %v", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse content filter: %v", err)
+	}
+	chain, err := FromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content filter: %v", err)
+	}
+	return chain, nil
+}
+
+// Action returns what the chain does with violating messages
+func (c *Chain) Action() string {
+	if c == nil {
+		return ActionReject
+	}
+	return c.action
+}
+
+// Check runs text through the filters and returns the text to send along
+// with the violations found. With ActionReject any violation fails the check
+// with a *RejectedError; with ActionRedact the redacted text is returned.
+func (c *Chain) Check(text string) (string, []Violation, error) {
+	if c == nil || text == "" {
+		return text, nil, nil
+	}
+
+	var violations []Violation
+	for _, filter := range c.filters {
+		var details []string
+		text, details = filter.Apply(text)
+		for _, detail := range details {
+			violations = append(violations, Violation{Rule: filter.Name(), Detail: detail})
+		}
+	}
+
+	if len(violations) > 0 && c.action == ActionReject {
+		return "", violations, &RejectedError{Violations: violations}
+	}
+	return text, violations, nil
+}
@@ -0,0 +1,217 @@
+package contentfilter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Replacement substituted for redacted words, patterns and links
+const Replacement = "[redacted]"
+
+// Filter inspects the text of an outgoing message. Apply returns the text with
+// offending parts redacted, and a description of each violation found.
+type Filter interface {
+	Name() string
+	Apply(text string) (string, []string)
+}
+
+// DenyWords flags whole words from a list, matched case-insensitively
+type DenyWords struct {
+	pattern *regexp.Regexp
+}
+
+// NewDenyWords creates a filter for the given words. Empty words are ignored.
+func NewDenyWords(words []string) *DenyWords {
+	var quoted []string
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return &DenyWords{}
+	}
+	return &DenyWords{pattern: regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)}
+}
+
+// Name returns the rule name reported in violations
+func (f *DenyWords) Name() string { return "deny_words" }
+
+// Apply redacts denied words
+func (f *DenyWords) Apply(text string) (string, []string) {
+	if f.pattern == nil {
+		return text, nil
+	}
+	var violations []string
+	text = f.pattern.ReplaceAllStringFunc(text, func(match string) string {
+		violations = append(violations, fmt.Sprintf("denied word %q", strings.ToLower(match)))
+		return Replacement
+	})
+	return text, violations
+}
+
+// DenyPatterns flags text matching any of a list of regular expressions
+type DenyPatterns struct {
+	patterns []*regexp.Regexp
+}
+
+// NewDenyPatterns compiles the given regular expressions
+func NewDenyPatterns(patterns []string) (*DenyPatterns, error) {
+	f := &DenyPatterns{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deny pattern %q: %v", pattern, err)
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f, nil
+}
+
+// Name returns the rule name reported in violations
+func (f *DenyPatterns) Name() string { return "deny_patterns" }
+
+// Apply redacts matches of each pattern
+func (f *DenyPatterns) Apply(text string) (string, []string) {
+	var violations []string
+	for _, re := range f.patterns {
+		if re.MatchString(text) {
+			violations = append(violations, fmt.Sprintf("matches pattern %q", re.String()))
+			text = re.ReplaceAllString(text, Replacement)
+		}
+	}
+	return text, violations
+}
+
+// MaxLength flags text longer than a number of characters
+type MaxLength struct {
+	max int
+}
+
+// NewMaxLength creates a filter for text longer than max characters
+func NewMaxLength(max int) *MaxLength {
+	return &MaxLength{max: max}
+}
+
+// Name returns the rule name reported in violations
+func (f *MaxLength) Name() string { return "max_length" }
+
+// Apply truncates text to the maximum length
+func (f *MaxLength) Apply(text string) (string, []string) {
+	length := utf8.RuneCountInString(text)
+	if f.max <= 0 || length <= f.max {
+		return text, nil
+	}
+	runes := []rune(text)
+	return string(runes[:f.max]), []string{fmt.Sprintf("%d characters, limit is %d", length, f.max)}
+}
+
+// URL policies
+const (
+	URLPolicyAllow     = "allow"     // Any link may be sent
+	URLPolicyDeny      = "deny"      // No link may be sent
+	URLPolicyAllowlist = "allowlist" // Only links to the allowed domains and their subdomains
+)
+
+// urlPattern finds links written with a scheme or a www. prefix, and bare
+// host names such as example.com/path. bareLink tells the latter from other
+// dotted words.
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+` +
+	`|\b(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,63}\b(?::[0-9]{1,5})?(?:/[^\s<>"]*)?`)
+
+// schemePattern matches links written with a scheme or a www. prefix
+var schemePattern = regexp.MustCompile(`(?i)^(?:https?://|www\.)`)
+
+// URLPolicy flags links the policy does not permit
+type URLPolicy struct {
+	policy  string
+	domains []string
+}
+
+// NewURLPolicy creates a filter for a URL policy. domains lists the hosts
+// allowed by URLPolicyAllowlist.
+func NewURLPolicy(policy string, domains []string) (*URLPolicy, error) {
+	switch policy {
+	case URLPolicyAllow, URLPolicyDeny, URLPolicyAllowlist:
+	default:
+		return nil, fmt.Errorf("invalid url_policy %q, must be one of allow, deny, allowlist", policy)
+	}
+	f := &URLPolicy{policy: policy}
+	for _, domain := range domains {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			f.domains = append(f.domains, domain)
+		}
+	}
+	return f, nil
+}
+
+// Name returns the rule name reported in violations
+func (f *URLPolicy) Name() string { return "url_policy" }
+
+// Apply redacts links the policy does not permit
+func (f *URLPolicy) Apply(text string) (string, []string) {
+	if f.policy == URLPolicyAllow {
+		return text, nil
+	}
+	var violations []string
+	var b strings.Builder
+	last := 0
+	for _, match := range urlPattern.FindAllStringIndex(text, -1) {
+		link := text[match[0]:match[1]]
+		if !schemePattern.MatchString(link) && !bareLink(text, match[0], link) {
+			continue
+		}
+		if f.policy == URLPolicyAllowlist && f.allowed(link) {
+			continue
+		}
+		violations = append(violations, fmt.Sprintf("link to %s", linkHost(link)))
+		b.WriteString(text[last:match[0]])
+		b.WriteString(Replacement)
+		last = match[1]
+	}
+	if violations == nil {
+		return text, nil
+	}
+	b.WriteString(text[last:])
+	return b.String(), violations
+}
+
+// bareLink reports whether link, found at start in text without a scheme, is
+// a host name: its top-level domain must be a real one, so file names such as
+// report.pdf are not links, and the domain of an email address is not either
+func bareLink(text string, start int, link string) bool {
+	if start > 0 && text[start-1] == '@' {
+		return false
+	}
+	host := linkHost(link)
+	suffix, icann := publicsuffix.PublicSuffix(host)
+	return icann && suffix != host
+}
+
+// allowed reports whether a link points to an allowed domain or one of its subdomains
+func (f *URLPolicy) allowed(link string) bool {
+	host := linkHost(link)
+	for _, domain := range f.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkHost returns the lowercase host of a link
+func linkHost(link string) string {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,6 @@
+package conversation
+
+import "errors"
+
+// ErrConversationUnavailable is returned when the conversation database could not be opened
+var ErrConversationUnavailable = errors.New("conversation store is not available")
@@ -0,0 +1,207 @@
+package conversation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/utils"
+)
+
+// Handlers contains HTTP handlers for conversation handoff
+type Handlers struct {
+	app     *app.App
+	service *Service
+}
+
+// NewHandlers creates a new conversation handlers instance
+func NewHandlers(app *app.App) *Handlers {
+	return &Handlers{
+		app:     app,
+		service: NewService(app),
+	}
+}
+
+// ListHandler handles GET /wa/conversations - lists a session's conversations that left the bot
+func (h *Handlers) ListHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	state := c.Query("state")
+	if state != "" && !app.ValidConversationState(state) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid state, must be one of bot, queued, assigned, closed"})
+		return
+	}
+
+	limit, offset := defaultListLimit, 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid limit, must be between 1 and %d", maxListLimit),
+			})
+			return
+		}
+		limit = parsed
+	}
+	if value := c.Query("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset, must be a non-negative integer"})
+			return
+		}
+		offset = parsed
+	}
+
+	response, err := h.service.List(user, state, c.Query("agent"), limit, offset)
+	if err != nil {
+		h.respondError(c, user, "List conversations", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetHandler handles GET /wa/conversation - shows the handoff state and notes of a chat
+func (h *Handlers) GetHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	chat := c.Query("chat")
+	if chat == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing chat"})
+		return
+	}
+	if _, err := utils.ChatJID(chat); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversation, err := h.service.Get(user, chat)
+	if err != nil {
+		h.respondError(c, user, "Get conversation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
+}
+
+// TransitionHandler handles POST /wa/conversation - moves a chat to another handoff state
+func (h *Handlers) TransitionHandler(c *gin.Context) {
+	var req TransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	if req.Chat == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing chat"})
+		return
+	}
+	if _, err := utils.ChatJID(req.Chat); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !app.ValidConversationState(req.State) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid state, must be one of bot, queued, assigned, closed"})
+		return
+	}
+	if req.State == app.ConversationAssigned && req.Agent == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing agent"})
+		return
+	}
+
+	response, err := h.service.Transition(req.User, req.Chat, req.State, req.Agent)
+	if err != nil {
+		if errors.Is(err, app.ErrInvalidTransition) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.respondError(c, req.User, "Conversation transition", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AddNoteHandler handles POST /chats/:jid/notes - attaches an internal note to a chat
+func (h *Handlers) AddNoteHandler(c *gin.Context) {
+	chat := c.Param("jid")
+	if _, err := utils.ChatJID(chat); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req NoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing body"})
+		return
+	}
+	if len(req.Body) > app.MaxChatNoteLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Note body is longer than %d characters", app.MaxChatNoteLength),
+		})
+		return
+	}
+
+	note, err := h.service.AddNote(req.User, chat, req.Author, req.Body)
+	if err != nil {
+		h.respondError(c, req.User, "Add chat note", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// ListNotesHandler handles GET /chats/:jid/notes - lists the internal notes of a chat
+func (h *Handlers) ListNotesHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	chat := c.Param("jid")
+	if _, err := utils.ChatJID(chat); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.Notes(user, chat)
+	if err != nil {
+		h.respondError(c, user, "List chat notes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// respondError maps a service error to its HTTP response
+func (h *Handlers) respondError(c *gin.Context, user, action string, err error) {
+	if errors.Is(err, ErrConversationUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	h.app.Logger.Printf("%s error for user %s: %v", action, user, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,52 @@
+package conversation
+
+import "github.com/neekaru/whatsappgo-bot/internal/app"
+
+// Pagination bounds for conversation listings
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// TransitionRequest represents a request to move a chat to another handoff state
+type TransitionRequest struct {
+	User  string `json:"user"`
+	Chat  string `json:"chat"`  // Phone number or JID
+	State string `json:"state"` // bot, queued, assigned or closed
+	Agent string `json:"agent"` // Required for assigned
+}
+
+// ChatDetail is the handoff state of a chat together with its internal notes
+type ChatDetail struct {
+	app.Conversation
+	Notes []app.ChatNote `json:"notes"`
+}
+
+// NoteRequest represents a request to attach an internal note to a chat
+type NoteRequest struct {
+	User   string `json:"user"`
+	Author string `json:"author"` // Optional, e.g. the agent writing the note
+	Body   string `json:"body"`
+}
+
+// NotesResponse lists the internal notes of a chat
+type NotesResponse struct {
+	User  string         `json:"user"`
+	Chat  string         `json:"chat"`
+	Notes []app.ChatNote `json:"notes"`
+}
+
+// TransitionResponse is the handoff state of a chat after a transition
+type TransitionResponse struct {
+	Conversation  app.Conversation `json:"conversation"`
+	PreviousState string           `json:"previous_state"`
+	PreviousAgent string           `json:"previous_agent,omitempty"`
+}
+
+// ListResponse is a page of a session's conversations
+type ListResponse struct {
+	Conversations []app.Conversation `json:"conversations"`
+	Total         int                `json:"total"`
+	Limit         int                `json:"limit"`
+	Offset        int                `json:"offset"`
+}
@@ -0,0 +1,114 @@
+package conversation
+
+import (
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"github.com/neekaru/whatsappgo-bot/internal/utils"
+)
+
+// Service handles the handoff state of chats between the bot and human agents
+type Service struct {
+	app *app.App
+}
+
+// NewService creates a new conversation service
+func NewService(app *app.App) *Service {
+	return &Service{app: app}
+}
+
+// Get returns the handoff state and internal notes of a chat
+func (s *Service) Get(user, chat string) (*ChatDetail, error) {
+	if s.app.Conversations == nil {
+		return nil, ErrConversationUnavailable
+	}
+	jid, err := utils.CanonicalJID(chat)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation, err := s.app.Conversations.Get(user, jid.String())
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.app.Conversations.Notes(user, conversation.Chat)
+	if err != nil {
+		return nil, err
+	}
+	return &ChatDetail{Conversation: conversation, Notes: notes}, nil
+}
+
+// AddNote attaches an internal note to a chat. Notes are never sent to WhatsApp.
+func (s *Service) AddNote(user, chat, author, body string) (app.ChatNote, error) {
+	if s.app.Conversations == nil {
+		return app.ChatNote{}, ErrConversationUnavailable
+	}
+	jid, err := utils.CanonicalJID(chat)
+	if err != nil {
+		return app.ChatNote{}, err
+	}
+	return s.app.Conversations.AddNote(user, jid.String(), author, body)
+}
+
+// Notes returns the internal notes of a chat
+func (s *Service) Notes(user, chat string) (*NotesResponse, error) {
+	if s.app.Conversations == nil {
+		return nil, ErrConversationUnavailable
+	}
+	jid, err := utils.CanonicalJID(chat)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := s.app.Conversations.Notes(user, jid.String())
+	if err != nil {
+		return nil, err
+	}
+	return &NotesResponse{User: user, Chat: jid.String(), Notes: notes}, nil
+}
+
+// Transition moves a chat to another handoff state and publishes a
+// conversation event for the change
+func (s *Service) Transition(user, chat, state, agent string) (*TransitionResponse, error) {
+	if s.app.Conversations == nil {
+		return nil, ErrConversationUnavailable
+	}
+	jid, err := utils.CanonicalJID(chat)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, current, err := s.app.Conversations.Transition(user, jid.String(), state, agent)
+	if err != nil {
+		return nil, err
+	}
+
+	s.app.Logger.Printf("Conversation %s of user %s moved from %s to %s", current.Chat, user, previous.State, current.State)
+	s.app.GetClientManager().DispatchEvent(client.NewConversationEvent(
+		user, current.Chat, current.State, previous.State, current.Agent, previous.Agent, *current.UpdatedAt,
+	))
+
+	return &TransitionResponse{
+		Conversation:  current,
+		PreviousState: previous.State,
+		PreviousAgent: previous.Agent,
+	}, nil
+}
+
+// List returns a page of a user's conversations, optionally filtered by state and agent
+func (s *Service) List(user, state, agent string, limit, offset int) (*ListResponse, error) {
+	if s.app.Conversations == nil {
+		return nil, ErrConversationUnavailable
+	}
+
+	conversations, total, err := s.app.Conversations.List(user, state, agent, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListResponse{
+		Conversations: conversations,
+		Total:         total,
+		Limit:         limit,
+		Offset:        offset,
+	}, nil
+}
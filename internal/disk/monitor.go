@@ -0,0 +1,135 @@
+package disk
+
+import (
+	"io/fs"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/alert"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Directory is a directory whose usage is reported
+type Directory struct {
+	Name string
+	Path string
+}
+
+// Config holds the disk monitor settings
+type Config struct {
+	Path        string        // Directory whose filesystem is watched, normally the data directory
+	Directories []Directory   // Directories whose usage is reported
+	Interval    time.Duration // Time between checks
+	MinFree     uint64        // Free bytes below which space is low; 0 never reports low space
+}
+
+// Monitor periodically measures free disk space and directory usage into the
+// app's disk guard. When free space drops below MinFree it logs a warning and
+// alerts once, and again after space has recovered and run low anew.
+type Monitor struct {
+	app    *app.App
+	cfg    Config
+	alerts *alert.Sender
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewMonitor creates a disk monitor
+func NewMonitor(app *app.App, cfg Config, alerts *alert.Sender) *Monitor {
+	return &Monitor{
+		app:    app,
+		cfg:    cfg,
+		alerts: alerts,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start checks right away, then in the background every interval
+func (m *Monitor) Start() {
+	m.check()
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				m.check()
+			}
+		}
+	}()
+}
+
+// Close stops the monitor
+func (m *Monitor) Close() {
+	close(m.stop)
+	<-m.done
+}
+
+// check measures the disk and reports changes between low and enough space
+func (m *Monitor) check() {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(m.cfg.Path, &stat); err != nil {
+		m.app.Logger.Printf("Failed to check free disk space of %s: %v", m.cfg.Path, err)
+		return
+	}
+
+	status := app.DiskStatus{
+		Path:         m.cfg.Path,
+		TotalBytes:   stat.Blocks * uint64(stat.Bsize),
+		FreeBytes:    stat.Bavail * uint64(stat.Bsize),
+		MinFreeBytes: m.cfg.MinFree,
+		Usage:        make([]app.DirectoryUsage, 0, len(m.cfg.Directories)),
+		CheckedAt:    time.Now(),
+	}
+	status.Low = status.FreeBytes < m.cfg.MinFree
+	for _, dir := range m.cfg.Directories {
+		status.Usage = append(status.Usage, app.DirectoryUsage{Name: dir.Name, Path: dir.Path, Bytes: directorySize(dir.Path)})
+	}
+
+	wasLow := m.app.Disk.Low()
+	m.app.Disk.Update(status)
+
+	switch {
+	case status.Low && !wasLow:
+		m.app.Logger.Printf("Warning: %d MB free on the disk of %s, below %d MB; pausing the raw event archive and backups",
+			status.FreeBytes>>20, m.cfg.Path, m.cfg.MinFree>>20)
+		go func() {
+			err := m.alerts.Send("disk_low", map[string]any{
+				"path":           m.cfg.Path,
+				"free_bytes":     status.FreeBytes,
+				"min_free_bytes": m.cfg.MinFree,
+			})
+			if err != nil {
+				m.app.Logger.Printf("Failed to send disk space alert: %v", err)
+			}
+		}()
+	case !status.Low && wasLow:
+		m.app.Logger.Printf("Disk space of %s recovered, %d MB free", m.cfg.Path, status.FreeBytes>>20)
+	}
+}
+
+// directorySize returns the bytes taken by the files under path. Files that
+// cannot be read or vanish while walking, such as rotated logs, are skipped;
+// a missing directory takes no space.
+func directorySize(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
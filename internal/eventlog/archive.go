@@ -0,0 +1,226 @@
+package eventlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+)
+
+// Name of the active archive file; rotated files get a .1, .2, ... suffix
+const archiveFileName = "events.jsonl"
+
+// Archive persists the raw whatsmeow events of sessions in debug mode, one
+// JSON line per event under {dir}/{user}/. Each file is capped at maxBytes and
+// rotated, keeping at most maxFiles files per session.
+type Archive struct {
+	app      *app.App
+	dir      string
+	maxBytes int64
+	maxFiles int
+
+	mu    sync.Mutex
+	files map[string]*os.File
+}
+
+// NewArchive creates an archive rooted at dir
+func NewArchive(app *app.App, dir string, maxBytes int64, maxFiles int) *Archive {
+	if maxFiles < 1 {
+		maxFiles = 1
+	}
+	return &Archive{
+		app:      app,
+		dir:      dir,
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+		files:    make(map[string]*os.File),
+	}
+}
+
+// Start registers the archive for raw client events
+func (a *Archive) Start() {
+	a.app.GetClientManager().RegisterObserver(client.EventTypeRaw, client.ObserverFunc(a.OnEvent))
+}
+
+// OnEvent archives the event if its session has debug_events enabled
+func (a *Archive) OnEvent(event client.Event) {
+	user := event.GetClientID()
+	if !a.app.Options.Get(user).DebugEvents {
+		return
+	}
+	// The archive is a debugging aid; drop events rather than fill the disk
+	if a.app.Disk.Low() {
+		return
+	}
+
+	if err := a.append(user, newEntry(event.GetData())); err != nil {
+		a.app.Logger.Printf("Failed to archive raw event for user %s: %v", user, err)
+	}
+}
+
+// newEntry encodes a raw event, falling back to a text dump
+func newEntry(data interface{}) Entry {
+	entry := Entry{Time: time.Now(), Type: fmt.Sprintf("%T", data)}
+	if encoded, err := json.Marshal(data); err == nil {
+		entry.Event = encoded
+	} else {
+		entry.Dump = fmt.Sprintf("%+v", data)
+	}
+	return entry
+}
+
+// append writes an entry to the user's archive, rotating it when full
+func (a *Archive) append(user string, entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file, err := a.fileLocked(user)
+	if err != nil {
+		return err
+	}
+
+	if info, err := file.Stat(); err == nil && info.Size() > 0 && info.Size()+int64(len(line)) > a.maxBytes {
+		if err := a.rotateLocked(user); err != nil {
+			return err
+		}
+		if file, err = a.fileLocked(user); err != nil {
+			return err
+		}
+	}
+
+	_, err = file.Write(line)
+	return err
+}
+
+// fileLocked returns the open active file of a user. The caller must hold a.mu.
+func (a *Archive) fileLocked(user string) (*os.File, error) {
+	if file, ok := a.files[user]; ok {
+		return file, nil
+	}
+
+	dir := filepath.Join(a.dir, user)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %v", err)
+	}
+	file, err := os.OpenFile(filepath.Join(dir, archiveFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive file: %v", err)
+	}
+	a.files[user] = file
+	return file, nil
+}
+
+// rotateLocked shifts the user's files by one suffix, dropping the oldest.
+// The caller must hold a.mu.
+func (a *Archive) rotateLocked(user string) error {
+	a.closeLocked(user)
+
+	paths := a.paths(user)
+	_ = os.Remove(paths[len(paths)-1])
+	for i := len(paths) - 1; i > 0; i-- {
+		if err := os.Rename(paths[i-1], paths[i]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate archive file: %v", err)
+		}
+	}
+	return nil
+}
+
+// closeLocked closes the user's active file. The caller must hold a.mu.
+func (a *Archive) closeLocked(user string) {
+	if file, ok := a.files[user]; ok {
+		file.Close()
+		delete(a.files, user)
+	}
+}
+
+// paths returns the user's archive files, newest first
+func (a *Archive) paths(user string) []string {
+	base := filepath.Join(a.dir, user, archiveFileName)
+	paths := []string{base}
+	for i := 1; i < a.maxFiles; i++ {
+		paths = append(paths, fmt.Sprintf("%s.%d", base, i))
+	}
+	return paths
+}
+
+// Tail returns up to limit of the user's most recent entries, oldest first
+func (a *Archive) Tail(user string, limit int) ([]Entry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	paths := a.paths(user)
+	var entries []Entry
+	// Read oldest file first so entries come out in order
+	for i := len(paths) - 1; i >= 0; i-- {
+		fileEntries, err := readEntries(paths[i])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// readEntries reads the entries of one archive file, skipping a missing file
+// and malformed lines
+func readEntries(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive file: %v", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read archive file: %v", err)
+	}
+	return entries, nil
+}
+
+// Clear deletes the user's archive
+func (a *Archive) Clear(user string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.closeLocked(user)
+	if err := os.RemoveAll(filepath.Join(a.dir, user)); err != nil {
+		return fmt.Errorf("failed to delete archive: %v", err)
+	}
+	return nil
+}
+
+// Close closes every open archive file
+func (a *Archive) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for user := range a.files {
+		a.closeLocked(user)
+	}
+}
@@ -0,0 +1,70 @@
+package eventlog
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Handlers contains HTTP handlers for the raw event archive
+type Handlers struct {
+	app     *app.App
+	archive *Archive
+}
+
+// NewHandlers creates a new event archive handlers instance
+func NewHandlers(app *app.App, archive *Archive) *Handlers {
+	return &Handlers{
+		app:     app,
+		archive: archive,
+	}
+}
+
+// ListHandler handles GET /wa/debug/events - returns the latest archived raw events of a session
+func (h *Handlers) ListHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	limit := defaultListLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit, must be between 1 and 1000"})
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := h.archive.Tail(user, limit)
+	if err != nil {
+		h.app.Logger.Printf("Read event archive error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if entries == nil {
+		entries = []Entry{}
+	}
+
+	c.JSON(http.StatusOK, ListResponse{User: user, Entries: entries, Total: len(entries)})
+}
+
+// ClearHandler handles DELETE /wa/debug/events - deletes the archived raw events of a session
+func (h *Handlers) ClearHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	if err := h.archive.Clear(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"msg": "Event archive deleted", "user": user})
+}
@@ -0,0 +1,27 @@
+package eventlog
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Bounds for archive listings
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// Entry is one archived raw event
+type Entry struct {
+	Time  time.Time       `json:"time"`
+	Type  string          `json:"type"`            // Go type of the whatsmeow event, e.g. *events.Receipt
+	Event json.RawMessage `json:"event,omitempty"` // JSON encoding of the event
+	Dump  string          `json:"dump,omitempty"`  // %+v dump, for events that cannot be encoded as JSON
+}
+
+// ListResponse is the tail of a session's archive
+type ListResponse struct {
+	User    string  `json:"user"`
+	Entries []Entry `json:"entries"`
+	Total   int     `json:"total"`
+}
@@ -0,0 +1,10 @@
+package group
+
+import "errors"
+
+var (
+	// ErrInvalidName is returned when a group name is empty or too long
+	ErrInvalidName = errors.New("invalid group name")
+	// ErrInvalidParticipant is returned when a participant is not a phone number or user JID
+	ErrInvalidParticipant = errors.New("invalid participant")
+)
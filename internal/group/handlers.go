@@ -0,0 +1,189 @@
+package group
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/auth"
+)
+
+// Handlers contains HTTP handlers for group management
+type Handlers struct {
+	app     *app.App
+	service *Service
+}
+
+// NewHandlers creates a new group handlers instance
+func NewHandlers(app *app.App) *Handlers {
+	return &Handlers{
+		app:     app,
+		service: NewService(app),
+	}
+}
+
+// GetParticipantsHandler handles GET /group/participants - returns the members of a group
+func (h *Handlers) GetParticipantsHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	jid := c.Query("jid")
+	if jid == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing jid"})
+		return
+	}
+
+	response, err := h.service.GetParticipants(user, jid)
+	if err != nil {
+		h.app.Logger.Printf("Get group participants error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get group participants",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// groupQuery reads the user and jid query parameters, writing a 400 response when either is missing
+func groupQuery(c *gin.Context) (string, string, bool) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return "", "", false
+	}
+
+	jid := c.Query("jid")
+	if jid == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing jid"})
+		return "", "", false
+	}
+	return user, jid, true
+}
+
+// GetInfoHandler handles GET /group/info - returns the name, description and settings of a group
+func (h *Handlers) GetInfoHandler(c *gin.Context) {
+	user, jid, ok := groupQuery(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.GetInfo(user, jid)
+	if err != nil {
+		h.app.Logger.Printf("Get group info error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get group info",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetInviteHandler handles GET /group/invite - returns the invite link of a group
+func (h *Handlers) GetInviteHandler(c *gin.Context) {
+	user, jid, ok := groupQuery(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.GetInviteLink(user, jid)
+	if err != nil {
+		h.app.Logger.Printf("Get group invite link error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get group invite link",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetInviteQRHandler handles GET /group/invite.png - returns the invite link of a group rendered as a QR code
+func (h *Handlers) GetInviteQRHandler(c *gin.Context) {
+	user, jid, ok := groupQuery(c)
+	if !ok {
+		return
+	}
+
+	opts, ok := auth.ParseQROptions(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.GetInviteLink(user, jid)
+	if err != nil {
+		h.app.Logger.Printf("Get group invite link error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get group invite link",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if opts.Format == auth.QRFormatRaw {
+		c.String(http.StatusOK, response.InviteLink)
+		return
+	}
+
+	png, err := auth.RenderQRCodePNG(response.InviteLink, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// CreateHandler handles POST /group/create - creates a group with the given participants
+func (h *Handlers) CreateHandler(c *gin.Context) {
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request. Required: {\"user\": \"username\", \"name\": \"group name\"}"})
+		return
+	}
+
+	response, err := h.service.CreateGroup(req.User, req.Name, req.Participants)
+	if err != nil {
+		if errors.Is(err, ErrInvalidName) || errors.Is(err, ErrInvalidParticipant) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.app.Logger.Printf("Create group error for user %s: %v", req.User, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create group",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListHandler handles POST /group/list - returns the groups the session is a member of
+func (h *Handlers) ListHandler(c *gin.Context) {
+	var req ListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request. Required: {\"user\": \"username\"}"})
+		return
+	}
+
+	response, err := h.service.ListGroups(req.User)
+	if err != nil {
+		h.app.Logger.Printf("List groups error for user %s: %v", req.User, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list groups",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,85 @@
+package group
+
+import "time"
+
+// Participant represents a member of a WhatsApp group
+type Participant struct {
+	JID          string `json:"jid"`                    // Primary JID used to message the participant
+	PhoneNumber  string `json:"phone_number,omitempty"` // Phone number, if known
+	LID          string `json:"lid,omitempty"`          // Linked identity JID, if known
+	Name         string `json:"name"`                   // Resolved name from the contact store
+	IsAdmin      bool   `json:"is_admin"`
+	IsSuperAdmin bool   `json:"is_super_admin"`
+}
+
+// ParticipantsResponse represents the response for group participant listing
+type ParticipantsResponse struct {
+	GroupJID     string        `json:"group_jid"`
+	Name         string        `json:"name"`
+	Participants []Participant `json:"participants"`
+	Total        int           `json:"total"`
+	User         string        `json:"user"`
+}
+
+// InfoResponse represents a group's details, including its description
+type InfoResponse struct {
+	GroupJID         string     `json:"group_jid"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	DescriptionSetAt *time.Time `json:"description_set_at,omitempty"`
+	Owner            string     `json:"owner,omitempty"`
+	CreatedAt        *time.Time `json:"created_at,omitempty"`
+	ParticipantCount int        `json:"participant_count"`
+	IsAnnounce       bool       `json:"is_announce"` // Only admins can send messages
+	IsLocked         bool       `json:"is_locked"`   // Only admins can edit group info
+	User             string     `json:"user"`
+}
+
+// InviteResponse represents a group's invite link
+type InviteResponse struct {
+	GroupJID   string `json:"group_jid"`
+	InviteLink string `json:"invite_link"`
+	User       string `json:"user"`
+}
+
+// CreateRequest represents the request body for creating a group
+type CreateRequest struct {
+	User         string   `json:"user" binding:"required"`
+	Name         string   `json:"name" binding:"required"`
+	Participants []string `json:"participants"` // Phone numbers or JIDs; the session is added implicitly
+}
+
+// CreateResponse represents a newly created group
+type CreateResponse struct {
+	InfoResponse
+	FailedParticipants []FailedParticipant `json:"failed_participants,omitempty"`
+}
+
+// FailedParticipant is a participant WhatsApp did not add to a new group
+type FailedParticipant struct {
+	JID   string `json:"jid"`
+	Error int    `json:"error"` // WhatsApp error code, e.g. 403 when the participant's privacy settings forbid it
+}
+
+// ListRequest represents the request body for listing groups
+type ListRequest struct {
+	User string `json:"user" binding:"required"`
+}
+
+// GroupSummary represents a group the session is a member of
+type GroupSummary struct {
+	GroupJID         string     `json:"group_jid"`
+	Name             string     `json:"name"`
+	Owner            string     `json:"owner,omitempty"`
+	CreatedAt        *time.Time `json:"created_at,omitempty"`
+	ParticipantCount int        `json:"participant_count"`
+	IsAnnounce       bool       `json:"is_announce"`
+	IsLocked         bool       `json:"is_locked"`
+}
+
+// ListResponse represents the response for group listing
+type ListResponse struct {
+	Groups []GroupSummary `json:"groups"`
+	Total  int            `json:"total"`
+	User   string         `json:"user"`
+}
@@ -0,0 +1,311 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"github.com/neekaru/whatsappgo-bot/internal/contact"
+	"github.com/neekaru/whatsappgo-bot/internal/utils"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Service handles group-related operations
+type Service struct {
+	app *app.App
+}
+
+// NewService creates a new group service
+func NewService(app *app.App) *Service {
+	return &Service{
+		app: app,
+	}
+}
+
+// parseGroupJID parses a group JID, accepting the bare group ID without the server part
+func parseGroupJID(jid string) (types.JID, error) {
+	jid = strings.TrimSpace(jid)
+	if jid == "" {
+		return types.JID{}, fmt.Errorf("group jid is empty")
+	}
+	if !strings.Contains(jid, "@") {
+		jid += "@" + types.GroupServer
+	}
+
+	parsed, err := types.ParseJID(jid)
+	if err != nil {
+		return types.JID{}, fmt.Errorf("invalid group jid: %v", err)
+	}
+	if parsed.Server != types.GroupServer {
+		return types.JID{}, fmt.Errorf("jid %s is not a group", jid)
+	}
+	return parsed, nil
+}
+
+// loggedInClient returns the client for a user if it is logged in
+func (s *Service) loggedInClient(user string) (*client.Client, error) {
+	whatsappClient, exists := s.app.GetClientManager().GetClient(user)
+	if !exists {
+		return nil, fmt.Errorf("client not found for user %s", user)
+	}
+
+	if !whatsappClient.IsLoggedIn() {
+		return nil, fmt.Errorf("client is not logged in")
+	}
+
+	return whatsappClient, nil
+}
+
+// resolveName looks up a participant's name in the contact store, trying each known JID
+func resolveName(ctx context.Context, whatsappClient *client.Client, jids ...types.JID) string {
+	for _, jid := range jids {
+		if jid.IsEmpty() {
+			continue
+		}
+		info, err := whatsappClient.WhatsmeowClient.Store.Contacts.GetContact(ctx, jid)
+		if err != nil || !info.Found {
+			continue
+		}
+		if name := contact.DisplayName(info); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// GetParticipants retrieves the participants of a group with admin flags and resolved names
+func (s *Service) GetParticipants(user, groupJID string) (*ParticipantsResponse, error) {
+	jid, err := parseGroupJID(groupJID)
+	if err != nil {
+		return nil, err
+	}
+
+	whatsappClient, err := s.loggedInClient(user)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	info, err := whatsappClient.WhatsmeowClient.GetGroupInfo(ctx, jid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group info: %v", err)
+	}
+
+	participants := make([]Participant, 0, len(info.Participants))
+	for _, p := range info.Participants {
+		participant := Participant{
+			JID:          p.JID.String(),
+			Name:         resolveName(ctx, whatsappClient, p.JID, p.PhoneNumber, p.LID),
+			IsAdmin:      p.IsAdmin,
+			IsSuperAdmin: p.IsSuperAdmin,
+		}
+		if !p.PhoneNumber.IsEmpty() {
+			participant.PhoneNumber = p.PhoneNumber.User
+		}
+		if !p.LID.IsEmpty() {
+			participant.LID = p.LID.String()
+		}
+		if participant.Name == "" {
+			participant.Name = p.DisplayName
+		}
+		participants = append(participants, participant)
+	}
+
+	return &ParticipantsResponse{
+		GroupJID:     info.JID.String(),
+		Name:         info.Name,
+		Participants: participants,
+		Total:        len(participants),
+		User:         user,
+	}, nil
+}
+
+// GetInfo retrieves the name, description and settings of a group
+func (s *Service) GetInfo(user, groupJID string) (*InfoResponse, error) {
+	jid, err := parseGroupJID(groupJID)
+	if err != nil {
+		return nil, err
+	}
+
+	whatsappClient, err := s.loggedInClient(user)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	info, err := whatsappClient.WhatsmeowClient.GetGroupInfo(ctx, jid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group info: %v", err)
+	}
+
+	return newInfoResponse(user, info), nil
+}
+
+// newInfoResponse describes a group's details
+func newInfoResponse(user string, info *types.GroupInfo) *InfoResponse {
+	response := &InfoResponse{
+		GroupJID:         info.JID.String(),
+		Name:             info.Name,
+		Description:      info.Topic,
+		DescriptionSetAt: app.OptionalTime(info.TopicSetAt),
+		CreatedAt:        app.OptionalTime(info.GroupCreated),
+		ParticipantCount: len(info.Participants),
+		IsAnnounce:       info.IsAnnounce,
+		IsLocked:         info.IsLocked,
+		User:             user,
+	}
+	if !info.OwnerJID.IsEmpty() {
+		response.Owner = info.OwnerJID.String()
+	}
+	return response
+}
+
+// GetInviteLink retrieves the invite link of a group. The session must be an
+// admin of the group.
+func (s *Service) GetInviteLink(user, groupJID string) (*InviteResponse, error) {
+	jid, err := parseGroupJID(groupJID)
+	if err != nil {
+		return nil, err
+	}
+
+	whatsappClient, err := s.loggedInClient(user)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	link, err := whatsappClient.WhatsmeowClient.GetGroupInviteLink(ctx, jid, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group invite link: %v", err)
+	}
+
+	return &InviteResponse{
+		GroupJID:   jid.String(),
+		InviteLink: link,
+		User:       user,
+	}, nil
+}
+
+// maxNameLength is the longest group name WhatsApp accepts, in characters
+const maxNameLength = 100
+
+// parseParticipant parses a participant given as a phone number or user JID
+func parseParticipant(participant string) (types.JID, error) {
+	jid, err := utils.ChatJID(participant)
+	if err != nil {
+		return types.JID{}, fmt.Errorf("%w: %v", ErrInvalidParticipant, err)
+	}
+	if jid.Server != types.DefaultUserServer && jid.Server != types.HiddenUserServer {
+		return types.JID{}, fmt.Errorf("%w: %s is not a user", ErrInvalidParticipant, participant)
+	}
+	if jid.User == "" {
+		return types.JID{}, fmt.Errorf("%w: %q", ErrInvalidParticipant, participant)
+	}
+	return jid.ToNonAD(), nil
+}
+
+// CreateGroup creates a group with the session as its owner and the given
+// participants. Participants WhatsApp refused to add are reported rather than
+// failing the whole request.
+func (s *Service) CreateGroup(user, name string, participants []string) (*CreateResponse, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is empty", ErrInvalidName)
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return nil, fmt.Errorf("%w: name is longer than %d characters", ErrInvalidName, maxNameLength)
+	}
+
+	jids := make([]types.JID, 0, len(participants))
+	for _, participant := range participants {
+		jid, err := parseParticipant(participant)
+		if err != nil {
+			return nil, err
+		}
+		jids = append(jids, jid)
+	}
+
+	whatsappClient, err := s.loggedInClient(user)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	info, err := whatsappClient.WhatsmeowClient.CreateGroup(ctx, whatsmeow.ReqCreateGroup{
+		Name:         name,
+		Participants: jids,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group: %v", err)
+	}
+	s.app.Logger.Printf("User %s created group %s with %d participants", user, info.JID, len(jids))
+
+	response := &CreateResponse{InfoResponse: *newInfoResponse(user, info)}
+	for _, p := range info.Participants {
+		if p.Error != 0 {
+			response.FailedParticipants = append(response.FailedParticipants, FailedParticipant{
+				JID:   p.JID.String(),
+				Error: p.Error,
+			})
+		}
+	}
+	return response, nil
+}
+
+// ListGroups lists the groups the session is a member of, sorted by name
+func (s *Service) ListGroups(user string) (*ListResponse, error) {
+	whatsappClient, err := s.loggedInClient(user)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	joined, err := whatsappClient.WhatsmeowClient.GetJoinedGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get joined groups: %v", err)
+	}
+
+	groups := make([]GroupSummary, 0, len(joined))
+	for _, info := range joined {
+		summary := GroupSummary{
+			GroupJID:         info.JID.String(),
+			Name:             info.Name,
+			CreatedAt:        app.OptionalTime(info.GroupCreated),
+			ParticipantCount: len(info.Participants),
+			IsAnnounce:       info.IsAnnounce,
+			IsLocked:         info.IsLocked,
+		}
+		if !info.OwnerJID.IsEmpty() {
+			summary.Owner = info.OwnerJID.String()
+		}
+		groups = append(groups, summary)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Name != groups[j].Name {
+			return groups[i].Name < groups[j].Name
+		}
+		return groups[i].GroupJID < groups[j].GroupJID
+	})
+
+	return &ListResponse{
+		Groups: groups,
+		Total:  len(groups),
+		User:   user,
+	}, nil
+}
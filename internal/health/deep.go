@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// How long the WhatsApp round-trip may take before the check fails
+	deepCheckTimeout = 10 * time.Second
+	// How long a result is reused, so frequent probes do not hit WhatsApp each time
+	deepCheckCacheTTL = 10 * time.Second
+)
+
+// DeepCheckResult is the outcome of a round-trip check through a canary session
+type DeepCheckResult struct {
+	Status      string    `json:"status"` // ok or unavailable
+	User        string    `json:"user"`
+	LoggedIn    bool      `json:"logged_in"`
+	Connected   bool      `json:"connected"`
+	RoundTripMs int64     `json:"round_trip_ms,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CheckedAt   time.Time `json:"checked_at"`
+	Cached      bool      `json:"cached"`
+}
+
+// deepCheckCache keeps the latest result per user
+type deepCheckCache struct {
+	mu      sync.Mutex
+	results map[string]DeepCheckResult
+}
+
+// DeepHealthHandler handles GET /health/deep - checks WhatsApp reachability end to end
+// by making a server round-trip through the canary session
+func (h *Handlers) DeepHealthHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		user = h.canaryUser
+	}
+	if user == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No canary session configured, set HEALTH_CANARY_USER or pass user"})
+		return
+	}
+
+	result := h.deepCheck(user)
+	status := http.StatusOK
+	if result.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, result)
+}
+
+// deepCheck returns a recent cached result or runs a new round-trip
+func (h *Handlers) deepCheck(user string) DeepCheckResult {
+	h.deep.mu.Lock()
+	defer h.deep.mu.Unlock()
+
+	if result, ok := h.deep.results[user]; ok && time.Since(result.CheckedAt) < deepCheckCacheTTL {
+		result.Cached = true
+		return result
+	}
+
+	result := h.roundTrip(user)
+	h.deep.results[user] = result
+	if result.Status != "ok" {
+		h.app.Logger.Printf("Warning: deep health check through session %s failed: %s", user, result.Error)
+	}
+	return result
+}
+
+// roundTrip fetches the account's privacy settings from the server, bypassing
+// the cache, which needs a working connection and a live login
+func (h *Handlers) roundTrip(user string) DeepCheckResult {
+	result := DeepCheckResult{Status: "unavailable", User: user, CheckedAt: time.Now()}
+
+	whatsappClient, ok := h.app.GetClientManager().GetClient(user)
+	if !ok {
+		result.Error = "session not found"
+		return result
+	}
+	result.LoggedIn = whatsappClient.IsLoggedIn()
+	result.Connected = whatsappClient.IsConnected()
+	if !result.Connected {
+		result.Error = "session is not connected"
+		return result
+	}
+	if !result.LoggedIn {
+		result.Error = "session is not logged in"
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deepCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := whatsappClient.WhatsmeowClient.TryFetchPrivacySettings(ctx, true); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.RoundTripMs = time.Since(start).Milliseconds()
+	result.Status = "ok"
+	return result
+}
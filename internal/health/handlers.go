@@ -1,21 +1,42 @@
 package health
 
 import (
+	"fmt"
 	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/circuit"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"github.com/neekaru/whatsappgo-bot/internal/sink"
 )
 
+// Version of the service, reported by / and /version
+const Version = "1.0.2"
+
 // Handlers contains HTTP handlers for health checks
 type Handlers struct {
-	app *app.App
+	app        *app.App
+	canaryUser string
+	features   map[string]bool
+	deep       *deepCheckCache
 }
 
-// NewHandlers creates a new health handlers instance
-func NewHandlers(app *app.App) *Handlers {
-	return &Handlers{app: app}
+// NewHandlers creates a new health handlers instance. canaryUser is the session
+// used by the deep health check; it may be empty. features are the states of
+// the feature flags, reported by /version.
+func NewHandlers(app *app.App, canaryUser string, features map[string]bool) *Handlers {
+	return &Handlers{
+		app:        app,
+		canaryUser: canaryUser,
+		features:   features,
+		deep:       &deepCheckCache{results: make(map[string]DeepCheckResult)},
+	}
 }
 
 // RootHandler handles the root endpoint for Docker health checks
@@ -63,7 +84,7 @@ func (h *Handlers) RootHandler(c *gin.Context) {
 		"status":        "ok",
 		"uptime":        uptime,
 		"session_count": sessionCount,
-		"version":       "1.0.2",
+		"version":       Version,
 	})
 }
 
@@ -119,13 +140,52 @@ func (h *Handlers) HealthCheckHandler(c *gin.Context) {
 	// Log health check access for debugging
 	h.app.Logger.Printf("Health check requested from %s", c.ClientIP())
 
-	// Always return 200 OK status
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"status":          "ok",
 		"uptime":          uptime,
 		"total_sessions":  sessionCount,
 		"active_sessions": activeCount,
 		"timestamp":       time.Now().Format(time.RFC3339),
+	}
+	if disk, ok := h.app.Disk.Status(); ok {
+		resp["disk"] = disk
+	}
+
+	// Always return 200 OK status
+	c.JSON(http.StatusOK, resp)
+}
+
+// RuntimeHandler handles GET /admin/runtime - reports the Go runtime's
+// state and the disk usage of the service
+func (h *Handlers) RuntimeHandler(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	resp := gin.H{
+		"uptime":     time.Since(h.app.StartTime).String(),
+		"go_version": runtime.Version(),
+		"goroutines": runtime.NumGoroutine(),
+		"cpus":       runtime.NumCPU(),
+		"memory": gin.H{
+			"alloc_bytes":  mem.Alloc,
+			"sys_bytes":    mem.Sys,
+			"heap_objects": mem.HeapObjects,
+			"num_gc":       mem.NumGC,
+		},
+	}
+	if disk, ok := h.app.Disk.Status(); ok {
+		resp["disk"] = disk
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// VersionHandler handles GET /version - reports the service version and
+// which experimental features are turned on
+func (h *Handlers) VersionHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"version":    Version,
+		"go_version": runtime.Version(),
+		"features":   h.features,
 	})
 }
 
@@ -133,3 +193,95 @@ func (h *Handlers) HealthCheckHandler(c *gin.Context) {
 func (h *Handlers) HealthCheckHandlerWithSlash(c *gin.Context) {
 	h.HealthCheckHandler(c)
 }
+
+// MetricsHandler exposes per-session counters in the Prometheus text format
+func (h *Handlers) MetricsHandler(c *gin.Context) {
+	clients := h.app.GetClientManager().GetAllClients()
+
+	ids := make([]string, 0, len(clients))
+	for id := range clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	metrics := []struct {
+		name  string
+		help  string
+		value func(client.MetricsSnapshot) uint64
+	}{
+		{"whatsapp_messages_sent_total", "Messages sent per session", func(m client.MetricsSnapshot) uint64 { return m.MessagesSent }},
+		{"whatsapp_messages_received_total", "Messages received per session", func(m client.MetricsSnapshot) uint64 { return m.MessagesReceived }},
+		{"whatsapp_bytes_uploaded_total", "Media bytes uploaded per session", func(m client.MetricsSnapshot) uint64 { return m.BytesUploaded }},
+		{"whatsapp_reconnects_total", "Reconnect attempts per session", func(m client.MetricsSnapshot) uint64 { return m.Reconnects }},
+		{"whatsapp_qr_generated_total", "QR codes generated per session", func(m client.MetricsSnapshot) uint64 { return m.QRGenerated }},
+		{"whatsapp_watchdog_remediations_total", "Forced reconnects by the watchdog per session", func(m client.MetricsSnapshot) uint64 { return m.Remediations }},
+	}
+
+	snapshots := make(map[string]client.MetricsSnapshot, len(ids))
+	for _, id := range ids {
+		snapshots[id] = clients[id].Metrics()
+	}
+
+	var b strings.Builder
+	for _, metric := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(&b, "# TYPE %s counter\n", metric.name)
+		for _, id := range ids {
+			fmt.Fprintf(&b, "%s{user=%q} %d\n", metric.name, id, metric.value(snapshots[id]))
+		}
+	}
+
+	fmt.Fprintf(&b, "# HELP whatsapp_http_panics_total Panics recovered in HTTP handlers\n")
+	fmt.Fprintf(&b, "# TYPE whatsapp_http_panics_total counter\n")
+	fmt.Fprintf(&b, "whatsapp_http_panics_total %d\n", h.app.PanicCount.Load())
+
+	breakers := circuit.All()
+	fmt.Fprintf(&b, "# HELP whatsapp_circuit_breaker_state Circuit breaker state (0 closed, 1 open, 2 half-open)\n")
+	fmt.Fprintf(&b, "# TYPE whatsapp_circuit_breaker_state gauge\n")
+	for _, stats := range breakers {
+		fmt.Fprintf(&b, "whatsapp_circuit_breaker_state{breaker=%q} %d\n", stats.Name, breakerStateValue(stats.State))
+	}
+	breakerCounters := []struct {
+		name  string
+		help  string
+		value func(circuit.Stats) uint64
+	}{
+		{"whatsapp_circuit_breaker_successes_total", "Calls that succeeded through the circuit breaker", func(s circuit.Stats) uint64 { return s.Successes }},
+		{"whatsapp_circuit_breaker_failures_total", "Calls that failed through the circuit breaker", func(s circuit.Stats) uint64 { return s.Failures }},
+		{"whatsapp_circuit_breaker_rejections_total", "Calls rejected while the circuit breaker was open", func(s circuit.Stats) uint64 { return s.Rejections }},
+		{"whatsapp_circuit_breaker_opens_total", "Times the circuit breaker opened", func(s circuit.Stats) uint64 { return s.Opens }},
+	}
+	for _, metric := range breakerCounters {
+		fmt.Fprintf(&b, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(&b, "# TYPE %s counter\n", metric.name)
+		for _, stats := range breakers {
+			fmt.Fprintf(&b, "%s{breaker=%q} %d\n", metric.name, stats.Name, metric.value(stats))
+		}
+	}
+
+	fmt.Fprintf(&b, "# HELP whatsapp_event_dispatch_seconds Time from receiving a WhatsApp event to delivering it to a sink\n")
+	fmt.Fprintf(&b, "# TYPE whatsapp_event_dispatch_seconds histogram\n")
+	for _, stats := range sink.DispatchLatency() {
+		labels := fmt.Sprintf("event=%q,sink=%q", stats.Event, stats.Sink)
+		for i, bound := range stats.Buckets {
+			fmt.Fprintf(&b, "whatsapp_event_dispatch_seconds_bucket{%s,le=%q} %d\n", labels, strconv.FormatFloat(bound, 'g', -1, 64), stats.Counts[i])
+		}
+		fmt.Fprintf(&b, "whatsapp_event_dispatch_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, stats.Count)
+		fmt.Fprintf(&b, "whatsapp_event_dispatch_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(stats.Sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "whatsapp_event_dispatch_seconds_count{%s} %d\n", labels, stats.Count)
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
+
+// breakerStateValue maps a circuit breaker state name to its gauge value
+func breakerStateValue(state string) int {
+	switch state {
+	case circuit.StateOpen.String():
+		return 1
+	case circuit.StateHalfOpen.String():
+		return 2
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,129 @@
+// Package langdetect guesses the language of short chat messages. It is
+// deliberately lightweight: languages with their own script are recognised by
+// script, and Latin-script languages by their most common words.
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// scripts maps Unicode scripts to the language written in them, checked in
+// order so Japanese kana wins over the Han characters mixed into it
+var scripts = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Thai, "th"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Bengali, "bn"},
+	{unicode.Tamil, "ta"},
+	{unicode.Cyrillic, "ru"},
+}
+
+// ukrainianLetters are Cyrillic letters used in Ukrainian but not Russian
+const ukrainianLetters = "іїєґ"
+
+// stopwords are frequent words of Latin-script languages
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "you", "to", "of", "it", "this", "that", "what", "for", "with", "have", "my", "your", "please", "thanks", "thank", "can", "not", "was", "will", "how", "hello", "yes", "would", "there"},
+	"id": {"yang", "dan", "di", "ini", "itu", "tidak", "saya", "aku", "kamu", "apa", "ada", "untuk", "dengan", "ke", "dari", "sudah", "belum", "bisa", "mau", "tolong", "terima", "kasih", "selamat", "pagi", "siang", "malam", "juga", "akan", "kami", "kita", "anda", "bagaimana", "berapa", "gak", "nggak", "sih", "dong", "kok", "mohon", "bapak", "ibu"},
+	"es": {"el", "la", "los", "las", "que", "y", "es", "en", "un", "una", "por", "para", "con", "no", "pero", "muy", "gracias", "hola", "como", "está", "estoy", "qué", "yo", "usted", "del", "buenos", "días"},
+	"pt": {"o", "os", "as", "que", "e", "é", "em", "um", "uma", "não", "para", "com", "mas", "muito", "obrigado", "obrigada", "olá", "você", "está", "eu", "do", "da", "bom", "dia", "tudo", "isso"},
+	"fr": {"le", "la", "les", "et", "est", "un", "une", "des", "je", "vous", "tu", "pas", "pour", "avec", "merci", "bonjour", "oui", "non", "mais", "très", "c'est", "ce", "du", "au", "suis", "nous"},
+	"de": {"der", "die", "das", "und", "ist", "ich", "nicht", "sie", "du", "ein", "eine", "mit", "für", "auf", "danke", "hallo", "ja", "nein", "aber", "sehr", "wie", "bitte", "haben", "wir", "auch", "guten"},
+	"it": {"il", "lo", "gli", "che", "e", "è", "un", "una", "non", "per", "con", "ma", "molto", "grazie", "ciao", "sono", "come", "sì", "della", "del", "io", "buongiorno", "questo", "anche"},
+	"nl": {"de", "het", "een", "en", "is", "ik", "je", "niet", "van", "dat", "met", "voor", "op", "maar", "dank", "bedankt", "hallo", "ja", "nee", "zijn", "wat", "hoe", "graag", "wij", "ook"},
+}
+
+// stopwordLangs indexes stopwords by word
+var stopwordLangs = func() map[string][]string {
+	index := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, word := range words {
+			index[word] = append(index[word], lang)
+		}
+	}
+	return index
+}()
+
+// Detect returns the ISO 639-1 code of the language text is most likely
+// written in, or "" when it cannot tell, for example for very short or mixed
+// text
+func Detect(text string) string {
+	if lang := detectScript(text); lang != "" {
+		return lang
+	}
+	return detectWords(text)
+}
+
+// detectScript returns the language of the dominant non-Latin script, if any
+func detectScript(text string) string {
+	counts := make(map[string]int)
+	latin, other := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for _, script := range scripts {
+			if unicode.Is(script.table, r) {
+				counts[script.lang]++
+				other++
+				break
+			}
+		}
+	}
+	if other == 0 || other < latin {
+		return ""
+	}
+
+	for _, script := range scripts {
+		if counts[script.lang] == 0 {
+			continue
+		}
+		if script.lang == "ru" && strings.ContainsAny(strings.ToLower(text), ukrainianLetters) {
+			return "uk"
+		}
+		return script.lang
+	}
+	return ""
+}
+
+// detectWords scores Latin-script text by its stopwords, returning the best
+// scoring language only when it is unambiguous
+func detectWords(text string) string {
+	scores := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		for _, lang := range stopwordLangs[word] {
+			scores[lang]++
+		}
+	}
+
+	best, bestScore, runnerUp := "", 0, 0
+	for lang, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, runnerUp = lang, score, bestScore
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+	if bestScore == 0 || bestScore == runnerUp {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,22 @@
+package media
+
+import "errors"
+
+var (
+	// ErrPhoneEmpty is returned when media is sent without a phone number
+	ErrPhoneEmpty = errors.New("phone number is empty, cannot send media")
+	// ErrPhoneInvalid is returned when the phone number is not all digits with an optional '+'
+	ErrPhoneInvalid = errors.New("phone number is invalid, must be all digits or start with '+' followed by digits")
+	// ErrDownloadFailed is returned when media could not be downloaded from its URL
+	ErrDownloadFailed = errors.New("failed to download media")
+	// ErrInvalidFormat is returned when inline media data is not valid base64
+	ErrInvalidFormat = errors.New("invalid media format")
+	// ErrUploadFailed is returned when WhatsApp did not accept the media upload
+	ErrUploadFailed = errors.New("failed to upload media")
+	// ErrSendFailed is returned when the media message could not be sent
+	ErrSendFailed = errors.New("failed to send media message")
+	// ErrInvalidMediaType is returned for media types other than image, video and file
+	ErrInvalidMediaType = errors.New("invalid media type")
+	// ErrHandleTypeMismatch is returned when a media handle is sent as another media type than it was uploaded as
+	ErrHandleTypeMismatch = errors.New("media handle was uploaded as another media type")
+)
@@ -1,11 +1,15 @@
 package media
 
 import (
+	"errors"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/contentfilter"
+	"github.com/neekaru/whatsappgo-bot/internal/optin"
 )
 
 // Handlers contains HTTP handlers for media
@@ -37,6 +41,16 @@ func (h *Handlers) SendVideoHandler(c *gin.Context) {
 	h.sendMediaHandler(c, "video")
 }
 
+// SendVoiceHandler handles sending a voice note, transcoded from any audio format
+func (h *Handlers) SendVoiceHandler(c *gin.Context) {
+	h.sendMediaHandler(c, "voice")
+}
+
+// SendAudioHandler handles sending audio played inline as a file, unlike a voice note
+func (h *Handlers) SendAudioHandler(c *gin.Context) {
+	h.sendMediaHandler(c, "audio")
+}
+
 // sendMediaHandler is a common handler for sending media
 func (h *Handlers) sendMediaHandler(c *gin.Context, mediaType string) {
 	var req SendMediaRequest
@@ -45,29 +59,126 @@ func (h *Handlers) sendMediaHandler(c *gin.Context, mediaType string) {
 		return
 	}
 
-	fileName, err := h.service.SendMedia(
+	if _, err := app.ParseSendPriority(req.Priority); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Options.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Handle != "" && (req.Media != "" || req.URL != "" || req.MimeType != "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "handle cannot be combined with media, url or mime_type"})
+		return
+	}
+	if mediaType == "voice" && req.Caption != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "voice notes cannot have a caption"})
+		return
+	}
+	if mediaType == "audio" && req.Caption != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "audio cannot have a caption"})
+		return
+	}
+
+	now := time.Now()
+	sendAfter, err := app.ParseSendAfter(req.SendAfter, now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expiresAt, err := app.ParseExpiresAt(req.ExpiresAt, now, sendAfter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if sendAfter.After(now) {
+		h.scheduleMedia(c, req, mediaType, sendAfter, expiresAt)
+		return
+	}
+
+	result, err := h.service.SendMedia(
 		req.User,
 		req.PhoneNumber,
 		mediaType,
 		req.Media,
 		req.URL,
+		req.Handle,
 		req.Caption,
 		req.FileName,
+		req.MimeType,
+		req.Priority,
+		expiresAt,
+		req.Options,
 	)
 	if err != nil {
-		// Log the detailed error
-		h.app.Logger.Printf("Media send error for type %s: %v", mediaType, err)
+		var tooLong *app.TooLongError
+		if errors.As(err, &tooLong) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "Message too long",
+				"details": err.Error(),
+				"field":   tooLong.Field,
+				"length":  tooLong.Length,
+				"limit":   tooLong.Limit,
+			})
+			return
+		}
+
+		var rejected *contentfilter.RejectedError
+		if errors.As(err, &rejected) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":      "Message rejected by content filter",
+				"details":    err.Error(),
+				"violations": rejected.Violations,
+			})
+			return
+		}
+
+		if errors.Is(err, app.ErrReceiveOnly) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Session is receive-only",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		if h.handleError(c, err, mediaType) || validationError(c, err) {
+			return
+		}
 
-		// Special handling for empty phone number
-		if err.Error() == "phone number is empty, cannot send media" {
+		if errors.Is(err, optin.ErrOptInPending) {
 			c.JSON(http.StatusOK, gin.H{
-				"error":   "Media cannot be send",
+				"warn":    "Recipient has not opted in",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		var throttled *app.ThrottledError
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":               "WhatsApp is throttling this session",
+				"details":             err.Error(),
+				"retry_after_seconds": throttled.RetryAfterSeconds(),
+			})
+			return
+		}
+
+		if errors.Is(err, app.ErrMessageExpired) {
+			h.app.Logger.Printf("Media from user %s to %s expired before it could be sent", req.User, req.PhoneNumber)
+			c.JSON(http.StatusOK, gin.H{
+				"error":   "Media expired",
 				"details": err.Error(),
 			})
 			return
 		}
-		// Special handling for invalid phone number format
-		if err.Error() == "phone number is invalid, must contain only digits" {
+
+		// Log the detailed error
+		h.app.Logger.Printf("Media send error for type %s: %v", mediaType, err)
+
+		// Special handling for an empty or invalid phone number
+		if errors.Is(err, ErrPhoneEmpty) || errors.Is(err, ErrPhoneInvalid) {
 			c.JSON(http.StatusOK, gin.H{
 				"error":   "Media cannot be send",
 				"details": err.Error(),
@@ -76,11 +187,10 @@ func (h *Handlers) sendMediaHandler(c *gin.Context, mediaType string) {
 		}
 
 		// Check if error is related to file/URL access
-		if err.Error() == "failed to download media from URL" ||
-			strings.Contains(err.Error(), "failed to download media") ||
-			strings.Contains(err.Error(), "invalid media format") ||
-			strings.Contains(err.Error(), "failed to upload media") ||
-			strings.Contains(err.Error(), "failed to send media message") {
+		if errors.Is(err, ErrDownloadFailed) ||
+			errors.Is(err, ErrInvalidFormat) ||
+			errors.Is(err, ErrUploadFailed) ||
+			errors.Is(err, ErrSendFailed) {
 			c.JSON(http.StatusOK, gin.H{
 				"msg":     "file/url cannot be send",
 				"details": err.Error(),
@@ -96,8 +206,172 @@ func (h *Handlers) sendMediaHandler(c *gin.Context, mediaType string) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"msg":       mediaType + " sent successfully",
-		"file_name": fileName,
+	c.JSON(http.StatusOK, SendMediaResponse{
+		Msg:             mediaType + " sent successfully",
+		SendMediaResult: result,
+	})
+}
+
+// scheduleMedia holds a media message in the outbox until sendAfter
+func (h *Handlers) scheduleMedia(c *gin.Context, req SendMediaRequest, mediaType string, sendAfter, expiresAt time.Time) {
+	outboxID, err := h.service.ScheduleMedia(
+		req.User,
+		req.PhoneNumber,
+		mediaType,
+		req.Media,
+		req.URL,
+		req.Handle,
+		req.Caption,
+		req.FileName,
+		req.MimeType,
+		req.Priority,
+		sendAfter,
+		expiresAt,
+		req.Options,
+	)
+	if err != nil {
+		var tooLong *app.TooLongError
+		if errors.As(err, &tooLong) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "Message too long",
+				"details": err.Error(),
+				"field":   tooLong.Field,
+				"length":  tooLong.Length,
+				"limit":   tooLong.Limit,
+			})
+			return
+		}
+
+		var rejected *contentfilter.RejectedError
+		if errors.As(err, &rejected) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":      "Message rejected by content filter",
+				"details":    err.Error(),
+				"violations": rejected.Violations,
+			})
+			return
+		}
+
+		if errors.Is(err, app.ErrReceiveOnly) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Session is receive-only",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		if h.handleError(c, err, mediaType) || validationError(c, err) {
+			return
+		}
+
+		if errors.Is(err, optin.ErrOptInPending) {
+			c.JSON(http.StatusOK, gin.H{
+				"warn":    "Recipient has not opted in",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		h.app.Logger.Printf("Media schedule error for type %s: %v", mediaType, err)
+		c.JSON(http.StatusOK, gin.H{
+			"error":   "Media cannot be scheduled",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"msg":        mediaType + " scheduled",
+		"outbox_id":  outboxID,
+		"send_after": sendAfter,
+	})
+}
+
+// handleError responds to errors about the media handle of a send, reporting
+// whether it did
+func (h *Handlers) handleError(c *gin.Context, err error, mediaType string) bool {
+	switch {
+	case errors.Is(err, app.ErrMediaHandleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Media handle not found",
+			"details": err.Error(),
+		})
+	case errors.Is(err, ErrHandleTypeMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Media handle cannot be sent as " + mediaType,
+			"details": err.Error(),
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// validationError responds to media rejected before it was uploaded, reporting
+// whether it did. The code tells callers why without parsing the details.
+func validationError(c *gin.Context, err error) bool {
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   "Media rejected",
+		"code":    invalid.Code,
+		"details": err.Error(),
+	})
+	return true
+}
+
+// UploadHandler uploads media once and returns a handle that sends can
+// reference instead of the media
+func (h *Handlers) UploadHandler(c *gin.Context) {
+	var req UploadMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is required"})
+		return
+	}
+
+	handle, err := h.service.UploadMedia(req.User, req.Type, req.Media, req.URL, req.FileName, req.MimeType)
+	if err != nil {
+		if validationError(c, err) {
+			return
+		}
+
+		var throttled *app.ThrottledError
+		switch {
+		case errors.Is(err, ErrInvalidMediaType):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "type must be image, video, file, voice or audio",
+				"details": err.Error(),
+			})
+		case errors.As(err, &throttled):
+			c.Header("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":               "WhatsApp is throttling this session",
+				"details":             err.Error(),
+				"retry_after_seconds": throttled.RetryAfterSeconds(),
+			})
+		case errors.Is(err, ErrDownloadFailed) || errors.Is(err, ErrInvalidFormat):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Media cannot be read",
+				"details": err.Error(),
+			})
+		default:
+			h.app.Logger.Printf("Media upload error for user %s: %v", req.User, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Media cannot be uploaded",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, UploadMediaResponse{
+		Msg:         "media uploaded successfully",
+		MediaHandle: handle,
 	})
 }
@@ -1,11 +1,62 @@
 package media
 
+import (
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
 // SendMediaRequest represents a request to send media
 type SendMediaRequest struct {
 	User        string `json:"user"`
 	PhoneNumber string `json:"phone_number"`
 	Media       string `json:"media"`
 	URL         string `json:"url"`
+	Handle      string `json:"handle"` // Optional: handle from /media/upload, instead of media or url
 	Caption     string `json:"caption"`
-	FileName    string `json:"file_name"` // Optional filename parameter
+	FileName    string `json:"file_name"`  // Optional filename parameter
+	MimeType    string `json:"mime_type"`  // Optional: declared type, checked against the content
+	Priority    string `json:"priority"`   // Optional: high, normal (default) or low
+	SendAfter   string `json:"send_after"` // Optional: delay such as "5m", or RFC 3339 timestamp; requires url
+	ExpiresAt   string `json:"expires_at"` // Optional: deadline after which the message is dropped, same formats
+
+	Options *app.SendOptions `json:"options"` // Optional: disappearing message settings
+}
+
+// SendMediaResult describes a sent media message. The upload fields are what
+// WhatsApp needs to reference the media again, so it can be audited or sent
+// again without uploading it a second time.
+type SendMediaResult struct {
+	MessageID     string    `json:"message_id"`
+	Timestamp     time.Time `json:"timestamp"`
+	FileName      string    `json:"file_name"`
+	MimeType      string    `json:"mime_type"`
+	Size          uint64    `json:"size"` // Bytes before encryption
+	URL           string    `json:"url"`
+	DirectPath    string    `json:"direct_path"`
+	MediaKey      []byte    `json:"media_key"`       // Base64 in JSON
+	FileSHA256    []byte    `json:"file_sha256"`     // Base64 in JSON
+	FileEncSHA256 []byte    `json:"file_enc_sha256"` // Base64 in JSON
+}
+
+// SendMediaResponse is the response to a media send
+type SendMediaResponse struct {
+	Msg string `json:"msg"`
+	*SendMediaResult
+}
+
+// UploadMediaRequest represents a request to upload media ahead of sending it
+type UploadMediaRequest struct {
+	User     string `json:"user"`
+	Type     string `json:"type"` // image, video, file, voice or audio; media can only be sent as the type it was uploaded as
+	Media    string `json:"media"`
+	URL      string `json:"url"`
+	FileName string `json:"file_name"` // Optional filename parameter
+	MimeType string `json:"mime_type"` // Optional: declared type, checked against the content
+}
+
+// UploadMediaResponse is the response to a media upload
+type UploadMediaResponse struct {
+	Msg string `json:"msg"`
+	*app.MediaHandle
 }
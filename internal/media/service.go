@@ -2,17 +2,14 @@ package media
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
-	"io"
 	"math/rand"
-	"mime"
-	"net/http"
-	"net/url"
 	"strings"
 	"time"
 
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"github.com/neekaru/whatsappgo-bot/internal/optin"
 	"github.com/neekaru/whatsappgo-bot/internal/session"
 	"github.com/neekaru/whatsappgo-bot/internal/utils"
 	"go.mau.fi/whatsmeow"
@@ -25,6 +22,7 @@ import (
 type Service struct {
 	app            *app.App
 	sessionService *session.Service
+	optinService   *optin.Service
 }
 
 // NewService creates a new media service
@@ -32,6 +30,7 @@ func NewService(app *app.App) *Service {
 	return &Service{
 		app:            app,
 		sessionService: session.NewService(app),
+		optinService:   optin.NewService(app),
 	}
 }
 
@@ -46,8 +45,8 @@ func humanDelay(minMs, maxMs int) time.Duration {
 
 // simulateMediaAttach simulates the human behavior of attaching and sending media.
 // This includes going online, showing a composing indicator (as if selecting/attaching
-// a file), then stopping before the actual send.
-func (s *Service) simulateMediaAttach(client *whatsmeow.Client, recipient types.JID) {
+// a file, or recording for audio media), then stopping before the actual send.
+func (s *Service) simulateMediaAttach(client *whatsmeow.Client, recipient types.JID, media types.ChatPresenceMedia) {
 	// 1. Set online presence
 	if err := client.SendPresence(context.Background(), types.PresenceAvailable); err != nil {
 		s.app.Logger.Printf("Warning: failed to send online presence: %v", err)
@@ -57,7 +56,7 @@ func (s *Service) simulateMediaAttach(client *whatsmeow.Client, recipient types.
 	time.Sleep(humanDelay(1000, 3000))
 
 	// 3. Send composing indicator
-	if err := client.SendChatPresence(context.Background(), recipient, types.ChatPresenceComposing, types.ChatPresenceMediaText); err != nil {
+	if err := client.SendChatPresence(context.Background(), recipient, types.ChatPresenceComposing, media); err != nil {
 		s.app.Logger.Printf("Warning: failed to send composing presence: %v", err)
 	}
 
@@ -65,7 +64,7 @@ func (s *Service) simulateMediaAttach(client *whatsmeow.Client, recipient types.
 	time.Sleep(humanDelay(2000, 6000))
 
 	// 5. Stop composing
-	if err := client.SendChatPresence(context.Background(), recipient, types.ChatPresencePaused, types.ChatPresenceMediaText); err != nil {
+	if err := client.SendChatPresence(context.Background(), recipient, types.ChatPresencePaused, media); err != nil {
 		s.app.Logger.Printf("Warning: failed to send paused presence: %v", err)
 	}
 
@@ -73,23 +72,130 @@ func (s *Service) simulateMediaAttach(client *whatsmeow.Client, recipient types.
 	time.Sleep(humanDelay(200, 500))
 }
 
-// SendMedia sends media (image, video, file) to a WhatsApp contact
-func (s *Service) SendMedia(user, phoneNumber, mediaType, mediaData, mediaURL, caption, fileName string) (string, error) {
-	// Use random delay instead of fixed delay to avoid bot detection
-	sendDelay := humanDelay(4000, 10000)
+// SendMedia sends media (image, video, file) to a WhatsApp contact and
+// describes the sent message and uploaded media. The media is given as base64
+// data, a URL, or the ID of a handle uploaded with UploadMedia. mimeType
+// optionally declares the type of data or URL media; media that does not
+// match it, or is not a supported format, fails with a *ValidationError
+// before it is uploaded. A non-zero expiresAt gives up with
+// app.ErrMessageExpired once the deadline passes without a send. options may be nil.
+func (s *Service) SendMedia(user, phoneNumber, mediaType, mediaData, mediaURL, handleID, caption, fileName, mimeType, priority string, expiresAt time.Time, options *app.SendOptions) (*SendMediaResult, error) {
+	priority, err := app.ParseSendPriority(priority)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	caption = s.app.NormalizeContent(user, caption, options)
+	if err := app.CheckLength("caption", caption, app.MaxCaptionLength); err != nil {
+		return nil, err
+	}
+	if mimeType != "" {
+		if _, err := normalizeMimeType(mimeType); err != nil {
+			return nil, err
+		}
+	}
+	return s.sendMedia(user, phoneNumber, mediaType, mediaData, mediaURL, handleID, caption, fileName, mimeType, priority, "", expiresAt, options)
+}
+
+// ScheduleMedia validates a media message and holds it in the outbox until
+// sendAfter, returning its outbox ID. The outbox scheduler sends it once due,
+// unless expiresAt has passed by then. Only media sent by URL or handle can
+// be scheduled, since inline data is not kept in the outbox.
+func (s *Service) ScheduleMedia(user, phoneNumber, mediaType, mediaData, mediaURL, handleID, caption, fileName, mimeType, priority string, sendAfter, expiresAt time.Time, options *app.SendOptions) (string, error) {
+	if mediaURL == "" && handleID == "" {
+		if mediaData != "" {
+			return "", fmt.Errorf("send_after requires media sent by url or handle, inline media data is not retained")
+		}
+		return "", fmt.Errorf("either media, URL or handle must be provided")
+	}
+	priority, err := app.ParseSendPriority(priority)
+	if err != nil {
+		return "", err
+	}
+	if err := options.Validate(); err != nil {
+		return "", err
+	}
+	caption = s.app.NormalizeContent(user, caption, options)
+	if err := app.CheckLength("caption", caption, app.MaxCaptionLength); err != nil {
+		return "", err
+	}
+	if mimeType != "" {
+		if _, err := normalizeMimeType(mimeType); err != nil {
+			return "", err
+		}
+	}
+	if s.app.Options.Get(user).ReceiveOnly {
+		return "", app.ErrReceiveOnly
+	}
+	if err := s.checkRecipient(user, phoneNumber); err != nil {
+		return "", err
+	}
+	if err := s.optinService.Check(user, phoneNumber, priority); err != nil {
+		return "", err
+	}
+	if handleID != "" {
+		handle, err := s.mediaHandle(user, mediaType, handleID)
+		if err != nil {
+			return "", err
+		}
+		if fileName == "" {
+			fileName = handle.FileName
+		}
+	}
+	caption, err = s.app.FilterContent(user, phoneNumber, caption)
+	if err != nil {
+		return "", err
+	}
+
+	outboxID, err := s.app.Outbox.Record(app.OutboxMessage{
+		User:        user,
+		Recipient:   phoneNumber,
+		Type:        mediaType,
+		Body:        caption,
+		FileName:    fileName,
+		MimeType:    mimeType,
+		MediaURL:    mediaURL,
+		MediaHandle: handleID,
+		Priority:    priority,
+		SendAfter:   &sendAfter,
+		ExpiresAt:   app.OptionalTime(expiresAt),
+		Options:     options,
+	})
+	if err != nil {
+		return "", err
+	}
+	s.app.Logger.Printf("Media to %s from user %s scheduled for %s", phoneNumber, user, sendAfter.Format(time.RFC3339))
+	return outboxID, nil
+}
+
+// ResendOutboxMessage sends a dead-lettered or due scheduled media message under its existing outbox ID.
+// Only media sent by URL or handle can be resent, since inline data is not kept in the outbox.
+func (s *Service) ResendOutboxMessage(msg app.OutboxMessage) error {
+	if msg.MediaURL == "" && msg.MediaHandle == "" {
+		return fmt.Errorf("media data was sent inline and is not retained, cannot resend")
+	}
+	_, err := s.sendMedia(msg.User, msg.Recipient, msg.Type, "", msg.MediaURL, msg.MediaHandle, msg.Body, msg.FileName, msg.MimeType, msg.Priority, msg.ID, msg.Deadline(), msg.Options)
+	return err
+}
 
+// checkRecipient validates the recipient of a media message
+func (s *Service) checkRecipient(user, phoneNumber string) error {
 	// Check if phoneNumber is empty or only whitespace
 	if strings.TrimSpace(phoneNumber) == "" {
 		s.app.Logger.Printf("Warning: phone number is empty for user %s", user)
-		return "", fmt.Errorf("phone number is empty, cannot send media")
+		return ErrPhoneEmpty
 	}
 	// Check if phoneNumber is valid: all digits or starts with '+' followed by digits
-	valid := true
-	if phoneNumber[0] == '+' {
-		if len(phoneNumber) == 1 {
+	// LID recipients ("123@lid") are validated on their numeric part
+	number := utils.RecipientNumber(phoneNumber)
+	valid := number != ""
+	if valid && number[0] == '+' {
+		if len(number) == 1 {
 			valid = false
 		} else {
-			for _, c := range phoneNumber[1:] {
+			for _, c := range number[1:] {
 				if c < '0' || c > '9' {
 					valid = false
 					break
@@ -97,7 +203,7 @@ func (s *Service) SendMedia(user, phoneNumber, mediaType, mediaData, mediaURL, c
 			}
 		}
 	} else {
-		for _, c := range phoneNumber {
+		for _, c := range number {
 			if c < '0' || c > '9' {
 				valid = false
 				break
@@ -106,165 +212,119 @@ func (s *Service) SendMedia(user, phoneNumber, mediaType, mediaData, mediaURL, c
 	}
 	if !valid {
 		s.app.Logger.Printf("Warning: phone number is invalid for user %s: %s", user, phoneNumber)
-		return "", fmt.Errorf("phone number is invalid, must be all digits or start with '+' followed by digits")
-	}
-	sess, exists := s.sessionService.FindSessionByUser(user)
-	if !exists {
-		return "", fmt.Errorf("session not found")
+		return ErrPhoneInvalid
 	}
-
-	s.app.SendLimiter.Wait(user, sendDelay)
-
-	// Ensure client is connected before sending
-	if !sess.Client.IsConnected() {
-		err := sess.Client.Connect()
-		if err != nil {
-			return "", fmt.Errorf("failed to connect: %v", err)
+	if whatsappClient, ok := s.app.GetClientManager().GetClient(user); ok {
+		if err := whatsappClient.VerifyPhone(); err != nil {
+			return err
 		}
 	}
 
-	recipient := types.JID{
-		User:   phoneNumber,
-		Server: "s.whatsapp.net",
-	}
-
-	var media []byte
-	var mimeType string
-	var detectedFileName string
+	return nil
+}
 
-	// Set filename if provided
-	if fileName != "" {
-		detectedFileName = fileName
-	}
+// throttled backs the user's sends off after WhatsApp throttled one
+func (s *Service) throttled(user string, err error) error {
+	retryAfter := s.app.SendLimiter.Throttle(user)
+	s.app.Logger.Printf("WhatsApp is throttling user %s, backing off sends for %s", user, retryAfter)
+	return &app.ThrottledError{RetryAfter: retryAfter, Err: err}
+}
 
-	// Check if URL or base64 media is provided
-	if mediaURL != "" {
+// sendMedia sends media and records it in the outbox, reusing outboxID when resending.
+// Media given by handleID was uploaded ahead of time and is not uploaded again.
+// It stops with app.ErrMessageExpired if expiresAt passes before the send.
+func (s *Service) sendMedia(user, phoneNumber, mediaType, mediaData, mediaURL, handleID, caption, fileName, mimeType, priority, outboxID string, expiresAt time.Time, options *app.SendOptions) (_ *SendMediaResult, err error) {
+	// Use random delay instead of fixed delay to avoid bot detection
+	sendDelay := humanDelay(4000, 10000)
 
-		client := &http.Client{
-			Timeout: 30 * time.Second,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				// Allow up to 10 redirects
-				if len(via) >= 10 {
-					return fmt.Errorf("too many redirects")
-				}
-				return nil
-			},
+	// Resent and scheduled messages must leave pending however the send ends
+	var messageID string
+	defer func() {
+		if finishErr := s.app.Outbox.Finish(outboxID, messageID, err); finishErr != nil {
+			s.app.Logger.Printf("Warning: failed to update outbox message %s: %v", outboxID, finishErr)
 		}
+	}()
 
-		// Download media from URL
-		httpResp, err := client.Get(mediaURL)
-		if err != nil {
-			return "", fmt.Errorf("failed to download media from URL")
-		}
-		defer httpResp.Body.Close()
+	if s.app.Options.Get(user).ReceiveOnly {
+		return nil, app.ErrReceiveOnly
+	}
+	if err := s.checkRecipient(user, phoneNumber); err != nil {
+		return nil, err
+	}
+	sess, exists := s.sessionService.FindSessionByUser(user)
+	if !exists {
+		return nil, fmt.Errorf("session not found")
+	}
 
-		if httpResp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("failed to download media")
+	var handle *app.MediaHandle
+	if handleID != "" {
+		if handle, err = s.mediaHandle(user, mediaType, handleID); err != nil {
+			return nil, err
 		}
-
-		// add limiter for 100 mb download size
-		const maxDownloadSize = 100 << 20 // 100 MB
-		limitedReader := io.LimitReader(httpResp.Body, maxDownloadSize)
-		media, err = io.ReadAll(limitedReader)
-		if err != nil {
-			return "", fmt.Errorf("failed to download media")
+		if fileName == "" {
+			fileName = handle.FileName
 		}
+	}
 
-		mimeType = httpResp.Header.Get("Content-Type")
-		if mimeType != "" {
-			if parsedMimeType, _, err := mime.ParseMediaType(mimeType); err == nil {
-				mimeType = parsedMimeType
-			}
+	if outboxID == "" {
+		if err := s.optinService.Check(user, phoneNumber, priority); err != nil {
+			return nil, err
 		}
-		if mimeType == "" {
-			mimeType = http.DetectContentType(media)
+		// Don't queue more sends while WhatsApp is throttling the session
+		if wait := s.app.SendLimiter.ThrottledFor(user); wait > 0 {
+			return nil, &app.ThrottledError{RetryAfter: wait}
 		}
-
-		// Extract filename from URL if not provided
-		if detectedFileName == "" {
-			// Parse URL to extract filename
-			parsedURL, err := url.Parse(mediaURL)
-
-			// add validation for URL parsing
-			if err != nil {
-				s.app.Logger.Printf("Failed to parse media URL: %v", err)
-			}
-
-			if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-				s.app.Logger.Printf("Invalid URL scheme for media: %s", parsedURL.Scheme)
-			}
-
-			if err == nil {
-				// Get the last part of the path
-				parts := strings.Split(parsedURL.Path, "/")
-				if len(parts) > 0 {
-					urlFileName := parts[len(parts)-1]
-					// Remove query parameters if present
-					urlFileName = strings.Split(urlFileName, "?")[0]
-					// Use it if it looks like a valid filename
-					if urlFileName != "" && !strings.HasSuffix(urlFileName, "/") {
-						detectedFileName = urlFileName
-						s.app.Logger.Printf("Extracted filename from URL: %s", detectedFileName)
-					}
-				}
-			}
+		if caption, err = s.app.FilterContent(user, phoneNumber, caption); err != nil {
+			return nil, err
 		}
 
-		// Try to get filename from Content-Disposition header if still not found
-		if detectedFileName == "" {
-			contentDisposition := httpResp.Header.Get("Content-Disposition")
-			if contentDisposition != "" {
-				if _, params, err := mime.ParseMediaType(contentDisposition); err == nil {
-					if fn, ok := params["filename"]; ok && fn != "" {
-						detectedFileName = fn
-						s.app.Logger.Printf("Extracted filename from Content-Disposition: %s", detectedFileName)
-					}
-				}
-			}
-		}
-	} else if mediaData != "" {
-		// Decode base64 media
-		var err error
-		media, err = base64.StdEncoding.DecodeString(mediaData)
-		if err != nil {
-			return "", fmt.Errorf("invalid media format")
+		var recordErr error
+		outboxID, recordErr = s.app.Outbox.Record(app.OutboxMessage{
+			User:        user,
+			Recipient:   phoneNumber,
+			Type:        mediaType,
+			Body:        caption,
+			FileName:    fileName,
+			MimeType:    mimeType,
+			MediaURL:    mediaURL,
+			MediaHandle: handleID,
+			Priority:    priority,
+			ExpiresAt:   app.OptionalTime(expiresAt),
+			Options:     options,
+		})
+		if recordErr != nil {
+			s.app.Logger.Printf("Warning: %v", recordErr)
 		}
-		mimeType = http.DetectContentType(media)
-	} else {
-		return "", fmt.Errorf("either media or URL must be provided")
 	}
 
-	var waMediaType whatsmeow.MediaType
-	switch mediaType {
-	case "image":
-		waMediaType = whatsmeow.MediaImage
-	case "video":
-		waMediaType = whatsmeow.MediaVideo
-	case "file":
-		waMediaType = whatsmeow.MediaDocument
-	default:
-		return "", fmt.Errorf("invalid media type: %s", mediaType)
+	s.app.SendLimiter.WaitPriority(user, priority, sendDelay)
+	if app.MessageExpired(expiresAt) {
+		return nil, app.ErrMessageExpired
 	}
 
-	uploaded, err := sess.Client.Upload(context.Background(), media, waMediaType)
-	if err != nil {
-		return "", fmt.Errorf("failed to upload media: %v", err)
+	// Sandbox sessions accept the media without connecting to WhatsApp
+	whatsappClient, hasClient := s.app.GetClientManager().GetClient(user)
+	sandbox := hasClient && whatsappClient.IsSandbox()
+
+	// Ensure client is connected before sending
+	if !sandbox && !sess.Client.IsConnected() {
+		err := sess.Client.Connect()
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect: %v", err)
+		}
 	}
 
-	var thumbnail []byte
-	if mediaType == "video" {
-		var errThumbnail error
-		thumbnail, errThumbnail = utils.VideoThumbnail(
-			media,
-			0,
-			struct{ Width int }{Width: 72},
-		)
+	recipient := utils.RecipientJID(phoneNumber)
 
-		if errThumbnail != nil {
-			s.app.Logger.Printf("Failed to generate video thumbnail: %v", errThumbnail)
-			thumbnail = nil // Proceed without a thumbnail if generation fails
+	// Media sent by handle was uploaded ahead of time
+	if handle == nil {
+		if handle, err = s.upload(sess.Client, user, mediaType, mediaData, mediaURL, fileName, mimeType); err != nil {
+			return nil, err
 		}
 	}
+	if fileName == "" {
+		fileName = handle.FileName
+	}
 
 	var msg waE2E.Message
 	switch mediaType {
@@ -272,65 +332,119 @@ func (s *Service) SendMedia(user, phoneNumber, mediaType, mediaData, mediaURL, c
 		msg = waE2E.Message{
 			ImageMessage: &waE2E.ImageMessage{
 				Caption:       proto.String(caption),
-				URL:           proto.String(uploaded.URL),
-				DirectPath:    proto.String(uploaded.DirectPath),
-				MediaKey:      uploaded.MediaKey,
-				Mimetype:      proto.String(mimeType),
-				FileEncSHA256: uploaded.FileEncSHA256,
-				FileSHA256:    uploaded.FileSHA256,
-				FileLength:    proto.Uint64(uint64(len(media))),
+				URL:           proto.String(handle.URL),
+				DirectPath:    proto.String(handle.DirectPath),
+				MediaKey:      handle.MediaKey,
+				Mimetype:      proto.String(handle.MimeType),
+				FileEncSHA256: handle.FileEncSHA256,
+				FileSHA256:    handle.FileSHA256,
+				FileLength:    proto.Uint64(handle.Size),
 			},
 		}
 	case "video":
 		msg = waE2E.Message{
 			VideoMessage: &waE2E.VideoMessage{
 				Caption:       proto.String(caption),
-				URL:           proto.String(uploaded.URL),
-				DirectPath:    proto.String(uploaded.DirectPath),
-				MediaKey:      uploaded.MediaKey,
-				Mimetype:      proto.String(mimeType),
-				FileEncSHA256: uploaded.FileEncSHA256,
-				FileSHA256:    uploaded.FileSHA256,
-				FileLength:    proto.Uint64(uploaded.FileLength),
-				JPEGThumbnail: thumbnail,
+				URL:           proto.String(handle.URL),
+				DirectPath:    proto.String(handle.DirectPath),
+				MediaKey:      handle.MediaKey,
+				Mimetype:      proto.String(handle.MimeType),
+				FileEncSHA256: handle.FileEncSHA256,
+				FileSHA256:    handle.FileSHA256,
+				FileLength:    proto.Uint64(handle.Size),
+				JPEGThumbnail: handle.Thumbnail,
 			},
 		}
 	case "file":
 		msg = waE2E.Message{
 			DocumentMessage: &waE2E.DocumentMessage{
 				Caption:       proto.String(caption),
-				URL:           proto.String(uploaded.URL),
-				DirectPath:    proto.String(uploaded.DirectPath),
-				MediaKey:      uploaded.MediaKey,
-				Mimetype:      proto.String(mimeType),
-				FileEncSHA256: uploaded.FileEncSHA256,
-				FileSHA256:    uploaded.FileSHA256,
-				FileLength:    proto.Uint64(uint64(len(media))),
-				FileName:      proto.String(detectedFileName),
+				URL:           proto.String(handle.URL),
+				DirectPath:    proto.String(handle.DirectPath),
+				MediaKey:      handle.MediaKey,
+				Mimetype:      proto.String(handle.MimeType),
+				FileEncSHA256: handle.FileEncSHA256,
+				FileSHA256:    handle.FileSHA256,
+				FileLength:    proto.Uint64(handle.Size),
+				FileName:      proto.String(fileName),
+			},
+		}
+	case "voice":
+		msg = waE2E.Message{
+			AudioMessage: &waE2E.AudioMessage{
+				URL:           proto.String(handle.URL),
+				DirectPath:    proto.String(handle.DirectPath),
+				MediaKey:      handle.MediaKey,
+				Mimetype:      proto.String(handle.MimeType),
+				FileEncSHA256: handle.FileEncSHA256,
+				FileSHA256:    handle.FileSHA256,
+				FileLength:    proto.Uint64(handle.Size),
+				Seconds:       proto.Uint32(handle.Seconds),
+				PTT:           proto.Bool(true),
+				Waveform:      handle.Waveform,
+			},
+		}
+	case "audio":
+		msg = waE2E.Message{
+			AudioMessage: &waE2E.AudioMessage{
+				URL:           proto.String(handle.URL),
+				DirectPath:    proto.String(handle.DirectPath),
+				MediaKey:      handle.MediaKey,
+				Mimetype:      proto.String(handle.MimeType),
+				FileEncSHA256: handle.FileEncSHA256,
+				FileSHA256:    handle.FileSHA256,
+				FileLength:    proto.Uint64(handle.Size),
+				Seconds:       proto.Uint32(handle.Seconds),
+				PTT:           proto.Bool(false),
 			},
 		}
 	}
 
+	applySendOptions(&msg, options)
+
 	// Generate message ID using client-scoped generator
 	opts := whatsmeow.SendRequestExtra{
 		ID: sess.Client.GenerateMessageID(),
 	}
 
 	// === ANTI-BAN: Simulate human behavior before sending media ===
-	s.simulateMediaAttach(sess.Client, recipient)
+	presenceMedia := types.ChatPresenceMediaText
+	if mediaType == "voice" {
+		presenceMedia = types.ChatPresenceMediaAudio
+	}
+	if !sandbox {
+		s.simulateMediaAttach(sess.Client, recipient, presenceMedia)
+	}
 
 	// Use a context with a timeout for the SendMessage operation
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	_, err = sess.Client.SendMessage(ctx, recipient, &msg, opts)
+	release, err := s.app.AcquireClientOp(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	// Downloading, uploading and attaching take time; don't send once the deadline has passed
+	if app.MessageExpired(expiresAt) {
+		release()
+		return nil, app.ErrMessageExpired
+	}
+	_ = s.app.Outbox.RecordAttempt(outboxID)
+	sentAt := time.Now()
+	var resp whatsmeow.SendResponse
+	if sandbox {
+		resp, err = whatsappClient.SandboxSend(recipient, &msg, opts)
+	} else {
+		resp, err = sess.Client.SendMessage(ctx, recipient, &msg, opts)
+	}
+	release()
 	if err != nil {
 		// Check if this is a websocket disconnection error
-		if strings.Contains(err.Error(), "websocket disconnected") {
+		if client.IsDisconnected(err) {
 			// Check if the user is logged in before attempting to reconnect
 			if !sess.IsLoggedIn {
 				s.app.Logger.Printf("User %s is not logged in, not attempting to reconnect", user)
-				return "", fmt.Errorf("user is not logged in, cannot reconnect: %v", err)
+				return nil, fmt.Errorf("user is not logged in, cannot reconnect: %w", err)
 			}
 
 			s.app.Logger.Printf("Websocket disconnected during media send. Reconnecting...")
@@ -343,7 +457,7 @@ func (s *Service) SendMedia(user, phoneNumber, mediaType, mediaData, mediaURL, c
 			err = sess.Client.Connect()
 			if err != nil {
 				s.app.Logger.Printf("Failed to reconnect: %v", err)
-				return "", fmt.Errorf("failed to reconnect after websocket disconnection: %v", err)
+				return nil, fmt.Errorf("failed to reconnect after websocket disconnection: %v", err)
 			}
 
 			s.app.Logger.Printf("Successfully reconnected, retrying media send")
@@ -352,23 +466,83 @@ func (s *Service) SendMedia(user, phoneNumber, mediaType, mediaData, mediaURL, c
 			ctx2, cancel2 := context.WithTimeout(context.Background(), 60*time.Second)
 			defer cancel2()
 
-			_, err = sess.Client.SendMessage(ctx2, recipient, &msg, opts)
+			release, err = s.app.AcquireClientOp(ctx2, user)
 			if err != nil {
-				return "", fmt.Errorf("failed to send media message after reconnection: %v", err)
+				return nil, err
+			}
+			if app.MessageExpired(expiresAt) {
+				release()
+				return nil, app.ErrMessageExpired
 			}
+			_ = s.app.Outbox.RecordAttempt(outboxID)
+			sentAt = time.Now()
+			resp, err = sess.Client.SendMessage(ctx2, recipient, &msg, opts)
+			release()
+			if err != nil {
+				if app.IsThrottling(err) {
+					return nil, s.throttled(user, err)
+				}
+				return nil, fmt.Errorf("%w after reconnection: %w", ErrSendFailed, err)
+			}
+		} else if app.IsThrottling(err) {
+			return nil, s.throttled(user, err)
 		} else {
-			return "", fmt.Errorf("failed to send media message: %v", err)
+			return nil, fmt.Errorf("%w: %w", ErrSendFailed, err)
 		}
 	}
 
+	messageID = string(resp.ID)
+
 	// Log successful message send
 	s.app.Logger.Printf("Media sent successfully to %s from user %s", recipient.String(), user)
+	s.app.RecordSentThread(user, recipient, messageID, caption, options, resp.Timestamp)
+	s.app.Deliveries.Sent(user, messageID, sentAt)
+	s.app.SendLimiter.ClearThrottle(user)
+	s.optinService.Sent(user, phoneNumber, priority)
+	if hasClient {
+		whatsappClient.RecordMessageSent()
+	}
 
-	// Post-send: set presence back to unavailable after a random delay
-	go func() {
-		time.Sleep(humanDelay(2000, 5000))
-		_ = sess.Client.SendPresence(context.Background(), types.PresenceUnavailable)
-	}()
+	// Post-send: set presence back to unavailable after a random delay,
+	// unless the session was set available through /wa/presence
+	if !sandbox && (!hasClient || !whatsappClient.StaysOnline()) {
+		go func() {
+			time.Sleep(humanDelay(2000, 5000))
+			_ = sess.Client.SendPresence(context.Background(), types.PresenceUnavailable)
+		}()
+	}
+
+	return &SendMediaResult{
+		MessageID:     messageID,
+		Timestamp:     resp.Timestamp,
+		FileName:      fileName,
+		MimeType:      handle.MimeType,
+		Size:          handle.Size,
+		URL:           handle.URL,
+		DirectPath:    handle.DirectPath,
+		MediaKey:      handle.MediaKey,
+		FileSHA256:    handle.FileSHA256,
+		FileEncSHA256: handle.FileEncSHA256,
+	}, nil
+}
 
-	return detectedFileName, nil
+// applySendOptions sets the per-message options that apply to media. Link
+// previews only exist for text messages.
+func applySendOptions(msg *waE2E.Message, options *app.SendOptions) {
+	if options == nil || options.EphemeralSeconds == 0 {
+		return
+	}
+	contextInfo := &waE2E.ContextInfo{
+		Expiration: proto.Uint32(options.EphemeralSeconds),
+	}
+	switch {
+	case msg.ImageMessage != nil:
+		msg.ImageMessage.ContextInfo = contextInfo
+	case msg.VideoMessage != nil:
+		msg.VideoMessage.ContextInfo = contextInfo
+	case msg.DocumentMessage != nil:
+		msg.DocumentMessage.ContextInfo = contextInfo
+	case msg.AudioMessage != nil:
+		msg.AudioMessage.ContextInfo = contextInfo
+	}
 }
@@ -0,0 +1,317 @@
+package media
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/utils"
+	"go.mau.fi/whatsmeow"
+)
+
+// UploadMedia uploads media to WhatsApp once and stores it as a handle that
+// sends of the same session can reference instead of the media itself.
+// mimeType optionally declares the media's type; it must match the content.
+func (s *Service) UploadMedia(user, mediaType, mediaData, mediaURL, fileName, mimeType string) (*app.MediaHandle, error) {
+	if _, err := whatsmeowMediaType(mediaType); err != nil {
+		return nil, err
+	}
+	if s.app.MediaHandles == nil {
+		return nil, fmt.Errorf("media handle store is not available")
+	}
+	sess, exists := s.sessionService.FindSessionByUser(user)
+	if !exists {
+		return nil, fmt.Errorf("session not found")
+	}
+	if wait := s.app.SendLimiter.ThrottledFor(user); wait > 0 {
+		return nil, &app.ThrottledError{RetryAfter: wait}
+	}
+
+	// Sandbox sessions accept the media without connecting to WhatsApp
+	whatsappClient, hasClient := s.app.GetClientManager().GetClient(user)
+	sandbox := hasClient && whatsappClient.IsSandbox()
+	if !sandbox && !sess.Client.IsConnected() {
+		if err := sess.Client.Connect(); err != nil {
+			return nil, fmt.Errorf("failed to connect: %v", err)
+		}
+	}
+
+	handle, err := s.upload(sess.Client, user, mediaType, mediaData, mediaURL, fileName, mimeType)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.app.MediaHandles.Create(handle); err != nil {
+		return nil, err
+	}
+	s.app.Logger.Printf("Uploaded %s media handle %s for user %s (%d bytes)", mediaType, handle.ID, user, handle.Size)
+	return handle, nil
+}
+
+// mediaHandle returns the user's handle to send as mediaType
+func (s *Service) mediaHandle(user, mediaType, handleID string) (*app.MediaHandle, error) {
+	handle, err := s.app.MediaHandles.Get(user, handleID)
+	if err != nil {
+		return nil, err
+	}
+	if handle.Type != mediaType {
+		return nil, fmt.Errorf("%w: handle is %s, not %s", ErrHandleTypeMismatch, handle.Type, mediaType)
+	}
+	return handle, nil
+}
+
+// whatsmeowMediaType maps a media type of the API onto the type media is uploaded as
+func whatsmeowMediaType(mediaType string) (whatsmeow.MediaType, error) {
+	switch mediaType {
+	case "image":
+		return whatsmeow.MediaImage, nil
+	case "video":
+		return whatsmeow.MediaVideo, nil
+	case "file":
+		return whatsmeow.MediaDocument, nil
+	case "voice", "audio":
+		return whatsmeow.MediaAudio, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidMediaType, mediaType)
+	}
+}
+
+// upload downloads or decodes media, validates it and uploads it to WhatsApp,
+// returning a handle without an ID that describes the uploaded media
+func (s *Service) upload(waClient *whatsmeow.Client, user, mediaType, mediaData, mediaURL, fileName, declaredMimeType string) (*app.MediaHandle, error) {
+	waMediaType, err := whatsmeowMediaType(mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	media, mimeType, fileName, err := s.loadMedia(mediaData, mediaURL, fileName)
+	if err != nil {
+		return nil, err
+	}
+	if mimeType, err = validateMedia(mediaType, media, declaredMimeType, mimeType, fileName); err != nil {
+		return nil, err
+	}
+
+	// Voice notes only play as OGG/Opus, whatever the caller recorded them in
+	var seconds uint32
+	var waveform []byte
+	if mediaType == "voice" {
+		if media, seconds, waveform, err = voiceNote(media); err != nil {
+			return nil, err
+		}
+		mimeType = utils.VoiceNoteMimeType
+	}
+	if mediaType == "audio" {
+		if seconds, err = audioSeconds(media); err != nil {
+			return nil, err
+		}
+	}
+
+	// Wait for a free operation slot, bounded so a stuck upload cannot block forever
+	slotCtx, cancelSlot := context.WithTimeout(context.Background(), 60*time.Second)
+	release, err := s.app.AcquireClientOp(slotCtx, user)
+	cancelSlot()
+	if err != nil {
+		return nil, err
+	}
+	var uploaded whatsmeow.UploadResponse
+	whatsappClient, hasClient := s.app.GetClientManager().GetClient(user)
+	if hasClient && whatsappClient.IsSandbox() {
+		uploaded, err = whatsappClient.SandboxUpload(media)
+	} else {
+		uploaded, err = waClient.Upload(context.Background(), media, waMediaType)
+	}
+	release()
+	if err != nil {
+		if app.IsThrottling(err) {
+			return nil, s.throttled(user, err)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrUploadFailed, err)
+	}
+	if hasClient {
+		whatsappClient.RecordBytesUploaded(len(media))
+	}
+
+	var thumbnail []byte
+	if mediaType == "video" {
+		var errThumbnail error
+		thumbnail, errThumbnail = utils.VideoThumbnail(
+			media,
+			0,
+			struct{ Width int }{Width: 72},
+		)
+
+		if errThumbnail != nil {
+			s.app.Logger.Printf("Failed to generate video thumbnail: %v", errThumbnail)
+			thumbnail = nil // Proceed without a thumbnail if generation fails
+		}
+	}
+
+	return &app.MediaHandle{
+		User:          user,
+		Type:          mediaType,
+		FileName:      fileName,
+		MimeType:      mimeType,
+		Size:          uploaded.FileLength,
+		URL:           uploaded.URL,
+		DirectPath:    uploaded.DirectPath,
+		MediaKey:      uploaded.MediaKey,
+		FileSHA256:    uploaded.FileSHA256,
+		FileEncSHA256: uploaded.FileEncSHA256,
+		Thumbnail:     thumbnail,
+		Seconds:       seconds,
+		Waveform:      waveform,
+	}, nil
+}
+
+// voiceNote transcodes audio into a voice note. Audio ffmpeg cannot read fails
+// with a *ValidationError; a missing ffmpeg does not, as the audio may be fine.
+func voiceNote(media []byte) ([]byte, uint32, []byte, error) {
+	voice, seconds, waveform, err := utils.VoiceNote(media)
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, 0, nil, fmt.Errorf("ffmpeg is required to send voice notes: %w", err)
+	}
+	if err != nil {
+		return nil, 0, nil, &ValidationError{
+			Code:    CodeUnsupportedFormat,
+			Message: fmt.Sprintf("audio cannot be converted to a voice note: %v", err),
+		}
+	}
+	return voice, seconds, waveform, nil
+}
+
+// audioSeconds measures the length of audio sent as a file. Audio ffprobe
+// cannot read fails with a *ValidationError, like in voiceNote.
+func audioSeconds(media []byte) (uint32, error) {
+	seconds, err := utils.AudioSeconds(media)
+	if errors.Is(err, exec.ErrNotFound) {
+		return 0, fmt.Errorf("ffprobe is required to send audio: %w", err)
+	}
+	if err != nil {
+		return 0, &ValidationError{
+			Code:    CodeUnsupportedFormat,
+			Message: fmt.Sprintf("audio length cannot be read: %v", err),
+		}
+	}
+	return seconds, nil
+}
+
+// loadMedia downloads media from mediaURL or decodes the base64 mediaData,
+// detecting its MIME type and, for downloads, a file name when none is given
+func (s *Service) loadMedia(mediaData, mediaURL, fileName string) ([]byte, string, string, error) {
+	var media []byte
+	var mimeType string
+	var detectedFileName string
+
+	// Set filename if provided
+	if fileName != "" {
+		detectedFileName = fileName
+	}
+
+	// Check if URL or base64 media is provided
+	if mediaURL != "" {
+
+		client := &http.Client{
+			Timeout: 30 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				// Allow up to 10 redirects
+				if len(via) >= 10 {
+					return fmt.Errorf("too many redirects")
+				}
+				return nil
+			},
+		}
+
+		// Download media from URL
+		httpResp, err := client.Get(mediaURL)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("%w from URL", ErrDownloadFailed)
+		}
+		defer httpResp.Body.Close()
+
+		if httpResp.StatusCode != http.StatusOK {
+			return nil, "", "", ErrDownloadFailed
+		}
+
+		// add limiter for 100 mb download size
+		const maxDownloadSize = 100 << 20 // 100 MB
+		limitedReader := io.LimitReader(httpResp.Body, maxDownloadSize)
+		media, err = io.ReadAll(limitedReader)
+		if err != nil {
+			return nil, "", "", ErrDownloadFailed
+		}
+
+		mimeType = httpResp.Header.Get("Content-Type")
+		if mimeType != "" {
+			if parsedMimeType, _, err := mime.ParseMediaType(mimeType); err == nil {
+				mimeType = parsedMimeType
+			}
+		}
+		if mimeType == "" {
+			mimeType = http.DetectContentType(media)
+		}
+
+		// Extract filename from URL if not provided
+		if detectedFileName == "" {
+			// Parse URL to extract filename
+			parsedURL, err := url.Parse(mediaURL)
+
+			// add validation for URL parsing
+			if err != nil {
+				s.app.Logger.Printf("Failed to parse media URL: %v", err)
+			}
+
+			if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+				s.app.Logger.Printf("Invalid URL scheme for media: %s", parsedURL.Scheme)
+			}
+
+			if err == nil {
+				// Get the last part of the path
+				parts := strings.Split(parsedURL.Path, "/")
+				if len(parts) > 0 {
+					urlFileName := parts[len(parts)-1]
+					// Remove query parameters if present
+					urlFileName = strings.Split(urlFileName, "?")[0]
+					// Use it if it looks like a valid filename
+					if urlFileName != "" && !strings.HasSuffix(urlFileName, "/") {
+						detectedFileName = urlFileName
+						s.app.Logger.Printf("Extracted filename from URL: %s", detectedFileName)
+					}
+				}
+			}
+		}
+
+		// Try to get filename from Content-Disposition header if still not found
+		if detectedFileName == "" {
+			contentDisposition := httpResp.Header.Get("Content-Disposition")
+			if contentDisposition != "" {
+				if _, params, err := mime.ParseMediaType(contentDisposition); err == nil {
+					if fn, ok := params["filename"]; ok && fn != "" {
+						detectedFileName = fn
+						s.app.Logger.Printf("Extracted filename from Content-Disposition: %s", detectedFileName)
+					}
+				}
+			}
+		}
+	} else if mediaData != "" {
+		// Decode base64 media
+		var err error
+		media, err = base64.StdEncoding.DecodeString(mediaData)
+		if err != nil {
+			return nil, "", "", ErrInvalidFormat
+		}
+		mimeType = http.DetectContentType(media)
+	} else {
+		return nil, "", "", fmt.Errorf("either media or URL must be provided")
+	}
+
+	return media, mimeType, detectedFileName, nil
+}
@@ -0,0 +1,186 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// Codes of validation errors, returned to callers so they can tell rejected
+// media apart without parsing messages
+const (
+	CodeInvalidMimeType   = "invalid_mime_type"
+	CodeUnsupportedFormat = "unsupported_format"
+	CodeMimeTypeMismatch  = "mime_type_mismatch"
+	CodeExtensionMismatch = "extension_mismatch"
+)
+
+// ValidationError rejects media before it is uploaded
+type ValidationError struct {
+	Code    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// supportedTypes are the formats WhatsApp plays inline for each media kind.
+// Files are sent as documents and may be of any format.
+var supportedTypes = map[string][]string{
+	"image": {"image/jpeg", "image/png", "image/webp"},
+	"video": {"video/mp4", "video/3gpp"},
+	"audio": {"audio/aac", "audio/mp4", "audio/mpeg", "audio/amr", "audio/ogg"},
+}
+
+// genericTypes are sniffed for content the sniffer cannot tell apart, such as
+// office documents (zip) or CSV (text); a declared type is trusted over them
+var genericTypes = map[string]bool{
+	"application/octet-stream": true,
+	"application/zip":          true,
+	"text/plain":               true,
+	"text/xml":                 true,
+}
+
+// mimeAliases maps non-standard MIME types callers commonly send onto the standard ones
+var mimeAliases = map[string]string{
+	"image/jpg":       "image/jpeg",
+	"image/pjpeg":     "image/jpeg",
+	"video/3gp":       "video/3gpp",
+	"audio/mp3":       "audio/mpeg",
+	"audio/x-m4a":     "audio/mp4",
+	"application/ogg": "audio/ogg",
+}
+
+// normalizeMimeType lowercases a MIME type, drops its parameters and resolves aliases
+func normalizeMimeType(value string) (string, error) {
+	parsed, _, err := mime.ParseMediaType(value)
+	if err != nil || !strings.Contains(parsed, "/") {
+		return "", &ValidationError{Code: CodeInvalidMimeType, Message: fmt.Sprintf("invalid mime_type %q", value)}
+	}
+	if alias, ok := mimeAliases[parsed]; ok {
+		return alias, nil
+	}
+	return parsed, nil
+}
+
+// sniffMimeType detects the format of media from its content. ISO base media
+// files (MP4, 3GP, MOV, HEIC) are told apart by their brand, which the
+// standard library sniffer only does for some MP4 brands. Audio formats the
+// sniffer does not know, or only knows with a tag in front, are detected by
+// their headers.
+func sniffMimeType(data []byte) string {
+	if len(data) >= 12 && string(data[4:8]) == "ftyp" {
+		brand := string(data[8:12])
+		switch {
+		case strings.HasPrefix(brand, "3g"):
+			return "video/3gpp"
+		case brand == "qt  ":
+			return "video/quicktime"
+		case strings.HasPrefix(brand, "M4A"):
+			return "audio/mp4"
+		case brand == "heic" || brand == "heix" || brand == "mif1" || brand == "msf1":
+			return "image/heic"
+		case brand == "avif":
+			return "image/avif"
+		default: // isom, mp41, mp42, avc1 and the other MP4 brands
+			return "video/mp4"
+		}
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return "audio/ogg"
+	case bytes.HasPrefix(data, []byte("#!AMR")):
+		return "audio/amr"
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xF0 == 0xF0 && data[1]&0x06 == 0:
+		// MPEG frame sync with layer 0: an ADTS AAC frame
+		return "audio/aac"
+	case mpegAudioFrame(data):
+		// MPEG frame sync with a layer: an MP3 without an ID3 tag
+		return "audio/mpeg"
+	}
+	parsed, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return parsed
+}
+
+// mpegAudioFrame reports whether data starts with a valid MPEG audio frame
+// header: frame sync, a layer, and a known version, bitrate and sample rate.
+// FF FE is the UTF-16LE byte order mark, so text starting with it is not
+// taken for audio, even though it reads as a Layer I header.
+func mpegAudioFrame(data []byte) bool {
+	if len(data) < 3 || data[0] != 0xFF || data[1]&0xE0 != 0xE0 || data[1] == 0xFE {
+		return false
+	}
+	version := data[1] >> 3 & 0x03
+	layer := data[1] >> 1 & 0x03
+	bitrate := data[2] >> 4
+	sampleRate := data[2] >> 2 & 0x03
+	return version != 0x01 && layer != 0 && bitrate != 0 && bitrate != 0x0F && sampleRate != 0x03
+}
+
+// validateMedia checks media before it is uploaded as mediaType and returns
+// the MIME type to send it with. declared is the type the caller gave, if any,
+// and detected the type reported by the media's server or sniffed on load.
+// Images and videos must be a format WhatsApp plays, and any declared type and
+// file name extension must agree with what the content is.
+func validateMedia(mediaType string, data []byte, declared, detected, fileName string) (string, error) {
+	if declared != "" {
+		var err error
+		if declared, err = normalizeMimeType(declared); err != nil {
+			return "", err
+		}
+	}
+
+	sniffed := sniffMimeType(data)
+	if declared != "" && declared != sniffed && !genericTypes[sniffed] {
+		return "", &ValidationError{
+			Code:    CodeMimeTypeMismatch,
+			Message: fmt.Sprintf("mime_type %s does not match the media content, which is %s", declared, sniffed),
+		}
+	}
+
+	mimeType := declared
+	if supported, strict := supportedTypes[mediaType]; strict {
+		// Inline playback depends on the real format, whatever the media claims to be
+		mimeType = sniffed
+		if !contains(supported, mimeType) {
+			return "", &ValidationError{
+				Code:    CodeUnsupportedFormat,
+				Message: fmt.Sprintf("%s is not a supported %s format, use one of %s", mimeType, mediaType, strings.Join(supported, ", ")),
+			}
+		}
+	} else if mimeType == "" {
+		mimeType = detected
+		if normalized, err := normalizeMimeType(mimeType); err == nil {
+			mimeType = normalized
+		} else {
+			mimeType = sniffed
+		}
+	}
+
+	if ext := filepath.Ext(fileName); ext != "" && !genericTypes[mimeType] {
+		if extType, err := normalizeMimeType(mime.TypeByExtension(ext)); err == nil && !genericTypes[extType] && extType != mimeType {
+			return "", &ValidationError{
+				Code:    CodeExtensionMismatch,
+				Message: fmt.Sprintf("file_name extension %s is for %s, but the media is %s", ext, extType, mimeType),
+			}
+		}
+	}
+	return mimeType, nil
+}
+
+// contains reports whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package messaging
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/utils"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Most contact cards sent in one message
+const maxContactCards = 20
+
+// vCardEscaper escapes text values of a vCard 3.0 property
+var vCardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+
+// vCardUnescaper reverses vCardEscaper
+var vCardUnescaper = strings.NewReplacer(`\\`, `\`, `\,`, ",", `\;`, ";", `\n`, "\n", `\N`, "\n")
+
+// SendContacts sends contact cards to a WhatsApp contact, one card as a
+// contact message and several as a contacts array message. It returns the
+// WhatsApp message ID.
+func (s *Service) SendContacts(user, phoneNumber string, cards []ContactCard, priority string, expiresAt time.Time, options *app.SendOptions) (string, error) {
+	vCards, err := buildVCards(cards)
+	if err != nil {
+		return "", err
+	}
+	body := strings.Join(vCards, "\n")
+
+	priority, err = s.checkSend(user, phoneNumber, body, priority, options)
+	if err != nil {
+		return "", err
+	}
+	// Don't queue more sends while WhatsApp is throttling the session
+	if wait := s.app.SendLimiter.ThrottledFor(user); wait > 0 {
+		return "", &app.ThrottledError{RetryAfter: wait}
+	}
+
+	outboxID, err := s.app.Outbox.Record(app.OutboxMessage{
+		User:      user,
+		Recipient: phoneNumber,
+		Type:      "contact",
+		Body:      body,
+		Priority:  priority,
+		ExpiresAt: app.OptionalTime(expiresAt),
+		Options:   options,
+	})
+	if err != nil {
+		s.app.Logger.Printf("Warning: %v", err)
+	}
+
+	s.app.SendLimiter.WaitPriority(user, priority, randomSendDelay())
+	messageID, err := s.sendContactsRecorded(user, phoneNumber, vCards, outboxID, expiresAt, options)
+	if err == nil {
+		s.optinService.Sent(user, phoneNumber, priority)
+	}
+	return messageID, err
+}
+
+// sendContactsRecorded sends vCards and stores the outcome in the outbox,
+// returning the WhatsApp message ID
+func (s *Service) sendContactsRecorded(user, phoneNumber string, vCards []string, outboxID string, expiresAt time.Time, options *app.SendOptions) (string, error) {
+	msg, names := contactMessage(vCards, utils.RecipientJID(phoneNumber), options)
+	return s.sendBuiltRecorded(user, phoneNumber, msg, strings.Join(names, ", "), outboxID, expiresAt, options)
+}
+
+// buildVCards validates contact cards and returns their vCards
+func buildVCards(cards []ContactCard) ([]string, error) {
+	if len(cards) == 0 {
+		return nil, fmt.Errorf("at least one contact is required")
+	}
+	if len(cards) > maxContactCards {
+		return nil, fmt.Errorf("at most %d contacts can be sent in one message", maxContactCards)
+	}
+
+	vCards := make([]string, len(cards))
+	for i, card := range cards {
+		vCard, err := card.vCard()
+		if err != nil {
+			if len(cards) > 1 {
+				return nil, fmt.Errorf("contacts[%d]: %v", i, err)
+			}
+			return nil, err
+		}
+		vCards[i] = vCard
+	}
+	return vCards, nil
+}
+
+// vCard returns the card's raw vCard, or builds one from its name and phone
+func (c ContactCard) vCard() (string, error) {
+	if c.VCard != "" {
+		if c.Name != "" || c.Phone != "" {
+			return "", fmt.Errorf("vcard cannot be combined with name or phone")
+		}
+		vCard := strings.TrimSpace(strings.ReplaceAll(c.VCard, "\r\n", "\n"))
+		upper := strings.ToUpper(vCard)
+		if !strings.HasPrefix(upper, "BEGIN:VCARD") || !strings.HasSuffix(upper, "END:VCARD") {
+			return "", fmt.Errorf("vcard must start with BEGIN:VCARD and end with END:VCARD")
+		}
+		if strings.Count(upper, "BEGIN:VCARD") > 1 {
+			return "", fmt.Errorf("vcard must hold a single card, send several as separate contacts")
+		}
+		// Outbox resends split recorded cards at their end line
+		vCard = vCard[:len(vCard)-len("END:VCARD")] + "END:VCARD"
+		if vCardName(vCard) == "" {
+			return "", fmt.Errorf("vcard must have an FN (formatted name) property")
+		}
+		return vCard, nil
+	}
+
+	name := strings.TrimSpace(c.Name)
+	if name == "" || c.Phone == "" {
+		return "", fmt.Errorf("name and phone are required, or a raw vcard")
+	}
+	number := strings.TrimPrefix(strings.Map(func(r rune) rune {
+		// Allow the usual formatting of phone numbers
+		if r == ' ' || r == '-' || r == '(' || r == ')' || r == '.' {
+			return -1
+		}
+		return r
+	}, c.Phone), "+")
+	if number == "" || strings.Trim(number, "0123456789") != "" {
+		return "", fmt.Errorf("phone %q is invalid, must be digits with an optional leading '+'", c.Phone)
+	}
+
+	// waid links the card to the number's WhatsApp account, so recipients
+	// can message it from the card
+	escaped := vCardEscaper.Replace(name)
+	return strings.Join([]string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		"N:;" + escaped + ";;;",
+		"FN:" + escaped,
+		"TEL;type=CELL;type=VOICE;waid=" + number + ":+" + number,
+		"END:VCARD",
+	}, "\n"), nil
+}
+
+// vCardName returns the formatted name (FN) of a vCard
+func vCardName(vCard string) string {
+	for _, line := range strings.Split(vCard, "\n") {
+		property, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		property, _, _ = strings.Cut(property, ";")
+		if strings.EqualFold(property, "FN") {
+			return strings.TrimSpace(vCardUnescaper.Replace(value))
+		}
+	}
+	return ""
+}
+
+// splitVCards splits vCards recorded together in an outbox body
+func splitVCards(body string) []string {
+	var vCards []string
+	for _, part := range strings.SplitAfter(body, "END:VCARD") {
+		if part = strings.TrimSpace(part); part != "" {
+			vCards = append(vCards, part)
+		}
+	}
+	return vCards
+}
+
+// contactMessage builds a message carrying vCards to recipient, returning it
+// with the display names of the cards
+func contactMessage(vCards []string, recipient types.JID, options *app.SendOptions) (*waE2E.Message, []string) {
+	contacts := make([]*waE2E.ContactMessage, len(vCards))
+	names := make([]string, len(vCards))
+	for i, vCard := range vCards {
+		names[i] = vCardName(vCard)
+		contacts[i] = &waE2E.ContactMessage{
+			DisplayName: proto.String(names[i]),
+			Vcard:       proto.String(vCard),
+		}
+	}
+
+	info := contextInfo(recipient, options)
+	if len(contacts) == 1 {
+		contacts[0].ContextInfo = info
+		return &waE2E.Message{ContactMessage: contacts[0]}, names
+	}
+	return &waE2E.Message{
+		ContactsArrayMessage: &waE2E.ContactsArrayMessage{
+			DisplayName: proto.String(fmt.Sprintf("%d contacts", len(contacts))),
+			Contacts:    contacts,
+			ContextInfo: info,
+		},
+	}, names
+}
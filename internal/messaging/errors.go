@@ -1,6 +1,7 @@
 package messaging
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
@@ -28,3 +29,10 @@ func isDuplicateMessageError(err error) (*DuplicateMessageError, bool) {
 	dupErr, ok := err.(*DuplicateMessageError)
 	return dupErr, ok
 }
+
+// ErrNotOwnMessage is returned when revoking a message this session did not
+// send to the given chat
+var ErrNotOwnMessage = errors.New("message was not sent by this session to this chat")
+
+// ErrAlreadyRevoked is returned when revoking a message that was already revoked
+var ErrAlreadyRevoked = errors.New("message was already revoked")
@@ -1,10 +1,17 @@
 package messaging
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/contentfilter"
+	"github.com/neekaru/whatsappgo-bot/internal/optin"
+	"github.com/neekaru/whatsappgo-bot/internal/utils"
 )
 
 // Handlers contains HTTP handlers for messaging
@@ -29,14 +36,201 @@ func (h *Handlers) SendMessageHandler(c *gin.Context) {
 		return
 	}
 
-	err := h.service.SendMessage(req.User, req.PhoneNumber, req.Message)
+	if _, err := app.ParseSendPriority(req.Priority); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	options, err := req.SendOptions()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Options = options
+
+	now := time.Now()
+	sendAfter, err := app.ParseSendAfter(req.SendAfter, now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expiresAt, err := app.ParseExpiresAt(req.ExpiresAt, now, sendAfter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if sendAfter.After(now) {
+		if req.Split {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "split cannot be combined with send_after"})
+			return
+		}
+		h.scheduleMessage(c, req, sendAfter, expiresAt)
+		return
+	}
+
+	var split *SplitResult
+	if req.Split {
+		split, err = h.service.SendSplitMessage(req.User, req.PhoneNumber, req.Message, req.Priority, req.SplitLength, expiresAt, req.Options)
+	} else {
+		err = h.service.SendMessage(req.User, req.PhoneNumber, req.Message, req.Priority, expiresAt, req.Options)
+	}
+	if err != nil && split != nil && split.Sent > 0 {
+		h.app.Logger.Printf("Split message from user %s to %s stopped after %d of %d parts: %v", req.User, req.PhoneNumber, split.Sent, len(split.Parts), err)
+		c.JSON(http.StatusOK, gin.H{
+			"error":   "Message partly sent",
+			"details": err.Error(),
+			"sent":    split.Sent,
+			"parts":   split.Parts,
+		})
+		return
+	}
+	if err != nil {
+		h.respondSendError(c, req.User, req.PhoneNumber, err)
+		return
+	}
+
+	if split != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"msg":   "Message sent successfully",
+			"sent":  split.Sent,
+			"parts": split.Parts,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "Message sent successfully"})
+}
+
+// SendContactHandler handles sending contact cards
+func (h *Handlers) SendContactHandler(c *gin.Context) {
+	var req SendContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if _, err := app.ParseSendPriority(req.Priority); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Options.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cards, err := req.Cards()
+	if err == nil {
+		_, err = buildVCards(cards)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expiresAt, err := app.ParseExpiresAt(req.ExpiresAt, time.Now(), time.Time{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	messageID, err := h.service.SendContacts(req.User, req.PhoneNumber, cards, req.Priority, expiresAt, req.Options)
+	if err != nil {
+		h.respondSendError(c, req.User, req.PhoneNumber, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "Contact sent successfully", "message_id": messageID})
+}
+
+// respondSendError maps an error of an immediate send to its response
+func (h *Handlers) respondSendError(c *gin.Context, user, phoneNumber string, err error) {
+	if dupErr, ok := isDuplicateMessageError(err); ok {
+		retrySeconds := int(dupErr.RetryAfter.Seconds())
+		if retrySeconds < 1 {
+			retrySeconds = 1
+		}
+		h.app.Logger.Printf("Message cooldown active for user %s to %s", user, phoneNumber)
+		c.JSON(http.StatusOK, gin.H{
+			"warn":                "Message cooldown active",
+			"details":             dupErr.Error(),
+			"retry_after_seconds": retrySeconds,
+		})
+		return
+	}
+
+	var tooLong *app.TooLongError
+	if errors.As(err, &tooLong) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Message too long",
+			"details": err.Error(),
+			"field":   tooLong.Field,
+			"length":  tooLong.Length,
+			"limit":   tooLong.Limit,
+		})
+		return
+	}
+
+	var rejected *contentfilter.RejectedError
+	if errors.As(err, &rejected) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":      "Message rejected by content filter",
+			"details":    err.Error(),
+			"violations": rejected.Violations,
+		})
+		return
+	}
+
+	if errors.Is(err, app.ErrReceiveOnly) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Session is receive-only",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, optin.ErrOptInPending) {
+		c.JSON(http.StatusOK, gin.H{
+			"warn":    "Recipient has not opted in",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var throttled *app.ThrottledError
+	if errors.As(err, &throttled) {
+		c.Header("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":               "WhatsApp is throttling this session",
+			"details":             err.Error(),
+			"retry_after_seconds": throttled.RetryAfterSeconds(),
+		})
+		return
+	}
+
+	if errors.Is(err, app.ErrMessageExpired) {
+		h.app.Logger.Printf("Message from user %s to %s expired before it could be sent", user, phoneNumber)
+		c.JSON(http.StatusOK, gin.H{
+			"error":   "Message expired",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Log the detailed error
+	h.app.Logger.Printf("Message send error: %v", err)
+
+	// Return 200 status with error details
+	c.JSON(http.StatusOK, gin.H{
+		"error":   "Message cannot be send",
+		"details": err.Error(),
+	})
+}
+
+// scheduleMessage holds a text message in the outbox until sendAfter
+func (h *Handlers) scheduleMessage(c *gin.Context, req SendMessageRequest, sendAfter, expiresAt time.Time) {
+	outboxID, err := h.service.ScheduleMessage(req.User, req.PhoneNumber, req.Message, req.Priority, sendAfter, expiresAt, req.Options)
 	if err != nil {
 		if dupErr, ok := isDuplicateMessageError(err); ok {
 			retrySeconds := int(dupErr.RetryAfter.Seconds())
 			if retrySeconds < 1 {
 				retrySeconds = 1
 			}
-			h.app.Logger.Printf("Message cooldown active for user %s to %s", req.User, req.PhoneNumber)
 			c.JSON(http.StatusOK, gin.H{
 				"warn":                "Message cooldown active",
 				"details":             dupErr.Error(),
@@ -45,18 +239,57 @@ func (h *Handlers) SendMessageHandler(c *gin.Context) {
 			return
 		}
 
-		// Log the detailed error
-		h.app.Logger.Printf("Message send error: %v", err)
+		var tooLong *app.TooLongError
+		if errors.As(err, &tooLong) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "Message too long",
+				"details": err.Error(),
+				"field":   tooLong.Field,
+				"length":  tooLong.Length,
+				"limit":   tooLong.Limit,
+			})
+			return
+		}
 
-		// Return 200 status with error details
+		var rejected *contentfilter.RejectedError
+		if errors.As(err, &rejected) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":      "Message rejected by content filter",
+				"details":    err.Error(),
+				"violations": rejected.Violations,
+			})
+			return
+		}
+
+		if errors.Is(err, app.ErrReceiveOnly) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Session is receive-only",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, optin.ErrOptInPending) {
+			c.JSON(http.StatusOK, gin.H{
+				"warn":    "Recipient has not opted in",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		h.app.Logger.Printf("Message schedule error: %v", err)
 		c.JSON(http.StatusOK, gin.H{
-			"error":   "Message cannot be send",
+			"error":   "Message cannot be scheduled",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"msg": "Message sent successfully"})
+	c.JSON(http.StatusAccepted, gin.H{
+		"msg":        "Message scheduled",
+		"outbox_id":  outboxID,
+		"send_after": sendAfter,
+	})
 }
 
 // MarkReadHandler handles marking messages as read
@@ -67,7 +300,12 @@ func (h *Handlers) MarkReadHandler(c *gin.Context) {
 		return
 	}
 
-	err := h.service.MarkRead(req.User, req.MessageID, req.FromJID, req.ToJID)
+	if len(req.Chats) > 0 {
+		h.markReadBatch(c, req)
+		return
+	}
+
+	receiptType, err := h.service.MarkRead(req.User, req.MessageID, req.FromJID, req.ToJID)
 	if err != nil {
 		// Log the detailed error
 		h.app.Logger.Printf("Mark read error: %v", err)
@@ -80,5 +318,112 @@ func (h *Handlers) MarkReadHandler(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"msg": "Messages marked as read"})
+	c.JSON(http.StatusOK, gin.H{"msg": "Messages marked as read", "receipt_type": receiptType})
+}
+
+// markReadBatch marks messages as read across the chats of a request
+func (h *Handlers) markReadBatch(c *gin.Context, req MarkReadRequest) {
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	for i, chat := range req.Chats {
+		if chat.Chat == "" || len(chat.MessageIDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("chats[%d] needs chat and message_ids", i)})
+			return
+		}
+	}
+
+	results, err := h.service.MarkReadBatch(req.User, req.Chats)
+	if err != nil {
+		h.app.Logger.Printf("Mark read error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Messages cannot be marked as read",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status == "failed" {
+			failed++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"read":    len(results) - failed,
+		"failed":  failed,
+	})
+}
+
+// AckHandler handles acknowledging incoming messages, marking them as read
+// when the session's auto-read mode is ack
+func (h *Handlers) AckHandler(c *gin.Context) {
+	var req AckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	if req.Chat == "" || len(req.MessageIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing chat or message_ids"})
+		return
+	}
+
+	marked, receiptType, err := h.service.MarkAcknowledged(req.User, app.AutoReadAck, req.Chat, req.Sender, req.MessageIDs)
+	if !marked {
+		c.JSON(http.StatusConflict, gin.H{"error": "Session auto_read is not set to ack"})
+		return
+	}
+	if err != nil {
+		h.app.Logger.Printf("Ack error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Messages cannot be marked as read",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"msg": "Messages marked as read", "receipt_type": receiptType})
+}
+
+// RevokeHandler deletes a message sent by the session for everyone
+func (h *Handlers) RevokeHandler(c *gin.Context) {
+	var req RevokeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	if req.Chat == "" || req.MessageID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing chat or message_id"})
+		return
+	}
+	if _, err := utils.ChatJID(req.Chat); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chat", "details": err.Error()})
+		return
+	}
+
+	err := h.service.RevokeMessage(req.User, req.Chat, req.MessageID)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"msg": "Message revoked", "message_id": req.MessageID})
+	case errors.Is(err, app.ErrReceiveOnly):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Session is receive-only", "details": err.Error()})
+	case errors.Is(err, ErrNotOwnMessage):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found", "details": err.Error()})
+	case errors.Is(err, ErrAlreadyRevoked):
+		c.JSON(http.StatusConflict, gin.H{"error": "Message already revoked", "details": err.Error()})
+	default:
+		h.app.Logger.Printf("Revoke error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Message cannot be revoked", "details": err.Error()})
+	}
 }
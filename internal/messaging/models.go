@@ -1,16 +1,148 @@
 package messaging
 
+import (
+	"fmt"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"go.mau.fi/whatsmeow/types"
+)
+
 // SendMessageRequest represents a request to send a text message
 type SendMessageRequest struct {
 	User        string `json:"user"`
 	PhoneNumber string `json:"phone_number"`
 	Message     string `json:"message"`
+	Priority    string `json:"priority"`     // Optional: high, normal (default) or low
+	SendAfter   string `json:"send_after"`   // Optional: delay such as "5m", or RFC 3339 timestamp
+	ExpiresAt   string `json:"expires_at"`   // Optional: deadline after which the message is dropped, same formats
+	Split       bool   `json:"split"`        // Optional: send overlong messages as numbered parts instead of rejecting them
+	SplitLength int    `json:"split_length"` // Optional: longest part when splitting, WhatsApp's limit by default
+
+	// Optional: reply to a message. The sender is the quoted message's author
+	// as a phone number or JID; the text is shown in the quote bubble.
+	QuotedMessageID string `json:"quoted_message_id"`
+	QuotedSender    string `json:"quoted_sender"`
+	QuotedText      string `json:"quoted_text"`
+
+	Options *app.SendOptions `json:"options"` // Optional: link preview and disappearing message settings
+}
+
+// SendOptions returns the options of the send with its quoted message, if
+// any, applied. The request's Options are not modified.
+func (r SendMessageRequest) SendOptions() (*app.SendOptions, error) {
+	if r.QuotedMessageID == "" {
+		if r.QuotedSender != "" || r.QuotedText != "" {
+			return nil, fmt.Errorf("quoted_message_id is required to quote a message")
+		}
+		return r.Options, r.Options.Validate()
+	}
+
+	var options app.SendOptions
+	if r.Options != nil {
+		options = *r.Options
+	}
+	options.Quote = &app.QuotedMessage{
+		MessageID: r.QuotedMessageID,
+		Sender:    r.QuotedSender,
+		Text:      r.QuotedText,
+	}
+	return &options, options.Validate()
+}
+
+// ContactCard is a contact to send, given as a name and phone number or as a
+// raw vCard
+type ContactCard struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+	VCard string `json:"vcard"`
+}
+
+// SendContactRequest represents a request to send one contact card (contact)
+// or several in one message (contacts)
+type SendContactRequest struct {
+	User        string        `json:"user"`
+	PhoneNumber string        `json:"phone_number"`
+	Contact     *ContactCard  `json:"contact"`
+	Contacts    []ContactCard `json:"contacts"`
+	Priority    string        `json:"priority"`   // Optional: high, normal (default) or low
+	ExpiresAt   string        `json:"expires_at"` // Optional: deadline after which the message is dropped
+
+	Options *app.SendOptions `json:"options"` // Optional: disappearing message and quote settings
+}
+
+// Cards returns the contact cards of the request
+func (r SendContactRequest) Cards() ([]ContactCard, error) {
+	if r.Contact != nil {
+		if len(r.Contacts) > 0 {
+			return nil, fmt.Errorf("contact cannot be combined with contacts")
+		}
+		return []ContactCard{*r.Contact}, nil
+	}
+	return r.Contacts, nil
 }
 
-// MarkReadRequest represents a request to mark messages as read
+// States of the parts of a split message
+const (
+	SplitPartSent    = "sent"
+	SplitPartFailed  = "failed"
+	SplitPartSkipped = "skipped" // Not attempted because an earlier part failed
+)
+
+// SplitPart is the outcome of one part of a split message
+type SplitPart struct {
+	Part      int    `json:"part"`
+	Status    string `json:"status"`
+	OutboxID  string `json:"outbox_id,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SplitResult is the combined outcome of the parts of a split message, in the
+// order they were sent
+type SplitResult struct {
+	Sent  int         `json:"sent"`
+	Parts []SplitPart `json:"parts"`
+}
+
+// MarkReadRequest represents a request to mark messages as read, either in
+// one chat (message_ids, from_jid, to_jid) or across several chats (chats)
 type MarkReadRequest struct {
-	User      string   `json:"user"`
-	MessageID []string `json:"message_ids"`
-	FromJID   string   `json:"from_jid"`
-	ToJID     string   `json:"to_jid"`
+	User      string         `json:"user"`
+	MessageID []string       `json:"message_ids"`
+	FromJID   string         `json:"from_jid"`
+	ToJID     string         `json:"to_jid"`
+	Chats     []MarkReadChat `json:"chats"`
+}
+
+// MarkReadChat is a set of messages to mark as read in one chat
+type MarkReadChat struct {
+	Chat        string   `json:"chat"` // Phone number, user/LID JID or group JID
+	MessageIDs  []string `json:"message_ids"`
+	Participant string   `json:"participant"` // Sender of the messages, required for groups
+}
+
+// MarkReadResult is the outcome of marking one chat's messages as read
+type MarkReadResult struct {
+	Chat       string   `json:"chat"`
+	MessageIDs []string `json:"message_ids"`
+	Status     string   `json:"status"` // read or failed
+	Error      string   `json:"error,omitempty"`
+
+	// Receipt type sent: read, or read-self when read receipts are disabled
+	ReceiptType types.ReceiptType `json:"receipt_type,omitempty"`
+}
+
+// RevokeRequest deletes a message sent by the session for everyone in the chat
+type RevokeRequest struct {
+	User      string `json:"user"`
+	Chat      string `json:"chat"` // Phone number or JID the message was sent to
+	MessageID string `json:"message_id"`
+}
+
+// AckRequest acknowledges incoming messages delivered through an event sink
+type AckRequest struct {
+	User       string   `json:"user"`
+	Chat       string   `json:"chat"`   // chat of the message event
+	Sender     string   `json:"sender"` // sender of the message event
+	MessageIDs []string `json:"message_ids"`
 }
@@ -0,0 +1,66 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/utils"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// RevokeMessage deletes a message for everyone in chat. Only messages this
+// session sent, as recorded in its outbox, can be revoked; anything else
+// returns ErrNotOwnMessage.
+func (s *Service) RevokeMessage(user, chat, messageID string) error {
+	if s.app.Options.Get(user).ReceiveOnly {
+		return app.ErrReceiveOnly
+	}
+
+	chatJID, err := utils.CanonicalJID(chat)
+	if err != nil {
+		return fmt.Errorf("invalid chat: %v", err)
+	}
+
+	msg, found, err := s.app.Outbox.FindSent(user, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to look up message: %v", err)
+	}
+	if !found {
+		return ErrNotOwnMessage
+	}
+	if recipient, err := utils.CanonicalJID(msg.Recipient); err != nil || recipient != chatJID {
+		return ErrNotOwnMessage
+	}
+	if msg.Status == app.OutboxStatusRevoked {
+		return ErrAlreadyRevoked
+	}
+
+	sess, exists := s.sessionService.FindSessionByUser(user)
+	if !exists {
+		return fmt.Errorf("session not found")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Wait for a free operation slot on this session's websocket
+	release, err := s.app.AcquireClientOp(ctx, user)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// An empty sender revokes one of our own messages
+	revoke := sess.Client.BuildRevoke(chatJID, types.EmptyJID, types.MessageID(messageID))
+	if _, err := sess.Client.SendMessage(ctx, chatJID, revoke); err != nil {
+		return fmt.Errorf("failed to revoke message: %w", err)
+	}
+
+	s.app.Logger.Printf("Message %s revoked in %s by user %s", messageID, chatJID, user)
+	if err := s.app.Outbox.MarkRevoked(msg.ID); err != nil {
+		s.app.Logger.Printf("Failed to mark outbox message %s as revoked: %v", msg.ID, err)
+	}
+	return nil
+}
@@ -9,7 +9,10 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"github.com/neekaru/whatsappgo-bot/internal/optin"
 	"github.com/neekaru/whatsappgo-bot/internal/session"
+	"github.com/neekaru/whatsappgo-bot/internal/utils"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
@@ -19,6 +22,7 @@ import (
 type Service struct {
 	app            *app.App
 	sessionService *session.Service
+	optinService   *optin.Service
 }
 
 // NewService creates a new messaging service
@@ -26,6 +30,7 @@ func NewService(app *app.App) *Service {
 	return &Service{
 		app:            app,
 		sessionService: session.NewService(app),
+		optinService:   optin.NewService(app),
 	}
 }
 
@@ -90,25 +95,182 @@ func (s *Service) simulateTyping(client *whatsmeow.Client, recipient types.JID,
 	time.Sleep(humanDelay(200, 500))
 }
 
-// SendMessage sends a text message to a WhatsApp contact
-func (s *Service) SendMessage(user, phoneNumber, message string) error {
+// SendMessage sends a text message to a WhatsApp contact. A non-zero expiresAt
+// gives up with app.ErrMessageExpired once the deadline passes without a send.
+// Messages longer than WhatsApp accepts fail with an *app.TooLongError.
+// options may be nil.
+func (s *Service) SendMessage(user, phoneNumber, message, priority string, expiresAt time.Time, options *app.SendOptions) error {
+	_, err := s.SendMessageWithID(user, phoneNumber, message, priority, expiresAt, options)
+	return err
+}
+
+// SendMessageWithID sends a text message like SendMessage, returning its
+// WhatsApp message ID
+func (s *Service) SendMessageWithID(user, phoneNumber, message, priority string, expiresAt time.Time, options *app.SendOptions) (string, error) {
+	message = s.app.NormalizeContent(user, message, options)
+	if err := app.CheckLength("message", message, app.MaxTextLength); err != nil {
+		return "", err
+	}
+	priority, err := s.checkSend(user, phoneNumber, message, priority, options)
+	if err != nil {
+		return "", err
+	}
+	message, err = s.app.FilterContent(user, phoneNumber, message)
+	if err != nil {
+		return "", err
+	}
+	// Don't queue more sends while WhatsApp is throttling the session
+	if wait := s.app.SendLimiter.ThrottledFor(user); wait > 0 {
+		return "", &app.ThrottledError{RetryAfter: wait}
+	}
+	_, messageID, err := s.sendText(user, phoneNumber, message, priority, expiresAt, options)
+	return messageID, err
+}
+
+// SendSplitMessage sends a text message like SendMessage, but splits messages
+// longer than splitLength characters into numbered parts, broken at word
+// boundaries, that are sent one after the other in order. A splitLength of 0
+// splits at WhatsApp's limit. A failed part stops the rest; the result
+// describes every part either way, and is nil when nothing was attempted.
+func (s *Service) SendSplitMessage(user, phoneNumber, message, priority string, splitLength int, expiresAt time.Time, options *app.SendOptions) (*SplitResult, error) {
+	message = s.app.NormalizeContent(user, message, options)
+	if splitLength == 0 {
+		splitLength = app.MaxTextLength
+	}
+	if splitLength < minSplitLength || splitLength > app.MaxTextLength {
+		return nil, fmt.Errorf("split_length must be between %d and %d", minSplitLength, app.MaxTextLength)
+	}
+	// Duplicate limits and opt-in apply to the message as a whole, not to every part
+	priority, err := s.checkSend(user, phoneNumber, message, priority, options)
+	if err != nil {
+		return nil, err
+	}
+	message, err = s.app.FilterContent(user, phoneNumber, message)
+	if err != nil {
+		return nil, err
+	}
+	if wait := s.app.SendLimiter.ThrottledFor(user); wait > 0 {
+		return nil, &app.ThrottledError{RetryAfter: wait}
+	}
+
+	parts := splitNumbered(message, splitLength)
+	result := &SplitResult{Parts: make([]SplitPart, len(parts))}
+	for i := range result.Parts {
+		result.Parts[i] = SplitPart{Part: i + 1, Status: SplitPartSkipped}
+	}
+	for i, part := range parts {
+		partOptions := options
+		if i > 0 && options != nil && options.Quote != nil {
+			// Only the first part replies to the quoted message
+			withoutQuote := *options
+			withoutQuote.Quote = nil
+			partOptions = &withoutQuote
+		}
+
+		outboxID, messageID, err := s.sendText(user, phoneNumber, part, priority, expiresAt, partOptions)
+		result.Parts[i].OutboxID = outboxID
+		if err != nil {
+			result.Parts[i].Status = SplitPartFailed
+			result.Parts[i].Error = err.Error()
+			return result, err
+		}
+		result.Parts[i].Status = SplitPartSent
+		result.Parts[i].MessageID = messageID
+		result.Sent++
+	}
+	if len(parts) > 1 {
+		s.app.Logger.Printf("Message to %s from user %s sent in %d parts", phoneNumber, user, len(parts))
+	}
+	return result, nil
+}
+
+// sendText records a checked text message in the outbox, waits for its turn
+// and sends it, returning its outbox and WhatsApp message IDs
+func (s *Service) sendText(user, phoneNumber, message, priority string, expiresAt time.Time, options *app.SendOptions) (string, string, error) {
+	outboxID, err := s.app.Outbox.Record(app.OutboxMessage{
+		User:      user,
+		Recipient: phoneNumber,
+		Type:      "text",
+		Body:      message,
+		Priority:  priority,
+		ExpiresAt: app.OptionalTime(expiresAt),
+		Options:   options,
+	})
+	if err != nil {
+		s.app.Logger.Printf("Warning: %v", err)
+	}
+
+	// Use random delay instead of fixed delay to avoid bot detection
+	s.app.SendLimiter.WaitPriority(user, priority, randomSendDelay())
+
+	messageID, err := s.sendRecorded(user, phoneNumber, message, outboxID, expiresAt, options)
+	if err == nil {
+		s.optinService.Sent(user, phoneNumber, priority)
+	}
+	return outboxID, messageID, err
+}
+
+// ScheduleMessage validates a text message like SendMessage, then holds it in
+// the outbox until sendAfter, returning its outbox ID. The outbox scheduler
+// sends it once due, unless expiresAt has passed by then.
+func (s *Service) ScheduleMessage(user, phoneNumber, message, priority string, sendAfter, expiresAt time.Time, options *app.SendOptions) (string, error) {
+	message = s.app.NormalizeContent(user, message, options)
+	if err := app.CheckLength("message", message, app.MaxTextLength); err != nil {
+		return "", err
+	}
+	priority, err := s.checkSend(user, phoneNumber, message, priority, options)
+	if err != nil {
+		return "", err
+	}
+	message, err = s.app.FilterContent(user, phoneNumber, message)
+	if err != nil {
+		return "", err
+	}
+
+	outboxID, err := s.app.Outbox.Record(app.OutboxMessage{
+		User:      user,
+		Recipient: phoneNumber,
+		Type:      "text",
+		Body:      message,
+		Priority:  priority,
+		SendAfter: &sendAfter,
+		ExpiresAt: app.OptionalTime(expiresAt),
+		Options:   options,
+	})
+	if err != nil {
+		return "", err
+	}
+	s.app.Logger.Printf("Message to %s from user %s scheduled for %s", phoneNumber, user, sendAfter.Format(time.RFC3339))
+	return outboxID, nil
+}
+
+// checkSend rejects sends from receive-only sessions, validates the recipient
+// and options and applies the duplicate limits and opt-in confirmation to a
+// text message, returning its parsed priority
+func (s *Service) checkSend(user, phoneNumber, message, priority string, options *app.SendOptions) (string, error) {
 	const duplicateWindow = 15 * time.Second
 	const duplicateMax = 3
 	const duplicateMessageWindow = 15 * time.Second
 	const duplicateMessageMax = 1
 
+	if s.app.Options.Get(user).ReceiveOnly {
+		return "", app.ErrReceiveOnly
+	}
+
 	// Check if phoneNumber is empty or only whitespace
 	if strings.TrimSpace(phoneNumber) == "" {
 		s.app.Logger.Printf("Warning: phone number is empty for user %s", user)
-		return fmt.Errorf("phone number is empty, cannot send message")
+		return "", fmt.Errorf("phone number is empty, cannot send message")
 	}
 	// Check if phoneNumber is valid: all digits or starts with '+' followed by digits
-	valid := true
-	if phoneNumber[0] == '+' {
-		if len(phoneNumber) == 1 {
+	// LID recipients ("123@lid") are validated on their numeric part
+	number := utils.RecipientNumber(phoneNumber)
+	valid := number != ""
+	if valid && number[0] == '+' {
+		if len(number) == 1 {
 			valid = false
 		} else {
-			for _, c := range phoneNumber[1:] {
+			for _, c := range number[1:] {
 				if c < '0' || c > '9' {
 					valid = false
 					break
@@ -116,7 +278,7 @@ func (s *Service) SendMessage(user, phoneNumber, message string) error {
 			}
 		}
 	} else {
-		for _, c := range phoneNumber {
+		for _, c := range number {
 			if c < '0' || c > '9' {
 				valid = false
 				break
@@ -125,42 +287,111 @@ func (s *Service) SendMessage(user, phoneNumber, message string) error {
 	}
 	if !valid {
 		s.app.Logger.Printf("Warning: phone number is invalid for user %s: %s", user, phoneNumber)
-		return fmt.Errorf("phone number is invalid, must be all digits or start with '+' followed by digits")
+		return "", fmt.Errorf("phone number is invalid, must be all digits or start with '+' followed by digits")
+	}
+
+	if whatsappClient, ok := s.app.GetClientManager().GetClient(user); ok {
+		if err := whatsappClient.VerifyPhone(); err != nil {
+			return "", err
+		}
 	}
 
 	dupKey := fmt.Sprintf("num|%s|%s", user, phoneNumber)
 	allowed, retryAfter := s.app.DuplicateLimiter.Allow(dupKey, duplicateMax, duplicateWindow)
 	if !allowed {
-		return &DuplicateMessageError{RetryAfter: retryAfter}
+		return "", &DuplicateMessageError{RetryAfter: retryAfter}
 	}
 
 	msgKey := fmt.Sprintf("msg|%s|%s|%s", user, phoneNumber, message)
 	msgAllowed, msgRetryAfter := s.app.DuplicateLimiter.Allow(msgKey, duplicateMessageMax, duplicateMessageWindow)
 	if !msgAllowed {
-		return &DuplicateMessageError{RetryAfter: msgRetryAfter}
+		return "", &DuplicateMessageError{RetryAfter: msgRetryAfter}
 	}
 
-	// Use random delay instead of fixed delay to avoid bot detection
-	s.app.SendLimiter.Wait(user, randomSendDelay())
+	if err := options.Validate(); err != nil {
+		return "", err
+	}
+	if options != nil && options.Quote != nil {
+		if _, err := quoteParticipant(utils.RecipientJID(phoneNumber), options.Quote); err != nil {
+			return "", err
+		}
+	}
 
-	return s.sendMessageWithRetry(user, phoneNumber, message)
+	priority, err := app.ParseSendPriority(priority)
+	if err != nil {
+		return "", err
+	}
+	if err := s.optinService.Check(user, phoneNumber, priority); err != nil {
+		return "", err
+	}
+	return priority, nil
+}
+
+// ResendOutboxMessage sends a dead-lettered or due scheduled text or contact message under its
+// existing outbox ID. Duplicate checks are skipped because they ran when the message was accepted.
+func (s *Service) ResendOutboxMessage(msg app.OutboxMessage) error {
+	s.app.SendLimiter.WaitPriority(msg.User, msg.Priority, randomSendDelay())
+	var err error
+	if msg.Type == "contact" {
+		_, err = s.sendContactsRecorded(msg.User, msg.Recipient, splitVCards(msg.Body), msg.ID, msg.Deadline(), msg.Options)
+	} else {
+		_, err = s.sendRecorded(msg.User, msg.Recipient, msg.Body, msg.ID, msg.Deadline(), msg.Options)
+	}
+	if err == nil {
+		s.optinService.Sent(msg.User, msg.Recipient, msg.Priority)
+	}
+	return err
+}
+
+// sendRecorded sends a text message and stores the outcome in the outbox,
+// returning the WhatsApp message ID
+func (s *Service) sendRecorded(user, phoneNumber, message, outboxID string, expiresAt time.Time, options *app.SendOptions) (string, error) {
+	msg := textMessage(message, utils.RecipientJID(phoneNumber), options)
+	return s.sendBuiltRecorded(user, phoneNumber, msg, message, outboxID, expiresAt, options)
+}
+
+// sendBuiltRecorded sends a built message and stores the outcome in the
+// outbox, returning the WhatsApp message ID. text is the message's text as
+// recorded for threads.
+func (s *Service) sendBuiltRecorded(user, phoneNumber string, msg *waE2E.Message, text, outboxID string, expiresAt time.Time, options *app.SendOptions) (string, error) {
+	messageID, err := s.sendMessageWithRetry(user, phoneNumber, msg, text, outboxID, expiresAt, options)
+	if finishErr := s.app.Outbox.Finish(outboxID, messageID, err); finishErr != nil {
+		s.app.Logger.Printf("Warning: failed to update outbox message %s: %v", outboxID, finishErr)
+	}
+	return messageID, err
 }
 
 // sendMessageWithRetry attempts to send a message with automatic reconnection and retry
-// if a websocket disconnection error occurs
-func (s *Service) sendMessageWithRetry(user, phoneNumber, message string) error {
+// if a websocket disconnection error occurs, returning the WhatsApp message ID.
+// It stops with app.ErrMessageExpired once expiresAt passes.
+func (s *Service) sendMessageWithRetry(user, phoneNumber string, msg *waE2E.Message, message, outboxID string, expiresAt time.Time, options *app.SendOptions) (string, error) {
 	maxRetries := 3
 	var lastErr error
 
+	// Also covers scheduled messages and resends recorded before the
+	// session became receive-only
+	if s.app.Options.Get(user).ReceiveOnly {
+		return "", app.ErrReceiveOnly
+	}
+
 	for attempt := 0; attempt < maxRetries; attempt++ {
+		if app.MessageExpired(expiresAt) {
+			return "", app.ErrMessageExpired
+		}
+		_ = s.app.Outbox.RecordAttempt(outboxID)
+
 		// Get the session
 		sess, exists := s.sessionService.FindSessionByUser(user)
 		if !exists {
-			return fmt.Errorf("session not found")
+			return "", fmt.Errorf("session not found")
 		}
 
+		// Sandbox sessions accept the message without connecting to WhatsApp
+		whatsappClient, hasClient := s.app.GetClientManager().GetClient(user)
+		sandbox := hasClient && whatsappClient.IsSandbox()
+
 		// Ensure client is connected before sending
-		if !sess.Client.IsConnected() {
+		if !sandbox && !sess.Client.IsConnected() {
 			err := sess.Client.Connect()
 			if err != nil {
 				s.app.Logger.Printf("Failed to connect on attempt %d: %v", attempt+1, err)
@@ -171,17 +402,11 @@ func (s *Service) sendMessageWithRetry(user, phoneNumber, message string) error
 		}
 
 		// Create recipient JID
-		recipient := types.JID{
-			User:   phoneNumber,
-			Server: "s.whatsapp.net",
-		}
+		recipient := utils.RecipientJID(phoneNumber)
 
 		// === ANTI-BAN: Simulate human typing behavior ===
-		s.simulateTyping(sess.Client, recipient, len(message))
-
-		// Create message and send
-		msg := &waE2E.Message{
-			Conversation: proto.String(message),
+		if !sandbox {
+			s.simulateTyping(sess.Client, recipient, len(message))
 		}
 
 		opts := whatsmeow.SendRequestExtra{
@@ -191,19 +416,40 @@ func (s *Service) sendMessageWithRetry(user, phoneNumber, message string) error
 		// Use a context with a longer timeout (60 seconds) for message sending operations
 		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 
+		// Wait for a free operation slot on this session's websocket
+		release, err := s.app.AcquireClientOp(ctx, user)
+		if err != nil {
+			cancel()
+			return "", err
+		}
+
+		// Connecting and typing take time; don't send once the deadline has passed
+		if app.MessageExpired(expiresAt) {
+			release()
+			cancel()
+			return "", app.ErrMessageExpired
+		}
+
 		// Send the message
-		_, err := sess.Client.SendMessage(ctx, recipient, msg, opts)
+		sentAt := time.Now()
+		var resp whatsmeow.SendResponse
+		if sandbox {
+			resp, err = whatsappClient.SandboxSend(recipient, msg, opts)
+		} else {
+			resp, err = sess.Client.SendMessage(ctx, recipient, msg, opts)
+		}
+		release()
 		cancel() // Cancel the context after sending
 
 		if err != nil {
-			lastErr = fmt.Errorf("failed to send message: %v", err)
+			lastErr = fmt.Errorf("failed to send message: %w", err)
 
 			// Check if this is a websocket disconnection error
-			if strings.Contains(err.Error(), "websocket disconnected") {
+			if client.IsDisconnected(err) {
 				// Check if the user is logged in before attempting to reconnect
 				if !sess.IsLoggedIn {
 					s.app.Logger.Printf("User %s is not logged in, not attempting to reconnect", user)
-					return fmt.Errorf("user is not logged in, cannot reconnect: %v", lastErr)
+					return "", fmt.Errorf("user is not logged in, cannot reconnect: %w", lastErr)
 				}
 
 				s.app.Logger.Printf("Websocket disconnected during message send (attempt %d/%d). Reconnecting...",
@@ -225,50 +471,214 @@ func (s *Service) sendMessageWithRetry(user, phoneNumber, message string) error
 				continue
 			}
 
+			// Back off while WhatsApp throttles the session
+			if app.IsThrottling(err) {
+				retryAfter := s.app.SendLimiter.Throttle(user)
+				s.app.Logger.Printf("WhatsApp is throttling user %s, backing off sends for %s", user, retryAfter)
+				return "", &app.ThrottledError{RetryAfter: retryAfter, Err: err}
+			}
+
 			// For other types of errors, return immediately
-			return lastErr
+			return "", lastErr
 		}
 
 		// If we get here, the message was sent successfully
 		s.app.Logger.Printf("Message sent successfully to %s from user %s", recipient.String(), user)
+		s.app.RecordSentThread(user, recipient, string(resp.ID), message, options, resp.Timestamp)
+		s.app.Deliveries.Sent(user, string(resp.ID), sentAt)
+		s.app.SendLimiter.ClearThrottle(user)
+		if hasClient {
+			whatsappClient.RecordMessageSent()
+		}
 
-		// Post-send: set presence back to unavailable after a random delay
-		go func() {
-			time.Sleep(humanDelay(2000, 5000))
-			_ = sess.Client.SendPresence(context.Background(), types.PresenceUnavailable)
-		}()
+		// Post-send: set presence back to unavailable after a random delay,
+		// unless the session was set available through /wa/presence
+		if !sandbox && (!hasClient || !whatsappClient.StaysOnline()) {
+			go func() {
+				time.Sleep(humanDelay(2000, 5000))
+				_ = sess.Client.SendPresence(context.Background(), types.PresenceUnavailable)
+			}()
+		}
 
-		return nil
+		return string(resp.ID), nil
 	}
 
 	// If we've exhausted all retries, return the last error
-	return lastErr
+	return "", lastErr
+}
+
+// textMessage builds a text message to recipient, using an extended text
+// message when options need fields a plain conversation message does not have
+func textMessage(message string, recipient types.JID, options *app.SendOptions) *waE2E.Message {
+	if options.IsZero() {
+		return &waE2E.Message{
+			Conversation: proto.String(message),
+		}
+	}
+
+	extended := &waE2E.ExtendedTextMessage{
+		Text: proto.String(message),
+	}
+	if options.NoLinkPreview {
+		extended.PreviewType = waE2E.ExtendedTextMessage_NONE.Enum()
+	}
+	extended.ContextInfo = contextInfo(recipient, options)
+	return &waE2E.Message{ExtendedTextMessage: extended}
+}
+
+// contextInfo builds the context of a message to recipient carrying its
+// disappearing timer and quoted message, or nil when options need neither
+func contextInfo(recipient types.JID, options *app.SendOptions) *waE2E.ContextInfo {
+	if options == nil || (options.EphemeralSeconds == 0 && options.Quote == nil) {
+		return nil
+	}
+
+	info := &waE2E.ContextInfo{}
+	if options.EphemeralSeconds > 0 {
+		info.Expiration = proto.Uint32(options.EphemeralSeconds)
+	}
+	if quote := options.Quote; quote != nil {
+		info.StanzaID = proto.String(quote.MessageID)
+		if participant, err := quoteParticipant(recipient, quote); err == nil && !participant.IsEmpty() {
+			info.Participant = proto.String(participant.String())
+		}
+		info.QuotedMessage = &waE2E.Message{
+			Conversation: proto.String(quote.Text),
+		}
+	}
+	return info
+}
+
+// quoteParticipant returns the author of a quoted message in a chat with
+// recipient, or an empty JID when a direct chat reply names none. Group
+// replies must name the sender, as any member may have written the message.
+func quoteParticipant(recipient types.JID, quote *app.QuotedMessage) (types.JID, error) {
+	if quote.Sender == "" {
+		if recipient.Server == types.GroupServer {
+			return types.JID{}, fmt.Errorf("quoted_sender is required to quote a message in group %s", recipient)
+		}
+		return types.EmptyJID, nil
+	}
+
+	sender, err := utils.CanonicalJID(quote.Sender)
+	if err != nil {
+		return types.JID{}, fmt.Errorf("invalid quoted_sender: %v", err)
+	}
+	return sender, nil
 }
 
-// MarkRead marks messages as read
-func (s *Service) MarkRead(user string, messageIDs []string, fromJID, toJID string) error {
+// MarkRead marks messages as read and returns the receipt type that was sent
+func (s *Service) MarkRead(user string, messageIDs []string, fromJID, toJID string) (types.ReceiptType, error) {
 	sess, exists := s.sessionService.FindSessionByUser(user)
 	if !exists {
-		return fmt.Errorf("session not found")
+		return "", fmt.Errorf("session not found")
 	}
 
+	return s.markRead(sess.Client, messageIDs, utils.RecipientJID(toJID), utils.RecipientJID(fromJID))
+}
+
+// MarkReadBatch marks messages as read across several chats. A chat that
+// fails does not stop the others; its result carries the error.
+func (s *Service) MarkReadBatch(user string, chats []MarkReadChat) ([]MarkReadResult, error) {
+	sess, exists := s.sessionService.FindSessionByUser(user)
+	if !exists {
+		return nil, fmt.Errorf("session not found")
+	}
+
+	results := make([]MarkReadResult, len(chats))
+	for i, chat := range chats {
+		results[i] = MarkReadResult{Chat: chat.Chat, MessageIDs: chat.MessageIDs, Status: "read"}
+
+		chatJID, sender, err := markReadTarget(chat)
+		if err == nil {
+			results[i].ReceiptType, err = s.markRead(sess.Client, chat.MessageIDs, chatJID, sender)
+		}
+		if err != nil {
+			s.app.Logger.Printf("Mark read error for chat %s of user %s: %v", chat.Chat, user, err)
+			results[i].Status = "failed"
+			results[i].Error = err.Error()
+		}
+	}
+
+	return results, nil
+}
+
+// markReadTarget resolves the chat and sender JIDs of a batch entry. Group
+// receipts must name the participant who sent the messages.
+func markReadTarget(chat MarkReadChat) (types.JID, types.JID, error) {
+	chatJID, err := utils.ChatJID(chat.Chat)
+	if err != nil {
+		return types.JID{}, types.JID{}, err
+	}
+
+	if chat.Participant == "" {
+		if chatJID.Server == types.GroupServer {
+			return types.JID{}, types.JID{}, fmt.Errorf("participant is required for group chat %s", chatJID)
+		}
+		return chatJID, types.EmptyJID, nil
+	}
+
+	sender, err := utils.ChatJID(chat.Participant)
+	if err != nil {
+		return types.JID{}, types.JID{}, fmt.Errorf("invalid participant: %v", err)
+	}
+	return chatJID, sender, nil
+}
+
+// markRead sends a read receipt for messages of one sender in one chat and
+// returns the receipt type used
+func (s *Service) markRead(whatsappClient *whatsmeow.Client, messageIDs []string, chat, sender types.JID) (types.ReceiptType, error) {
 	// Convert string message IDs to types.MessageID
 	typedMessageIDs := make([]types.MessageID, len(messageIDs))
 	for i, id := range messageIDs {
 		typedMessageIDs[i] = types.MessageID(id)
 	}
 
-	fromJIDObj := types.JID{User: fromJID, Server: "s.whatsapp.net"}
-	toJIDObj := types.JID{User: toJID, Server: "s.whatsapp.net"}
-
 	// Use a context with a timeout for the MarkRead operation
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	err := sess.Client.MarkRead(ctx, typedMessageIDs, time.Now(), toJIDObj, fromJIDObj, types.ReceiptTypeRead)
+	receiptType := readReceiptType(ctx, whatsappClient, chat)
+	err := whatsappClient.MarkRead(ctx, typedMessageIDs, time.Now(), chat, sender, receiptType)
 	if err != nil {
-		return fmt.Errorf("failed to mark as read: %v", err)
+		return "", fmt.Errorf("failed to mark as read: %v", err)
+	}
+
+	return receiptType, nil
+}
+
+// readReceiptType returns the receipt type to mark messages as read with.
+// Accounts with read receipts disabled, and newsletters, can only send
+// read-self receipts: the messages are marked read on the account's own
+// devices but the sender sees no blue ticks.
+func readReceiptType(ctx context.Context, whatsappClient *whatsmeow.Client, chat types.JID) types.ReceiptType {
+	if chat.Server == types.NewsletterServer {
+		return types.ReceiptTypeReadSelf
+	}
+	if whatsappClient.GetPrivacySettings(ctx).ReadReceipts == types.PrivacySettingNone {
+		return types.ReceiptTypeReadSelf
 	}
+	return types.ReceiptTypeRead
+}
 
-	return nil
+// MarkAcknowledged marks incoming messages as read on behalf of a consumer
+// that acknowledged them in the given way. It returns false without marking
+// anything when the session's auto-read mode is different.
+func (s *Service) MarkAcknowledged(user, mode, chat, sender string, messageIDs []string) (bool, types.ReceiptType, error) {
+	if s.app.Options.Get(user).AutoRead != mode {
+		return false, "", nil
+	}
+
+	results, err := s.MarkReadBatch(user, []MarkReadChat{{
+		Chat:        chat,
+		Participant: sender,
+		MessageIDs:  messageIDs,
+	}})
+	if err != nil {
+		return true, "", err
+	}
+	if results[0].Error != "" {
+		return true, "", fmt.Errorf("%s", results[0].Error)
+	}
+	return true, results[0].ReceiptType, nil
 }
@@ -0,0 +1,77 @@
+package messaging
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Shortest part length a caller may split messages into; shorter parts would
+// be mostly numbering
+const minSplitLength = 100
+
+// splitNumbered splits text into parts of at most limit characters, each
+// starting with its position such as "(1/3) ". Parts end at paragraph, line
+// or word boundaries where possible. Text within the limit is returned as is.
+func splitNumbered(text string, limit int) []string {
+	if len([]rune(text)) <= limit {
+		return []string{text}
+	}
+
+	// The prefix grows with the number of parts, which in turn depends on how
+	// much room the prefix leaves; retry until the count fits its own prefix
+	count := 9
+	for {
+		chunks := splitWords(text, limit-len(partPrefix(count, count)))
+		if len(partPrefix(len(chunks), len(chunks))) <= len(partPrefix(count, count)) {
+			parts := make([]string, len(chunks))
+			for i, chunk := range chunks {
+				parts[i] = partPrefix(i+1, len(chunks)) + chunk
+			}
+			return parts
+		}
+		count = len(chunks)
+	}
+}
+
+// splitWords splits text into chunks of at most size characters. A chunk ends
+// at the last paragraph break, line break or space in its second half, and is
+// cut mid-word only when there is none. Whitespace around breaks is dropped.
+func splitWords(text string, size int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	var chunks []string
+	for len(runes) > size {
+		end := breakPoint(runes[:size+1])
+		chunks = append(chunks, strings.TrimRightFunc(string(runes[:end]), unicode.IsSpace))
+		runes = []rune(strings.TrimLeftFunc(string(runes[end:]), unicode.IsSpace))
+	}
+	if len(runes) > 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
+
+// breakPoint returns where to end a chunk of window minus its last character,
+// which is included only to tell whether the chunk ends right before a space
+func breakPoint(window []rune) int {
+	size := len(window) - 1
+	text := string(window)
+	for _, sep := range []string{"\n\n", "\n"} {
+		if i := strings.LastIndex(text, sep); i >= 0 {
+			if end := len([]rune(text[:i])); end >= size/2 {
+				return end
+			}
+		}
+	}
+	for end := size; end >= size/2; end-- {
+		if unicode.IsSpace(window[end]) {
+			return end
+		}
+	}
+	return size
+}
+
+// partPrefix numbers a part of a split message
+func partPrefix(part, count int) string {
+	return fmt.Sprintf("(%d/%d) ", part, count)
+}
@@ -0,0 +1,10 @@
+package optin
+
+import "errors"
+
+var (
+	// ErrOptInPending is returned for low priority sends to a recipient that has not confirmed opt-in
+	ErrOptInPending = errors.New("recipient has not confirmed opt-in, low priority messages are held back until they reply with the opt-in keyword")
+	// ErrOptInUnavailable is returned when the opt-in database could not be opened
+	ErrOptInUnavailable = errors.New("opt-in store is not available")
+)
@@ -0,0 +1,106 @@
+package optin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Handlers contains HTTP handlers for opt-in confirmation
+type Handlers struct {
+	app     *app.App
+	service *Service
+}
+
+// NewHandlers creates a new opt-in handlers instance
+func NewHandlers(app *app.App) *Handlers {
+	return &Handlers{
+		app:     app,
+		service: NewService(app),
+	}
+}
+
+// ListHandler handles GET /wa/optin - lists the opt-in state of a session's recipients
+func (h *Handlers) ListHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", app.OptInPending, app.OptInConfirmed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status, must be one of pending, confirmed"})
+		return
+	}
+
+	limit, offset := defaultListLimit, 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid limit, must be between 1 and %d", maxListLimit),
+			})
+			return
+		}
+		limit = parsed
+	}
+	if value := c.Query("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset, must be a non-negative integer"})
+			return
+		}
+		offset = parsed
+	}
+
+	response, err := h.service.List(user, status, limit, offset)
+	if err != nil {
+		h.app.Logger.Printf("List opt-ins error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetHandler handles POST /wa/optin - sets the opt-in state of a recipient
+func (h *Handlers) SetHandler(c *gin.Context) {
+	var req SetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	if req.Recipient == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing recipient"})
+		return
+	}
+	switch req.Status {
+	case app.OptInPending, app.OptInConfirmed, statusNone:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status, must be one of pending, confirmed, none"})
+		return
+	}
+
+	if err := h.service.Set(req.User, req.Recipient, req.Status); err != nil {
+		if errors.Is(err, ErrOptInUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		h.app.Logger.Printf("Set opt-in error for user %s: %v", req.User, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"msg": "Opt-in updated", "user": req.User, "recipient": req.Recipient, "status": req.Status})
+}
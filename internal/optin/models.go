@@ -0,0 +1,28 @@
+package optin
+
+import "github.com/neekaru/whatsappgo-bot/internal/app"
+
+// Pagination bounds for opt-in listings
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// statusNone forgets a recipient's opt-in state
+const statusNone = "none"
+
+// SetRequest represents a request to set a recipient's opt-in state, for
+// consent collected outside WhatsApp
+type SetRequest struct {
+	User      string `json:"user"`
+	Recipient string `json:"recipient"` // Phone number or LID JID
+	Status    string `json:"status"`    // pending, confirmed, or none to forget the recipient
+}
+
+// ListResponse is a page of recipients' opt-in states
+type ListResponse struct {
+	OptIns []app.OptIn `json:"opt_ins"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
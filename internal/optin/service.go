@@ -0,0 +1,161 @@
+package optin
+
+import (
+	"strings"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"github.com/neekaru/whatsappgo-bot/internal/utils"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Service handles opt-in confirmation: sessions with an opt_in_keyword only
+// send one low priority message to a new recipient until the recipient replies
+// with the keyword.
+type Service struct {
+	app *app.App
+}
+
+// NewService creates a new opt-in service
+func NewService(app *app.App) *Service {
+	return &Service{app: app}
+}
+
+// Start registers the service for incoming messages, to confirm recipients
+// replying with the keyword
+func (s *Service) Start() {
+	s.app.GetClientManager().RegisterObserver(client.EventTypeRaw, client.ObserverFunc(s.OnEvent))
+}
+
+// Check reports whether a message of the given priority may be sent to a
+// recipient. Only low priority sends are gated; the first one to a new
+// recipient is allowed and asks for confirmation once Sent records it.
+func (s *Service) Check(user, recipient, priority string) error {
+	if !s.gated(user, priority) {
+		return nil
+	}
+
+	optIn, found, err := s.app.OptIns.Get(user, recipientKey(recipient))
+	if err != nil {
+		return err
+	}
+	if found && optIn.Status != app.OptInConfirmed {
+		return ErrOptInPending
+	}
+	return nil
+}
+
+// Sent marks a new recipient pending once a message of the given priority was
+// sent to them. Sends that failed must not be recorded, or the recipient would
+// be held back without ever having been asked.
+func (s *Service) Sent(user, recipient, priority string) {
+	if !s.gated(user, priority) {
+		return
+	}
+
+	key := recipientKey(recipient)
+	first, err := s.app.OptIns.Request(user, key)
+	if err != nil {
+		s.app.Logger.Printf("Failed to record opt-in request from %s for user %s: %v", key, user, err)
+		return
+	}
+	if first {
+		s.app.Logger.Printf("Opt-in requested from %s for user %s", key, user)
+	}
+}
+
+// gated reports whether sends of the given priority from user need opt-in
+func (s *Service) gated(user, priority string) bool {
+	return priority == app.SendPriorityLow && s.app.OptIns != nil &&
+		s.app.Options.Get(user).OptInKeyword != ""
+}
+
+// OnEvent confirms the sender of an incoming direct message that is the
+// session's opt-in keyword
+func (s *Service) OnEvent(event client.Event) {
+	msg, ok := event.GetData().(*events.Message)
+	if !ok || msg.Info.IsFromMe || msg.Info.IsGroup || msg.Message == nil {
+		return
+	}
+
+	user := event.GetClientID()
+	keyword := s.app.Options.Get(user).OptInKeyword
+	if keyword == "" {
+		return
+	}
+
+	text := msg.Message.GetConversation()
+	if text == "" {
+		text = msg.Message.GetExtendedTextMessage().GetText()
+	}
+	if !strings.EqualFold(strings.TrimSpace(text), keyword) {
+		return
+	}
+
+	// The sender may be addressed by phone number or LID; the opt-in was
+	// recorded under whichever the session sent to
+	for _, jid := range []types.JID{msg.Info.Sender, msg.Info.SenderAlt} {
+		key := senderKey(jid)
+		if key == "" {
+			continue
+		}
+		confirmed, err := s.app.OptIns.Confirm(user, key)
+		if err != nil {
+			s.app.Logger.Printf("Failed to confirm opt-in of %s for user %s: %v", key, user, err)
+			return
+		}
+		if confirmed {
+			s.app.Logger.Printf("Opt-in confirmed by %s for user %s", key, user)
+		}
+	}
+}
+
+// List returns a page of a user's recipients, optionally with the given status
+func (s *Service) List(user, status string, limit, offset int) (*ListResponse, error) {
+	if s.app.OptIns == nil {
+		return nil, ErrOptInUnavailable
+	}
+
+	optIns, total, err := s.app.OptIns.List(user, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListResponse{
+		OptIns: optIns,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// Set stores a recipient's opt-in state; "none" forgets the recipient
+func (s *Service) Set(user, recipient, status string) error {
+	if s.app.OptIns == nil {
+		return ErrOptInUnavailable
+	}
+	if status == statusNone {
+		status = ""
+	}
+	return s.app.OptIns.Set(user, recipientKey(recipient), status)
+}
+
+// recipientKey identifies a recipient given as a phone number or LID JID
+func recipientKey(recipient string) string {
+	jid := utils.RecipientJID(strings.TrimSpace(recipient))
+	return senderKey(jid)
+}
+
+// senderKey identifies a user JID the way recipientKey does: the phone number,
+// or the LID JID for hidden users
+func senderKey(jid types.JID) string {
+	switch jid.Server {
+	case types.DefaultUserServer:
+		return jid.User
+	case types.HiddenUserServer:
+		return jid.User + "@" + types.HiddenUserServer
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,78 @@
+package outbox
+
+import (
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Start registers the service for messages the account sends from its phone or
+// other linked devices, to record them in the outbox next to the API's sends
+func (s *Service) Start() {
+	s.app.GetClientManager().RegisterObserver(client.EventTypeRaw, client.ObserverFunc(s.OnEvent))
+}
+
+// OnEvent records an own message sent from another device as sent, for
+// sessions with the own_messages option. Messages sent through the API are not
+// echoed back by WhatsApp, so each is recorded once.
+func (s *Service) OnEvent(event client.Event) {
+	msg, ok := event.GetData().(*events.Message)
+	if !ok || !msg.Info.IsFromMe || msg.Message == nil {
+		return
+	}
+	if !s.app.Options.Get(event.GetClientID()).OwnMessages {
+		return
+	}
+	outboxMsg, ok := deviceMessage(msg.Message)
+	if !ok {
+		return
+	}
+
+	sentAt := msg.Info.Timestamp
+	outboxMsg.User = event.GetClientID()
+	outboxMsg.Recipient = deviceRecipient(msg.Info.Chat)
+	outboxMsg.MessageID = msg.Info.ID
+	outboxMsg.Origin = app.OutboxOriginDevice
+	outboxMsg.SentAt = &sentAt
+	if _, err := s.app.Outbox.RecordSent(outboxMsg); err != nil {
+		s.app.Logger.Printf("Failed to record message %s sent by user %s from another device: %v", msg.Info.ID, outboxMsg.User, err)
+	}
+}
+
+// deviceRecipient formats a chat the way API sends record their recipient: a
+// bare phone number for users, the full JID for LIDs and groups
+func deviceRecipient(chat types.JID) string {
+	chat = chat.ToNonAD()
+	if chat.Server == types.DefaultUserServer {
+		return chat.User
+	}
+	return chat.String()
+}
+
+// deviceMessage describes a message in the outbox's terms. Only the message
+// types the API sends are recorded; reactions, edits, revokes and the like are not.
+func deviceMessage(m *waE2E.Message) (app.OutboxMessage, bool) {
+	switch {
+	case m.GetConversation() != "":
+		return app.OutboxMessage{Type: "text", Body: m.GetConversation()}, true
+	case m.GetExtendedTextMessage() != nil:
+		return app.OutboxMessage{Type: "text", Body: m.GetExtendedTextMessage().GetText()}, true
+	case m.GetImageMessage() != nil:
+		image := m.GetImageMessage()
+		return app.OutboxMessage{Type: "image", Body: image.GetCaption(), MimeType: image.GetMimetype()}, true
+	case m.GetVideoMessage() != nil:
+		video := m.GetVideoMessage()
+		return app.OutboxMessage{Type: "video", Body: video.GetCaption(), MimeType: video.GetMimetype()}, true
+	case m.GetDocumentMessage() != nil:
+		document := m.GetDocumentMessage()
+		return app.OutboxMessage{Type: "file", Body: document.GetCaption(), FileName: document.GetFileName(), MimeType: document.GetMimetype()}, true
+	case m.GetAudioMessage().GetPTT():
+		return app.OutboxMessage{Type: "voice", MimeType: m.GetAudioMessage().GetMimetype()}, true
+	case m.GetAudioMessage() != nil:
+		return app.OutboxMessage{Type: "audio", MimeType: m.GetAudioMessage().GetMimetype()}, true
+	default:
+		return app.OutboxMessage{}, false
+	}
+}
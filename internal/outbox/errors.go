@@ -0,0 +1,14 @@
+package outbox
+
+import "errors"
+
+var (
+	// ErrMessageNotFound is returned when no outbox message has the requested ID
+	ErrMessageNotFound = errors.New("outbox message not found")
+	// ErrNotDeadLettered is returned when retrying a message that is not in the dead letter queue
+	ErrNotDeadLettered = errors.New("message is not in the dead letter queue")
+	// ErrNotResendable is returned when a message's content was not retained and cannot be sent again
+	ErrNotResendable = errors.New("media data was sent inline and is not retained, cannot resend")
+	// ErrOutboxUnavailable is returned when the outbox database could not be opened
+	ErrOutboxUnavailable = errors.New("outbox is not available")
+)
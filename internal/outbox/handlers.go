@@ -0,0 +1,155 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Handlers contains HTTP handlers for the outbox
+type Handlers struct {
+	app     *app.App
+	service *Service
+}
+
+// NewHandlers creates a new outbox handlers instance
+func NewHandlers(app *app.App) *Handlers {
+	return &Handlers{
+		app:     app,
+		service: NewService(app),
+	}
+}
+
+// ListHandler handles GET /outbox - lists outgoing messages of a session
+func (h *Handlers) ListHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	filter := app.OutboxFilter{
+		User:      user,
+		Recipient: c.Query("recipient"),
+	}
+
+	switch status := c.Query("status"); status {
+	case "", app.OutboxStatusScheduled, app.OutboxStatusPending, app.OutboxStatusSent, app.OutboxStatusFailed, app.OutboxStatusExpired, app.OutboxStatusRevoked:
+		filter.Status = status
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status, must be one of scheduled, pending, sent, failed, expired, revoked"})
+		return
+	}
+
+	var ok bool
+	if filter.Since, ok = parseTimeQuery(c, "since"); !ok {
+		return
+	}
+	if filter.Until, ok = parseTimeQuery(c, "until"); !ok {
+		return
+	}
+
+	if filter.Limit, filter.Offset, ok = parsePagination(c); !ok {
+		return
+	}
+
+	response, err := h.service.List(filter)
+	if err != nil {
+		h.app.Logger.Printf("List outbox error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeadLettersHandler handles GET /outbox/failed - lists permanently failed messages of a session
+func (h *Handlers) DeadLettersHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.DeadLetters(user, limit, offset)
+	if err != nil {
+		h.app.Logger.Printf("List dead letters error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RetryHandler handles POST /outbox/retry/:id - re-queues a dead-lettered message
+func (h *Handlers) RetryHandler(c *gin.Context) {
+	id := c.Param("id")
+
+	msg, err := h.service.Retry(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrMessageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrNotDeadLettered), errors.Is(err, ErrNotResendable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.app.Logger.Printf("Retry outbox message %s error: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"msg": "Message re-queued", "message": msg})
+}
+
+// parsePagination reads the limit and offset query parameters, writing a 400 response on invalid input
+func parsePagination(c *gin.Context) (int, int, bool) {
+	limit, offset := defaultListLimit, 0
+
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid limit, must be between 1 and %d", maxListLimit),
+			})
+			return 0, 0, false
+		}
+		limit = parsed
+	}
+	if value := c.Query("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset, must be a non-negative integer"})
+			return 0, 0, false
+		}
+		offset = parsed
+	}
+
+	return limit, offset, true
+}
+
+// parseTimeQuery reads an optional RFC 3339 timestamp query parameter, writing a 400 response on invalid input
+func parseTimeQuery(c *gin.Context, name string) (time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, true
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid %s, must be an RFC 3339 timestamp", name),
+		})
+		return time.Time{}, false
+	}
+	return parsed, true
+}
@@ -0,0 +1,25 @@
+package outbox
+
+import "github.com/neekaru/whatsappgo-bot/internal/app"
+
+// Pagination bounds for outbox listings
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// ListResponse is a page of outbox messages
+type ListResponse struct {
+	Messages []app.OutboxMessage `json:"messages"`
+	Total    int                 `json:"total"`
+	Limit    int                 `json:"limit"`
+	Offset   int                 `json:"offset"`
+}
+
+// DeadLetterResponse is a page of permanently failed messages
+type DeadLetterResponse struct {
+	Messages []app.DeadLetter `json:"messages"`
+	Total    int              `json:"total"`
+	Limit    int              `json:"limit"`
+	Offset   int              `json:"offset"`
+}
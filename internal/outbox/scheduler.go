@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Scheduled messages picked up per poll
+const schedulerBatchSize = 50
+
+// Scheduler sends outbox messages held with send_after once they are due.
+// Scheduled messages live in the outbox database, so they survive restarts.
+type Scheduler struct {
+	app      *app.App
+	service  *Service
+	interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewScheduler creates a scheduler polling the outbox every interval
+func NewScheduler(app *app.App, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		app:      app,
+		service:  NewService(app),
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start polls for due messages in the background once ready is closed, so
+// messages that fell due while the server was down are not sent before their
+// sessions are restored
+func (s *Scheduler) Start(ready <-chan struct{}) {
+	go func() {
+		defer close(s.done)
+		select {
+		case <-s.stop:
+			return
+		case <-ready:
+		}
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.sendDue()
+			}
+		}
+	}()
+}
+
+// Close stops polling. Sends already started finish in the background.
+func (s *Scheduler) Close() {
+	close(s.stop)
+	<-s.done
+}
+
+// sendDue claims every due message and sends it. Each send waits on the
+// session's send rate limit, so they run concurrently.
+func (s *Scheduler) sendDue() {
+	for {
+		messages, err := s.app.Outbox.Due(time.Now(), schedulerBatchSize)
+		if err != nil {
+			s.app.Logger.Printf("Failed to read scheduled outbox messages: %v", err)
+			return
+		}
+
+		for _, msg := range messages {
+			claimed, err := s.app.Outbox.Claim(msg.ID)
+			if err != nil {
+				s.app.Logger.Printf("Failed to claim scheduled outbox message %s: %v", msg.ID, err)
+				return
+			}
+			if !claimed {
+				continue
+			}
+			msg.Status = app.OutboxStatusPending
+			go s.service.send(msg, "Scheduled send")
+		}
+
+		if len(messages) < schedulerBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package outbox
+
+import (
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/media"
+	"github.com/neekaru/whatsappgo-bot/internal/messaging"
+)
+
+// Service handles outbox-related business logic
+type Service struct {
+	app              *app.App
+	messagingService *messaging.Service
+	mediaService     *media.Service
+}
+
+// NewService creates a new outbox service
+func NewService(app *app.App) *Service {
+	return &Service{
+		app:              app,
+		messagingService: messaging.NewService(app),
+		mediaService:     media.NewService(app),
+	}
+}
+
+// List returns a page of recorded outgoing messages
+func (s *Service) List(filter app.OutboxFilter) (*ListResponse, error) {
+	if s.app.Outbox == nil {
+		return nil, ErrOutboxUnavailable
+	}
+
+	messages, total, err := s.app.Outbox.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListResponse{
+		Messages: messages,
+		Total:    total,
+		Limit:    filter.Limit,
+		Offset:   filter.Offset,
+	}, nil
+}
+
+// DeadLetters returns a page of permanently failed messages for a user
+func (s *Service) DeadLetters(user string, limit, offset int) (*DeadLetterResponse, error) {
+	if s.app.Outbox == nil {
+		return nil, ErrOutboxUnavailable
+	}
+
+	letters, total, err := s.app.Outbox.DeadLetters(user, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeadLetterResponse{
+		Messages: letters,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+	}, nil
+}
+
+// Retry moves a dead-lettered message back to pending and sends it again in the
+// background. The outcome is recorded on the same outbox message.
+func (s *Service) Retry(id string) (*app.OutboxMessage, error) {
+	if s.app.Outbox == nil {
+		return nil, ErrOutboxUnavailable
+	}
+
+	msg, found, err := s.app.Outbox.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrMessageNotFound
+	}
+	if msg.Type != "text" && msg.Type != "contact" && msg.MediaURL == "" && msg.MediaHandle == "" {
+		return nil, ErrNotResendable
+	}
+
+	requeued, err := s.app.Outbox.Requeue(id)
+	if err != nil {
+		return nil, err
+	}
+	if !requeued {
+		return nil, ErrNotDeadLettered
+	}
+	msg.Status = app.OutboxStatusPending
+	msg.Error = ""
+
+	go s.send(*msg, "Retry")
+
+	return msg, nil
+}
+
+// send delivers an outbox message under its existing ID and logs the outcome.
+// The outcome itself is recorded on the outbox message by the send.
+func (s *Service) send(msg app.OutboxMessage, action string) {
+	var err error
+	if msg.Type == "text" || msg.Type == "contact" {
+		err = s.messagingService.ResendOutboxMessage(msg)
+	} else {
+		err = s.mediaService.ResendOutboxMessage(msg)
+	}
+	if err != nil {
+		s.app.Logger.Printf("%s of outbox message %s failed: %v", action, msg.ID, err)
+		return
+	}
+	s.app.Logger.Printf("%s of outbox message %s succeeded", action, msg.ID)
+}
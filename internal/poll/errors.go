@@ -0,0 +1,10 @@
+package poll
+
+import "errors"
+
+// ErrPollUnavailable is returned when the poll database could not be opened
+var ErrPollUnavailable = errors.New("poll store is not available")
+
+// ErrPollNotFound is returned for polls the session has not seen since poll
+// votes are recorded
+var ErrPollNotFound = errors.New("poll not found")
@@ -0,0 +1,50 @@
+package poll
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Handlers contains HTTP handlers for polls
+type Handlers struct {
+	app     *app.App
+	service *Service
+}
+
+// NewHandlers creates a new poll handlers instance
+func NewHandlers(app *app.App) *Handlers {
+	return &Handlers{
+		app:     app,
+		service: NewService(app),
+	}
+}
+
+// ResultsHandler handles GET /poll/results - returns the votes for each option of a poll
+func (h *Handlers) ResultsHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing id"})
+		return
+	}
+
+	results, err := h.service.Results(user, id)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, results)
+	case errors.Is(err, ErrPollNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrPollUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		h.app.Logger.Printf("Get poll results error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
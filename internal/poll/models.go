@@ -0,0 +1,23 @@
+package poll
+
+import (
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// OptionResult is the tally of one option of a poll
+type OptionResult struct {
+	Name   string   `json:"name"`
+	Votes  int      `json:"votes"`
+	Voters []string `json:"voters"`
+}
+
+// ResultsResponse is a poll with the votes for each of its options
+type ResultsResponse struct {
+	User string `json:"user"`
+	app.Poll
+	Results     []OptionResult `json:"results"`
+	TotalVoters int            `json:"total_voters"`         // Voters with at least one option selected
+	UpdatedAt   *time.Time     `json:"updated_at,omitempty"` // Time of the latest vote
+}
@@ -0,0 +1,149 @@
+package poll
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Service records polls and the votes cast in them, and tallies the results
+type Service struct {
+	app *app.App
+}
+
+// NewService creates a new poll service
+func NewService(app *app.App) *Service {
+	return &Service{app: app}
+}
+
+// Start registers the service for incoming messages and own messages sent
+// from other devices, to record polls and votes
+func (s *Service) Start() {
+	s.app.GetClientManager().RegisterObserver(client.EventTypeRaw, client.ObserverFunc(s.OnEvent))
+}
+
+// OnEvent records poll creation messages and decrypts and records poll votes
+func (s *Service) OnEvent(event client.Event) {
+	msg, ok := event.GetData().(*events.Message)
+	if !ok || msg.Message == nil || msg.Info.Chat == types.StatusBroadcastJID {
+		return
+	}
+	user := event.GetClientID()
+
+	if creation := pollCreation(msg.Message); creation != nil {
+		poll := app.Poll{
+			ID:              msg.Info.ID,
+			Chat:            msg.Info.Chat.ToNonAD().String(),
+			Sender:          msg.Info.Sender.ToNonAD().String(),
+			Question:        creation.GetName(),
+			Options:         make([]string, 0, len(creation.GetOptions())),
+			SelectableCount: int(creation.GetSelectableOptionsCount()),
+			CreatedAt:       msg.Info.Timestamp,
+		}
+		for _, option := range creation.GetOptions() {
+			poll.Options = append(poll.Options, option.GetOptionName())
+		}
+		if err := s.app.Polls.RecordPoll(user, poll); err != nil {
+			s.app.Logger.Printf("Failed to record poll %s of user %s: %v", poll.ID, user, err)
+		}
+		return
+	}
+
+	update := msg.Message.GetPollUpdateMessage()
+	if update == nil {
+		return
+	}
+	whatsappClient, ok := s.app.GetClientManager().GetClient(user)
+	if !ok || whatsappClient.WhatsmeowClient == nil {
+		return
+	}
+	// The vote is encrypted with the secret of the poll, kept by whatsmeow
+	// when the poll was sent or received
+	pollID := update.GetPollCreationMessageKey().GetID()
+	vote, err := whatsappClient.WhatsmeowClient.DecryptPollVote(context.Background(), msg)
+	if err != nil {
+		s.app.Logger.Printf("Failed to decrypt vote in poll %s of user %s: %v", pollID, user, err)
+		return
+	}
+
+	votedAt := msg.Info.Timestamp
+	if ms := update.GetSenderTimestampMS(); ms > 0 {
+		votedAt = time.UnixMilli(ms)
+	}
+	err = s.app.Polls.RecordVote(user, pollID, app.PollVote{
+		Voter:     msg.Info.Sender.ToNonAD().String(),
+		Options:   vote.GetSelectedOptions(),
+		Timestamp: votedAt,
+	})
+	if err != nil {
+		s.app.Logger.Printf("Failed to record vote in poll %s of user %s: %v", pollID, user, err)
+	}
+}
+
+// Results tallies the latest vote of every voter in a poll of user
+func (s *Service) Results(user, id string) (*ResultsResponse, error) {
+	if s.app.Polls == nil {
+		return nil, ErrPollUnavailable
+	}
+
+	poll, found, err := s.app.Polls.Poll(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrPollNotFound
+	}
+	votes, err := s.app.Polls.Votes(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ResultsResponse{User: user, Poll: *poll, Results: make([]OptionResult, len(poll.Options))}
+	hashes := whatsmeow.HashPollOptions(poll.Options)
+	for i, name := range poll.Options {
+		resp.Results[i] = OptionResult{Name: name, Voters: []string{}}
+	}
+	for _, vote := range votes {
+		counted := false
+		for _, selected := range vote.Options {
+			for i, hash := range hashes {
+				if bytes.Equal(selected, hash) {
+					resp.Results[i].Votes++
+					resp.Results[i].Voters = append(resp.Results[i].Voters, vote.Voter)
+					counted = true
+				}
+			}
+		}
+		if counted {
+			resp.TotalVoters++
+		}
+		// Votes are oldest first
+		votedAt := vote.Timestamp
+		resp.UpdatedAt = &votedAt
+	}
+	return resp, nil
+}
+
+// pollCreation returns the poll a message creates, whichever version of the
+// poll message it is sent as
+func pollCreation(m *waE2E.Message) *waE2E.PollCreationMessage {
+	for _, poll := range []*waE2E.PollCreationMessage{
+		m.GetPollCreationMessage(),
+		m.GetPollCreationMessageV2(),
+		m.GetPollCreationMessageV3(),
+		m.GetPollCreationMessageV5(),
+		m.GetPollCreationMessageV6(),
+	} {
+		if poll != nil {
+			return poll
+		}
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package selftest
+
+import "errors"
+
+// ErrSessionNotFound is returned for sessions that are not loaded
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrNotLoggedIn is returned for sessions that cannot send because they are
+// not logged in
+var ErrNotLoggedIn = errors.New("session is not logged in")
@@ -0,0 +1,65 @@
+package selftest
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Handlers contains HTTP handlers for session self-tests
+type Handlers struct {
+	app     *app.App
+	service *Service
+}
+
+// NewHandlers creates a new self-test handlers instance
+func NewHandlers(app *app.App) *Handlers {
+	return &Handlers{
+		app:     app,
+		service: NewService(app),
+	}
+}
+
+// SelfTestHandler handles POST /wa/selftest - sends a message from a session
+// to its own number and reports how long it took to come back
+func (h *Handlers) SelfTestHandler(c *gin.Context) {
+	var req Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	if req.TimeoutSeconds < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "timeout_seconds must not be negative"})
+		return
+	}
+
+	result, err := h.service.Run(req.User, time.Duration(req.TimeoutSeconds)*time.Second)
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrNotLoggedIn):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.app.Logger.Printf("Self-test of session %s: %s in %dms", req.User, result.Status, result.TotalMs)
+	switch result.Status {
+	case StatusSendFailed:
+		c.JSON(http.StatusBadGateway, result)
+	case StatusTimeout:
+		c.JSON(http.StatusGatewayTimeout, result)
+	default:
+		c.JSON(http.StatusOK, result)
+	}
+}
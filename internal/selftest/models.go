@@ -0,0 +1,36 @@
+package selftest
+
+import "time"
+
+// Outcomes of a self-test
+const (
+	StatusOK         = "ok"
+	StatusSendFailed = "send_failed" // The message could not be sent
+	StatusTimeout    = "timeout"     // The message was sent, but nothing came back in time
+)
+
+// Events a self-test message can come back as
+const (
+	EventMessage = "message" // The message itself, as sent from the account
+	EventReceipt = "receipt" // A receipt for it from another device of the account
+)
+
+// Request represents a request to run a self-test on a session
+type Request struct {
+	User           string `json:"user"`
+	TimeoutSeconds int    `json:"timeout_seconds"` // How long to wait for the message to come back; 0 uses 30
+}
+
+// Result is the outcome of a self-test, with the time each step took
+type Result struct {
+	Status    string    `json:"status"`
+	User      string    `json:"user"`
+	Phone     string    `json:"phone"`
+	MessageID string    `json:"message_id,omitempty"`
+	Event     string    `json:"event,omitempty"`      // What came back first
+	SendMs    int64     `json:"send_ms"`              // Until the send finished, including pacing
+	ReceiveMs *int64    `json:"receive_ms,omitempty"` // From the send finishing until the event arrived
+	TotalMs   int64     `json:"total_ms"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
@@ -0,0 +1,152 @@
+package selftest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"github.com/neekaru/whatsappgo-bot/internal/messaging"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Bounds of how long a self-test waits for its message to come back
+const (
+	defaultTimeout = 30 * time.Second
+	maxTimeout     = 2 * time.Minute
+)
+
+// Service sends messages from sessions to their own number and times how
+// long they take to come back as events
+type Service struct {
+	app       *app.App
+	messaging *messaging.Service
+}
+
+// NewService creates a new self-test service
+func NewService(app *app.App) *Service {
+	return &Service{
+		app:       app,
+		messaging: messaging.NewService(app),
+	}
+}
+
+// Run sends a message from user to its own number through the regular send
+// path and waits up to timeout for it, or a receipt for it, to come back.
+// The event is seen as the session receives it; whether sinks and webhooks
+// deliver it is not checked.
+func (s *Service) Run(user string, timeout time.Duration) (*Result, error) {
+	whatsappClient, exists := s.app.GetClientManager().GetClient(user)
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+	if !whatsappClient.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	timeout = min(timeout, maxTimeout)
+
+	result := &Result{User: user, Phone: whatsappClient.Phone(), StartedAt: time.Now()}
+
+	// Watch before sending, as the event may arrive before the send returns
+	w := &watch{user: user, seen: make(map[types.MessageID]sighting), changed: make(chan struct{}, 1)}
+	manager := s.app.GetClientManager()
+	manager.RegisterObserver(client.EventTypeRaw, w)
+	defer manager.UnregisterObserver(client.EventTypeRaw, w)
+
+	text := fmt.Sprintf("Self-test %s", result.StartedAt.Format(time.RFC3339Nano))
+	messageID, err := s.messaging.SendMessageWithID(user, result.Phone, text, app.SendPriorityHigh, time.Time{}, nil)
+	sent := time.Now()
+	result.SendMs = sent.Sub(result.StartedAt).Milliseconds()
+	result.TotalMs = result.SendMs
+	if err != nil {
+		result.Status = StatusSendFailed
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.MessageID = messageID
+
+	seen, ok := w.wait(types.MessageID(messageID), timeout)
+	if !ok {
+		result.Status = StatusTimeout
+		result.Error = fmt.Sprintf("no event for the message within %v", timeout)
+		result.TotalMs = time.Since(result.StartedAt).Milliseconds()
+		return result, nil
+	}
+
+	receiveMs := max(seen.at.Sub(sent).Milliseconds(), 0)
+	result.Status = StatusOK
+	result.Event = seen.kind
+	result.ReceiveMs = &receiveMs
+	result.TotalMs = seen.at.Sub(result.StartedAt).Milliseconds()
+	return result, nil
+}
+
+// sighting is the first event seen for a message
+type sighting struct {
+	kind string
+	at   time.Time
+}
+
+// watch records the first message or receipt event seen for each message ID
+// of a session
+type watch struct {
+	user    string
+	mu      sync.Mutex
+	seen    map[types.MessageID]sighting
+	changed chan struct{}
+}
+
+// OnEvent records the messages and receipts of the watched session
+func (w *watch) OnEvent(event client.Event) {
+	if event.GetClientID() != w.user {
+		return
+	}
+	now := time.Now()
+	switch e := event.GetData().(type) {
+	case *events.Message:
+		w.saw(e.Info.ID, EventMessage, now)
+	case *events.Receipt:
+		for _, id := range e.MessageIDs {
+			w.saw(id, EventReceipt, now)
+		}
+	}
+}
+
+// saw records an event for id unless one was seen already
+func (w *watch) saw(id types.MessageID, kind string, at time.Time) {
+	w.mu.Lock()
+	if _, ok := w.seen[id]; !ok {
+		w.seen[id] = sighting{kind: kind, at: at}
+	}
+	w.mu.Unlock()
+
+	select {
+	case w.changed <- struct{}{}:
+	default:
+	}
+}
+
+// wait returns the first event seen for id, waiting up to timeout for one
+func (w *watch) wait(id types.MessageID, timeout time.Duration) (sighting, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		w.mu.Lock()
+		seen, ok := w.seen[id]
+		w.mu.Unlock()
+		if ok {
+			return seen, true
+		}
+
+		select {
+		case <-w.changed:
+		case <-timer.C:
+			return sighting{}, false
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/alert"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/session"
+)
+
+// UserMiddleware resolves session aliases in the "user" query parameter to the
+// real session user, and rejects requests whose user is not a valid session
+// identifier. Routes taking the user in their body add BodyUserMiddleware.
+func UserMiddleware(application *app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		if user := query.Get("user"); user != "" {
+			resolved := application.Aliases.Resolve(user)
+			if err := session.ValidateUser(resolved); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if resolved != user {
+				query.Set("user", resolved)
+				c.Request.URL.RawQuery = query.Encode()
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// BodyUserMiddleware resolves a session alias in the "user" field of a JSON
+// request body like UserMiddleware does for the query. The body is read in
+// full, so it is only added to the routes whose body names the user.
+func BodyUserMiddleware(application *app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body != nil && strings.HasPrefix(c.ContentType(), "application/json") {
+			if err := resolveBodyUser(c, application); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// resolveBodyUser validates the "user" field of a JSON object body and replaces it
+// if it is an alias.
+func resolveBodyUser(c *gin.Context, application *app.App) error {
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body.Close()
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil
+	}
+
+	var user string
+	if err := json.Unmarshal(fields["user"], &user); err != nil || user == "" {
+		return nil
+	}
+
+	resolved := application.Aliases.Resolve(user)
+	if err := session.ValidateUser(resolved); err != nil {
+		return err
+	}
+	if resolved == user {
+		return nil
+	}
+
+	fields["user"], _ = json.Marshal(resolved)
+	rewritten, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(rewritten))
+	c.Request.ContentLength = int64(len(rewritten))
+	return nil
+}
+
+// gzipWriter compresses the response body written through it.
+type gzipWriter struct {
+	gin.ResponseWriter
+	writer  *gzip.Writer
+	written bool
+}
+
+func (w *gzipWriter) WriteHeader(code int) {
+	w.Header().Del("Content-Length")
+	if !bodyAllowed(code) {
+		// Headers may be sent before the middleware returns, so the encoding
+		// of a response without a body has to go here
+		w.Header().Del("Content-Encoding")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	w.Header().Del("Content-Length")
+	w.written = true
+	return w.writer.Write(data)
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// bodyAllowed reports whether a response with the status code may have a body.
+func bodyAllowed(code int) bool {
+	return code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified
+}
+
+// GzipMiddleware compresses responses for clients that accept gzip encoding.
+func GzipMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.Next()
+			return
+		}
+
+		gz := gzip.NewWriter(c.Writer)
+		writer := &gzipWriter{ResponseWriter: c.Writer, writer: gz}
+
+		c.Header("Content-Encoding", "gzip")
+		c.Header("Vary", "Accept-Encoding")
+		c.Writer = writer
+
+		c.Next()
+
+		if writer.written {
+			gz.Close()
+		} else {
+			// Nothing to compress, so drop the encoding header if it wasn't sent yet
+			c.Writer.Header().Del("Content-Encoding")
+		}
+		c.Writer = writer.ResponseWriter
+	}
+}
+
+// bufferedWriter holds back the response so it can be inspected before sending.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+// ETagMiddleware adds a content hash ETag to successful responses and answers
+// 304 Not Modified when it matches the request's If-None-Match header.
+func ETagMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		original := c.Writer
+		writer := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = original
+
+		if writer.status != http.StatusOK {
+			original.WriteHeader(writer.status)
+			original.Write(writer.body.Bytes())
+			return
+		}
+
+		sum := sha256.Sum256(writer.body.Bytes())
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		original.Header().Set("ETag", etag)
+
+		if match := c.GetHeader("If-None-Match"); match != "" && etagMatches(match, etag) {
+			original.WriteHeader(http.StatusNotModified)
+			original.WriteHeaderNow()
+			return
+		}
+
+		original.WriteHeader(http.StatusOK)
+		original.Write(writer.body.Bytes())
+	}
+}
+
+// etagMatches reports whether an If-None-Match header value matches the ETag.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestIDMiddleware assigns every request an ID, reusing the caller's X-Request-ID if given.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" {
+			idBytes := make([]byte, 8)
+			_, _ = rand.Read(idBytes)
+			requestID = hex.EncodeToString(idBytes)
+		}
+
+		c.Set("request_id", requestID)
+		c.Header("X-Request-ID", requestID)
+		c.Next()
+	}
+}
+
+// RecoveryMiddleware recovers from panics in handlers, logs the stack trace with the
+// request ID, counts the panic, optionally alerts the configured webhook and responds
+// with the standard error envelope.
+func RecoveryMiddleware(application *app.App, alerts *alert.Sender) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			requestID := c.GetString("request_id")
+			application.PanicCount.Add(1)
+			application.Logger.Printf("Handler failed with panic request_id=%s %s %s: %v\n%s",
+				requestID, c.Request.Method, c.Request.URL.Path, recovered, debug.Stack())
+
+			if alerts.Enabled() {
+				go sendPanicAlert(application, alerts, requestID, c.Request.Method, c.Request.URL.Path, recovered)
+			}
+
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error":      "Internal server error",
+				"details":    fmt.Sprint(recovered),
+				"request_id": requestID,
+			})
+		}()
+
+		c.Next()
+	}
+}
+
+// sendPanicAlert posts a short panic report to the ops alert webhook.
+func sendPanicAlert(application *app.App, alerts *alert.Sender, requestID, method, path string, recovered any) {
+	err := alerts.Send("panic", map[string]any{
+		"request_id": requestID,
+		"method":     method,
+		"path":       path,
+		"error":      fmt.Sprint(recovered),
+	})
+	if err != nil {
+		application.Logger.Printf("Failed to send panic alert: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenBucket is a single caller's bucket
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// RateLimiter is a token bucket rate limiter keyed by caller
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens added per second
+	burst   float64 // bucket capacity
+	buckets map[string]*tokenBucket
+	sweep   time.Time
+}
+
+// NewRateLimiter creates a rate limiter allowing rate requests per second with the given burst
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		sweep:   time.Now(),
+	}
+}
+
+// SetLimits changes the rate and burst. Callers keep the tokens they have,
+// up to the new burst.
+func (l *RateLimiter) SetLimits(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.burst = float64(burst)
+}
+
+// Limit returns the burst, the most requests a caller can make at once
+func (l *RateLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.burst)
+}
+
+// Allow takes a token for key, returning whether it was allowed, the tokens left and
+// how long until the bucket is full again
+func (l *RateLimiter) Allow(key string) (bool, int, time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Periodically drop buckets that have refilled completely
+	if now.Sub(l.sweep) > time.Minute {
+		for k, b := range l.buckets {
+			if now.Sub(b.lastSeen).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, k)
+			}
+		}
+		l.sweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, lastSeen: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.lastSeen).Seconds()*l.rate)
+	b.lastSeen = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	reset := time.Duration((l.burst - b.tokens) / l.rate * float64(time.Second))
+	return allowed, int(b.tokens), reset
+}
+
+// RateLimitMiddleware limits requests per API key (X-API-Key header) or, without a key,
+// per client IP, and sets the standard RateLimit headers.
+func RateLimitMiddleware(limiter *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			key = "key:" + apiKey
+		}
+
+		allowed, remaining, reset := limiter.Allow(key)
+		resetSeconds := int(math.Ceil(reset.Seconds()))
+
+		c.Header("RateLimit-Limit", strconv.Itoa(limiter.Limit()))
+		c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(resetSeconds))
+
+		if !allowed {
+			retryAfter := int(math.Ceil(1 / limiter.rate))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":               "Rate limit exceeded",
+				"retry_after_seconds": retryAfter,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
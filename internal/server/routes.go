@@ -1,33 +1,118 @@
 package server
 
 import (
+	"github.com/neekaru/whatsappgo-bot/internal/appstate"
 	"github.com/neekaru/whatsappgo-bot/internal/auth"
+	"github.com/neekaru/whatsappgo-bot/internal/backup"
+	"github.com/neekaru/whatsappgo-bot/internal/businesshours"
+	"github.com/neekaru/whatsappgo-bot/internal/config"
 	"github.com/neekaru/whatsappgo-bot/internal/contact"
+	"github.com/neekaru/whatsappgo-bot/internal/conversation"
+	"github.com/neekaru/whatsappgo-bot/internal/eventlog"
+	"github.com/neekaru/whatsappgo-bot/internal/group"
 	"github.com/neekaru/whatsappgo-bot/internal/health"
 	"github.com/neekaru/whatsappgo-bot/internal/media"
 	"github.com/neekaru/whatsappgo-bot/internal/messaging"
+	"github.com/neekaru/whatsappgo-bot/internal/optin"
+	"github.com/neekaru/whatsappgo-bot/internal/outbox"
+	"github.com/neekaru/whatsappgo-bot/internal/poll"
+	"github.com/neekaru/whatsappgo-bot/internal/selftest"
 	"github.com/neekaru/whatsappgo-bot/internal/session"
+	"github.com/neekaru/whatsappgo-bot/internal/sink"
+	"github.com/neekaru/whatsappgo-bot/internal/stats"
+	"github.com/neekaru/whatsappgo-bot/internal/thread"
 )
 
 // SetupRoutes configures all the routes for the application
 func (s *Server) SetupRoutes() {
+	// Rate limit for endpoints that are expensive or reach WhatsApp
+	rateLimiter := NewRateLimiter(s.config.RateLimitPerSecond, s.config.RateLimitBurst)
+	rateLimit := RateLimitMiddleware(rateLimiter)
+
+	// Alias resolution for routes taking the session user in their JSON body
+	bodyUser := BodyUserMiddleware(s.app)
+
 	// Register health check handlers
-	healthHandlers := health.NewHandlers(s.app)
+	healthHandlers := health.NewHandlers(s.app, s.config.HealthCanaryUser, s.config.FeatureFlags())
 	s.router.GET("/", healthHandlers.RootHandler)
+	s.router.GET("/version", healthHandlers.VersionHandler)
 	s.router.GET("/health", healthHandlers.HealthCheckHandler)
 	s.router.GET("/health/", healthHandlers.HealthCheckHandlerWithSlash)
+	s.router.GET("/health/deep", healthHandlers.DeepHealthHandler)
+	s.router.GET("/metrics", healthHandlers.MetricsHandler)
+	s.router.GET("/admin/runtime", healthHandlers.RuntimeHandler)
+
+	// Register statistics handlers
+	statsHandlers := stats.NewHandlers(s.app)
+	s.router.GET("/stats/slo", statsHandlers.SLOHandler)
 
 	// Register session handlers
 	sessionHandlers := session.NewHandlers(s.app)
-	s.router.POST("/wa/add", sessionHandlers.AddSessionHandler)
+	s.router.POST("/wa/add", bodyUser, sessionHandlers.AddSessionHandler)
+	s.router.POST("/wa/ensure", bodyUser, sessionHandlers.EnsureSessionHandler)
+	s.router.GET("/wa/sessions", sessionHandlers.ListSessionsHandler)
+	s.router.GET("/wa/aliases", sessionHandlers.ListAliasesHandler)
+	s.router.POST("/wa/alias", bodyUser, sessionHandlers.SetAliasHandler)
+	s.router.DELETE("/wa/alias", sessionHandlers.DeleteAliasHandler)
+	s.router.GET("/wa/options", sessionHandlers.GetOptionsHandler)
+	s.router.POST("/wa/options", bodyUser, sessionHandlers.SetOptionsHandler)
+	s.router.GET("/wa/metadata", sessionHandlers.GetMetadataHandler)
+	s.router.POST("/wa/metadata", bodyUser, sessionHandlers.SetMetadataHandler)
+
+	// Register business hours handlers
+	businessHoursHandlers := businesshours.NewHandlers(s.app)
+	s.router.GET("/wa/business-hours", businessHoursHandlers.GetHandler)
+	s.router.POST("/wa/business-hours", bodyUser, businessHoursHandlers.SetHandler)
+	s.router.DELETE("/wa/business-hours", businessHoursHandlers.DeleteHandler)
+
+	// Register conversation handoff handlers
+	conversationHandlers := conversation.NewHandlers(s.app)
+	s.router.GET("/wa/conversations", conversationHandlers.ListHandler)
+	s.router.GET("/wa/conversation", conversationHandlers.GetHandler)
+	s.router.POST("/wa/conversation", bodyUser, conversationHandlers.TransitionHandler)
+	s.router.GET("/chats/:jid/notes", conversationHandlers.ListNotesHandler)
+	s.router.POST("/chats/:jid/notes", bodyUser, conversationHandlers.AddNoteHandler)
+
+	// Register opt-in handlers
+	optinHandlers := optin.NewHandlers(s.app)
+	s.router.GET("/wa/optin", optinHandlers.ListHandler)
+	s.router.POST("/wa/optin", bodyUser, optinHandlers.SetHandler)
+	s.router.GET("/wa/log-level", sessionHandlers.GetLogLevelHandler)
+	s.router.POST("/wa/log-level", bodyUser, sessionHandlers.SetLogLevelHandler)
 	s.router.POST("/wa/status", sessionHandlers.StatusHandler)
 	s.router.GET("/wa/status", sessionHandlers.StatusHandler)
+	s.router.GET("/wa/status/history", sessionHandlers.StatusHistoryHandler)
 	s.router.POST("/wa/restart", sessionHandlers.RestartHandler)
-	s.router.POST("/wa/logout", sessionHandlers.LogoutHandler)
+	s.router.POST("/wa/restart-all", sessionHandlers.RestartAllHandler)
+	s.router.GET("/wa/restore-report", sessionHandlers.RestoreReportHandler)
+	s.router.POST("/wa/logout", bodyUser, sessionHandlers.LogoutHandler)
+	s.router.GET("/wa/logout/status/:id", sessionHandlers.LogoutStatusHandler)
+	s.router.POST("/wa/disconnect", bodyUser, sessionHandlers.DisconnectHandler)
+	s.router.POST("/wa/presence", bodyUser, sessionHandlers.PresenceHandler)
+	s.router.POST("/wa/sandbox", bodyUser, sessionHandlers.CreateSandboxHandler)
+	s.router.POST("/wa/sandbox/incoming", bodyUser, sessionHandlers.SandboxIncomingHandler)
+
+	// Register self-test handlers
+	selftestHandlers := selftest.NewHandlers(s.app)
+	s.router.POST("/wa/selftest", bodyUser, selftestHandlers.SelfTestHandler)
+
+	// Register versioned session handlers
+	v1 := s.router.Group("/v1")
+	v1.POST("/wa/add", bodyUser, sessionHandlers.CreateSessionHandler)
+	v1.POST("/wa/ensure", bodyUser, sessionHandlers.EnsureSessionHandler)
+
+	// Register app state handlers
+	appStateHandlers := appstate.NewHandlers(s.app)
+	s.router.POST("/wa/appstate/sync", rateLimit, bodyUser, appStateHandlers.SyncHandler)
+	s.router.GET("/wa/appstate/status", appStateHandlers.StatusHandler)
 
 	// Register authentication handlers
 	authHandlers := auth.NewHandlers(s.app)
-	s.router.GET("/wa/qr-image", authHandlers.QRImageHandler)
+	s.router.GET("/wa/qr-image", rateLimit, authHandlers.QRImageHandler)
+	s.router.GET("/wa/qr.png", rateLimit, authHandlers.QRPNGHandler)
+	s.router.GET("/wa/qr/latest", authHandlers.QRLatestHandler)
+	s.router.POST("/wa/qr/cancel", authHandlers.QRCancelHandler)
+	s.router.POST("/wa/pair-code", rateLimit, bodyUser, authHandlers.PairCodeHandler)
 
 	// Register passkey pairing handlers
 	s.router.GET("/wa/passkey/status", authHandlers.PasskeyStatusHandler)
@@ -36,19 +121,87 @@ func (s *Server) SetupRoutes() {
 
 	// Register messaging handlers
 	messagingHandlers := messaging.NewHandlers(s.app)
-	s.router.POST("/send", messagingHandlers.SendMessageHandler)
-	s.router.POST("/msg/read", messagingHandlers.MarkReadHandler)
+	s.router.POST("/send", rateLimit, bodyUser, messagingHandlers.SendMessageHandler)
+	s.router.POST("/send/contact", rateLimit, bodyUser, messagingHandlers.SendContactHandler)
+	s.router.POST("/msg/read", bodyUser, messagingHandlers.MarkReadHandler)
+	s.router.POST("/msg/ack", bodyUser, messagingHandlers.AckHandler)
+	s.router.POST("/msg/revoke", rateLimit, bodyUser, messagingHandlers.RevokeHandler)
 
 	// Register media handlers
 	mediaHandlers := media.NewHandlers(s.app)
-	s.router.POST("/send/file", mediaHandlers.SendFileHandler)
-	s.router.POST("/send/image", mediaHandlers.SendImageHandler)
-	s.router.POST("/send/video", mediaHandlers.SendVideoHandler)
+	s.router.POST("/send/file", rateLimit, bodyUser, mediaHandlers.SendFileHandler)
+	s.router.POST("/send/image", rateLimit, bodyUser, mediaHandlers.SendImageHandler)
+	s.router.POST("/send/video", rateLimit, bodyUser, mediaHandlers.SendVideoHandler)
+	s.router.POST("/send/voice", rateLimit, bodyUser, mediaHandlers.SendVoiceHandler)
+	s.router.POST("/send/audio", rateLimit, bodyUser, mediaHandlers.SendAudioHandler)
+	s.router.POST("/media/upload", rateLimit, bodyUser, mediaHandlers.UploadHandler)
+
+	// Register outbox handlers
+	outboxHandlers := outbox.NewHandlers(s.app)
+	s.router.GET("/outbox", outboxHandlers.ListHandler)
+	s.router.GET("/outbox/failed", outboxHandlers.DeadLettersHandler)
+	s.router.POST("/outbox/retry/:id", outboxHandlers.RetryHandler)
+
+	// Register message thread handlers
+	threadHandlers := thread.NewHandlers(s.app)
+	s.router.GET("/messages/:id/thread", threadHandlers.ThreadHandler)
+
+	// Register poll handlers
+	pollHandlers := poll.NewHandlers(s.app)
+	s.router.GET("/poll/results", pollHandlers.ResultsHandler)
+
+	// Register raw event archive handlers
+	if s.archive != nil {
+		eventlogHandlers := eventlog.NewHandlers(s.app, s.archive)
+		s.router.GET("/wa/debug/events", eventlogHandlers.ListHandler)
+		s.router.DELETE("/wa/debug/events", eventlogHandlers.ClearHandler)
+	}
+
+	// Register backup handlers
+	if s.backups != nil {
+		backupHandlers := backup.NewHandlers(s.app, s.backups)
+		s.router.GET("/backups", backupHandlers.ListHandler)
+		s.router.POST("/backups", backupHandlers.CreateHandler)
+		s.router.POST("/backups/restore", backupHandlers.RestoreHandler)
+	}
+
+	// Register config reload handlers
+	if s.reloader != nil {
+		s.reloader.Handle(func(cfg *config.Config) error {
+			rateLimiter.SetLimits(cfg.RateLimitPerSecond, cfg.RateLimitBurst)
+			return nil
+		}, "RateLimitPerSecond", "RateLimitBurst")
+		configHandlers := config.NewHandlers(s.reloader)
+		s.router.POST("/admin/config/reload", configHandlers.ReloadHandler)
+	}
+
+	// Register event sink handlers
+	if s.sinks != nil {
+		sinkHandlers := sink.NewHandlers(s.sinks, s.websocket, s.sse, s.socket)
+		s.router.GET("/sinks", sinkHandlers.ListHandler)
+		s.router.GET("/wa/sinks", sinkHandlers.GetSessionHandler)
+		s.router.POST("/wa/sinks", bodyUser, sinkHandlers.SetSessionHandler)
+		s.router.DELETE("/wa/sinks", sinkHandlers.ResetSessionHandler)
+		s.router.GET("/ws/events", sinkHandlers.StreamHandler)
+		s.router.GET("/ws", sinkHandlers.SocketHandler)
+		s.router.GET("/wa/events", sinkHandlers.EventsHandler)
+	}
 
 	// Register contact handlers
 	contactHandlers := contact.NewHandlers(s.app)
-	s.router.POST("/contact", contactHandlers.GetAllContactsHandler)
-	s.router.POST("/contact/saved", contactHandlers.GetSavedContactsHandler)
-	s.router.POST("/contact/unsaved", contactHandlers.GetUnsavedContactsHandler)
-	s.router.POST("/contact/refresh", contactHandlers.RefreshContactsHandler)
+	contactLists := s.router.Group("/contact", GzipMiddleware(), ETagMiddleware())
+	contactLists.POST("", bodyUser, contactHandlers.GetAllContactsHandler)
+	contactLists.POST("/saved", bodyUser, contactHandlers.GetSavedContactsHandler)
+	contactLists.POST("/unsaved", bodyUser, contactHandlers.GetUnsavedContactsHandler)
+	s.router.POST("/contact/refresh", bodyUser, contactHandlers.RefreshContactsHandler)
+	s.router.GET("/contact/resolve", contactHandlers.ResolveJIDHandler)
+
+	// Register group handlers
+	groupHandlers := group.NewHandlers(s.app)
+	s.router.GET("/group/participants", groupHandlers.GetParticipantsHandler)
+	s.router.GET("/group/info", groupHandlers.GetInfoHandler)
+	s.router.GET("/group/invite", groupHandlers.GetInviteHandler)
+	s.router.GET("/group/invite.png", groupHandlers.GetInviteQRHandler)
+	s.router.POST("/group/create", rateLimit, bodyUser, groupHandlers.CreateHandler)
+	s.router.POST("/group/list", bodyUser, groupHandlers.ListHandler)
 }
@@ -9,8 +9,13 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/alert"
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/backup"
+	"github.com/neekaru/whatsappgo-bot/internal/circuit"
 	"github.com/neekaru/whatsappgo-bot/internal/config"
+	"github.com/neekaru/whatsappgo-bot/internal/eventlog"
+	"github.com/neekaru/whatsappgo-bot/internal/sink"
 	"github.com/neekaru/whatsappgo-bot/pkg/logger"
 )
 
@@ -19,6 +24,15 @@ type Server struct {
 	router *gin.Engine
 	app    *app.App
 	config *config.Config
+	alerts *alert.Sender
+
+	sinks     *sink.Dispatcher
+	websocket *sink.WebSocketSink
+	sse       *sink.SSESink
+	socket    *sink.SocketServer
+	archive   *eventlog.Archive
+	backups   *backup.Manager
+	reloader  *config.Reloader
 }
 
 // NewServer creates a new server instance
@@ -27,19 +41,65 @@ func NewServer(app *app.App, config *config.Config) *Server {
 	gin.DefaultWriter = io.MultiWriter(os.Stdout, logger.GetWriter(app.Logger))
 	gin.DefaultErrorWriter = io.MultiWriter(os.Stderr, logger.GetWriter(app.Logger))
 
-	r := gin.Default()
+	r := gin.New()
+	r.Use(RequestIDMiddleware())
+	r.Use(gin.Logger())
+	alertBreaker := circuit.New("alert_webhook", config.CircuitBreakerFailures, config.CircuitBreakerCooldown)
+	alerts := alert.NewSender(config.AlertWebhookURL, alertBreaker)
+	r.Use(RecoveryMiddleware(app, alerts))
 
 	// Configure CORS
 	corsConfig := config.GetCorsConfig()
 	r.Use(cors.New(corsConfig))
 
+	// Resolve session aliases to real users and validate identifiers
+	r.Use(UserMiddleware(app))
+
 	return &Server{
 		router: r,
 		app:    app,
 		config: config,
+		alerts: alerts,
 	}
 }
 
+// SetSinks exposes the event sink dispatcher, the WebSocket and Server-Sent
+// Events streams and the observer socket over HTTP. It must be called before
+// SetupRoutes.
+func (s *Server) SetSinks(dispatcher *sink.Dispatcher, websocket *sink.WebSocketSink, sse *sink.SSESink, socket *sink.SocketServer) {
+	s.sinks = dispatcher
+	s.websocket = websocket
+	s.sse = sse
+	s.socket = socket
+}
+
+// SetEventArchive exposes the raw event archive over HTTP. It must be called
+// before SetupRoutes.
+func (s *Server) SetEventArchive(archive *eventlog.Archive) {
+	s.archive = archive
+}
+
+// SetBackups exposes backups of the data directory over HTTP. It must be
+// called before SetupRoutes.
+func (s *Server) SetBackups(backups *backup.Manager) {
+	s.backups = backups
+}
+
+// SetConfigReloader exposes config reloading over HTTP, and applies reloaded
+// rate limits and alert webhook URLs. It must be called before SetupRoutes.
+func (s *Server) SetConfigReloader(reloader *config.Reloader) {
+	s.reloader = reloader
+	reloader.Handle(func(cfg *config.Config) error {
+		s.alerts.SetURL(cfg.AlertWebhookURL)
+		return nil
+	}, "AlertWebhookURL")
+}
+
+// Alerts returns the sender of ops alerts
+func (s *Server) Alerts() *alert.Sender {
+	return s.alerts
+}
+
 // Router returns the gin router
 func (s *Server) Router() *gin.Engine {
 	return s.router
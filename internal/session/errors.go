@@ -0,0 +1,20 @@
+package session
+
+import "fmt"
+
+// SessionExistsError indicates a session with the requested name already exists.
+type SessionExistsError struct {
+	User string
+}
+
+func (e *SessionExistsError) Error() string {
+	return fmt.Sprintf("session %s already exists", e.User)
+}
+
+func isSessionExistsError(err error) (*SessionExistsError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	existsErr, ok := err.(*SessionExistsError)
+	return existsErr, ok
+}
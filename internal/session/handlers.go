@@ -1,11 +1,23 @@
 package session
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"github.com/neekaru/whatsappgo-bot/pkg/logger"
+)
+
+// Concurrency bounds for restarting all sessions
+const (
+	defaultRestartConcurrency = 4
+	maxRestartConcurrency     = 16
 )
 
 // Handlers contains HTTP handlers for session management
@@ -36,10 +48,165 @@ func (h *Handlers) AddSessionHandler(c *gin.Context) {
 		return
 	}
 
+	if req.ExpectedPhone != "" {
+		if err := h.service.SetExpectedPhone(req.User, req.ExpectedPhone); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// Inform the client that the session was created successfully, but QR generation is pending
 	c.JSON(http.StatusOK, gin.H{"msg": "Session created. Please request QR code using /wa/qr-image"})
 }
 
+// CreateSessionHandler handles creating a new WhatsApp session, responding with
+// 409 Conflict and the current status if the session already exists
+func (h *Handlers) CreateSessionHandler(c *gin.Context) {
+	var req AddSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	_, err := h.service.CreateSession(req.User)
+	if err != nil {
+		if existsErr, ok := isSessionExistsError(err); ok {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":  existsErr.Error(),
+				"status": h.sessionStatus(req.User),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.ExpectedPhone != "" {
+		if err := h.service.SetExpectedPhone(req.User, req.ExpectedPhone); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"msg": "Session created. Please request QR code using /wa/qr-image"})
+}
+
+// CreateSandboxHandler handles POST /wa/sandbox - creates a session that
+// simulates a connected account, responding with 409 Conflict and the current
+// status if the session already exists
+func (h *Handlers) CreateSandboxHandler(c *gin.Context) {
+	var req SandboxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	_, err := h.service.CreateSandboxSession(req.User, req.Phone, client.SandboxOptions{
+		DeliveredAfter: time.Duration(req.DeliveredAfterMS) * time.Millisecond,
+		ReadAfter:      time.Duration(req.ReadAfterMS) * time.Millisecond,
+		Reply:          req.Reply,
+	})
+	if err != nil {
+		if existsErr, ok := isSessionExistsError(err); ok {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":  existsErr.Error(),
+				"status": h.sessionStatus(req.User),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"msg":    "Sandbox session created",
+		"status": h.sessionStatus(req.User),
+	})
+}
+
+// SandboxIncomingHandler handles POST /wa/sandbox/incoming - simulates an
+// incoming text message on a sandbox session
+func (h *Handlers) SandboxIncomingHandler(c *gin.Context) {
+	var req SandboxIncomingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	if req.PhoneNumber == "" || req.Message == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing phone_number or message"})
+		return
+	}
+
+	id, err := h.service.SimulateIncoming(req.User, req.PhoneNumber, req.Message)
+	if errors.Is(err, client.ErrNotSandbox) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": req.User, "message_id": id})
+}
+
+// EnsureSessionHandler idempotently makes sure a session exists, creating it if needed
+func (h *Handlers) EnsureSessionHandler(c *gin.Context) {
+	var req AddSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	existed := h.service.SessionExists(req.User)
+
+	_, err := h.service.AddSession(req.User)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.ExpectedPhone != "" {
+		if err := h.service.SetExpectedPhone(req.User, req.ExpectedPhone); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"created": !existed,
+		"status":  h.sessionStatus(req.User),
+	})
+}
+
+// sessionStatus returns a short status description of a loaded session
+func (h *Handlers) sessionStatus(user string) map[string]any {
+	status := map[string]any{
+		"user":      user,
+		"paired":    false,
+		"logged_in": false,
+		"connected": false,
+	}
+	if whatsappClient, exists := h.app.GetClientManager().GetClient(user); exists {
+		status["paired"] = whatsappClient.IsPaired()
+		status["logged_in"] = whatsappClient.IsLoggedIn()
+		status["connected"] = whatsappClient.IsConnected()
+		status["phone"] = whatsappClient.Phone()
+		if expected := whatsappClient.ExpectedPhone(); expected != "" {
+			status["expected_phone"] = expected
+			status["phone_mismatch"] = whatsappClient.VerifyPhone() != nil
+		}
+		if whatsappClient.IsSandbox() {
+			status["sandbox"] = true
+		}
+	}
+	return status
+}
+
 // StatusHandler handles checking the status of a WhatsApp session
 func (h *Handlers) StatusHandler(c *gin.Context) {
 	user := c.Query("user")
@@ -55,107 +222,427 @@ func (h *Handlers) StatusHandler(c *gin.Context) {
 	}
 
 	// Get connection details
+	isPaired := sess.Client.Store.ID != nil
 	isLoggedIn := sess.IsLoggedIn
 	isConnected := sess.Client.IsConnected()
+	whatsappClient, hasClient := h.app.GetClientManager().GetClient(user)
+	sandbox := hasClient && whatsappClient.IsSandbox()
+	if sandbox {
+		// Sandbox sessions have no websocket but count as connected
+		isConnected = true
+	}
 
 	// Log the status check
-	h.app.Logger.Printf("Status check for user %s: logged_in=%v, connected=%v",
-		user, isLoggedIn, isConnected)
+	h.app.Logger.Printf("Status check for user %s: paired=%v, logged_in=%v, connected=%v",
+		user, isPaired, isLoggedIn, isConnected)
 
 	// Return detailed status
-	c.JSON(http.StatusOK, gin.H{
+	status := gin.H{
+		"paired":    isPaired,
 		"logged_in": isLoggedIn,
 		"connected": isConnected,
 		"user":      user,
+		"phone":     sess.Phone,
 		"needs_qr":  !isLoggedIn || !isConnected,
 		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	if isPaired {
+		status["jid"] = sess.Client.Store.ID.ToNonAD().String()
+	}
+	if hasClient {
+		if pairing := whatsappClient.LastPairing(); pairing != nil {
+			status["pairing"] = pairing
+		}
+		if ban := whatsappClient.Ban(); ban != nil {
+			status["ban"] = ban
+		}
+		if expected := whatsappClient.ExpectedPhone(); expected != "" {
+			status["expected_phone"] = expected
+			status["phone_mismatch"] = whatsappClient.VerifyPhone() != nil
+		}
+	}
+	if sandbox {
+		status["sandbox"] = true
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// ListSessionsHandler handles listing the sessions known to the client manager,
+// filtered by the status, connected and tag=key:value query parameters and paginated
+// with limit and offset
+func (h *Handlers) ListSessionsHandler(c *gin.Context) {
+	var filter SessionFilter
+	for _, tag := range c.QueryArray("tag") {
+		key, value, ok := strings.Cut(tag, ":")
+		if !ok || key == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid tag %q, must be key:value", tag)})
+			return
+		}
+		if filter.Tags == nil {
+			filter.Tags = make(map[string]string)
+		}
+		filter.Tags[key] = value
+	}
+
+	for _, status := range c.QueryArray("status") {
+		if !validStatusFilter(status) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid status %q, must be one of disconnected, connecting, connected, logged_in, logged_out, error, needs_qr", status),
+			})
+			return
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	if value := c.Query("connected"); value != "" {
+		connected, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid connected, must be true or false"})
+			return
+		}
+		filter.Connected = &connected
+	}
+
+	sessions := h.service.ListSessions(filter)
+	total := len(sessions)
+
+	// Without a limit every matching session is returned, as before pagination existed
+	limit, offset := total, 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxSessionListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid limit, must be between 1 and %d", maxSessionListLimit),
+			})
+			return
+		}
+		limit = parsed
+	}
+	if value := c.Query("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset, must be a non-negative integer"})
+			return
+		}
+		offset = parsed
+	}
+
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions[offset:end],
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
 	})
 }
 
-// RestartHandler handles restarting a WhatsApp session
-func (h *Handlers) RestartHandler(c *gin.Context) {
+// validStatusFilter reports whether status can be used to filter the session listing
+func validStatusFilter(status string) bool {
+	if status == StatusNeedsQR {
+		return true
+	}
+	for s := client.StatusDisconnected; s <= client.StatusError; s++ {
+		if s.String() == status {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAliasHandler handles attaching a business-name alias to a session
+func (h *Handlers) SetAliasHandler(c *gin.Context) {
+	var req AliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if err := ValidateUser(req.Alias); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alias: " + err.Error()})
+		return
+	}
+	if err := ValidateUser(req.User); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.service.SessionExists(req.Alias) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Alias conflicts with an existing session name"})
+		return
+	}
+
+	if err := h.app.Aliases.Set(req.Alias, req.User); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, app.ErrAliasFileInvalid) {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.app.Logger.Printf("Alias %s now points to session %s", req.Alias, req.User)
+	c.JSON(http.StatusOK, gin.H{"msg": "Alias saved", "alias": req.Alias, "user": req.User})
+}
+
+// DeleteAliasHandler handles removing a session alias
+func (h *Handlers) DeleteAliasHandler(c *gin.Context) {
+	alias := c.Query("alias")
+	if alias == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing alias"})
+		return
+	}
+
+	existed, err := h.app.Aliases.Delete(alias)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !existed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alias not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"msg": "Alias deleted", "alias": alias})
+}
+
+// ListAliasesHandler handles listing all session aliases
+func (h *Handlers) ListAliasesHandler(c *gin.Context) {
+	aliases := h.app.Aliases.List()
+	c.JSON(http.StatusOK, gin.H{"aliases": aliases, "total": len(aliases)})
+}
+
+// GetOptionsHandler handles showing the options of a session
+func (h *Handlers) GetOptionsHandler(c *gin.Context) {
 	user := c.Query("user")
 	if user == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
 		return
 	}
 
-	h.app.Logger.Printf("Restarting session for user: %s", user)
+	c.JSON(http.StatusOK, gin.H{"user": user, "options": h.app.Options.Get(user)})
+}
 
-	// First disconnect existing session if it exists
-	if oldSess, exists := h.service.FindSessionByUser(user); exists {
-		h.app.Logger.Printf("Disconnecting existing session for user: %s", user)
-		// Safe disconnect with retry
-		if oldSess.Client.IsConnected() {
-			oldSess.Client.Disconnect()
-			// Give it a moment to properly disconnect
-			time.Sleep(500 * time.Millisecond)
+// SetOptionsHandler handles changing the options of a session
+func (h *Handlers) SetOptionsHandler(c *gin.Context) {
+	var req SessionOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	if req.AutoRead != nil {
+		if _, err := app.ParseAutoRead(*req.AutoRead); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
+	}
 
-		// Remove from memory to force database restoration
-		h.app.SessionsLock.Lock()
-		delete(h.app.Sessions, user)
-		h.app.SessionsLock.Unlock()
+	options, err := h.app.Options.Update(req.User, func(options *app.SessionOptions) {
+		if req.AutoRead != nil {
+			options.AutoRead = *req.AutoRead
+		}
+		if req.DebugEvents != nil {
+			options.DebugEvents = *req.DebugEvents
+		}
+		if req.DetectLanguage != nil {
+			options.DetectLanguage = *req.DetectLanguage
+		}
+		if req.OptInKeyword != nil {
+			options.OptInKeyword = strings.TrimSpace(*req.OptInKeyword)
+		}
+		if req.ReceiveOnly != nil {
+			options.ReceiveOnly = *req.ReceiveOnly
+		}
+		if req.NormalizeText != nil {
+			options.NormalizeText = *req.NormalizeText
+		}
+		if req.OwnMessages != nil {
+			options.OwnMessages = *req.OwnMessages
+		}
+		if req.AvailableOnConnect != nil {
+			options.AvailableOnConnect = *req.AvailableOnConnect
+		}
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Attempt to restore session from database
-	sess, err := h.service.RestoreSession(user)
+	c.JSON(http.StatusOK, gin.H{"user": req.User, "options": options})
+}
+
+// GetMetadataHandler handles showing the metadata of a session
+func (h *Handlers) GetMetadataHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user, "metadata": h.app.Metadata.Get(user)})
+}
+
+// SetMetadataHandler handles setting and removing metadata keys of a session
+func (h *Handlers) SetMetadataHandler(c *gin.Context) {
+	var req MetadataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	if len(req.Metadata) == 0 && len(req.Remove) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing metadata or remove"})
+		return
+	}
+
+	metadata, err := h.app.Metadata.Update(req.User, req.Metadata, req.Remove)
 	if err != nil {
-		h.app.Logger.Printf("Failed to restore session for user %s: %v", user, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore session: " + err.Error()})
+		status := http.StatusBadRequest
+		if errors.Is(err, app.ErrMetadataFileInvalid) {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	h.app.Logger.Printf("Session restored from database for user: %s", user)
+	c.JSON(http.StatusOK, gin.H{"user": req.User, "metadata": metadata})
+}
 
-	// Add restored session to memory
-	h.app.SessionsLock.Lock()
-	h.app.Sessions[user] = sess
-	h.app.SessionsLock.Unlock()
+// GetLogLevelHandler handles showing a session's whatsmeow log level
+func (h *Handlers) GetLogLevelHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	level, custom := logger.WhatsmeowLevel(user)
+	c.JSON(http.StatusOK, gin.H{"user": user, "level": level, "custom": custom})
+}
+
+// SetLogLevelHandler handles changing a session's whatsmeow log level at runtime
+func (h *Handlers) SetLogLevelHandler(c *gin.Context) {
+	var req LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	if err := logger.SetWhatsmeowLevel(req.User, req.Level); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	level, custom := logger.WhatsmeowLevel(req.User)
+	h.app.Logger.Printf("Whatsmeow log level of session %s set to %s", req.User, level)
+	c.JSON(http.StatusOK, gin.H{"user": req.User, "level": level, "custom": custom})
+}
+
+// PresenceHandler handles POST /wa/presence - shows a session online or offline
+func (h *Handlers) PresenceHandler(c *gin.Context) {
+	var req PresenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	if req.Presence != client.PresenceAvailable && req.Presence != client.PresenceUnavailable {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid presence, must be one of available, unavailable"})
+		return
+	}
 
-	// Connect the restored session with retry logic
-	err = sess.Client.Connect()
+	whatsappClient, exists := h.app.GetClientManager().GetClient(req.User)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session not found"})
+		return
+	}
+	if !whatsappClient.IsLoggedIn() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Session not logged in"})
+		return
+	}
+
+	if err := whatsappClient.SetPresence(c.Request.Context(), req.Presence); err != nil {
+		h.app.Logger.Printf("Set presence error for user %s: %v", req.User, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set presence", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": req.User, "presence": req.Presence})
+}
+
+// StatusHistoryHandler handles listing the recorded status transitions of a session
+func (h *Handlers) StatusHistoryHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	whatsappClient, exists := h.app.GetClientManager().GetClient(user)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session not found"})
+		return
+	}
+
+	history := whatsappClient.StatusHistory()
+	c.JSON(http.StatusOK, gin.H{
+		"user":    user,
+		"status":  whatsappClient.GetStatus().String(),
+		"history": history,
+		"total":   len(history),
+	})
+}
+
+// RestartHandler handles restarting a WhatsApp session
+func (h *Handlers) RestartHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	sess, err := h.service.RestartSession(user)
 	if err != nil {
-		// Try to handle specific error types
-		if err.Error() == "websocket is already connected" {
-			h.app.Logger.Printf("Got 'already connected' error for %s, trying to disconnect and reconnect", user)
-			// Force disconnect and try again after a delay
-			sess.Client.Disconnect()
-			time.Sleep(1 * time.Second)
-			err = sess.Client.Connect()
-			if err != nil {
-				h.app.Logger.Printf("Failed to connect after retry for user %s: %v", user, err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": "Failed to connect after retry: " + err.Error(),
-					"status": map[string]any{
-						"logged_in": sess.IsLoggedIn,
-						"connected": sess.Client.IsConnected(),
-						"user":      user,
-					},
-				})
-				return
-			}
-		} else {
-			h.app.Logger.Printf("Failed to connect for user %s: %v", user, err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to connect restored session: " + err.Error(),
-				"status": map[string]any{
-					"logged_in": sess.IsLoggedIn,
-					"connected": sess.Client.IsConnected(),
-					"user":      user,
-				},
-			})
+		if sess == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+			"status": map[string]any{
+				"logged_in": sess.IsLoggedIn,
+				"connected": sess.Client.IsConnected(),
+				"user":      user,
+			},
+		})
+		return
 	}
 
 	// Get connection details after reconnection
 	isLoggedIn := sess.IsLoggedIn
 	isConnected := sess.Client.IsConnected()
 
-	h.app.Logger.Printf("Session successfully reconnected for user: %s (logged_in=%v, connected=%v)",
-		user, isLoggedIn, isConnected)
-
 	// Check if QR code is needed
 	needsQR := !isLoggedIn || !isConnected
 
@@ -169,6 +656,7 @@ func (h *Handlers) RestartHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"msg": msg,
 		"status": map[string]any{
+			"paired":    sess.Client.Store.ID != nil,
 			"logged_in": isLoggedIn,
 			"connected": isConnected,
 			"user":      user,
@@ -177,6 +665,41 @@ func (h *Handlers) RestartHandler(c *gin.Context) {
 	})
 }
 
+// RestartAllHandler handles restarting every known session with bounded concurrency
+func (h *Handlers) RestartAllHandler(c *gin.Context) {
+	var req RestartAllRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultRestartConcurrency
+	}
+	if concurrency > maxRestartConcurrency {
+		concurrency = maxRestartConcurrency
+	}
+
+	results := h.service.RestartAllSessions(concurrency)
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":     results,
+		"total":       len(results),
+		"failed":      failed,
+		"concurrency": concurrency,
+	})
+}
+
 // LogoutHandler handles logging out a WhatsApp session
 func (h *Handlers) LogoutHandler(c *gin.Context) {
 	var req LogoutRequest
@@ -197,31 +720,74 @@ func (h *Handlers) LogoutHandler(c *gin.Context) {
 		isConnected = sess.Client.IsConnected()
 	}
 
+	job := logoutJobs.create(req.User)
+
 	// Provide appropriate feedback based on connection status
-	if isConnected {
-		c.JSON(http.StatusOK, gin.H{
-			"msg": "Logout process started for connected session",
-			"status": map[string]any{
-				"user":      req.User,
-				"connected": true,
-				"logged_in": sess.IsLoggedIn,
-			},
-		})
-	} else {
-		c.JSON(http.StatusOK, gin.H{
-			"msg": "Session is disconnected. Will attempt to connect before logout.",
-			"status": map[string]any{
-				"user":      req.User,
-				"connected": false,
-				"logged_in": sess.IsLoggedIn,
-			},
-		})
+	msg := "Logout process started for connected session"
+	if !isConnected {
+		msg = "Session is disconnected. Will attempt to connect before logout."
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"msg":    msg,
+		"job_id": job.ID,
+		"status": map[string]any{
+			"user":      req.User,
+			"connected": isConnected,
+			"logged_in": sess.IsLoggedIn,
+		},
+	})
+
 	// Process logout asynchronously to avoid blocking the response
 	go func() {
-		if err := h.service.LogoutSession(req.User); err != nil {
+		result, err := h.service.LogoutSession(req.User, req.DeleteData)
+		if err != nil {
 			h.app.Logger.Printf("Error during logout for %s: %v", req.User, err)
 		}
+		logoutJobs.finish(job.ID, result, err)
 	}()
 }
+
+// LogoutStatusHandler handles checking the outcome of an asynchronous logout
+func (h *Handlers) LogoutStatusHandler(c *gin.Context) {
+	job, exists := logoutJobs.get(c.Param("id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Logout job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DisconnectHandler handles disconnecting a WhatsApp session while keeping its credentials
+func (h *Handlers) DisconnectHandler(c *gin.Context) {
+	var req DisconnectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if err := h.service.DisconnectSession(req.User); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg": "Session disconnected. Credentials kept, use /wa/restart to reconnect",
+		"status": map[string]any{
+			"user":      req.User,
+			"connected": false,
+		},
+	})
+}
+
+// RestoreReportHandler handles reporting the outcome of the startup session restore
+func (h *Handlers) RestoreReportHandler(c *gin.Context) {
+	report, exists := GetRestoreReport()
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No restore has run yet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
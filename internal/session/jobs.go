@@ -0,0 +1,97 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// How long finished logout jobs are kept for status lookups
+const logoutJobRetention = time.Hour
+
+// Logout job states
+const (
+	JobStatusPending   = "pending"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// LogoutJob tracks an asynchronous logout
+type LogoutJob struct {
+	ID         string        `json:"id"`
+	User       string        `json:"user"`
+	Status     string        `json:"status"`
+	Error      string        `json:"error,omitempty"`
+	Result     *LogoutResult `json:"result,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+}
+
+// logoutJobStore keeps logout jobs in memory
+type logoutJobStore struct {
+	mu   sync.RWMutex
+	jobs map[string]*LogoutJob
+}
+
+// Global store for asynchronous logout jobs, shared by all handler instances
+var logoutJobs = &logoutJobStore{jobs: make(map[string]*LogoutJob)}
+
+// create registers a new pending job for a user
+func (s *logoutJobStore) create(user string) *LogoutJob {
+	idBytes := make([]byte, 8)
+	_, _ = rand.Read(idBytes)
+
+	job := &LogoutJob{
+		ID:        hex.EncodeToString(idBytes),
+		User:      user,
+		Status:    JobStatusPending,
+		CreatedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop old finished jobs
+	for id, existing := range s.jobs {
+		if existing.FinishedAt != nil && time.Since(*existing.FinishedAt) > logoutJobRetention {
+			delete(s.jobs, id)
+		}
+	}
+
+	s.jobs[job.ID] = job
+	return job
+}
+
+// finish records the outcome of a job
+func (s *logoutJobStore) finish(id string, result *LogoutResult, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Result = result
+	if err != nil {
+		job.Status = JobStatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = JobStatusSucceeded
+	}
+}
+
+// get returns a copy of a job
+func (s *logoutJobStore) get(id string) (LogoutJob, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return LogoutJob{}, false
+	}
+	return *job, true
+}
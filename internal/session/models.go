@@ -1,20 +1,147 @@
 package session
 
+import "github.com/neekaru/whatsappgo-bot/internal/client"
+
 // AddSessionRequest represents a request to add a new session
 type AddSessionRequest struct {
-	User string `json:"user"`
+	User          string `json:"user"`
+	ExpectedPhone string `json:"expected_phone"` // Optional phone number the session must log in as
 }
 
 // StatusResponse represents a session status response
 type StatusResponse struct {
+	Paired     bool   `json:"paired"`
 	LoggedIn   bool   `json:"logged_in"`
 	Connected  bool   `json:"connected"`
 	User       string `json:"user"`
+	Phone      string `json:"phone"`
 	NeedsQR    bool   `json:"needs_qr"`
 	Timestamp  string `json:"timestamp"`
 }
 
 // LogoutRequest represents a request to logout a session
 type LogoutRequest struct {
+	User       string `json:"user"`
+	DeleteData bool   `json:"delete_data"` // Also delete the session database file
+}
+
+// DisconnectRequest represents a request to disconnect a session without logging out
+type DisconnectRequest struct {
 	User string `json:"user"`
 }
+
+// PresenceRequest represents a request to show a session online or offline
+type PresenceRequest struct {
+	User     string `json:"user"`
+	Presence string `json:"presence"` // available or unavailable
+}
+
+// SandboxRequest represents a request to create a sandbox session
+type SandboxRequest struct {
+	User             string `json:"user"`
+	Phone            string `json:"phone"`              // Number the session is logged in as; generated when empty
+	DeliveredAfterMS int    `json:"delivered_after_ms"` // Until sends are reported delivered; 0 uses 1000
+	ReadAfterMS      int    `json:"read_after_ms"`      // From delivered until read; 0 uses 2000, negative never
+	Reply            string `json:"reply"`              // Text recipients reply with once they read a message
+}
+
+// SandboxIncomingRequest represents a request to simulate an incoming text
+// message on a sandbox session
+type SandboxIncomingRequest struct {
+	User        string `json:"user"`
+	PhoneNumber string `json:"phone_number"` // Sender
+	Message     string `json:"message"`
+}
+
+// SessionSummary represents a session entry in the session listing
+type SessionSummary struct {
+	User          string                 `json:"user"`
+	Phone         string                 `json:"phone"`
+	ExpectedPhone string                 `json:"expected_phone,omitempty"`
+	PhoneMismatch bool                   `json:"phone_mismatch,omitempty"`
+	Aliases       []string               `json:"aliases,omitempty"`
+	Status        string                 `json:"status"`
+	Paired        bool                   `json:"paired"`
+	LoggedIn      bool                   `json:"logged_in"`
+	Connected     bool                   `json:"connected"`
+	OpsInFlight   int                    `json:"ops_in_flight"`
+	Metrics       client.MetricsSnapshot `json:"metrics"`
+	Metadata      map[string]string      `json:"metadata,omitempty"`
+	Sandbox       bool                   `json:"sandbox,omitempty"`
+}
+
+// SessionFilter selects sessions in the session listing
+type SessionFilter struct {
+	Tags      map[string]string // Metadata key/value pairs the session must all have
+	Statuses  []string          // Client statuses, or needs_qr, any of which the session must have
+	Connected *bool             // Whether the session must be connected
+}
+
+// StatusNeedsQR is a session list status filter matching sessions that are not paired
+const StatusNeedsQR = "needs_qr"
+
+// Pagination bounds for the session listing
+const (
+	maxSessionListLimit = 500
+)
+
+// AliasRequest represents a request to create a session alias.
+// The target uses "target_user" so it is not itself rewritten by alias resolution.
+type AliasRequest struct {
+	Alias string `json:"alias"`
+	User  string `json:"target_user"`
+}
+
+// SessionOptionsRequest represents a request to change session options.
+// Omitted fields keep their current value.
+type SessionOptionsRequest struct {
+	User        string  `json:"user"`
+	AutoRead    *string `json:"auto_read"`
+	DebugEvents *bool   `json:"debug_events"`
+
+	DetectLanguage *bool   `json:"detect_language"`
+	OptInKeyword   *string `json:"opt_in_keyword"` // Empty disables opt-in confirmation
+	ReceiveOnly    *bool   `json:"receive_only"`   // Block all outbound messages
+	NormalizeText  *bool   `json:"normalize_text"` // Repair and NFC-normalize outgoing text
+	OwnMessages    *bool   `json:"own_messages"`   // Publish messages sent from other devices
+
+	AvailableOnConnect *bool `json:"available_on_connect"` // Set presence available after each reconnect
+}
+
+// MetadataRequest represents a request to change session metadata.
+// Keys in Metadata are set, keys in Remove are deleted, others are kept.
+type MetadataRequest struct {
+	User     string            `json:"user"`
+	Metadata map[string]string `json:"metadata"`
+	Remove   []string          `json:"remove"`
+}
+
+// LogLevelRequest represents a request to change a session's whatsmeow log level
+type LogLevelRequest struct {
+	User  string `json:"user"`
+	Level string `json:"level"` // DEBUG, INFO, WARN or ERROR; empty resets to the default
+}
+
+// LogoutResult describes the outcome of a logout
+type LogoutResult struct {
+	User        string `json:"user"`
+	LoggedOut   bool   `json:"logged_out"`
+	LogoutError string `json:"logout_error,omitempty"`
+	DataDeleted bool   `json:"data_deleted"`
+	DeleteError string `json:"delete_error,omitempty"`
+}
+
+// RestartAllRequest represents a request to restart every session
+type RestartAllRequest struct {
+	Concurrency int `json:"concurrency"`
+}
+
+// RestartResult describes the outcome of restarting a single session
+type RestartResult struct {
+	User      string `json:"user"`
+	Paired    bool   `json:"paired"`
+	LoggedIn  bool   `json:"logged_in"`
+	Connected bool   `json:"connected"`
+	NeedsQR   bool   `json:"needs_qr"`
+	Error     string `json:"error,omitempty"`
+}
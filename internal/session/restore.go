@@ -0,0 +1,117 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// Restore outcomes for a single session
+const (
+	RestoreConnected = "connected"
+	RestoreNeedsQR   = "needs_qr"
+	RestoreFailed    = "failed"
+)
+
+// RestoreEntry describes how a single session fared during startup restore
+type RestoreEntry struct {
+	User    string `json:"user"`
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RestoreReport summarises the startup restore of all sessions
+type RestoreReport struct {
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	Completed  bool           `json:"completed"`
+	Connected  int            `json:"connected"`
+	NeedsQR    int            `json:"needs_qr"`
+	Failed     int            `json:"failed"`
+	Sessions   []RestoreEntry `json:"sessions"`
+}
+
+var (
+	restoreReport     *RestoreReport
+	restoreReportLock sync.RWMutex
+)
+
+// GetRestoreReport returns a copy of the latest startup restore report, if any
+func GetRestoreReport() (RestoreReport, bool) {
+	restoreReportLock.RLock()
+	defer restoreReportLock.RUnlock()
+
+	if restoreReport == nil {
+		return RestoreReport{}, false
+	}
+
+	report := *restoreReport
+	report.Sessions = append([]RestoreEntry(nil), restoreReport.Sessions...)
+	return report, true
+}
+
+// StoredUsers returns the users that have a session in the session store
+func (s *Service) StoredUsers() ([]string, error) {
+	return sessionStore.Users()
+}
+
+// RestoreAllSessions restores every stored session, records a restore report and
+// logs a summary of the outcome
+func (s *Service) RestoreAllSessions() {
+	report := &RestoreReport{StartedAt: time.Now()}
+	restoreReportLock.Lock()
+	restoreReport = report
+	restoreReportLock.Unlock()
+
+	users, err := s.StoredUsers()
+	if err != nil {
+		s.app.Logger.Printf("Failed to list stored sessions: %v", err)
+	}
+
+	for _, user := range users {
+		entry := RestoreEntry{User: user}
+
+		sess, exists := s.FindSessionByUser(user)
+		switch {
+		case !exists:
+			entry.Outcome = RestoreFailed
+			entry.Error = "failed to restore session from database"
+		case sess.IsLoggedIn && sess.Client.IsConnected():
+			entry.Outcome = RestoreConnected
+		default:
+			entry.Outcome = RestoreNeedsQR
+		}
+
+		restoreReportLock.Lock()
+		report.Sessions = append(report.Sessions, entry)
+		switch entry.Outcome {
+		case RestoreConnected:
+			report.Connected++
+		case RestoreNeedsQR:
+			report.NeedsQR++
+		case RestoreFailed:
+			report.Failed++
+		}
+		restoreReportLock.Unlock()
+	}
+
+	restoreReportLock.Lock()
+	now := time.Now()
+	report.FinishedAt = &now
+	report.Completed = true
+	restoreReportLock.Unlock()
+
+	s.logRestoreSummary(report)
+}
+
+// logRestoreSummary logs a banner with the outcome of every restored session
+func (s *Service) logRestoreSummary(report *RestoreReport) {
+	s.app.Logger.Printf("===== Session restore: %d connected, %d need QR, %d failed =====",
+		report.Connected, report.NeedsQR, report.Failed)
+	for _, entry := range report.Sessions {
+		if entry.Error != "" {
+			s.app.Logger.Printf("  %-20s %s: %s", entry.User, entry.Outcome, entry.Error)
+		} else {
+			s.app.Logger.Printf("  %-20s %s", entry.User, entry.Outcome)
+		}
+	}
+}
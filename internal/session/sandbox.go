@@ -0,0 +1,92 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"github.com/neekaru/whatsappgo-bot/internal/utils"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// sandboxPhonePattern restricts sandbox phone numbers to international format without '+'
+var sandboxPhonePattern = regexp.MustCompile(`^[1-9][0-9]{6,14}$`)
+
+// sandboxPhone returns a fictional phone number for a sandbox session, from
+// the 555-01xx range reserved for fiction
+func sandboxPhone() string {
+	return fmt.Sprintf("1%03d55501%02d", 200+rand.Intn(800), rand.Intn(100))
+}
+
+// CreateSandboxSession creates a session that simulates a connected account
+// instead of connecting to WhatsApp, failing with a SessionExistsError if the
+// session already exists. Sandbox sessions are logged in as phone right away
+// and live in memory only, so they are gone after a restart or logout.
+func (s *Service) CreateSandboxSession(user, phone string, opts client.SandboxOptions) (*app.Session, error) {
+	sessionRestorationMutex.Lock(user)
+	defer sessionRestorationMutex.Unlock(user)
+
+	if s.SessionExists(user) {
+		return nil, &SessionExistsError{User: user}
+	}
+	if err := ValidateUser(user); err != nil {
+		return nil, err
+	}
+	if phone == "" {
+		phone = sandboxPhone()
+	} else if phone = strings.TrimPrefix(strings.TrimSpace(phone), "+"); !sandboxPhonePattern.MatchString(phone) {
+		return nil, fmt.Errorf("invalid phone %q: must be 7-15 digits in international format", phone)
+	}
+
+	// The device store is kept in memory, so nothing of the session is persisted
+	dbLog := s.app.Logger.Whatsmeow(user, "Database")
+	container, err := sqlstore.New(context.Background(), "sqlite3",
+		"file:sandbox-"+user+"?mode=memory&cache=shared&_foreign_keys=on", dbLog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox store: %v", err)
+	}
+	device := container.NewDevice()
+	device.ID = &types.JID{User: phone, Device: 1, Server: types.DefaultUserServer}
+	device.PushName = user
+	whatsmeowClient := whatsmeow.NewClient(device, s.app.Logger.Whatsmeow(user, "Client"))
+
+	if _, err := s.app.GetClientManager().AddSandboxClient(user, container, whatsmeowClient, opts); err != nil {
+		container.Close()
+		return nil, fmt.Errorf("error adding client to ClientManager: %v", err)
+	}
+
+	session := &app.Session{
+		Client:     whatsmeowClient,
+		Container:  container,
+		User:       user,
+		Phone:      phone,
+		IsLoggedIn: true,
+	}
+	s.app.SessionsLock.Lock()
+	s.app.Sessions[user] = session
+	s.app.SessionsLock.Unlock()
+
+	s.app.Logger.Printf("Created sandbox session for user %s as %s", user, phone)
+	return session, nil
+}
+
+// SimulateIncoming delivers a text message from phoneNumber to a sandbox session
+func (s *Service) SimulateIncoming(user, phoneNumber, text string) (types.MessageID, error) {
+	whatsappClient, exists := s.app.GetClientManager().GetClient(user)
+	if !exists {
+		return "", fmt.Errorf("client not found for user %s", user)
+	}
+	return whatsappClient.SimulateIncoming(utils.RecipientJID(phoneNumber), text)
+}
+
+// isSandbox reports whether user is a loaded sandbox session
+func (s *Service) isSandbox(user string) bool {
+	whatsappClient, exists := s.app.GetClientManager().GetClient(user)
+	return exists && whatsappClient.IsSandbox()
+}
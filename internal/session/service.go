@@ -2,18 +2,18 @@ package session
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"strings"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waCompanionReg"
 	"go.mau.fi/whatsmeow/store"
 	"go.mau.fi/whatsmeow/store/sqlstore"
-	waLog "go.mau.fi/whatsmeow/util/log"
 )
 
 // KeyedMutex is a string-keyed mutex for locking operations on specific keys
@@ -62,43 +62,48 @@ func (s *Service) RestoreSession(user string) (*app.Session, error) {
 			Client:     whatsappClient.WhatsmeowClient,
 			Container:  whatsappClient.Container,
 			User:       user,
+			Phone:      whatsappClient.Phone(),
 			IsLoggedIn: whatsappClient.IsLoggedIn(),
 		}
 		return session, nil
 	}
 
 	// Client doesn't exist, restore it from the database
-	dbPath := "data/" + user + ".db"
+	if err := ValidateUser(user); err != nil {
+		return nil, err
+	}
 
 	// Create a context with timeout to prevent indefinite blocking
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// Create a logger specifically for this database connection
-	dbLogger := waLog.Stdout("Database-"+user, "INFO", true)
-	s.app.Logger.Printf("Creating/restoring session for user: %s at %s", user, dbPath)
+	dbLogger := s.app.Logger.Whatsmeow(user, "Database")
+	s.app.Logger.Printf("Creating/restoring session for user: %s at %s", user, sessionStore.Location(user))
 
 	// Use a channel to handle the database operation with timeout
 	type dbResult struct {
 		container *sqlstore.Container
+		device    *store.Device
 		err       error
 	}
 
 	resultChan := make(chan dbResult, 1)
 
 	go func() {
-		// Try to open the database with compatibility for old format
-		container, err := sqlstore.New(ctx, "sqlite3", "file:"+dbPath+"?_foreign_keys=on", dbLogger)
-		resultChan <- dbResult{container, err}
+		container, device, err := sessionStore.Open(ctx, user, dbLogger)
+		resultChan <- dbResult{container, device, err}
 	}()
 
 	// Wait for either the result or timeout
 	var container *sqlstore.Container
+	var device *store.Device
 	var err error
 
 	select {
 	case result := <-resultChan:
 		container = result.container
+		device = result.device
 		err = result.err
 	case <-ctx.Done():
 		return nil, fmt.Errorf("database operation timed out: %v", ctx.Err())
@@ -106,52 +111,15 @@ func (s *Service) RestoreSession(user string) (*app.Session, error) {
 
 	if err != nil {
 		s.app.Logger.Printf("Database error for user %s: %v", user, err)
-		return nil, fmt.Errorf("database error: %v", err)
-	}
-
-	// Get device with timeout
-	deviceChan := make(chan struct {
-		device *store.Device
-		err    error
-	}, 1)
-
-	go func() {
-		deviceStore, err := container.GetFirstDevice(ctx)
-		deviceChan <- struct {
-			device *store.Device
-			err    error
-		}{deviceStore, err}
-	}()
-
-	var deviceStore *store.Device
-
-	select {
-	case result := <-deviceChan:
-		deviceStore = result.device
-		err = result.err
-	case <-ctx.Done():
-		// Close the container before returning
-		if container != nil {
-			container.Close()
-		}
-		return nil, fmt.Errorf("device operation timed out: %v", ctx.Err())
-	}
-
-	if err != nil {
-		s.app.Logger.Printf("Device error for user %s: %v", user, err)
-		// Close the container before returning
-		if container != nil {
-			container.Close()
-		}
-		return nil, fmt.Errorf("device error: %v", err)
+		return nil, err
 	}
 
 	store.SetOSInfo("Linux", store.GetWAVersion())
 	store.DeviceProps.PlatformType = waCompanionReg.DeviceProps_CHROME.Enum()
 
 	// Configure client with proper logging
-	clientLogger := waLog.Stdout("WhatsApp-"+user, "INFO", true)
-	whatsmeowClient := whatsmeow.NewClient(deviceStore, clientLogger)
+	clientLogger := s.app.Logger.Whatsmeow(user, "Client")
+	whatsmeowClient := whatsmeow.NewClient(device, clientLogger)
 
 	// Add the client to the ClientManager
 	_, err = clientManager.AddClient(user, container, whatsmeowClient)
@@ -163,6 +131,7 @@ func (s *Service) RestoreSession(user string) (*app.Session, error) {
 		}
 		return nil, fmt.Errorf("error adding client to ClientManager: %v", err)
 	}
+	sessionStore.Track(user, whatsmeowClient)
 
 	// Create a legacy Session for backward compatibility
 	session := &app.Session{
@@ -199,28 +168,28 @@ func (s *Service) RestoreSession(user string) (*app.Session, error) {
 }
 
 // ConnectWithRetry attempts to connect a client with retry logic
-func (s *Service) ConnectWithRetry(client *whatsmeow.Client, user string) error {
+func (s *Service) ConnectWithRetry(whatsmeowClient *whatsmeow.Client, user string) error {
 	var err error
 	maxRetries := 3
 
 	for i := 0; i < maxRetries; i++ {
 		// If client is already connected, disconnect first to avoid "already connected" errors
-		if client.IsConnected() {
+		if whatsmeowClient.IsConnected() {
 			s.app.Logger.Printf("Client for user %s is already connected, disconnecting first", user)
-			client.Disconnect()
+			whatsmeowClient.Disconnect()
 			time.Sleep(500 * time.Millisecond)
 		}
 
-		err = client.Connect()
+		err = whatsmeowClient.Connect()
 		if err == nil {
 			return nil // Successfully connected
 		}
 
-		if strings.Contains(err.Error(), "websocket is already connected") {
+		if errors.Is(err, client.ErrAlreadyConnected) {
 			// Special handling for this common error
 			s.app.Logger.Printf("Got 'already connected' error for user %s, trying again after disconnect (attempt %d/%d)",
 				user, i+1, maxRetries)
-			client.Disconnect()
+			whatsmeowClient.Disconnect()
 			time.Sleep(1 * time.Second) // Longer wait after this specific error
 		} else {
 			// For other errors, try again with shorter wait
@@ -248,6 +217,7 @@ func (s *Service) FindSessionByUser(user string) (*app.Session, bool) {
 			Client:     whatsappClient.WhatsmeowClient,
 			Container:  whatsappClient.Container,
 			User:       user,
+			Phone:      whatsappClient.Phone(),
 			IsLoggedIn: whatsappClient.IsLoggedIn(),
 		}
 		return session, true
@@ -302,7 +272,29 @@ func (s *Service) FindSessionByUser(user string) (*app.Session, bool) {
 	return sess, true
 }
 
-// AddSession creates a new WhatsApp session
+// SessionExists reports whether a session is loaded or has stored credentials
+func (s *Service) SessionExists(user string) bool {
+	if s.app.GetClientManager().ClientExists(user) {
+		return true
+	}
+	return sessionStore.Exists(user)
+}
+
+// CreateSession creates a new WhatsApp session, failing with a SessionExistsError
+// if the session already exists
+func (s *Service) CreateSession(user string) (*app.Session, error) {
+	sessionRestorationMutex.Lock(user)
+	defer sessionRestorationMutex.Unlock(user)
+
+	if s.SessionExists(user) {
+		return nil, &SessionExistsError{User: user}
+	}
+
+	return s.AddSession(user)
+}
+
+// AddSession creates a new WhatsApp session, or returns the existing one if the
+// session is already loaded
 func (s *Service) AddSession(user string) (*app.Session, error) {
 	// Check if the client already exists in the ClientManager
 	clientManager := s.app.GetClientManager()
@@ -313,30 +305,27 @@ func (s *Service) AddSession(user string) (*app.Session, error) {
 			Client:     whatsappClient.WhatsmeowClient,
 			Container:  whatsappClient.Container,
 			User:       user,
+			Phone:      whatsappClient.Phone(),
 			IsLoggedIn: whatsappClient.IsLoggedIn(),
 		}
 		return session, nil
 	}
 
-	dbPath := "data/" + user + ".db"
-
-	// Initialize the database connection
-	dbLog := waLog.Stdout("Database", "INFO", true)
-	container, err := sqlstore.New(context.Background(), "sqlite3", "file:"+dbPath+"?_foreign_keys=on", dbLog)
-	if err != nil {
-		return nil, fmt.Errorf("db error: %v", err)
+	if err := ValidateUser(user); err != nil {
+		return nil, err
 	}
 
-	// Get the device store from the database
-	deviceStore, err := container.GetFirstDevice(context.Background())
+	// Open the device store of the session
+	dbLog := s.app.Logger.Whatsmeow(user, "Database")
+	container, device, err := sessionStore.Open(context.Background(), user, dbLog)
 	if err != nil {
-		return nil, fmt.Errorf("device error: %v", err)
+		return nil, err
 	}
 
 	// Create the client, but don't connect yet
 	store.SetOSInfo("Linux", store.GetWAVersion())
 	store.DeviceProps.PlatformType = waCompanionReg.DeviceProps_CHROME.Enum()
-	whatsmeowClient := whatsmeow.NewClient(deviceStore, waLog.Noop)
+	whatsmeowClient := whatsmeow.NewClient(device, s.app.Logger.Whatsmeow(user, "Client"))
 
 	// Add the client to the ClientManager
 	_, err = clientManager.AddClient(user, container, whatsmeowClient)
@@ -348,6 +337,7 @@ func (s *Service) AddSession(user string) (*app.Session, error) {
 		}
 		return nil, fmt.Errorf("error adding client to ClientManager: %v", err)
 	}
+	sessionStore.Track(user, whatsmeowClient)
 
 	// Create a legacy Session for backward compatibility
 	session := &app.Session{
@@ -365,15 +355,70 @@ func (s *Service) AddSession(user string) (*app.Session, error) {
 	return session, nil
 }
 
-// LogoutSession logs out a session and cleans up resources
-func (s *Service) LogoutSession(user string) error {
+// DisconnectSession disconnects a session and frees its resources while keeping
+// the stored credentials, so the session can be restored later
+func (s *Service) DisconnectSession(user string) error {
+	// Sandbox sessions keep nothing, so disconnecting one removes it
+	sandbox := s.isSandbox(user)
+	clientManager := s.app.GetClientManager()
+	released := false
+	if clientManager.ClientExists(user) {
+		if err := clientManager.ReleaseClient(user); err != nil {
+			return err
+		}
+		released = true
+	}
+
+	s.app.SessionsLock.Lock()
+	sess, inMemory := s.app.Sessions[user]
+	delete(s.app.Sessions, user)
+	s.app.SessionsLock.Unlock()
+
+	if !released && !inMemory {
+		return fmt.Errorf("no session found for user %s", user)
+	}
+
+	if !released && sess.Client != nil && sess.Client.IsConnected() {
+		sess.Client.Disconnect()
+	}
+
+	if sandbox {
+		if inMemory && sess.Container != nil {
+			sess.Container.Close()
+		}
+		s.dropMetadata(user)
+		s.app.Logger.Printf("Removed sandbox session for %s", user)
+		return nil
+	}
+	s.app.Logger.Printf("Disconnected session for %s, credentials kept", user)
+	return nil
+}
+
+// dropMetadata removes the metadata of a session that no longer exists
+func (s *Service) dropMetadata(user string) {
+	if err := s.app.Metadata.Delete(user); err != nil {
+		s.app.Logger.Printf("Failed to delete metadata of session %s: %v", user, err)
+	}
+}
+
+// LogoutSession logs out a session and cleans up resources. The stored session is
+// only deleted when deleteData is true.
+func (s *Service) LogoutSession(user string, deleteData bool) (*LogoutResult, error) {
+	result := &LogoutResult{User: user}
+	// Sandbox sessions have no account to log out of nor stored data
+	sandbox := s.isSandbox(user)
+
 	// Check if the client exists in the ClientManager
 	clientManager := s.app.GetClientManager()
+	clientRemoved := false
 	if clientManager.ClientExists(user) {
 		// Remove the client from the ClientManager
-		err := clientManager.RemoveClient(user)
+		loggedOut, err := clientManager.RemoveClient(user)
 		if err != nil {
 			s.app.Logger.Printf("Error removing client from ClientManager: %v", err)
+		} else {
+			clientRemoved = true
+			result.LoggedOut = loggedOut || sandbox
 		}
 	}
 
@@ -391,13 +436,16 @@ func (s *Service) LogoutSession(user string) error {
 	}
 	s.app.SessionsLock.RUnlock()
 
-	if sess == nil {
-		return fmt.Errorf("no session found for user %s", user)
+	if sess == nil && !clientRemoved {
+		return result, fmt.Errorf("no session found for user %s", user)
 	}
 
 	// Step 1: Attempt to logout and disconnect client safely
 	// Note: We're not holding any locks here for these potentially slow operations
-	if sess.Client != nil {
+	if sess != nil && sess.Client != nil && result.LoggedOut {
+		// Already logged out while removing the client, or a sandbox
+		sess.Client = nil
+	} else if sess != nil && sess.Client != nil {
 		// Always try to disconnect first to ensure a clean state
 		if sess.Client.IsConnected() {
 			s.app.Logger.Printf("Disconnecting client for %s", user)
@@ -416,8 +464,10 @@ func (s *Service) LogoutSession(user string) error {
 			logoutErr := sess.Client.Logout(context.Background())
 			if logoutErr != nil {
 				s.app.Logger.Printf("Logout error for %s (this is usually not critical): %v", user, logoutErr)
+				result.LogoutError = logoutErr.Error()
 			} else {
 				s.app.Logger.Printf("Successfully logged out %s", user)
+				result.LoggedOut = true
 			}
 
 			// Always disconnect after logout attempt
@@ -432,23 +482,227 @@ func (s *Service) LogoutSession(user string) error {
 	}
 
 	// Step 2: Close database connection
-	if sess.Container != nil {
+	if sess != nil && sess.Container != nil {
 		sess.Container.Close()
 	}
 
-	// Step 3: Delete database file
-	dbFile := "data/" + user + ".db"
-	if err := os.Remove(dbFile); err != nil {
-		s.app.Logger.Printf("Error deleting database file for %s: %v", user, err)
-		// Continue with cleanup even if file deletion fails
-	} else {
-		s.app.Logger.Printf("Successfully deleted database file for %s", user)
+	// Step 3: Delete stored session data if requested
+	if deleteData && !sandbox {
+		if err := sessionStore.Delete(context.Background(), user); err != nil {
+			s.app.Logger.Printf("Error deleting session data for %s: %v", user, err)
+			result.DeleteError = err.Error()
+			// Continue with cleanup even if file deletion fails
+		} else {
+			s.app.Logger.Printf("Successfully deleted session data for %s", user)
+			result.DataDeleted = true
+		}
+	} else if !sandbox {
+		s.app.Logger.Printf("Keeping session data for %s (delete_data not set)", user)
+	}
+	if result.DataDeleted || sandbox {
+		s.dropMetadata(user)
 	}
 
 	// Step 4: Remove from sessions map
+	if sess != nil {
+		s.app.SessionsLock.Lock()
+		delete(s.app.Sessions, sessionKey)
+		s.app.SessionsLock.Unlock()
+	}
+
+	return result, nil
+}
+
+// RestartSession disconnects a session, restores it from the database and connects it again.
+// On connection failure the restored session is returned alongside the error.
+func (s *Service) RestartSession(user string) (*app.Session, error) {
+	s.app.Logger.Printf("Restarting session for user: %s", user)
+
+	// Sandbox sessions are never disconnected and cannot be restored
+	if s.isSandbox(user) {
+		sess, _ := s.FindSessionByUser(user)
+		return sess, nil
+	}
+
+	// First disconnect existing session if it exists
+	if oldSess, exists := s.FindSessionByUser(user); exists {
+		s.app.Logger.Printf("Disconnecting existing session for user: %s", user)
+		// Safe disconnect with retry
+		if oldSess.Client.IsConnected() {
+			oldSess.Client.Disconnect()
+			// Give it a moment to properly disconnect
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		// Remove from memory to force database restoration
+		s.app.SessionsLock.Lock()
+		delete(s.app.Sessions, user)
+		s.app.SessionsLock.Unlock()
+	}
+
+	// Attempt to restore session from database
+	sess, err := s.RestoreSession(user)
+	if err != nil {
+		s.app.Logger.Printf("Failed to restore session for user %s: %v", user, err)
+		return nil, fmt.Errorf("Failed to restore session: %v", err)
+	}
+
+	s.app.Logger.Printf("Session restored from database for user: %s", user)
+
+	// Add restored session to memory
 	s.app.SessionsLock.Lock()
-	delete(s.app.Sessions, sessionKey)
+	s.app.Sessions[user] = sess
 	s.app.SessionsLock.Unlock()
 
+	// Connect the restored session with retry logic
+	err = sess.Client.Connect()
+	if err != nil {
+		// Try to handle specific error types
+		if errors.Is(err, client.ErrAlreadyConnected) {
+			s.app.Logger.Printf("Got 'already connected' error for %s, trying to disconnect and reconnect", user)
+			// Force disconnect and try again after a delay
+			sess.Client.Disconnect()
+			time.Sleep(1 * time.Second)
+			err = sess.Client.Connect()
+			if err != nil {
+				s.app.Logger.Printf("Failed to connect after retry for user %s: %v", user, err)
+				return sess, fmt.Errorf("Failed to connect after retry: %v", err)
+			}
+		} else {
+			s.app.Logger.Printf("Failed to connect for user %s: %v", user, err)
+			return sess, fmt.Errorf("Failed to connect restored session: %v", err)
+		}
+	}
+
+	s.app.Logger.Printf("Session successfully reconnected for user: %s (logged_in=%v, connected=%v)",
+		user, sess.IsLoggedIn, sess.Client.IsConnected())
+
+	return sess, nil
+}
+
+// KnownUsers returns every session user loaded in memory, sorted
+func (s *Service) KnownUsers() []string {
+	seen := make(map[string]bool)
+	for id := range s.app.GetClientManager().GetAllClients() {
+		seen[id] = true
+	}
+
+	s.app.SessionsLock.RLock()
+	for user := range s.app.Sessions {
+		seen[user] = true
+	}
+	s.app.SessionsLock.RUnlock()
+
+	users := make([]string, 0, len(seen))
+	for user := range seen {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// RestartAllSessions restarts every known session, running at most concurrency restarts at once
+func (s *Service) RestartAllSessions(concurrency int) []RestartResult {
+	users := s.KnownUsers()
+	results := make([]RestartResult, len(users))
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, user := range users {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, user string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := RestartResult{User: user}
+			sess, err := s.RestartSession(user)
+			if err != nil {
+				result.Error = err.Error()
+			}
+			if sess != nil {
+				result.Paired = sess.Client.Store.ID != nil
+				result.LoggedIn = sess.IsLoggedIn
+				result.Connected = sess.Client.IsConnected()
+			}
+			result.NeedsQR = !result.LoggedIn || !result.Connected
+			results[i] = result
+		}(i, user)
+	}
+
+	wg.Wait()
+	s.app.Logger.Printf("Restarted %d sessions with concurrency %d", len(users), concurrency)
+	return results
+}
+
+// ListSessions returns a summary of every client matching the filter, sorted by user
+func (s *Service) ListSessions(filter SessionFilter) []SessionSummary {
+	clients := s.app.GetClientManager().GetAllClients()
+
+	sessions := make([]SessionSummary, 0, len(clients))
+	for id, whatsappClient := range clients {
+		if !s.app.Metadata.Matches(id, filter.Tags) || !filter.matches(whatsappClient) {
+			continue
+		}
+		sessions = append(sessions, SessionSummary{
+			User:          id,
+			Phone:         whatsappClient.Phone(),
+			ExpectedPhone: whatsappClient.ExpectedPhone(),
+			PhoneMismatch: whatsappClient.VerifyPhone() != nil,
+			Aliases:       s.app.Aliases.AliasesFor(id),
+			Status:        whatsappClient.GetStatus().String(),
+			Paired:        whatsappClient.IsPaired(),
+			LoggedIn:      whatsappClient.IsLoggedIn(),
+			Connected:     whatsappClient.IsConnected(),
+			OpsInFlight:   whatsappClient.OpsInFlight(),
+			Metrics:       whatsappClient.Metrics(),
+			Metadata:      s.app.Metadata.Get(id),
+			Sandbox:       whatsappClient.IsSandbox(),
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].User < sessions[j].User
+	})
+
+	return sessions
+}
+
+// matches reports whether a client has one of the filter's statuses and its
+// connection state
+func (f SessionFilter) matches(whatsappClient *client.Client) bool {
+	if f.Connected != nil && whatsappClient.IsConnected() != *f.Connected {
+		return false
+	}
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	status := whatsappClient.GetStatus().String()
+	for _, wanted := range f.Statuses {
+		if wanted == status || (wanted == StatusNeedsQR && whatsappClient.NeedsQR()) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetExpectedPhone binds a session to the phone number it must be logged in as.
+// The binding is stored with the session options, so it survives restarts and
+// re-adding the session. An empty phone removes it.
+func (s *Service) SetExpectedPhone(user, phone string) error {
+	whatsappClient, exists := s.app.GetClientManager().GetClient(user)
+	if !exists {
+		return fmt.Errorf("client not found for user %s", user)
+	}
+
+	if _, err := s.app.Options.Update(user, func(options *app.SessionOptions) {
+		options.ExpectedPhone = phone
+	}); err != nil {
+		return fmt.Errorf("failed to save expected phone: %w", err)
+	}
+	if err := whatsappClient.VerifyPhone(); err != nil {
+		s.app.Logger.Printf("Warning: %v", err)
+	}
 	return nil
 }
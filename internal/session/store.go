@@ -0,0 +1,285 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// Supported session store drivers
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+)
+
+// DeviceStore holds the WhatsApp device credentials of sessions
+type DeviceStore interface {
+	// Open returns the user's device, creating an unpaired one if there is none.
+	// The container is owned by the session and closed with it; it is nil when
+	// the store is shared between sessions.
+	Open(ctx context.Context, user string, log waLog.Logger) (*sqlstore.Container, *store.Device, error)
+	// Track keeps the store up to date with the pairing of the user's client
+	Track(user string, client *whatsmeow.Client)
+	// Exists reports whether the user has stored credentials
+	Exists(user string) bool
+	// Delete removes the user's stored credentials
+	Delete(ctx context.Context, user string) error
+	// Users lists the users with stored credentials
+	Users() ([]string, error)
+	// Location describes where the user's credentials are stored, for logs
+	Location(user string) string
+}
+
+// sessionStore is the store used for all sessions, one SQLite file per session by default
+var sessionStore DeviceStore = sqliteStore{}
+
+// ConfigureStore selects the driver sessions are stored with. The sqlite3
+// driver keeps a database per session in the data directory; postgres keeps
+// every session in the single database at dsn, so several replicas can share them.
+func ConfigureStore(driver, dsn string, log waLog.Logger) error {
+	switch driver {
+	case "", DriverSQLite:
+		sessionStore = sqliteStore{}
+		return nil
+	case DriverPostgres:
+		if dsn == "" {
+			return fmt.Errorf("DB_DSN is required for the %s driver", driver)
+		}
+		pgStore, err := newPostgresStore(dsn, log)
+		if err != nil {
+			return err
+		}
+		sessionStore = pgStore
+		return nil
+	default:
+		return fmt.Errorf("unsupported session store driver %q", driver)
+	}
+}
+
+// sqliteStore keeps each session in its own SQLite database in the data directory
+type sqliteStore struct{}
+
+func (sqliteStore) Open(ctx context.Context, user string, log waLog.Logger) (*sqlstore.Container, *store.Device, error) {
+	path, err := dbPath(user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Open the database with compatibility for the old format
+	container, err := sqlstore.New(ctx, "sqlite3", "file:"+path+"?_foreign_keys=on", log)
+	if err != nil {
+		return nil, nil, fmt.Errorf("database error: %v", err)
+	}
+
+	device, err := container.GetFirstDevice(ctx)
+	if err != nil {
+		container.Close()
+		return nil, nil, fmt.Errorf("device error: %v", err)
+	}
+	return container, device, nil
+}
+
+func (sqliteStore) Track(string, *whatsmeow.Client) {}
+
+func (sqliteStore) Exists(user string) bool {
+	path, err := dbPath(user)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+func (sqliteStore) Delete(_ context.Context, user string) error {
+	path, err := dbPath(user)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (sqliteStore) Users() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(sessionDataDir, "*.db"))
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]string, 0, len(files))
+	for _, file := range files {
+		user := strings.TrimSuffix(filepath.Base(file), ".db")
+		if app.IsAppDatabase(user) {
+			continue
+		}
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return users, nil
+}
+
+func (sqliteStore) Location(user string) string {
+	path, err := dbPath(user)
+	if err != nil {
+		return user
+	}
+	return path
+}
+
+// postgresStore keeps every session in one Postgres database. whatsmeow keys
+// devices by their JID, so a table maps each session to the JID it paired as.
+type postgresStore struct {
+	db        *sql.DB
+	container *sqlstore.Container
+	log       waLog.Logger
+}
+
+func newPostgresStore(dsn string, log waLog.Logger) (*postgresStore, error) {
+	db, err := sql.Open(DriverPostgres, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+
+	ctx := context.Background()
+	container := sqlstore.NewWithDB(db, DriverPostgres, log)
+	if err := container.Upgrade(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS whatsappgo_sessions (
+		name TEXT PRIMARY KEY,
+		jid  TEXT NOT NULL DEFAULT ''
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+
+	return &postgresStore{db: db, container: container, log: log}, nil
+}
+
+// jid returns the JID the user's session paired as, or an empty JID if it has not paired
+func (p *postgresStore) jid(ctx context.Context, user string) (types.JID, bool, error) {
+	var raw string
+	err := p.db.QueryRowContext(ctx, `SELECT jid FROM whatsappgo_sessions WHERE name = $1`, user).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.EmptyJID, false, nil
+	}
+	if err != nil {
+		return types.EmptyJID, false, err
+	}
+	if raw == "" {
+		return types.EmptyJID, true, nil
+	}
+	jid, err := types.ParseJID(raw)
+	if err != nil {
+		return types.EmptyJID, true, fmt.Errorf("invalid stored JID %q: %v", raw, err)
+	}
+	return jid, true, nil
+}
+
+func (p *postgresStore) Open(ctx context.Context, user string, _ waLog.Logger) (*sqlstore.Container, *store.Device, error) {
+	if err := ValidateUser(user); err != nil {
+		return nil, nil, err
+	}
+
+	jid, _, err := p.jid(ctx, user)
+	if err != nil {
+		return nil, nil, fmt.Errorf("database error: %v", err)
+	}
+
+	if !jid.IsEmpty() {
+		device, err := p.container.GetDevice(ctx, jid)
+		if err != nil {
+			return nil, nil, fmt.Errorf("device error: %v", err)
+		}
+		if device != nil {
+			return nil, device, nil
+		}
+		// The device logged out and was deleted; pair a new one
+	}
+
+	_, err = p.db.ExecContext(ctx, `INSERT INTO whatsappgo_sessions (name) VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET jid = ''`, user)
+	if err != nil {
+		return nil, nil, fmt.Errorf("database error: %v", err)
+	}
+	return nil, p.container.NewDevice(), nil
+}
+
+func (p *postgresStore) Track(user string, client *whatsmeow.Client) {
+	client.AddEventHandler(func(evt interface{}) {
+		pair, ok := evt.(*events.PairSuccess)
+		if !ok {
+			return
+		}
+		_, err := p.db.Exec(`UPDATE whatsappgo_sessions SET jid = $2 WHERE name = $1`, user, pair.ID.String())
+		if err != nil {
+			p.log.Errorf("Failed to record device %s of session %s: %v", pair.ID, user, err)
+		}
+	})
+}
+
+func (p *postgresStore) Exists(user string) bool {
+	_, exists, err := p.jid(context.Background(), user)
+	return err == nil && exists
+}
+
+func (p *postgresStore) Delete(ctx context.Context, user string) error {
+	jid, exists, err := p.jid(ctx, user)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return os.ErrNotExist
+	}
+
+	if !jid.IsEmpty() {
+		device, err := p.container.GetDevice(ctx, jid)
+		if err != nil {
+			return err
+		}
+		if device != nil {
+			if err := p.container.DeleteDevice(ctx, device); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = p.db.ExecContext(ctx, `DELETE FROM whatsappgo_sessions WHERE name = $1`, user)
+	return err
+}
+
+func (p *postgresStore) Users() ([]string, error) {
+	rows, err := p.db.Query(`SELECT name FROM whatsappgo_sessions ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []string
+	for rows.Next() {
+		var user string
+		if err := rows.Scan(&user); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (p *postgresStore) Location(user string) string {
+	return "postgres session " + user
+}
@@ -0,0 +1,44 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// sessionDataDir is the directory holding the per-session databases
+const sessionDataDir = "data"
+
+// userPattern restricts session identifiers to a safe charset and length
+var userPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// ValidateUser checks that a session identifier is safe to use as a file name
+func ValidateUser(user string) error {
+	if user == "" {
+		return fmt.Errorf("user is required")
+	}
+	if !userPattern.MatchString(user) {
+		return fmt.Errorf("invalid user %q: must be 1-64 characters of letters, digits, '_', '-' or '.', starting with a letter or digit", user)
+	}
+	// Session databases share the data directory with the app's own databases
+	if app.IsAppDatabase(user) {
+		return fmt.Errorf("invalid user %q: reserved name", user)
+	}
+	return nil
+}
+
+// dbPath returns the database path for a session, rejecting identifiers that could
+// escape the data directory
+func dbPath(user string) (string, error) {
+	if err := ValidateUser(user); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(sessionDataDir, user+".db")
+	if filepath.Dir(path) != sessionDataDir {
+		return "", fmt.Errorf("invalid user %q", user)
+	}
+	return path, nil
+}
@@ -0,0 +1,353 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/circuit"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"github.com/neekaru/whatsappgo-bot/internal/langdetect"
+)
+
+// How long a single sink may take to publish one event
+const publishTimeout = 10 * time.Second
+
+// Events buffered per sink and event type when no size is configured
+const defaultBufferSize = 1000
+
+// How often, and for how long at most, an event is offered again to a sink
+// whose circuit breaker is open
+const (
+	breakerRetryInterval = time.Second
+	breakerRetryLimit    = time.Minute
+)
+
+// SinkStats describes the queues of one sink
+type SinkStats struct {
+	Name   string       `json:"name"`
+	Queues []QueueStats `json:"queues"`
+}
+
+// sinkWorker feeds one sink from a queue per event type, each drained by its
+// own goroutine so a backlog of one event type does not delay the others
+type sinkWorker struct {
+	sink EventSink
+
+	mu     sync.Mutex
+	queues map[string]*queue
+	wg     sync.WaitGroup
+}
+
+// Dispatcher fans session events out to every registered sink enabled for
+// the session. It is the only client observer for published events, so new
+// transports only need to implement EventSink and be added here.
+//
+// Every sink has a bounded queue per event type. What happens when a queue is
+// full is decided by the event type's Policy; message events always use the
+// block policy, so they are only dropped when a sink stays stuck. Events a
+// sink's circuit breaker rejects are retried for a while before they are
+// dropped. Queuing never blocks the client manager's workers for long.
+type Dispatcher struct {
+	app        *app.App
+	settings   *Settings
+	bufferSize int
+
+	mu       sync.RWMutex
+	policies map[string]Policy
+	workers  []*sinkWorker
+	closed   bool
+}
+
+// NewDispatcher creates a dispatcher using the given per-session settings.
+// Each sink buffers up to bufferSize events per event type.
+func NewDispatcher(app *app.App, settings *Settings, bufferSize int) *Dispatcher {
+	if bufferSize < 1 {
+		bufferSize = defaultBufferSize
+	}
+	return &Dispatcher{
+		app:        app,
+		settings:   settings,
+		bufferSize: bufferSize,
+		policies: map[string]Policy{
+			EventMessage:      PolicyBlock,
+			EventStatus:       PolicyDropOldest,
+			EventAppState:     PolicyDropOldest,
+			EventPaired:       PolicyBlock,
+			EventPairFailed:   PolicyBlock,
+			EventBanned:       PolicyBlock,
+			EventConversation: PolicyBlock,
+			EventQR:           PolicyDropOldest,
+		},
+	}
+}
+
+// SetPolicy sets the backpressure policy of an event type. It must be called
+// before events are dispatched. Message events must use the block policy.
+func (d *Dispatcher) SetPolicy(event string, policy Policy) error {
+	if event == EventMessage && policy.Drops() {
+		return fmt.Errorf("%s events wait for room before they are dropped, only the %s policy is allowed", EventMessage, PolicyBlock)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.policies[event] = policy
+	return nil
+}
+
+// Add registers a sink
+func (d *Dispatcher) Add(sink EventSink) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.workers = append(d.workers, &sinkWorker{
+		sink:   sink,
+		queues: make(map[string]*queue),
+	})
+	d.app.Logger.Printf("Registered %s event sink", sink.Name())
+}
+
+// Start registers the dispatcher for the client events that are published
+func (d *Dispatcher) Start() {
+	manager := d.app.GetClientManager()
+	manager.RegisterObserver(client.EventTypeStatus, client.ObserverFunc(d.OnEvent))
+	manager.RegisterObserver(client.EventTypeRaw, client.ObserverFunc(d.OnEvent))
+	manager.RegisterObserver(client.EventTypeAppState, client.ObserverFunc(d.OnEvent))
+	manager.RegisterObserver(client.EventTypePaired, client.ObserverFunc(d.OnEvent))
+	manager.RegisterObserver(client.EventTypePairFailed, client.ObserverFunc(d.OnEvent))
+	manager.RegisterObserver(client.EventTypeBanned, client.ObserverFunc(d.OnEvent))
+	manager.RegisterObserver(client.EventTypeConversation, client.ObserverFunc(d.OnEvent))
+	manager.RegisterObserver(client.EventTypeQR, client.ObserverFunc(d.OnEvent))
+}
+
+// Names returns the names of the registered sinks
+func (d *Dispatcher) Names() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	names := make([]string, 0, len(d.workers))
+	for _, worker := range d.workers {
+		names = append(names, worker.sink.Name())
+	}
+	return names
+}
+
+// Has reports whether a sink with the given name is registered
+func (d *Dispatcher) Has(name string) bool {
+	for _, registered := range d.Names() {
+		if registered == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Settings returns the per-session sink settings
+func (d *Dispatcher) Settings() *Settings {
+	return d.settings
+}
+
+// Stats returns the queue stats of every registered sink
+func (d *Dispatcher) Stats() []SinkStats {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	stats := make([]SinkStats, 0, len(d.workers))
+	for _, worker := range d.workers {
+		worker.mu.Lock()
+		queues := make([]QueueStats, 0, len(worker.queues))
+		for _, q := range worker.queues {
+			queues = append(queues, q.stats())
+		}
+		worker.mu.Unlock()
+		stats = append(stats, SinkStats{Name: worker.sink.Name(), Queues: queues})
+	}
+	return stats
+}
+
+// OnEvent queues an event for every sink enabled for its session
+func (d *Dispatcher) OnEvent(event client.Event) {
+	evt, ok := EventFor(event)
+	if !ok || hiddenOwnMessage(d.app, evt) {
+		return
+	}
+	evt.ReceivedAt = event.GetReceivedAt()
+	if d.duplicate(evt) {
+		return
+	}
+	detectLanguage(d.app, &evt)
+
+	// Pushing may wait briefly on a full queue, so it happens after the lock
+	// is released; Close ends the wait by closing the queues
+	for _, target := range d.targets(evt) {
+		if !target.queue.push(evt) {
+			d.app.Logger.Printf("Warning: dropped %s event for user %s, %s sink queue is full", evt.Name, evt.User, target.sink.Name())
+		}
+	}
+}
+
+// target is the queue of a sink an event goes to
+type target struct {
+	sink  EventSink
+	queue *queue
+}
+
+// targets returns the queues of every sink enabled for an event's session,
+// or none once the dispatcher is closed
+func (d *Dispatcher) targets(evt Event) []target {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return nil
+	}
+
+	targets := make([]target, 0, len(d.workers))
+	for _, worker := range d.workers {
+		if !d.settings.Enabled(evt.User, worker.sink.Name()) {
+			continue
+		}
+		// QR codes can link a device to the account, keep them off external systems
+		if evt.Name == EventQR && !streamsToClients(worker.sink) {
+			continue
+		}
+		targets = append(targets, target{sink: worker.sink, queue: d.queueLocked(worker, evt.Name)})
+	}
+	return targets
+}
+
+// detectLanguage tags a message event with the language of its text when the
+// session has detect_language enabled
+func detectLanguage(application *app.App, evt *Event) {
+	payload, ok := evt.Payload.(MessagePayload)
+	if !ok || payload.Text == "" || !application.Options.Get(evt.User).DetectLanguage {
+		return
+	}
+	payload.Language = langdetect.Detect(payload.Text)
+	evt.Payload = payload
+}
+
+// hiddenOwnMessage reports whether an event is a message the account sent from
+// another device, which sessions only publish with the own_messages option
+func hiddenOwnMessage(application *app.App, evt Event) bool {
+	payload, ok := evt.Payload.(MessagePayload)
+	return ok && payload.FromMe && !application.Options.Get(evt.User).OwnMessages
+}
+
+// duplicate reports whether a message event was already delivered, possibly
+// before a restart
+func (d *Dispatcher) duplicate(evt Event) bool {
+	msg, ok := evt.Payload.(MessagePayload)
+	if !ok {
+		return false
+	}
+	seen, err := d.app.Received.MarkSeen(msg.User, msg.Chat, msg.ID)
+	if err != nil {
+		d.app.Logger.Printf("Warning: %v", err)
+	}
+	return seen
+}
+
+// streamsToClients reports whether a sink streams events to API clients
+// connected to this server, rather than to an external system
+func streamsToClients(sink EventSink) bool {
+	switch sink.(type) {
+	case *WebSocketSink, *SSESink:
+		return true
+	default:
+		return false
+	}
+}
+
+// queueLocked returns the worker's queue for an event type, starting it on
+// first use. The caller must hold d.mu.
+func (d *Dispatcher) queueLocked(worker *sinkWorker, event string) *queue {
+	worker.mu.Lock()
+	defer worker.mu.Unlock()
+
+	if q, ok := worker.queues[event]; ok {
+		return q
+	}
+
+	policy, ok := d.policies[event]
+	if !ok {
+		policy = PolicyBlock
+	}
+	q := newQueue(event, policy, d.bufferSize)
+	worker.queues[event] = q
+
+	worker.wg.Add(1)
+	go func() {
+		defer worker.wg.Done()
+		for {
+			select {
+			case evt := <-q.events:
+				d.publish(worker.sink, evt, q.done)
+			case <-q.done:
+				// Publish what is still buffered before the sink is closed
+				for {
+					select {
+					case evt := <-q.events:
+						d.publish(worker.sink, evt, q.done)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+	return q
+}
+
+// publish delivers one event to a sink, logging failures. While the sink's
+// circuit breaker is open the event is offered again, for up to
+// breakerRetryLimit or until done is closed, so a breaker that opens briefly
+// loses nothing but a dead sink does not hold its queue forever.
+func (d *Dispatcher) publish(sink EventSink, event Event, done <-chan struct{}) {
+	giveUp := time.Now().Add(breakerRetryLimit)
+	for {
+		err := d.publishOnce(sink, event)
+		if err == nil {
+			observeDispatchLatency(event, sink.Name())
+			return
+		}
+		if errors.Is(err, circuit.ErrOpen) && time.Now().Before(giveUp) {
+			select {
+			case <-time.After(breakerRetryInterval):
+				continue
+			case <-done:
+			}
+		}
+		d.app.Logger.Printf("Failed to publish %s event for user %s to %s sink: %v", event.Name, event.User, sink.Name(), err)
+		return
+	}
+}
+
+// publishOnce makes a single attempt to deliver an event to a sink
+func (d *Dispatcher) publishOnce(sink EventSink, event Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	return sink.Publish(ctx, event)
+}
+
+// Close stops accepting events, waits for the queued ones to be published
+// and closes every registered sink
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	d.closed = true
+	workers := d.workers
+	d.mu.Unlock()
+
+	for _, worker := range workers {
+		worker.mu.Lock()
+		for _, q := range worker.queues {
+			q.close()
+		}
+		worker.mu.Unlock()
+		worker.wg.Wait()
+
+		if err := worker.sink.Close(); err != nil {
+			d.app.Logger.Printf("Failed to close %s event sink: %v", worker.sink.Name(), err)
+		}
+	}
+}
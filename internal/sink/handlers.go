@@ -0,0 +1,163 @@
+package sink
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetSinksRequest is the body of POST /wa/sinks
+type SetSinksRequest struct {
+	User  string   `json:"user"`
+	Sinks []string `json:"sinks"`
+}
+
+// SessionSinksResponse describes where a session's events are published
+type SessionSinksResponse struct {
+	User   string   `json:"user"`
+	Sinks  []string `json:"sinks"`
+	Custom bool     `json:"custom"` // False when the session uses every sink by default
+}
+
+// Handlers contains HTTP handlers for event sinks
+type Handlers struct {
+	dispatcher *Dispatcher
+	websocket  *WebSocketSink
+	sse        *SSESink
+	socket     *SocketServer
+}
+
+// NewHandlers creates a new sink handlers instance
+func NewHandlers(dispatcher *Dispatcher, websocket *WebSocketSink, sse *SSESink, socket *SocketServer) *Handlers {
+	return &Handlers{
+		dispatcher: dispatcher,
+		websocket:  websocket,
+		sse:        sse,
+		socket:     socket,
+	}
+}
+
+// ListHandler handles GET /sinks - lists the registered sinks and their queues
+func (h *Handlers) ListHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"sinks": h.dispatcher.Stats()})
+}
+
+// GetSessionHandler handles GET /wa/sinks - shows the sinks of a session
+func (h *Handlers) GetSessionHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.sessionSinks(user))
+}
+
+// SetSessionHandler handles POST /wa/sinks - selects the sinks of a session
+func (h *Handlers) SetSessionHandler(c *gin.Context) {
+	var req SetSinksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if req.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+	if req.Sinks == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing sinks"})
+		return
+	}
+	for _, name := range req.Sinks {
+		if !h.dispatcher.Has(name) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown sink %q", name)})
+			return
+		}
+	}
+
+	if err := h.dispatcher.Settings().Set(req.User, req.Sinks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.sessionSinks(req.User))
+}
+
+// ResetSessionHandler handles DELETE /wa/sinks - restores the default of publishing to every sink
+func (h *Handlers) ResetSessionHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	if err := h.dispatcher.Settings().Delete(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.sessionSinks(user))
+}
+
+// StreamHandler handles GET /ws/events - streams a session's events over WebSocket
+func (h *Handlers) StreamHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	// The upgrader writes its own error response on a failed handshake
+	_ = h.websocket.Serve(c.Writer, c.Request, user)
+}
+
+// SocketHandler handles GET /ws - streams a session's events over WebSocket,
+// filtered by the comma-separated "events" query parameter
+func (h *Handlers) SocketHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	var names []string
+	if raw := c.Query("events"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	events, err := ParseSocketEvents(names)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The upgrader writes its own error response on a failed handshake
+	_ = h.socket.Serve(c.Writer, c.Request, user, events)
+}
+
+// EventsHandler handles GET /wa/events - streams a session's events as Server-Sent Events
+func (h *Handlers) EventsHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	// Once the stream has started the error can only be logged by gin
+	if err := h.sse.Serve(c.Writer, c.Request, user); err != nil && !c.Writer.Written() {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// sessionSinks returns the effective sinks of a session
+func (h *Handlers) sessionSinks(user string) SessionSinksResponse {
+	if sinks, ok := h.dispatcher.Settings().Get(user); ok {
+		return SessionSinksResponse{User: user, Sinks: sinks, Custom: true}
+	}
+	return SessionSinksResponse{User: user, Sinks: h.dispatcher.Names()}
+}
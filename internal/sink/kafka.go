@@ -0,0 +1,66 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig configures the Kafka sink
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+}
+
+// KafkaSink writes session events to a Kafka topic. Records are keyed by the
+// event key, so messages of one chat land on the same partition in order.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink creates a Kafka writer for the topic
+func NewKafkaSink(cfg KafkaConfig) (*KafkaSink, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers configured")
+	}
+
+	return &KafkaSink{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.Topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireOne,
+			// Publish waits for its write, so do not hold records for a fuller batch
+			BatchTimeout: 10 * time.Millisecond,
+		},
+	}, nil
+}
+
+// Name returns the sink name
+func (s *KafkaSink) Name() string {
+	return "kafka"
+}
+
+// Publish writes the event as one record
+func (s *KafkaSink) Publish(ctx context.Context, event Event) error {
+	body, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	return s.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.Key),
+		Value: body,
+		Headers: []kafka.Header{
+			{Key: "event", Value: []byte(event.Name)},
+			{Key: "user", Value: []byte(event.User)},
+		},
+	})
+}
+
+// Close flushes pending records and closes the writer
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,100 @@
+package sink
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// dispatchLatencyBuckets are the upper bounds, in seconds, of the dispatch
+// latency histogram buckets
+var dispatchLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// LatencyStats is a snapshot of the dispatch latency histogram of one event
+// type delivered to one sink
+type LatencyStats struct {
+	Event   string
+	Sink    string
+	Buckets []float64 // Upper bounds in seconds
+	Counts  []uint64  // Cumulative count of deliveries per bucket
+	Count   uint64
+	Sum     float64 // Seconds
+}
+
+// latencyKey identifies the histogram of an event type and sink
+type latencyKey struct {
+	event string
+	sink  string
+}
+
+// latencyHistogram counts delivery latencies into buckets
+type latencyHistogram struct {
+	counts []uint64 // Per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+var (
+	latencyLock       sync.Mutex
+	latencyHistograms = make(map[latencyKey]*latencyHistogram)
+)
+
+// observeDispatchLatency records how long an event took from being received
+// from WhatsApp to being delivered to a sink. Events without a receipt time
+// are not recorded.
+func observeDispatchLatency(event Event, sink string) {
+	if event.ReceivedAt.IsZero() {
+		return
+	}
+	seconds := time.Since(event.ReceivedAt).Seconds()
+
+	latencyLock.Lock()
+	defer latencyLock.Unlock()
+
+	key := latencyKey{event: event.Name, sink: sink}
+	histogram, ok := latencyHistograms[key]
+	if !ok {
+		histogram = &latencyHistogram{counts: make([]uint64, len(dispatchLatencyBuckets))}
+		latencyHistograms[key] = histogram
+	}
+	for i, bound := range dispatchLatencyBuckets {
+		if seconds <= bound {
+			histogram.counts[i]++
+			break
+		}
+	}
+	histogram.count++
+	histogram.sum += seconds
+}
+
+// DispatchLatency returns the dispatch latency histograms of every event type
+// and sink delivered to so far, ordered by event then sink
+func DispatchLatency() []LatencyStats {
+	latencyLock.Lock()
+	defer latencyLock.Unlock()
+
+	stats := make([]LatencyStats, 0, len(latencyHistograms))
+	for key, histogram := range latencyHistograms {
+		counts := make([]uint64, len(histogram.counts))
+		var cumulative uint64
+		for i, count := range histogram.counts {
+			cumulative += count
+			counts[i] = cumulative
+		}
+		stats = append(stats, LatencyStats{
+			Event:   key.event,
+			Sink:    key.sink,
+			Buckets: dispatchLatencyBuckets,
+			Counts:  counts,
+			Count:   histogram.count,
+			Sum:     histogram.sum,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Event != stats[j].Event {
+			return stats[i].Event < stats[j].Event
+		}
+		return stats[i].Sink < stats[j].Sink
+	})
+	return stats
+}
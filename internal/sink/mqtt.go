@@ -0,0 +1,94 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// MQTTConfig configures the MQTT sink
+type MQTTConfig struct {
+	BrokerURL string
+	ClientID  string
+	Username  string
+	Password  string
+	QoS       byte
+}
+
+// MQTTSink publishes session events to an MQTT broker on topics of the form
+// wa/{user}/{event}
+type MQTTSink struct {
+	client mqtt.Client
+	qos    byte
+}
+
+// NewMQTTSink connects to the broker. The client reconnects on its own if the
+// connection drops later.
+func NewMQTTSink(app *app.App, cfg MQTTConfig) (*MQTTSink, error) {
+	if cfg.QoS > 2 {
+		return nil, fmt.Errorf("invalid MQTT QoS %d, must be 0, 1 or 2", cfg.QoS)
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.BrokerURL).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			app.Logger.Printf("Warning: MQTT connection lost: %v", err)
+		})
+
+	mqttClient := mqtt.NewClient(opts)
+	token := mqttClient.Connect()
+	if !token.WaitTimeout(10 * time.Second) {
+		app.Logger.Printf("Warning: MQTT broker %s not reachable yet, retrying in the background", cfg.BrokerURL)
+	} else if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %v", err)
+	}
+
+	return &MQTTSink{
+		client: mqttClient,
+		qos:    cfg.QoS,
+	}, nil
+}
+
+// Name returns the sink name
+func (s *MQTTSink) Name() string {
+	return "mqtt"
+}
+
+// Publish publishes the event payload on the event's topic
+func (s *MQTTSink) Publish(ctx context.Context, event Event) error {
+	body, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	topic := mqttTopic(event.User, event.Name)
+	token := s.client.Publish(topic, s.qos, false, body)
+	select {
+	case <-token.Done():
+	case <-ctx.Done():
+		return fmt.Errorf("publish to topic %s timed out", topic)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("publish to topic %s: %v", topic, err)
+	}
+	return nil
+}
+
+// Close disconnects from the broker, waiting briefly for in-flight publishes
+func (s *MQTTSink) Close() error {
+	s.client.Disconnect(250)
+	return nil
+}
+
+// mqttTopic returns the MQTT topic of an event for a user
+func mqttTopic(user, event string) string {
+	return fmt.Sprintf("wa/%s/%s", user, event)
+}
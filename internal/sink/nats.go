@@ -0,0 +1,63 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// NATSSink publishes session events to NATS on subjects of the form
+// wa.{user}.{event}
+type NATSSink struct {
+	conn *nats.Conn
+}
+
+// NewNATSSink connects to the NATS server. The connection reconnects on its
+// own if it drops later.
+func NewNATSSink(app *app.App, url string) (*NATSSink, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("whatsappgo-bot"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				app.Logger.Printf("Warning: NATS connection lost: %v", err)
+			}
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %v", err)
+	}
+
+	return &NATSSink{conn: conn}, nil
+}
+
+// Name returns the sink name
+func (s *NATSSink) Name() string {
+	return "nats"
+}
+
+// Publish publishes the event payload on the event's subject
+func (s *NATSSink) Publish(ctx context.Context, event Event) error {
+	body, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	subject := natsSubject(event.User, event.Name)
+	if err := s.conn.Publish(subject, body); err != nil {
+		return fmt.Errorf("publish to subject %s: %v", subject, err)
+	}
+	return nil
+}
+
+// Close flushes buffered messages and closes the connection
+func (s *NATSSink) Close() error {
+	return s.conn.Drain()
+}
+
+// natsSubject returns the NATS subject of an event for a user
+func natsSubject(user, event string) string {
+	return fmt.Sprintf("wa.%s.%s", user, event)
+}
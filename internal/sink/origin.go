@@ -0,0 +1,44 @@
+package sink
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Origins decides which web pages may read session events from the
+// browser-facing streams. The CORS middleware does not cover WebSocket
+// upgrades, so the streams check the Origin header themselves.
+type Origins struct {
+	any     bool
+	allowed map[string]struct{}
+}
+
+// NewOrigins allows the given origins, such as "https://app.example.com",
+// besides the server's own. "*" allows every origin.
+func NewOrigins(origins []string) *Origins {
+	o := &Origins{allowed: make(map[string]struct{})}
+	for _, origin := range origins {
+		if origin == "*" {
+			o.any = true
+			continue
+		}
+		o.allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = struct{}{}
+	}
+	return o
+}
+
+// Allowed reports whether a request may read events. Requests without an
+// Origin header come from clients other than browsers and are allowed, as are
+// pages served from the same host.
+func (o *Origins) Allowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || o.any {
+		return true
+	}
+	if _, ok := o.allowed[strings.ToLower(origin)]; ok {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
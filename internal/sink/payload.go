@@ -0,0 +1,268 @@
+package sink
+
+import (
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Event names used in published payloads and topics
+const (
+	EventMessage      = "message"
+	EventStatus       = "status"
+	EventAppState     = "appstate"
+	EventPaired       = "paired"
+	EventPairFailed   = "pair_failed"
+	EventBanned       = "banned"
+	EventConversation = "conversation"
+	EventQR           = "qr"
+)
+
+// MessagePayload describes an incoming message
+type MessagePayload struct {
+	Event     string    `json:"event"`
+	User      string    `json:"user"`
+	ID        string    `json:"id"`
+	Chat      string    `json:"chat"`   // Without device suffix
+	Sender    string    `json:"sender"` // Without device suffix
+	PushName  string    `json:"push_name,omitempty"`
+	IsGroup   bool      `json:"is_group"`
+	FromMe    bool      `json:"from_me"` // Sent by the account from its phone or another linked device
+	Type      string    `json:"type"`
+	MediaType string    `json:"media_type,omitempty"`
+	Text      string    `json:"text,omitempty"`
+	Language  string    `json:"language,omitempty"` // ISO 639-1 code, with the session's detect_language option
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// StatusPayload describes a session status change
+type StatusPayload struct {
+	Event     string    `json:"event"`
+	User      string    `json:"user"`
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AppStatePayload describes the progress of an app state sync
+type AppStatePayload struct {
+	Event     string    `json:"event"`
+	User      string    `json:"user"`
+	Patch     string    `json:"patch"`
+	State     string    `json:"state"`
+	FullSync  bool      `json:"full_sync"`
+	Version   uint64    `json:"version,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PairedPayload describes an account that finished pairing with a session
+type PairedPayload struct {
+	Event        string    `json:"event"`
+	User         string    `json:"user"`
+	JID          string    `json:"jid"`
+	LID          string    `json:"lid,omitempty"`
+	Phone        string    `json:"phone"`
+	PushName     string    `json:"push_name,omitempty"`
+	BusinessName string    `json:"business_name,omitempty"`
+	Platform     string    `json:"platform"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// PairFailedPayload describes a pairing the phone accepted but that could not
+// be completed, leaving the session unpaired
+type PairFailedPayload struct {
+	Event        string    `json:"event"`
+	User         string    `json:"user"`
+	JID          string    `json:"jid"`
+	LID          string    `json:"lid,omitempty"`
+	BusinessName string    `json:"business_name,omitempty"`
+	Platform     string    `json:"platform"`
+	Error        string    `json:"error"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// BannedPayload describes a temporary ban of a session's account
+type BannedPayload struct {
+	Event     string     `json:"event"`
+	User      string     `json:"user"`
+	Code      int        `json:"code"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// ConversationPayload describes a change of a chat's handoff state
+type ConversationPayload struct {
+	Event         string    `json:"event"`
+	User          string    `json:"user"`
+	Chat          string    `json:"chat"`
+	State         string    `json:"state"`
+	PreviousState string    `json:"previous_state"`
+	Agent         string    `json:"agent,omitempty"`
+	PreviousAgent string    `json:"previous_agent,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// QRPayload carries the QR codes to scan for pairing a session. Each code
+// should be shown in turn: the first for 60 seconds, the others for 20.
+type QRPayload struct {
+	Event     string    `json:"event"`
+	User      string    `json:"user"`
+	Codes     []string  `json:"codes"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewMessagePayload builds the payload of an incoming message, or of an own
+// message sent from another device
+func NewMessagePayload(user string, msg *events.Message) MessagePayload {
+	return MessagePayload{
+		Event:     EventMessage,
+		User:      user,
+		ID:        msg.Info.ID,
+		Chat:      msg.Info.Chat.ToNonAD().String(),
+		Sender:    msg.Info.Sender.ToNonAD().String(),
+		PushName:  msg.Info.PushName,
+		IsGroup:   msg.Info.IsGroup,
+		FromMe:    msg.Info.IsFromMe,
+		Type:      msg.Info.Type,
+		MediaType: msg.Info.MediaType,
+		Text:      messageText(msg),
+		Timestamp: msg.Info.Timestamp,
+	}
+}
+
+// EventFor converts a client event into the event published to sinks. It
+// returns false for events that are not published. Own messages are converted
+// with from_me set; sessions only publish them with the own_messages option.
+func EventFor(event client.Event) (Event, bool) {
+	switch evt := event.(type) {
+	case *client.StatusEvent:
+		return Event{
+			Name: EventStatus,
+			User: evt.GetClientID(),
+			Key:  evt.GetClientID(),
+			Payload: StatusPayload{
+				Event:     EventStatus,
+				User:      evt.GetClientID(),
+				Status:    evt.Status.String(),
+				Timestamp: time.Now(),
+			},
+		}, true
+	case *client.AppStateEvent:
+		return Event{
+			Name: EventAppState,
+			User: evt.GetClientID(),
+			Key:  evt.GetClientID(),
+			Payload: AppStatePayload{
+				Event:     EventAppState,
+				User:      evt.GetClientID(),
+				Patch:     evt.Patch,
+				State:     evt.State,
+				FullSync:  evt.FullSync,
+				Version:   evt.Version,
+				Error:     evt.Error,
+				Timestamp: time.Now(),
+			},
+		}, true
+	case *client.PairedEvent:
+		return Event{
+			Name: EventPaired,
+			User: evt.GetClientID(),
+			Key:  evt.GetClientID(),
+			Payload: PairedPayload{
+				Event:        EventPaired,
+				User:         evt.GetClientID(),
+				JID:          evt.JID,
+				LID:          evt.LID,
+				Phone:        evt.Phone,
+				PushName:     evt.PushName,
+				BusinessName: evt.BusinessName,
+				Platform:     evt.Platform,
+				Timestamp:    evt.PairedAt,
+			},
+		}, true
+	case *client.PairFailedEvent:
+		return Event{
+			Name: EventPairFailed,
+			User: evt.GetClientID(),
+			Key:  evt.GetClientID(),
+			Payload: PairFailedPayload{
+				Event:        EventPairFailed,
+				User:         evt.GetClientID(),
+				JID:          evt.JID,
+				LID:          evt.LID,
+				BusinessName: evt.BusinessName,
+				Platform:     evt.Platform,
+				Error:        evt.Error,
+				Timestamp:    evt.FailedAt,
+			},
+		}, true
+	case *client.BannedEvent:
+		return Event{
+			Name: EventBanned,
+			User: evt.GetClientID(),
+			Key:  evt.GetClientID(),
+			Payload: BannedPayload{
+				Event:     EventBanned,
+				User:      evt.GetClientID(),
+				Code:      evt.Ban.Code,
+				Reason:    evt.Ban.Reason,
+				ExpiresAt: evt.Ban.ExpiresAt,
+				Timestamp: evt.Ban.BannedAt,
+			},
+		}, true
+	case *client.ConversationEvent:
+		return Event{
+			Name: EventConversation,
+			User: evt.GetClientID(),
+			Key:  evt.Chat,
+			Payload: ConversationPayload{
+				Event:         EventConversation,
+				User:          evt.GetClientID(),
+				Chat:          evt.Chat,
+				State:         evt.State,
+				PreviousState: evt.PreviousState,
+				Agent:         evt.Agent,
+				PreviousAgent: evt.PreviousAgent,
+				Timestamp:     evt.ChangedAt,
+			},
+		}, true
+	case *client.QREvent:
+		return Event{
+			Name: EventQR,
+			User: evt.GetClientID(),
+			Key:  evt.GetClientID(),
+			Payload: QRPayload{
+				Event:     EventQR,
+				User:      evt.GetClientID(),
+				Codes:     evt.QREvent.Codes,
+				Timestamp: time.Now(),
+			},
+		}, true
+	case *client.RawEvent:
+		msg, ok := evt.GetData().(*events.Message)
+		if !ok {
+			return Event{}, false
+		}
+		return Event{
+			Name:    EventMessage,
+			User:    evt.GetClientID(),
+			Key:     msg.Info.Chat.ToNonAD().String(),
+			Payload: NewMessagePayload(evt.GetClientID(), msg),
+		}, true
+	default:
+		return Event{}, false
+	}
+}
+
+// messageText returns the text of a plain or extended text message
+func messageText(msg *events.Message) string {
+	if msg.Message == nil {
+		return ""
+	}
+	if text := msg.Message.GetConversation(); text != "" {
+		return text
+	}
+	return msg.Message.GetExtendedTextMessage().GetText()
+}
@@ -0,0 +1,96 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"cloud.google.com/go/pubsub/v2"
+)
+
+// PubSubConfig configures the Google Cloud Pub/Sub sink
+type PubSubConfig struct {
+	ProjectID   string
+	TopicPrefix string
+}
+
+// PubSubSink publishes session events to Google Cloud Pub/Sub, one topic per
+// event type ({prefix}{event}). Events are ordered by their key: chat JID for
+// messages, session user for status changes. Credentials come from
+// Application Default Credentials (GOOGLE_APPLICATION_CREDENTIALS or the
+// metadata server).
+type PubSubSink struct {
+	client      *pubsub.Client
+	topicPrefix string
+
+	mu         sync.Mutex
+	publishers map[string]*pubsub.Publisher
+}
+
+// NewPubSubSink creates a Pub/Sub client for the project
+func NewPubSubSink(cfg PubSubConfig) (*PubSubSink, error) {
+	pubsubClient, err := pubsub.NewClient(context.Background(), cfg.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pub/Sub client: %v", err)
+	}
+
+	return &PubSubSink{
+		client:      pubsubClient,
+		topicPrefix: cfg.TopicPrefix,
+		publishers:  make(map[string]*pubsub.Publisher),
+	}, nil
+}
+
+// Name returns the sink name
+func (s *PubSubSink) Name() string {
+	return "pubsub"
+}
+
+// Publish publishes the event to its type's topic and waits for the result
+func (s *PubSubSink) Publish(ctx context.Context, event Event) error {
+	body, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	publisher := s.publisher(event.Name)
+	result := publisher.Publish(ctx, &pubsub.Message{
+		Data:        body,
+		OrderingKey: event.Key,
+		Attributes: map[string]string{
+			"event": event.Name,
+			"user":  event.User,
+		},
+	})
+	if _, err := result.Get(ctx); err != nil {
+		// A failed ordered publish pauses its key until resumed
+		publisher.ResumePublish(event.Key)
+		return fmt.Errorf("publish to topic %s: %v", publisher.ID(), err)
+	}
+	return nil
+}
+
+// publisher returns the ordered publisher of an event type's topic
+func (s *PubSubSink) publisher(event string) *pubsub.Publisher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	publisher, ok := s.publishers[event]
+	if !ok {
+		publisher = s.client.Publisher(s.topicPrefix + event)
+		publisher.EnableMessageOrdering = true
+		s.publishers[event] = publisher
+	}
+	return publisher
+}
+
+// Close flushes pending messages and closes the client
+func (s *PubSubSink) Close() error {
+	s.mu.Lock()
+	for _, publisher := range s.publishers {
+		publisher.Stop()
+	}
+	s.mu.Unlock()
+
+	return s.client.Close()
+}
@@ -0,0 +1,151 @@
+package sink
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// How long a push to a full queue with the block policy waits for room before
+// the event is dropped. Pushes run on the client manager's shared workers, so
+// the wait must stay short.
+const blockTimeout = 2 * time.Second
+
+// Policy decides what happens to a new event when a sink's queue is full
+type Policy string
+
+const (
+	// PolicyBlock waits a little for room, slowing down event dispatch, and
+	// drops the event when the queue stays full
+	PolicyBlock Policy = "block"
+	// PolicyDropNewest keeps the buffered events and discards the new one
+	PolicyDropNewest Policy = "drop_newest"
+	// PolicyDropOldest discards the oldest buffered event to make room
+	PolicyDropOldest Policy = "drop_oldest"
+)
+
+// ParsePolicy parses a backpressure policy name
+func ParsePolicy(name string) (Policy, error) {
+	switch policy := Policy(name); policy {
+	case PolicyBlock, PolicyDropNewest, PolicyDropOldest:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid backpressure policy %q, must be one of block, drop_newest, drop_oldest", name)
+	}
+}
+
+// Drops reports whether the policy discards events without waiting for room
+func (p Policy) Drops() bool {
+	return p != PolicyBlock
+}
+
+// QueueStats describes the queue of one event type for one sink
+type QueueStats struct {
+	Event    string `json:"event"`
+	Policy   Policy `json:"policy"`
+	Size     int    `json:"size"`
+	Capacity int    `json:"capacity"`
+	Dropped  uint64 `json:"dropped"`
+}
+
+// queue buffers the events of one type for one sink in front of its worker
+type queue struct {
+	event  string
+	policy Policy
+	events chan Event
+	done   chan struct{} // Closed on shutdown, releasing blocked producers and the worker
+
+	// Serialises drop_oldest producers so the slot they free is theirs
+	mu      sync.Mutex
+	dropped atomic.Uint64
+
+	// Set when a blocking push timed out, so the following pushes drop right
+	// away instead of each waiting until the queue has room again
+	stalled atomic.Bool
+}
+
+// newQueue creates a queue holding up to capacity events
+func newQueue(event string, policy Policy, capacity int) *queue {
+	return &queue{
+		event:  event,
+		policy: policy,
+		events: make(chan Event, capacity),
+		done:   make(chan struct{}),
+	}
+}
+
+// push enqueues an event according to the queue's policy. It returns false
+// when the new event itself was dropped for lack of room. Events pushed after
+// the queue was closed are discarded with the rest of the shutdown.
+func (q *queue) push(event Event) bool {
+	switch q.policy {
+	case PolicyDropNewest:
+		select {
+		case q.events <- event:
+			return true
+		default:
+			q.dropped.Add(1)
+			return false
+		}
+
+	case PolicyDropOldest:
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for {
+			select {
+			case q.events <- event:
+				return true
+			default:
+			}
+			select {
+			case <-q.events:
+				q.dropped.Add(1)
+			default:
+				// The worker emptied a slot meanwhile; try again
+			}
+		}
+
+	default:
+		select {
+		case q.events <- event:
+			q.stalled.Store(false)
+			return true
+		default:
+		}
+		if q.stalled.Load() {
+			q.dropped.Add(1)
+			return false
+		}
+
+		timer := time.NewTimer(blockTimeout)
+		defer timer.Stop()
+		select {
+		case q.events <- event:
+			return true
+		case <-q.done:
+			return true
+		case <-timer.C:
+			q.stalled.Store(true)
+			q.dropped.Add(1)
+			return false
+		}
+	}
+}
+
+// close stops the queue. The events still buffered are left for the worker
+// to drain.
+func (q *queue) close() {
+	close(q.done)
+}
+
+// stats returns the queue's current stats
+func (q *queue) stats() QueueStats {
+	return QueueStats{
+		Event:    q.event,
+		Policy:   q.policy,
+		Size:     len(q.events),
+		Capacity: cap(q.events),
+		Dropped:  q.dropped.Load(),
+	}
+}
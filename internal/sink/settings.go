@@ -0,0 +1,100 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Settings stores which sinks each session publishes to, persisted as a JSON
+// file. Sessions without an entry publish to every sink.
+type Settings struct {
+	mu    sync.RWMutex
+	path  string
+	sinks map[string][]string
+}
+
+// NewSettings creates Settings backed by the given file, loading existing entries.
+func NewSettings(path string) (*Settings, error) {
+	s := &Settings{
+		path:  path,
+		sinks: make(map[string][]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read sink settings: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &s.sinks); err != nil {
+		return s, fmt.Errorf("failed to parse sink settings: %v", err)
+	}
+
+	return s, nil
+}
+
+// Get returns the sinks configured for a user, and whether the user has an entry
+func (s *Settings) Get(user string) ([]string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sinks, ok := s.sinks[user]
+	return append([]string(nil), sinks...), ok
+}
+
+// Enabled reports whether a user's events go to the named sink
+func (s *Settings) Enabled(user, sink string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sinks, ok := s.sinks[user]
+	if !ok {
+		return true
+	}
+	for _, name := range sinks {
+		if name == sink {
+			return true
+		}
+	}
+	return false
+}
+
+// Set replaces the sinks of a user. An empty list disables publishing for the user.
+func (s *Settings) Set(user string, sinks []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sinks[user] = append([]string{}, sinks...)
+	return s.saveLocked()
+}
+
+// Delete removes a user's entry so it publishes to every sink again
+func (s *Settings) Delete(user string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sinks, user)
+	return s.saveLocked()
+}
+
+// saveLocked writes the settings to disk; the caller must hold the write lock.
+func (s *Settings) saveLocked() error {
+	data, err := json.MarshalIndent(s.sinks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sink settings: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create sink settings directory: %v", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write sink settings: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to save sink settings: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Event is a session event delivered to sinks
+type Event struct {
+	Name    string      // Event name, e.g. message or status
+	User    string      // Session user the event belongs to
+	Key     string      // Ordering key: chat JID for messages and conversations, session user otherwise
+	Payload interface{} // JSON-encodable body
+
+	ReceivedAt time.Time // When the client event was received, for latency metrics; not published
+}
+
+// EventSink is a transport that session events are fanned out to
+type EventSink interface {
+	// Name identifies the sink in per-session configuration
+	Name() string
+	// Publish delivers one event, giving up when ctx is done
+	Publish(ctx context.Context, event Event) error
+	// Close flushes pending events and releases the transport
+	Close() error
+}
+
+// encode returns the JSON body of an event
+func encode(event Event) ([]byte, error) {
+	return json.Marshal(event.Payload)
+}
@@ -0,0 +1,270 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+)
+
+// Event name sent to a socket after its subscriptions change
+const EventSubscriptions = "subscriptions"
+
+// observedTypes maps the event names a socket can subscribe to onto the
+// client event type they are converted from
+var observedTypes = map[string]string{
+	EventMessage:      client.EventTypeRaw,
+	EventStatus:       client.EventTypeStatus,
+	EventAppState:     client.EventTypeAppState,
+	EventPaired:       client.EventTypePaired,
+	EventPairFailed:   client.EventTypePairFailed,
+	EventBanned:       client.EventTypeBanned,
+	EventConversation: client.EventTypeConversation,
+	EventQR:           client.EventTypeQR,
+}
+
+// SocketCommand is a text frame sent by a /ws client to change its subscriptions
+type SocketCommand struct {
+	Action string   `json:"action"` // subscribe or unsubscribe
+	Events []string `json:"events"`
+}
+
+// SubscriptionsPayload lists the events a socket is subscribed to
+type SubscriptionsPayload struct {
+	Event  string   `json:"event"`
+	User   string   `json:"user"`
+	Events []string `json:"events"`
+}
+
+// socketObserver is the client observer of one /ws connection. It is attached
+// to the client manager for every event type the socket subscribes to.
+type socketObserver struct {
+	app  *app.App
+	user string
+	send chan []byte
+
+	mu     sync.Mutex
+	events map[string]bool
+}
+
+// OnEvent queues the event when it belongs to the socket's user and is subscribed
+func (o *socketObserver) OnEvent(event client.Event) {
+	if event.GetClientID() != o.user {
+		return
+	}
+	evt, ok := EventFor(event)
+	if !ok || hiddenOwnMessage(o.app, evt) {
+		return
+	}
+
+	o.mu.Lock()
+	subscribed := o.events[evt.Name]
+	o.mu.Unlock()
+	if !subscribed {
+		return
+	}
+
+	detectLanguage(o.app, &evt)
+	body, err := encode(evt)
+	if err != nil {
+		return
+	}
+	o.queue(body)
+}
+
+// queue sends a frame to the socket, dropping it when the socket is behind
+func (o *socketObserver) queue(body []byte) {
+	select {
+	case o.send <- body:
+	default:
+		// Slow subscriber; never block the client manager's workers
+	}
+}
+
+// subscriptions returns the subscribed event names in a stable order
+func (o *socketObserver) subscriptions() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	names := make([]string, 0, len(o.events))
+	for name := range o.events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SocketServer streams client events over WebSocket on GET /ws. Unlike the
+// websocket sink, which receives events through the dispatcher, every
+// connection attaches its own observer to the client manager for the event
+// types it subscribes to and detaches it when it closes or unsubscribes.
+type SocketServer struct {
+	app      *app.App
+	upgrader websocket.Upgrader
+}
+
+// NewSocketServer creates a WebSocket server for the application's client
+// events, accepting connections from the allowed origins
+func NewSocketServer(app *app.App, origins *Origins) *SocketServer {
+	return &SocketServer{
+		app: app,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: origins.Allowed,
+		},
+	}
+}
+
+// ParseSocketEvents validates a list of event names to subscribe to. An empty
+// list subscribes to every event.
+func ParseSocketEvents(names []string) ([]string, error) {
+	if len(names) == 0 {
+		names = make([]string, 0, len(observedTypes))
+		for name := range observedTypes {
+			names = append(names, name)
+		}
+		return names, nil
+	}
+	for _, name := range names {
+		if _, ok := observedTypes[name]; !ok {
+			return nil, fmt.Errorf("unknown event %q", name)
+		}
+	}
+	return names, nil
+}
+
+// Serve upgrades the request and streams the user's subscribed events until
+// the client disconnects. The client can send SocketCommand frames to
+// subscribe to or unsubscribe from events while connected.
+func (s *SocketServer) Serve(w http.ResponseWriter, r *http.Request, user string, events []string) error {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	observer := &socketObserver{
+		app:    s.app,
+		user:   user,
+		send:   make(chan []byte, wsSendBuffer),
+		events: make(map[string]bool),
+	}
+	defer func() { s.detach(observer, observer.subscriptions()) }()
+	s.attach(observer, events)
+	s.ackSubscriptions(observer)
+
+	// Read client frames so commands, close and pong frames are processed
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			s.handleCommand(observer, data)
+		}
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return nil
+		case body := <-observer.send:
+			ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := ws.WriteMessage(websocket.TextMessage, body); err != nil {
+				return err
+			}
+		case <-ping.C:
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handleCommand applies a subscribe or unsubscribe command. Invalid commands
+// are answered with an error frame and leave the subscriptions unchanged.
+func (s *SocketServer) handleCommand(observer *socketObserver, data []byte) {
+	var cmd SocketCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		s.sendError(observer, "Invalid command format")
+		return
+	}
+
+	events, err := ParseSocketEvents(cmd.Events)
+	if err != nil {
+		s.sendError(observer, err.Error())
+		return
+	}
+
+	switch cmd.Action {
+	case "subscribe":
+		s.attach(observer, events)
+	case "unsubscribe":
+		s.detach(observer, events)
+	default:
+		s.sendError(observer, fmt.Sprintf("Unknown action %q", cmd.Action))
+		return
+	}
+	s.ackSubscriptions(observer)
+}
+
+// attach subscribes the observer to events, registering it with the client
+// manager for the event types it was not yet observing
+func (s *SocketServer) attach(observer *socketObserver, events []string) {
+	manager := s.app.GetClientManager()
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	for _, name := range events {
+		if observer.events[name] {
+			continue
+		}
+		observer.events[name] = true
+		manager.RegisterObserver(observedTypes[name], observer)
+	}
+}
+
+// detach unsubscribes the observer from events and removes it from the client
+// manager for those event types
+func (s *SocketServer) detach(observer *socketObserver, events []string) {
+	manager := s.app.GetClientManager()
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	for _, name := range events {
+		if !observer.events[name] {
+			continue
+		}
+		delete(observer.events, name)
+		manager.UnregisterObserver(observedTypes[name], observer)
+	}
+}
+
+// ackSubscriptions tells the client which events it is now subscribed to
+func (s *SocketServer) ackSubscriptions(observer *socketObserver) {
+	body, err := json.Marshal(SubscriptionsPayload{
+		Event:  EventSubscriptions,
+		User:   observer.user,
+		Events: observer.subscriptions(),
+	})
+	if err != nil {
+		return
+	}
+	observer.queue(body)
+}
+
+// sendError reports a rejected command to the client
+func (s *SocketServer) sendError(observer *socketObserver, message string) {
+	body, err := json.Marshal(map[string]string{"event": "error", "error": message})
+	if err != nil {
+		return
+	}
+	observer.queue(body)
+}
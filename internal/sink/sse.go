@@ -0,0 +1,139 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// Events buffered per subscriber before new events are dropped for it
+	sseSendBuffer = 64
+	// Interval between keepalive comments, so proxies keep the stream open
+	sseKeepAliveInterval = 30 * time.Second
+)
+
+// sseFrame is one event queued for a subscriber
+type sseFrame struct {
+	name string
+	body []byte
+}
+
+// sseConn is one subscribed event stream
+type sseConn struct {
+	user    string
+	send    chan sseFrame
+	pairing bool // Whether the stream may receive QR codes
+}
+
+// SSESink streams session events to clients subscribed with
+// GET /wa/events?user=X as Server-Sent Events. Like the WebSocket sink, each
+// subscriber has its own buffer and a slow one loses its own events.
+//
+// Any web page may open the stream under the API's CORS settings, but QR
+// codes, which can link a device to the account, are only streamed to
+// allowed origins.
+type SSESink struct {
+	origins *Origins
+
+	mu    sync.RWMutex
+	conns map[*sseConn]struct{}
+}
+
+// NewSSESink creates a Server-Sent Events sink without subscribers, streaming
+// QR codes to the allowed origins only
+func NewSSESink(origins *Origins) *SSESink {
+	return &SSESink{origins: origins, conns: make(map[*sseConn]struct{})}
+}
+
+// Name returns the sink name
+func (s *SSESink) Name() string {
+	return "sse"
+}
+
+// Publish queues the event for every stream subscribed to its user
+func (s *SSESink) Publish(ctx context.Context, event Event) error {
+	body, err := encode(event)
+	if err != nil {
+		return err
+	}
+	frame := sseFrame{name: event.Name, body: body}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for conn := range s.conns {
+		if conn.user != event.User || (event.Name == EventQR && !conn.pairing) {
+			continue
+		}
+		select {
+		case conn.send <- frame:
+		default:
+			// Slow subscriber; drop rather than block the other sinks
+		}
+	}
+	return nil
+}
+
+// Serve streams the user's events until the client disconnects. Each event
+// is sent with the event name as the SSE event type and the JSON payload as
+// its data.
+func (s *SSESink) Serve(w http.ResponseWriter, r *http.Request, user string) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming is not supported by the connection")
+	}
+
+	conn := &sseConn{user: user, send: make(chan sseFrame, sseSendBuffer), pairing: s.origins.Allowed(r)}
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Keep reverse proxies such as nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case frame, ok := <-conn.send:
+			if !ok {
+				return nil
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", frame.name, frame.body); err != nil {
+				return err
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return err
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// Close ends every stream
+func (s *SSESink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		close(conn.send)
+		delete(s.conns, conn)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/circuit"
+)
+
+// WebhookSink posts session events to the configured webhook URL. Calls go
+// through a circuit breaker so an unreachable consumer fails fast instead of
+// holding a dispatch worker for the full timeout on every event.
+type WebhookSink struct {
+	mu         sync.RWMutex
+	url        string
+	httpClient *http.Client
+	breaker    *circuit.Breaker
+
+	onDelivered func(Event)
+}
+
+// NewWebhookSink creates a webhook sink
+func NewWebhookSink(url string, timeout time.Duration, breaker *circuit.Breaker) *WebhookSink {
+	return &WebhookSink{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+		breaker:    breaker,
+	}
+}
+
+// SetTarget changes the URL events are posted to and the request timeout.
+// Events already being posted finish against the previous target.
+func (s *WebhookSink) SetTarget(url string, timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.url = url
+	s.httpClient = &http.Client{Timeout: timeout}
+}
+
+// OnDelivered sets a function called with every event the webhook accepted
+// with a 2xx status. It must be set before events are published.
+func (s *WebhookSink) OnDelivered(fn func(Event)) {
+	s.onDelivered = fn
+}
+
+// Name returns the sink name
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Publish posts the event payload as JSON through the circuit breaker
+func (s *WebhookSink) Publish(ctx context.Context, event Event) error {
+	body, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	url, httpClient := s.url, s.httpClient
+	s.mu.RUnlock()
+
+	err = s.breaker.Do(func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+		}
+		return nil
+	})
+	if err == nil && s.onDelivered != nil {
+		s.onDelivered(event)
+	}
+	return err
+}
+
+// Close is a no-op; the webhook holds no connection
+func (s *WebhookSink) Close() error {
+	return nil
+}
@@ -0,0 +1,137 @@
+package sink
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Events buffered per connection before new events are dropped for it
+	wsSendBuffer = 64
+	// How long a single frame write may take before the connection is dropped
+	wsWriteTimeout = 10 * time.Second
+	// Interval between keepalive pings
+	wsPingInterval = 30 * time.Second
+)
+
+// wsConn is one subscribed WebSocket connection
+type wsConn struct {
+	user string
+	send chan []byte
+}
+
+// WebSocketSink streams session events to WebSocket clients subscribed with
+// GET /ws/events?user=X. Each connection has its own buffer, so a slow client
+// loses its own events instead of delaying the others.
+type WebSocketSink struct {
+	upgrader websocket.Upgrader
+
+	mu    sync.RWMutex
+	conns map[*wsConn]struct{}
+}
+
+// NewWebSocketSink creates a WebSocket sink without subscribers, accepting
+// connections from the allowed origins
+func NewWebSocketSink(origins *Origins) *WebSocketSink {
+	return &WebSocketSink{
+		upgrader: websocket.Upgrader{
+			CheckOrigin: origins.Allowed,
+		},
+		conns: make(map[*wsConn]struct{}),
+	}
+}
+
+// Name returns the sink name
+func (s *WebSocketSink) Name() string {
+	return "websocket"
+}
+
+// Publish queues the event for every connection subscribed to its user
+func (s *WebSocketSink) Publish(ctx context.Context, event Event) error {
+	body, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for conn := range s.conns {
+		if conn.user != event.User {
+			continue
+		}
+		select {
+		case conn.send <- body:
+		default:
+			// Slow subscriber; drop rather than block the other sinks
+		}
+	}
+	return nil
+}
+
+// Serve upgrades the request and streams the user's events until the client
+// disconnects
+func (s *WebSocketSink) Serve(w http.ResponseWriter, r *http.Request, user string) error {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	conn := &wsConn{user: user, send: make(chan []byte, wsSendBuffer)}
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	// Read and discard client frames so close and pong frames are processed
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := ws.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return nil
+		case body, ok := <-conn.send:
+			if !ok {
+				return nil
+			}
+			ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := ws.WriteMessage(websocket.TextMessage, body); err != nil {
+				return err
+			}
+		case <-ping.C:
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Close disconnects every subscriber
+func (s *WebSocketSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		close(conn.send)
+		delete(s.conns, conn)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package stats
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Handlers contains HTTP handlers for session statistics
+type Handlers struct {
+	app     *app.App
+	service *Service
+}
+
+// NewHandlers creates a new stats handlers instance
+func NewHandlers(app *app.App) *Handlers {
+	return &Handlers{
+		app:     app,
+		service: NewService(app),
+	}
+}
+
+// SLOHandler handles GET /stats/slo - returns send-to-delivery latency
+// percentiles per session, optionally for a single user
+func (h *Handlers) SLOHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.SLO(c.Query("user")))
+}
@@ -0,0 +1,9 @@
+package stats
+
+import "github.com/neekaru/whatsappgo-bot/internal/app"
+
+// SLOResponse is the send-to-delivery latency of sessions over the rolling window
+type SLOResponse struct {
+	WindowSeconds int                 `json:"window_seconds"`
+	Sessions      []app.DeliveryStats `json:"sessions"`
+}
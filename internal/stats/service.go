@@ -0,0 +1,61 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Service measures how long messages sent by the sessions take to be delivered
+type Service struct {
+	app *app.App
+}
+
+// NewService creates a new stats service
+func NewService(app *app.App) *Service {
+	return &Service{app: app}
+}
+
+// Start registers the service for receipts of sent messages
+func (s *Service) Start() {
+	s.app.GetClientManager().RegisterObserver(client.EventTypeRaw, client.ObserverFunc(s.OnEvent))
+}
+
+// OnEvent records the delivery of sent messages acknowledged by a receipt.
+// Read and played receipts count as well, as recipients may skip straight to
+// them when their phone was offline.
+func (s *Service) OnEvent(event client.Event) {
+	receipt, ok := event.GetData().(*events.Receipt)
+	if !ok || receipt.IsFromMe {
+		return
+	}
+	switch receipt.Type {
+	case types.ReceiptTypeDelivered, types.ReceiptTypeRead, types.ReceiptTypePlayed:
+	default:
+		return
+	}
+
+	deliveredAt := event.GetReceivedAt()
+	if deliveredAt.IsZero() {
+		deliveredAt = time.Now()
+	}
+	s.app.Deliveries.Delivered(event.GetClientID(), receipt.MessageIDs, deliveredAt)
+}
+
+// SLO returns the delivery latency of user, or of every session with sends in
+// the window when user is empty
+func (s *Service) SLO(user string) *SLOResponse {
+	resp := &SLOResponse{
+		WindowSeconds: int(s.app.Deliveries.Window() / time.Second),
+		Sessions:      []app.DeliveryStats{},
+	}
+	if user == "" {
+		resp.Sessions = s.app.Deliveries.Stats()
+	} else if stats, ok := s.app.Deliveries.UserStats(user); ok {
+		resp.Sessions = append(resp.Sessions, stats)
+	}
+	return resp
+}
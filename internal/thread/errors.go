@@ -0,0 +1,10 @@
+package thread
+
+import "errors"
+
+// ErrThreadUnavailable is returned when the thread database could not be opened
+var ErrThreadUnavailable = errors.New("thread store is not available")
+
+// ErrMessageNotFound is returned for messages that were not recorded, or were
+// pruned since
+var ErrMessageNotFound = errors.New("message not found")
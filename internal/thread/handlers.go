@@ -0,0 +1,45 @@
+package thread
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Handlers contains HTTP handlers for message threads
+type Handlers struct {
+	app     *app.App
+	service *Service
+}
+
+// NewHandlers creates a new thread handlers instance
+func NewHandlers(app *app.App) *Handlers {
+	return &Handlers{
+		app:     app,
+		service: NewService(app),
+	}
+}
+
+// ThreadHandler handles GET /messages/:id/thread - returns the reply chain of a message
+func (h *Handlers) ThreadHandler(c *gin.Context) {
+	user := c.Query("user")
+	if user == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user"})
+		return
+	}
+
+	thread, err := h.service.Thread(user, c.Param("id"))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, thread)
+	case errors.Is(err, ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrThreadUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		h.app.Logger.Printf("Get thread error for user %s: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,34 @@
+package thread
+
+import (
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+)
+
+// Longest reply chain followed up from a message
+const maxThreadDepth = 50
+
+// Reaction is the current reaction of one sender to a message
+type Reaction struct {
+	Sender    string    `json:"sender"`
+	Emoji     string    `json:"emoji"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Entry is a message of a thread with its latest edit applied and the
+// reactions it currently has
+type Entry struct {
+	app.ThreadMessage
+	Edited    bool       `json:"edited,omitempty"`
+	Reactions []Reaction `json:"reactions,omitempty"`
+}
+
+// ThreadResponse is the reply chain leading to a message, oldest first and
+// ending with the message itself, and the direct replies to it
+type ThreadResponse struct {
+	User    string  `json:"user"`
+	ID      string  `json:"id"`
+	Chain   []Entry `json:"chain"`
+	Replies []Entry `json:"replies"`
+}
@@ -0,0 +1,201 @@
+package thread
+
+import (
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Service records quote, reaction and edit relationships between messages and
+// rebuilds reply chains from them
+type Service struct {
+	app *app.App
+}
+
+// NewService creates a new thread service
+func NewService(app *app.App) *Service {
+	return &Service{app: app}
+}
+
+// Start registers the service for incoming messages and own messages sent
+// from other devices, to record them
+func (s *Service) Start() {
+	s.app.GetClientManager().RegisterObserver(client.EventTypeRaw, client.ObserverFunc(s.OnEvent))
+}
+
+// OnEvent records a message event in the thread store
+func (s *Service) OnEvent(event client.Event) {
+	msg, ok := event.GetData().(*events.Message)
+	if !ok || msg.Message == nil || msg.Info.Chat == types.StatusBroadcastJID {
+		return
+	}
+	threadMsg, ok := threadMessage(msg)
+	if !ok {
+		return
+	}
+	if err := s.app.Threads.Record(event.GetClientID(), threadMsg); err != nil {
+		s.app.Logger.Printf("Failed to record message %s of user %s for threading: %v", msg.Info.ID, event.GetClientID(), err)
+	}
+}
+
+// Thread returns the reply chain leading to a message of user and its replies.
+// The chain stops at the first message that was not recorded.
+func (s *Service) Thread(user, id string) (*ThreadResponse, error) {
+	if s.app.Threads == nil {
+		return nil, ErrThreadUnavailable
+	}
+
+	msg, found, err := s.app.Threads.Get(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrMessageNotFound
+	}
+
+	entry, replies, err := s.entry(user, *msg)
+	if err != nil {
+		return nil, err
+	}
+	chain := []Entry{entry}
+	seen := map[string]bool{msg.ID: true}
+	for parentID := msg.ParentID; parentID != "" && !seen[parentID] && len(chain) < maxThreadDepth; {
+		parent, found, err := s.app.Threads.Get(user, parentID)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			break
+		}
+		parentEntry, _, err := s.entry(user, *parent)
+		if err != nil {
+			return nil, err
+		}
+		chain = append([]Entry{parentEntry}, chain...)
+		seen[parentID] = true
+		parentID = parent.ParentID
+	}
+
+	response := &ThreadResponse{User: user, ID: id, Chain: chain, Replies: []Entry{}}
+	for _, reply := range replies {
+		replyEntry, _, err := s.entry(user, reply)
+		if err != nil {
+			return nil, err
+		}
+		response.Replies = append(response.Replies, replyEntry)
+	}
+	return response, nil
+}
+
+// entry applies the edits and reactions recorded for a message, returning the
+// messages replying to it alongside
+func (s *Service) entry(user string, msg app.ThreadMessage) (Entry, []app.ThreadMessage, error) {
+	children, err := s.app.Threads.Children(user, msg.ID)
+	if err != nil {
+		return Entry{}, nil, err
+	}
+
+	entry := Entry{ThreadMessage: msg}
+	var replies []app.ThreadMessage
+	latest := map[string]int{} // Index in entry.Reactions of each sender's reaction
+	for _, child := range children {
+		switch child.Kind {
+		case app.ThreadKindEdit:
+			// Children are oldest first, so the last edit wins
+			entry.Text = child.Text
+			entry.Edited = true
+		case app.ThreadKindReaction:
+			reaction := Reaction{Sender: child.Sender, Emoji: child.Text, Timestamp: child.Timestamp}
+			if i, ok := latest[child.Sender]; ok {
+				entry.Reactions[i] = reaction
+			} else {
+				latest[child.Sender] = len(entry.Reactions)
+				entry.Reactions = append(entry.Reactions, reaction)
+			}
+		default:
+			replies = append(replies, child)
+		}
+	}
+
+	// An empty emoji removes the sender's reaction
+	reactions := entry.Reactions[:0]
+	for _, reaction := range entry.Reactions {
+		if reaction.Emoji != "" {
+			reactions = append(reactions, reaction)
+		}
+	}
+	entry.Reactions = reactions
+	return entry, replies, nil
+}
+
+// threadMessage describes a message event for the thread store. Revokes and
+// other protocol messages are not recorded.
+func threadMessage(msg *events.Message) (app.ThreadMessage, bool) {
+	threadMsg := app.ThreadMessage{
+		ID:        msg.Info.ID,
+		Chat:      msg.Info.Chat.ToNonAD().String(),
+		Sender:    msg.Info.Sender.ToNonAD().String(),
+		FromMe:    msg.Info.IsFromMe,
+		Kind:      app.ThreadKindMessage,
+		Timestamp: msg.Info.Timestamp,
+	}
+
+	m := msg.Message
+	switch {
+	case m.GetReactionMessage() != nil:
+		threadMsg.Kind = app.ThreadKindReaction
+		threadMsg.ParentID = m.GetReactionMessage().GetKey().GetID()
+		threadMsg.Text = m.GetReactionMessage().GetText()
+	case m.GetProtocolMessage() != nil:
+		protocol := m.GetProtocolMessage()
+		if protocol.GetType() != waE2E.ProtocolMessage_MESSAGE_EDIT {
+			return app.ThreadMessage{}, false
+		}
+		threadMsg.Kind = app.ThreadKindEdit
+		threadMsg.ParentID = protocol.GetKey().GetID()
+		threadMsg.Text = messageText(protocol.GetEditedMessage())
+	default:
+		threadMsg.ParentID = quotedID(m)
+		threadMsg.Text = messageText(m)
+	}
+	return threadMsg, true
+}
+
+// quotedID returns the ID of the message a message replies to, if any
+func quotedID(m *waE2E.Message) string {
+	for _, info := range []*waE2E.ContextInfo{
+		m.GetExtendedTextMessage().GetContextInfo(),
+		m.GetImageMessage().GetContextInfo(),
+		m.GetVideoMessage().GetContextInfo(),
+		m.GetDocumentMessage().GetContextInfo(),
+		m.GetAudioMessage().GetContextInfo(),
+		m.GetStickerMessage().GetContextInfo(),
+		m.GetLocationMessage().GetContextInfo(),
+		m.GetContactMessage().GetContextInfo(),
+	} {
+		if id := info.GetStanzaID(); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// messageText returns the text of a text message or the caption of a media message
+func messageText(m *waE2E.Message) string {
+	switch {
+	case m.GetConversation() != "":
+		return m.GetConversation()
+	case m.GetExtendedTextMessage() != nil:
+		return m.GetExtendedTextMessage().GetText()
+	case m.GetImageMessage() != nil:
+		return m.GetImageMessage().GetCaption()
+	case m.GetVideoMessage() != nil:
+		return m.GetVideoMessage().GetCaption()
+	case m.GetDocumentMessage() != nil:
+		return m.GetDocumentMessage().GetCaption()
+	default:
+		return ""
+	}
+}
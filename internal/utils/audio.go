@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	ffmpeg_go "github.com/u2takey/ffmpeg-go"
+)
+
+// VoiceNoteMimeType is the format WhatsApp plays voice notes in
+const VoiceNoteMimeType = "audio/ogg; codecs=opus"
+
+// Longest ffmpeg or ffprobe may run on one piece of audio
+const ffmpegTimeout = time.Minute
+
+// Samples WhatsApp shows in a voice note's waveform, and the sample rate the
+// waveform and duration are measured at
+const (
+	waveformSamples    = 64
+	waveformSampleRate = 8000
+)
+
+// VoiceNote transcodes audio in any format ffmpeg reads to mono OGG/Opus, the
+// format of voice notes recorded in WhatsApp, and returns it with its length
+// in seconds and its waveform.
+func VoiceNote(content []byte) ([]byte, uint32, []byte, error) {
+	voice, err := transcode(content, ffmpeg_go.KwArgs{
+		"vn":          "",
+		"ac":          1,
+		"ar":          48000,
+		"c:a":         "libopus",
+		"b:a":         "32k",
+		"application": "voip",
+		"f":           "ogg",
+	})
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to transcode audio: %w", err)
+	}
+
+	pcm, err := transcode(voice, ffmpeg_go.KwArgs{
+		"ac": 1,
+		"ar": waveformSampleRate,
+		"f":  "s16le",
+	})
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to decode voice note: %w", err)
+	}
+	samples := make([]int16, len(pcm)/2)
+	if err := binary.Read(bytes.NewReader(pcm[:len(samples)*2]), binary.LittleEndian, samples); err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to decode voice note: %w", err)
+	}
+	if len(samples) == 0 {
+		return nil, 0, nil, fmt.Errorf("audio is empty")
+	}
+
+	seconds := uint32(math.Ceil(float64(len(samples)) / waveformSampleRate))
+	return voice, seconds, waveform(samples), nil
+}
+
+// AudioSeconds returns the length of audio in seconds, rounded up, as
+// reported by ffprobe. The audio is probed from a temporary file, as MP4
+// audio keeps its index at the end where a pipe cannot seek to.
+func AudioSeconds(content []byte) (uint32, error) {
+	path, err := tempAudioFile(content)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(path)
+
+	out, err := ffmpeg_go.ProbeWithTimeout(path, ffmpegTimeout, ffmpeg_go.KwArgs{"v": "error"})
+	if err != nil {
+		return 0, err
+	}
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal([]byte(out), &probe); err != nil {
+		return 0, fmt.Errorf("failed to read ffprobe output: %w", err)
+	}
+
+	hasAudio := false
+	for _, stream := range probe.Streams {
+		hasAudio = hasAudio || stream.CodecType == "audio"
+	}
+	if !hasAudio {
+		return 0, fmt.Errorf("no audio stream")
+	}
+	duration, err := strconv.ParseFloat(probe.Format.Duration, 64)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("unknown duration")
+	}
+	return uint32(math.Ceil(duration)), nil
+}
+
+// waveform reduces samples to the mean loudness of waveformSamples equal
+// slices, scaled to 0-100 with the loudest slice at 100
+func waveform(samples []int16) []byte {
+	levels := make([]float64, waveformSamples)
+	var loudest float64
+	for i := range levels {
+		start := i * len(samples) / waveformSamples
+		end := (i + 1) * len(samples) / waveformSamples
+		if end <= start {
+			end = min(start+1, len(samples))
+		}
+		var sum float64
+		for _, sample := range samples[start:end] {
+			sum += math.Abs(float64(sample))
+		}
+		levels[i] = sum / float64(end-start)
+		loudest = max(loudest, levels[i])
+	}
+
+	wave := make([]byte, waveformSamples)
+	if loudest == 0 {
+		return wave
+	}
+	for i, level := range levels {
+		wave[i] = byte(math.Round(level / loudest * 100))
+	}
+	return wave
+}
+
+// transcode runs content through ffmpeg with the given output options,
+// returning its output. ffmpeg's error output is included in errors. The
+// input is read from a temporary file, as MP4 audio keeps its index at the
+// end where a pipe cannot seek to.
+func transcode(content []byte, output ffmpeg_go.KwArgs) ([]byte, error) {
+	path, err := tempAudioFile(content)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+
+	var out, stderr bytes.Buffer
+	err = ffmpeg_go.Input(path).
+		Output("pipe:1", output).
+		WithOutput(&out).
+		WithErrorOutput(&stderr).
+		OverWriteOutput().
+		WithTimeout(ffmpegTimeout).
+		Run()
+	if err != nil {
+		if detail := lastLine(stderr.String()); detail != "" {
+			return nil, fmt.Errorf("%w: %s", err, detail)
+		}
+		return nil, err
+	}
+	if out.Len() == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return out.Bytes(), nil
+}
+
+// tempAudioFile writes content to a temporary file for ffmpeg to read,
+// returning its path. The caller removes the file.
+func tempAudioFile(content []byte) (string, error) {
+	file, err := os.CreateTemp("", "whatsapp-audio-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// lastLine returns the last non-empty line of ffmpeg's error output, which
+// holds the reason it failed
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
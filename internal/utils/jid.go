@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// IsLIDRecipient reports whether a recipient is given as a LID JID ("123@lid")
+func IsLIDRecipient(recipient string) bool {
+	return strings.HasSuffix(recipient, "@"+types.HiddenUserServer)
+}
+
+// RecipientNumber returns the numeric part of a recipient given as a phone number or LID JID
+func RecipientNumber(recipient string) string {
+	return strings.TrimSuffix(recipient, "@"+types.HiddenUserServer)
+}
+
+// RecipientJID builds the JID to send to from a phone number or a LID JID
+func RecipientJID(recipient string) types.JID {
+	if IsLIDRecipient(recipient) {
+		return types.NewJID(RecipientNumber(recipient), types.HiddenUserServer)
+	}
+	return types.NewJID(strings.TrimPrefix(recipient, "+"), types.DefaultUserServer)
+}
+
+// ChatJID parses a chat given as a full JID (user, LID or group) or as a bare
+// phone number
+func ChatJID(chat string) (types.JID, error) {
+	chat = strings.TrimSpace(chat)
+	if chat == "" {
+		return types.JID{}, fmt.Errorf("chat is empty")
+	}
+	if !strings.Contains(chat, "@") {
+		return RecipientJID(chat), nil
+	}
+
+	jid, err := types.ParseJID(chat)
+	if err != nil {
+		return types.JID{}, fmt.Errorf("invalid jid %s: %v", chat, err)
+	}
+	return jid, nil
+}
+
+// CanonicalJID parses a chat like ChatJID and strips any device and agent
+// suffix, so "123:1@s.whatsapp.net" and "123@s.whatsapp.net" resolve to the same
+// JID
+func CanonicalJID(chat string) (types.JID, error) {
+	jid, err := ChatJID(chat)
+	if err != nil {
+		return types.JID{}, err
+	}
+	return jid.ToNonAD(), nil
+}
@@ -0,0 +1,194 @@
+package watchdog
+
+import (
+	"sync"
+	"time"
+
+	"github.com/neekaru/whatsappgo-bot/internal/alert"
+	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/client"
+)
+
+// Config holds the watchdog thresholds
+type Config struct {
+	Interval          time.Duration // Time between checks
+	ConnectingTimeout time.Duration // How long a session may stay connecting
+	StaleActivity     time.Duration // How long a logged-in session may go without activity; 0 disables the check
+	MaxRemediations   int           // Forced reconnects before alerting
+}
+
+// sessionState tracks the remediation of one unhealthy session
+type sessionState struct {
+	remediations int
+	alerted      bool
+}
+
+// Watchdog periodically looks for sessions stuck connecting, or logged in
+// without any activity, and forces them to reconnect. A session that is still
+// unhealthy after MaxRemediations reconnects is reported to the alert webhook
+// once and left alone until it recovers. Temporary bans are alerted right
+// away, since reconnecting cannot help.
+type Watchdog struct {
+	app    *app.App
+	cfg    Config
+	alerts *alert.Sender
+
+	mu       sync.Mutex
+	sessions map[string]*sessionState
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New creates a watchdog
+func New(app *app.App, cfg Config, alerts *alert.Sender) *Watchdog {
+	if cfg.MaxRemediations < 1 {
+		cfg.MaxRemediations = 1
+	}
+	return &Watchdog{
+		app:      app,
+		cfg:      cfg,
+		alerts:   alerts,
+		sessions: make(map[string]*sessionState),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the checks in the background
+func (w *Watchdog) Start() {
+	w.app.GetClientManager().RegisterObserver(client.EventTypeBanned, client.ObserverFunc(w.onBanned))
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				w.check()
+			}
+		}
+	}()
+}
+
+// Close stops the watchdog
+func (w *Watchdog) Close() {
+	close(w.stop)
+	<-w.done
+}
+
+// check examines every session once
+func (w *Watchdog) check() {
+	clients := w.app.GetClientManager().GetAllClients()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for user := range w.sessions {
+		if _, ok := clients[user]; !ok {
+			delete(w.sessions, user)
+		}
+	}
+
+	for user, whatsappClient := range clients {
+		reason := w.diagnose(whatsappClient)
+		if reason == "" {
+			if state, ok := w.sessions[user]; ok {
+				w.app.Logger.Printf("Watchdog: session %s recovered after %d forced reconnects", user, state.remediations)
+				delete(w.sessions, user)
+			}
+			continue
+		}
+		w.remediate(user, whatsappClient, reason)
+	}
+}
+
+// diagnose returns why a session is unhealthy, or "" if it is fine. Sessions
+// waiting for a QR scan or logged out need a human and are skipped, as are
+// sandbox sessions, which have no connection to lose.
+func (w *Watchdog) diagnose(whatsappClient *client.Client) string {
+	if whatsappClient.NeedsQR() || whatsappClient.IsSandbox() {
+		return ""
+	}
+
+	switch whatsappClient.GetStatus() {
+	case client.StatusConnecting:
+		if since := time.Since(whatsappClient.StatusSince()); since > w.cfg.ConnectingTimeout {
+			return "stuck connecting for " + since.Round(time.Second).String()
+		}
+	case client.StatusLoggedIn:
+		if !whatsappClient.IsConnected() {
+			return "logged in without a connection"
+		}
+		if w.cfg.StaleActivity > 0 {
+			if idle := time.Since(whatsappClient.LastActivity()); idle > w.cfg.StaleActivity {
+				return "no activity for " + idle.Round(time.Second).String()
+			}
+		}
+	}
+	return ""
+}
+
+// remediate forces a reconnect, or alerts once the reconnect budget is spent.
+// The caller must hold w.mu.
+func (w *Watchdog) remediate(user string, whatsappClient *client.Client, reason string) {
+	state, ok := w.sessions[user]
+	if !ok {
+		state = &sessionState{}
+		w.sessions[user] = state
+	}
+
+	if state.remediations < w.cfg.MaxRemediations {
+		state.remediations++
+		w.app.Logger.Printf("Warning: watchdog forcing reconnect of session %s (%s), attempt %d of %d",
+			user, reason, state.remediations, w.cfg.MaxRemediations)
+		go func() {
+			if err := whatsappClient.ForceReconnect("watchdog: " + reason); err != nil {
+				w.app.Logger.Printf("Watchdog reconnect of session %s failed: %v", user, err)
+			}
+		}()
+		return
+	}
+
+	if state.alerted {
+		return
+	}
+	state.alerted = true
+	w.app.Logger.Printf("Error: watchdog could not recover session %s (%s) after %d forced reconnects", user, reason, state.remediations)
+
+	remediations := state.remediations
+	go func() {
+		err := w.alerts.Send("session_unhealthy", map[string]any{
+			"user":         user,
+			"reason":       reason,
+			"status":       whatsappClient.GetStatus().String(),
+			"remediations": remediations,
+		})
+		if err != nil {
+			w.app.Logger.Printf("Failed to send watchdog alert: %v", err)
+		}
+	}()
+}
+
+// onBanned alerts that a session's account was temporarily banned
+func (w *Watchdog) onBanned(event client.Event) {
+	banned, ok := event.(*client.BannedEvent)
+	if !ok {
+		return
+	}
+
+	fields := map[string]any{
+		"user":   banned.GetClientID(),
+		"code":   banned.Ban.Code,
+		"reason": banned.Ban.Reason,
+	}
+	if banned.Ban.ExpiresAt != nil {
+		fields["expires_at"] = banned.Ban.ExpiresAt.Format(time.RFC3339)
+	}
+	if err := w.alerts.Send("session_banned", fields); err != nil {
+		w.app.Logger.Printf("Failed to send ban alert: %v", err)
+	}
+}
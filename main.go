@@ -5,14 +5,32 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/neekaru/whatsappgo-bot/internal/app"
+	"github.com/neekaru/whatsappgo-bot/internal/backup"
+	"github.com/neekaru/whatsappgo-bot/internal/businesshours"
+	"github.com/neekaru/whatsappgo-bot/internal/circuit"
 	"github.com/neekaru/whatsappgo-bot/internal/config"
+	"github.com/neekaru/whatsappgo-bot/internal/consumer"
+	"github.com/neekaru/whatsappgo-bot/internal/contentfilter"
+	"github.com/neekaru/whatsappgo-bot/internal/disk"
+	"github.com/neekaru/whatsappgo-bot/internal/eventlog"
+	"github.com/neekaru/whatsappgo-bot/internal/messaging"
+	"github.com/neekaru/whatsappgo-bot/internal/optin"
+	"github.com/neekaru/whatsappgo-bot/internal/outbox"
+	"github.com/neekaru/whatsappgo-bot/internal/poll"
 	"github.com/neekaru/whatsappgo-bot/internal/server"
+	"github.com/neekaru/whatsappgo-bot/internal/session"
+	"github.com/neekaru/whatsappgo-bot/internal/sink"
+	"github.com/neekaru/whatsappgo-bot/internal/stats"
+	"github.com/neekaru/whatsappgo-bot/internal/thread"
+	"github.com/neekaru/whatsappgo-bot/internal/watchdog"
 	"github.com/neekaru/whatsappgo-bot/pkg/logger"
 
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -25,8 +43,16 @@ func main() {
 
 	appLogger.Println("Starting WhatsApp API service")
 
-	// Create application configuration
-	appConfig := config.NewConfig()
+	// Create application configuration from the environment and the optional config file
+	configFile := os.Getenv("CONFIG_FILE")
+	appConfig, err := config.Load(configFile)
+	if err != nil {
+		appLogger.Fatalf("Failed to load config: %v", err)
+	}
+	reloader := config.NewReloader(configFile, appConfig)
+	if unknown := appConfig.UnknownFeatures(); len(unknown) > 0 {
+		appLogger.Printf("Warning: ignoring unknown FEATURES %v", unknown)
+	}
 
 	// Ensure data directory exists
 	if err := appConfig.EnsureDataDir(); err != nil {
@@ -34,18 +60,306 @@ func main() {
 	}
 	appLogger.Println("Ensured data directory exists")
 
+	// Swap in a backup staged for restoring before any database is opened
+	if restored, err := backup.ApplyPendingRestore(appConfig.DataDir); err != nil {
+		appLogger.Fatalf("Failed to restore backup: %v", err)
+	} else if restored != "" {
+		appLogger.Printf("Restored backup %s", restored)
+	}
+
+	logger.SetPIIRedactionKey([]byte(appConfig.PIIRedactionKey))
+	if appConfig.PIIRedaction {
+		logger.SetPIIRedaction(true)
+		appLogger.Println("PII redaction enabled, phone numbers and message bodies in logs are masked")
+	}
+
+	if err := logger.SetDefaultWhatsmeowLevel(appConfig.WhatsmeowLogLevel); err != nil {
+		appLogger.Printf("Warning: ignoring WHATSMEOW_LOG_LEVEL: %v", err)
+	}
+
+	// Keep session credentials in the configured store
+	if err := session.ConfigureStore(appConfig.DBDriver, appConfig.DBDSN, appLogger.Whatsmeow("", "Database")); err != nil {
+		appLogger.Fatalf("Failed to open session store: %v", err)
+	}
+	if appConfig.DBDriver == session.DriverPostgres {
+		appLogger.Println("Session store: postgres")
+	}
+
 	// Create application instance
 	application := app.NewApp(appLogger)
+	application.GetClientManager().SetMaxConcurrentOps(appConfig.MaxConcurrentOpsPerSession)
+	application.GetClientManager().SetConnectTimeout(appConfig.ConnectTimeout)
+	application.GetClientManager().SetMaxReconnectAttempts(appConfig.MaxReconnectAttempts)
+	application.GetClientManager().SetAvailableOnConnect(func(user string) bool {
+		return application.Options.Get(user).AvailableOnConnect
+	})
+	application.GetClientManager().SetExpectedPhones(func(user string) string {
+		return application.Options.Get(user).ExpectedPhone
+	})
+	application.Received.SetLimit(appConfig.ReceivedDedupeLimit)
+	application.Outbox.SetBodyLimit(appConfig.AuditBodyMaxChars)
+	application.SendLimiter.SetThrottleBackoff(appConfig.ThrottleBackoff)
+	application.Deliveries.SetWindow(appConfig.DeliverySLOWindow)
+
+	// Apply reloaded settings without a restart
+	reloader.Handle(func(cfg *config.Config) error {
+		return logger.SetDefaultWhatsmeowLevel(cfg.WhatsmeowLogLevel)
+	}, "WhatsmeowLogLevel")
+	reloader.Handle(func(cfg *config.Config) error {
+		logger.SetPIIRedaction(cfg.PIIRedaction)
+		return nil
+	}, "PIIRedaction")
+	reloader.Handle(func(cfg *config.Config) error {
+		application.GetClientManager().SetConnectTimeout(cfg.ConnectTimeout)
+		application.GetClientManager().SetMaxReconnectAttempts(cfg.MaxReconnectAttempts)
+		application.Received.SetLimit(cfg.ReceivedDedupeLimit)
+		application.Outbox.SetBodyLimit(cfg.AuditBodyMaxChars)
+		application.SendLimiter.SetThrottleBackoff(cfg.ThrottleBackoff)
+		application.Deliveries.SetWindow(cfg.DeliverySLOWindow)
+		return nil
+	}, "ConnectTimeout", "MaxReconnectAttempts", "ReceivedDedupeLimit", "AuditBodyMaxChars", "ThrottleBackoff", "DeliverySLOWindow")
+
+	if appConfig.MessageStoreKey != "" {
+		key, err := app.ParseMasterKey(appConfig.MessageStoreKey)
+		if err != nil {
+			appLogger.Fatalf("Invalid MESSAGE_STORE_KEY: %v", err)
+		}
+		cipher, err := app.NewFieldCipher(key)
+		if err != nil {
+			appLogger.Fatalf("Invalid MESSAGE_STORE_KEY: %v", err)
+		}
+		application.Outbox.SetCipher(cipher)
+		application.MediaHandles.SetCipher(cipher)
+		application.Threads.SetCipher(cipher)
+		application.Polls.SetCipher(cipher)
+		appLogger.Println("Message store encryption enabled")
+	}
+
+	// Refuse to start with a broken content filter rather than send unfiltered
+	contentFilter, err := contentfilter.Load(appConfig.ContentFilterFile)
+	if err != nil {
+		appLogger.Fatalf("Failed to load content filter: %v", err)
+	}
+	if contentFilter != nil {
+		application.ContentFilter = contentFilter
+		appLogger.Printf("Content filter loaded from %s (action %s)", appConfig.ContentFilterFile, contentFilter.Action())
+	}
+
+	// Fan session events out to the configured sinks
+	sinkSettings, err := sink.NewSettings(filepath.Join(appConfig.DataDir, "sinks.json"))
+	if err != nil {
+		appLogger.Printf("Warning: %v", err)
+	}
+	dispatcher := sink.NewDispatcher(application, sinkSettings, appConfig.SinkBufferSize)
+	for event, name := range appConfig.SinkPolicies {
+		policy, err := sink.ParsePolicy(name)
+		if err == nil {
+			err = dispatcher.SetPolicy(event, policy)
+		}
+		if err != nil {
+			appLogger.Printf("Warning: ignoring backpressure policy for %s events: %v", event, err)
+		}
+	}
+	origins := sink.NewOrigins(appConfig.CORSOrigins)
+	websocketSink := sink.NewWebSocketSink(origins)
+	dispatcher.Add(websocketSink)
+	sseSink := sink.NewSSESink(origins)
+	dispatcher.Add(sseSink)
+
+	if appConfig.WebhookURL != "" {
+		breaker := circuit.New("webhook", appConfig.CircuitBreakerFailures, appConfig.CircuitBreakerCooldown)
+		webhookSink := sink.NewWebhookSink(appConfig.WebhookURL, appConfig.WebhookTimeout, breaker)
+
+		// Mark messages read once the consumer accepted them, for sessions that opted in
+		messagingService := messaging.NewService(application)
+		webhookSink.OnDelivered(func(evt sink.Event) {
+			msg, ok := evt.Payload.(sink.MessagePayload)
+			if !ok || msg.FromMe {
+				return
+			}
+			go func() {
+				if _, _, err := messagingService.MarkAcknowledged(msg.User, app.AutoReadWebhook, msg.Chat, msg.Sender, []string{msg.ID}); err != nil {
+					appLogger.Printf("Failed to mark message %s of user %s as read: %v", msg.ID, msg.User, err)
+				}
+			}()
+		})
+		dispatcher.Add(webhookSink)
+
+		reloader.Handle(func(cfg *config.Config) error {
+			if cfg.WebhookURL == "" {
+				return fmt.Errorf("the webhook can only be removed with a restart")
+			}
+			webhookSink.SetTarget(cfg.WebhookURL, cfg.WebhookTimeout)
+			return nil
+		}, "WebhookURL", "WebhookTimeout")
+	}
+
+	if appConfig.MQTTBrokerURL != "" {
+		mqttSink, err := sink.NewMQTTSink(application, sink.MQTTConfig{
+			BrokerURL: appConfig.MQTTBrokerURL,
+			ClientID:  appConfig.MQTTClientID,
+			Username:  appConfig.MQTTUsername,
+			Password:  appConfig.MQTTPassword,
+			QoS:       byte(appConfig.MQTTQoS),
+		})
+		if err != nil {
+			appLogger.Printf("Failed to start MQTT sink: %v", err)
+		} else {
+			dispatcher.Add(mqttSink)
+		}
+	}
+
+	if appConfig.PubSubProjectID != "" {
+		pubSubSink, err := sink.NewPubSubSink(sink.PubSubConfig{
+			ProjectID:   appConfig.PubSubProjectID,
+			TopicPrefix: appConfig.PubSubTopicPrefix,
+		})
+		if err != nil {
+			appLogger.Printf("Failed to start Pub/Sub sink: %v", err)
+		} else {
+			dispatcher.Add(pubSubSink)
+		}
+	}
+
+	if appConfig.NATSURL != "" {
+		natsSink, err := sink.NewNATSSink(application, appConfig.NATSURL)
+		if err != nil {
+			appLogger.Printf("Failed to start NATS sink: %v", err)
+		} else {
+			dispatcher.Add(natsSink)
+		}
+	}
+
+	if len(appConfig.KafkaBrokers) > 0 {
+		kafkaSink, err := sink.NewKafkaSink(sink.KafkaConfig{
+			Brokers: appConfig.KafkaBrokers,
+			Topic:   appConfig.KafkaTopic,
+		})
+		if err != nil {
+			appLogger.Printf("Failed to start Kafka sink: %v", err)
+		} else {
+			dispatcher.Add(kafkaSink)
+		}
+	}
+
+	dispatcher.Start()
+
+	// Archive raw events of sessions in debug mode
+	eventArchive := eventlog.NewArchive(application, filepath.Join(appConfig.DataDir, "debug"), appConfig.DebugArchiveMaxBytes, appConfig.DebugArchiveFiles)
+	eventArchive.Start()
 
 	// Create and configure HTTP server
 	srv := server.NewServer(application, appConfig)
+	srv.SetSinks(dispatcher, websocketSink, sseSink, sink.NewSocketServer(application, origins))
+	srv.SetEventArchive(eventArchive)
+
+	// Back up the data directory, if a target is configured
+	var backups *backup.Manager
+	if appConfig.BackupDir != "" || appConfig.BackupS3Bucket != "" {
+		backups, err = backup.NewManager(application, backup.Config{
+			DataDir:   appConfig.DataDir,
+			Interval:  appConfig.BackupInterval,
+			Retention: appConfig.BackupRetention,
+			Dir:       appConfig.BackupDir,
+			S3Bucket:  appConfig.BackupS3Bucket,
+			S3Prefix:  appConfig.BackupS3Prefix,
+		})
+		if err != nil {
+			appLogger.Printf("Failed to set up backups: %v", err)
+		} else {
+			backups.Start()
+			srv.SetBackups(backups)
+		}
+	}
+	srv.SetConfigReloader(reloader)
 	srv.SetupRoutes()
 
+	// Watch free disk space and the space taken by the service's directories
+	diskDirs := []disk.Directory{
+		{Name: "data", Path: appConfig.DataDir},
+		{Name: "logs", Path: logger.LogDir},
+		{Name: "debug_archive", Path: filepath.Join(appConfig.DataDir, "debug")},
+	}
+	if appConfig.BackupDir != "" {
+		diskDirs = append(diskDirs, disk.Directory{Name: "backups", Path: appConfig.BackupDir})
+	}
+	diskMonitor := disk.NewMonitor(application, disk.Config{
+		Path:        appConfig.DataDir,
+		Directories: diskDirs,
+		Interval:    appConfig.DiskCheckInterval,
+		MinFree:     appConfig.DiskMinFree,
+	}, srv.Alerts())
+	diskMonitor.Start()
+
 	// Start the server
 	if err := srv.Start(); err != nil {
 		appLogger.Fatalf("Failed to start server: %v", err)
 	}
 
+	// Send away messages to chats writing outside business hours
+	businesshours.NewService(application).Start()
+
+	// Confirm opt-in of recipients replying with their session's keyword
+	optin.NewService(application).Start()
+
+	// Record quotes, reactions and edits between messages for reply chains
+	thread.NewService(application).Start()
+
+	// Record polls and tally the votes cast in them
+	poll.NewService(application).Start()
+
+	// Measure how long sent messages take to be delivered
+	stats.NewService(application).Start()
+
+	// Record messages sent from the account's other devices in the outbox
+	outbox.NewService(application).Start()
+
+	// Restore stored sessions in the background
+	sessionsRestored := make(chan struct{})
+	go func() {
+		session.NewService(application).RestoreAllSessions()
+		close(sessionsRestored)
+	}()
+
+	// Send outbox messages held with send_after once due, after the sessions
+	// they are sent from are restored
+	outboxScheduler := outbox.NewScheduler(application, time.Second)
+	outboxScheduler.Start(sessionsRestored)
+
+	// Reconnect stuck sessions, alerting when that does not help
+	sessionWatchdog := watchdog.New(application, watchdog.Config{
+		Interval:          appConfig.WatchdogInterval,
+		ConnectingTimeout: appConfig.WatchdogConnectingTimeout,
+		StaleActivity:     appConfig.WatchdogStaleActivity,
+		MaxRemediations:   appConfig.WatchdogMaxRemediations,
+	}, srv.Alerts())
+	sessionWatchdog.Start()
+
+	// Execute send commands from RabbitMQ, if configured, once sessions are restored
+	var commandConsumer *consumer.AMQPConsumer
+	if appConfig.AMQPURL != "" {
+		commandConsumer = consumer.NewAMQPConsumer(application, consumer.AMQPConfig{
+			URL:      appConfig.AMQPURL,
+			Queue:    appConfig.AMQPQueue,
+			Prefetch: appConfig.AMQPPrefetch,
+		})
+		commandConsumer.Start(sessionsRestored)
+	}
+
+	// Reload the config file on SIGHUP
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			report, err := reloader.Reload()
+			if err != nil {
+				appLogger.Printf("Config reload failed: %v", err)
+				continue
+			}
+			appLogger.Printf("Config reloaded: applied %v, restart required for %v, rejected %v", report.Applied, report.RestartRequired, report.Errors)
+		}
+	}()
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -61,6 +375,46 @@ func main() {
 		appLogger.Fatalf("Server shutdown failed: %v", err)
 	}
 
+	if commandConsumer != nil {
+		commandConsumer.Close()
+	}
+
+	sessionWatchdog.Close()
+	diskMonitor.Close()
+	outboxScheduler.Close()
+	if backups != nil {
+		backups.Close()
+	}
+	dispatcher.Close()
+	eventArchive.Close()
+
+	if err := application.Outbox.Close(); err != nil {
+		appLogger.Printf("Failed to close outbox: %v", err)
+	}
+
+	if err := application.Received.Close(); err != nil {
+		appLogger.Printf("Failed to close received message store: %v", err)
+	}
+
+	if err := application.OptIns.Close(); err != nil {
+		appLogger.Printf("Failed to close opt-in store: %v", err)
+	}
+
+	if err := application.Conversations.Close(); err != nil {
+		appLogger.Printf("Failed to close conversation store: %v", err)
+	}
+
+	if err := application.MediaHandles.Close(); err != nil {
+		appLogger.Printf("Failed to close media handle store: %v", err)
+	}
+
+	if err := application.Threads.Close(); err != nil {
+		appLogger.Printf("Failed to close thread store: %v", err)
+	}
+	if err := application.Polls.Close(); err != nil {
+		appLogger.Printf("Failed to close poll store: %v", err)
+	}
+
 	// Close the logger to ensure all logs are flushed
 	appLogger.Println("Closing logger and flushing logs...")
 	if err := logger.CloseLogger(); err != nil {
@@ -11,6 +11,9 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// LogDir is the directory daily log files are written to
+const LogDir = "logs"
+
 // Global variable to track the rotating writer for proper cleanup
 var activeRotatingWriter *DailyRotatingWriter
 
@@ -35,19 +38,19 @@ func (l *Logger) WithPrefix(component string) *Logger {
 
 // Printf logs with a level inferred from message content.
 func (l *Logger) Printf(format string, v ...interface{}) {
-	msg := fmt.Sprintf(format, v...)
+	msg := redact(fmt.Sprintf(format, v...))
 	l.logWithInferredLevel(msg)
 }
 
 // Println logs informational messages.
 func (l *Logger) Println(v ...interface{}) {
-	msg := strings.TrimSpace(fmt.Sprintln(v...))
+	msg := redact(strings.TrimSpace(fmt.Sprintln(v...)))
 	l.zlog.Info().Msg(msg)
 }
 
 // Fatalf logs an error and exits with status code 1.
 func (l *Logger) Fatalf(format string, v ...interface{}) {
-	msg := fmt.Sprintf(format, v...)
+	msg := redact(fmt.Sprintf(format, v...))
 	l.zlog.Fatal().Msg(msg)
 }
 
@@ -71,7 +74,7 @@ func (l *Logger) logWithInferredLevel(msg string) {
 // SetupLogging configures the application logging
 func SetupLogging() (*Logger, error) {
 	// Ensure logs directory exists
-	logDir := "logs"
+	logDir := LogDir
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create logs directory: %v", err)
 	}
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// piiRedaction masks phone numbers and message bodies in log messages when set
+var piiRedaction atomic.Bool
+
+// redactionKey keys the hashes phone numbers are masked with. Without a key
+// the hashes could be reversed by hashing every possible phone number.
+var redactionKey atomic.Pointer[[]byte]
+
+func init() {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("failed to generate PII redaction key: " + err.Error())
+	}
+	redactionKey.Store(&key)
+}
+
+// phonePattern matches phone numbers and user JIDs, with an optional device
+// suffix: "+6281234567890", "6281234567890:12@s.whatsapp.net", "98765432101234@lid".
+// Group JIDs and message IDs are longer or contain letters and are kept.
+// Bare digit runs are checked further by isPhoneNumber.
+var phonePattern = regexp.MustCompile(`\+?\b\d{8,15}(?::\d+)?(?:@(?:s\.whatsapp\.net|lid|c\.us))?\b`)
+
+// bodyPattern matches the text fields of messages as whatsmeow and the
+// application print them, in protobuf text or JSON form:
+// `conversation:"hello"`, `"caption": "hello"`.
+var bodyPattern = regexp.MustCompile(`(?i)("?\b(?:conversation|text|caption|body|content)"?\s*[:=]\s*)"(?:[^"\\]|\\.)*"`)
+
+// SetPIIRedaction turns masking of phone numbers and message bodies in log
+// messages on or off
+func SetPIIRedaction(enabled bool) {
+	piiRedaction.Store(enabled)
+}
+
+// PIIRedaction reports whether log messages are masked
+func PIIRedaction() bool {
+	return piiRedaction.Load()
+}
+
+// SetPIIRedactionKey sets the secret phone numbers are hashed with. The same
+// key gives the same hashes across restarts; without one a random key is
+// used, so hashes only match within one run.
+func SetPIIRedactionKey(key []byte) {
+	if len(key) == 0 {
+		return
+	}
+	key = append([]byte(nil), key...)
+	redactionKey.Store(&key)
+}
+
+// RedactPII replaces phone numbers and user JIDs in text with a keyed hash of
+// the number, so log lines about the same person can still be correlated:
+// "6281234567890@s.whatsapp.net" becomes "pn#1f3a9c2e7b40@s.whatsapp.net".
+// Message bodies are replaced with "[redacted]".
+func RedactPII(text string) string {
+	text = bodyPattern.ReplaceAllString(text, `$1"[redacted]"`)
+
+	matches := phonePattern.FindAllStringIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		found := text[match[0]:match[1]]
+		number, server, _ := strings.Cut(found, "@")
+		plus := strings.HasPrefix(number, "+")
+		number, _, _ = strings.Cut(strings.TrimPrefix(number, "+"), ":")
+		if server == "" && !plus && !isPhoneNumber(text, match[0], match[1], number) {
+			continue
+		}
+
+		masked := "pn#" + hashNumber(number)
+		if server != "" {
+			masked += "@" + server
+		}
+		b.WriteString(text[last:match[0]])
+		b.WriteString(masked)
+		last = match[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isPhoneNumber reports whether a bare digit run found at text[start:end] is
+// a phone number in international format rather than a timestamp, amount or
+// part of a longer token. Numbers with country code 1 always have 11 digits,
+// which rules out Unix timestamps in seconds and milliseconds.
+func isPhoneNumber(text string, start, end int, number string) bool {
+	if number[0] == '0' || (number[0] == '1' && len(number) != 11) {
+		return false
+	}
+	if start > 0 && strings.ContainsRune(".,-/:", rune(text[start-1])) {
+		return false
+	}
+	if end < len(text)-1 && strings.ContainsRune(".,-/", rune(text[end])) && isDigit(text[end+1]) {
+		return false
+	}
+	return true
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// hashNumber returns the keyed hash a phone number is masked with
+func hashNumber(number string) string {
+	mac := hmac.New(sha256.New, *redactionKey.Load())
+	mac.Write([]byte(number))
+	return hex.EncodeToString(mac.Sum(nil)[:6])
+}
+
+// redact masks a log message if PII redaction is enabled
+func redact(msg string) string {
+	if !piiRedaction.Load() {
+		return msg
+	}
+	return RedactPII(msg)
+}
@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// Level thresholds of whatsmeow loggers, per session with a shared default
+var whatsmeowLevels = struct {
+	mu       sync.RWMutex
+	def      atomic.Int32
+	sessions map[string]zerolog.Level
+}{sessions: make(map[string]zerolog.Level)}
+
+func init() {
+	whatsmeowLevels.def.Store(int32(zerolog.InfoLevel))
+}
+
+// ParseWhatsmeowLevel parses a whatsmeow log level: DEBUG, INFO, WARN or ERROR
+func ParseWhatsmeowLevel(name string) (zerolog.Level, error) {
+	switch strings.ToUpper(name) {
+	case "DEBUG":
+		return zerolog.DebugLevel, nil
+	case "INFO":
+		return zerolog.InfoLevel, nil
+	case "WARN":
+		return zerolog.WarnLevel, nil
+	case "ERROR":
+		return zerolog.ErrorLevel, nil
+	default:
+		return zerolog.NoLevel, fmt.Errorf("invalid log level %q, must be one of DEBUG, INFO, WARN, ERROR", name)
+	}
+}
+
+// whatsmeowLevelName returns the name of a level as accepted by ParseWhatsmeowLevel
+func whatsmeowLevelName(level zerolog.Level) string {
+	return strings.ToUpper(level.String())
+}
+
+// SetDefaultWhatsmeowLevel sets the level of sessions without their own level
+func SetDefaultWhatsmeowLevel(name string) error {
+	level, err := ParseWhatsmeowLevel(name)
+	if err != nil {
+		return err
+	}
+	whatsmeowLevels.def.Store(int32(level))
+	return nil
+}
+
+// SetWhatsmeowLevel sets the level of one session's whatsmeow loggers. An empty
+// name resets the session to the default level.
+func SetWhatsmeowLevel(session, name string) error {
+	whatsmeowLevels.mu.Lock()
+	defer whatsmeowLevels.mu.Unlock()
+
+	if name == "" {
+		delete(whatsmeowLevels.sessions, session)
+		return nil
+	}
+
+	level, err := ParseWhatsmeowLevel(name)
+	if err != nil {
+		return err
+	}
+	whatsmeowLevels.sessions[session] = level
+	return nil
+}
+
+// WhatsmeowLevel returns the effective level of a session's whatsmeow loggers
+// and whether it was set for the session rather than inherited
+func WhatsmeowLevel(session string) (string, bool) {
+	level, custom := whatsmeowLevel(session)
+	return whatsmeowLevelName(level), custom
+}
+
+// whatsmeowLevel returns the effective level of a session
+func whatsmeowLevel(session string) (zerolog.Level, bool) {
+	whatsmeowLevels.mu.RLock()
+	level, ok := whatsmeowLevels.sessions[session]
+	whatsmeowLevels.mu.RUnlock()
+	if ok {
+		return level, true
+	}
+	return zerolog.Level(whatsmeowLevels.def.Load()), false
+}
+
+// whatsmeowLogger adapts Logger to whatsmeow's logger interface, filtering by
+// the session's current level so it can be changed at runtime
+type whatsmeowLogger struct {
+	zlog    zerolog.Logger
+	session string
+	module  string
+}
+
+// Whatsmeow returns a whatsmeow logger for a session that writes to the same
+// outputs as l, tagged with the session and whatsmeow module
+func (l *Logger) Whatsmeow(session, module string) waLog.Logger {
+	return &whatsmeowLogger{
+		zlog:    l.zlog.With().Str("component", "whatsmeow").Str("session", session).Logger(),
+		session: session,
+		module:  module,
+	}
+}
+
+func (w *whatsmeowLogger) log(level zerolog.Level, msg string, args []any) {
+	if min, _ := whatsmeowLevel(w.session); level < min {
+		return
+	}
+	w.zlog.WithLevel(level).Str("module", w.module).Msg(redact(fmt.Sprintf(msg, args...)))
+}
+
+func (w *whatsmeowLogger) Errorf(msg string, args ...any) { w.log(zerolog.ErrorLevel, msg, args) }
+func (w *whatsmeowLogger) Warnf(msg string, args ...any)  { w.log(zerolog.WarnLevel, msg, args) }
+func (w *whatsmeowLogger) Infof(msg string, args ...any)  { w.log(zerolog.InfoLevel, msg, args) }
+func (w *whatsmeowLogger) Debugf(msg string, args ...any) { w.log(zerolog.DebugLevel, msg, args) }
+
+// Sub returns a logger for a submodule, named like whatsmeow's own loggers
+func (w *whatsmeowLogger) Sub(module string) waLog.Logger {
+	return &whatsmeowLogger{
+		zlog:    w.zlog,
+		session: w.session,
+		module:  w.module + "/" + module,
+	}
+}